@@ -0,0 +1,24 @@
+package main
+
+import (
+	"RootfsQuota/pkg/aggregate"
+	"RootfsQuota/pkg/log"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	listen := flag.String("listen", ":9200", "Address to serve the aggregation API and metrics on")
+	staleAfter := flag.Duration("stale-after", 5*time.Minute, "Treat nodes that have not pushed for this long as stale")
+	flag.Parse()
+
+	log.Info("RootfsQuota aggregator is starting...", zap.String("listen", *listen))
+	if err := http.ListenAndServe(*listen, aggregate.NewServer(*staleAfter)); err != nil {
+		log.Error("Aggregator exited with error", zap.Error(err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"RootfsQuota/pkg/accounting"
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+)
+
+func showAccounting(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("accounting", flag.ExitOnError)
+	from := fs.String("from", "", "first day (YYYY-MM-DD, UTC)")
+	to := fs.String("to", "", "last day (YYYY-MM-DD, UTC)")
+	namespace := fs.String("namespace", "", "only show this namespace")
+	asJSON := fs.Bool("json", false, "print the summaries as JSON")
+	fs.Parse(args)
+
+	query := url.Values{}
+	for name, v := range map[string]string{"from": *from, "to": *to, "namespace": *namespace} {
+		if v != "" {
+			query.Set(name, v)
+		}
+	}
+	path := "/v1/accounting"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var summaries []accounting.Summary
+	if err := c.Do("GET", path, nil, &summaries); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(summaries)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tNAMESPACE\tCONTAINERS\tGB-HOURS\tMAX USED")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%s\n", s.Date, s.Namespace, s.Containers, s.GBHours, formatBytes(s.MaxUsedBytes))
+	}
+	return tw.Flush()
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/xfs"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// benchResult 为一种后端路径的测试结果，每个操作模拟一个容器的生命周期：设置块限额、设置 inode 限额、清除限额
+type benchResult struct {
+	Path       string  `json:"path"`
+	Containers int     `json:"containers"`
+	Errors     int     `json:"errors"`
+	Seconds    float64 `json:"seconds"`
+	PerSecond  float64 `json:"per_second"`
+	P50Millis  float64 `json:"p50_ms"`
+	P99Millis  float64 `json:"p99_ms"`
+	FirstError string  `json:"first_error,omitempty"`
+}
+
+// benchOps 为一种路径写入与清除限额的调用
+type benchOps struct {
+	setLimits func(ctx context.Context, projid uint32, soft, hard string, inodes *xfs.InodeLimits) error
+	clear     func(ctx context.Context, projid uint32) error
+}
+
+func benchBackend(_ *api.Client, args []string) error {
+	const usage = "usage: conquotactl bench-backend --id-min n --id-max n [--concurrency n] [--batch n] [--json]"
+	fs := flag.NewFlagSet("bench-backend", flag.ExitOnError)
+	idMin := fs.Uint("id-min", 0, "first scratch project ID, outside the daemon's project.id range")
+	idMax := fs.Uint("id-max", 0, "last scratch project ID")
+	concurrency := fs.Int("concurrency", 8, "number of simulated containers in flight")
+	batch := fs.Int("batch", xfs.DefaultPipelineBatch, "max_batch of the pipeline")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *idMin == 0 || *idMax < *idMin || *concurrency <= 0 {
+		return usageError(usage)
+	}
+
+	ctx := context.Background()
+	var ids []uint32
+	for id := uint32(*idMin); id <= uint32(*idMax); id++ {
+		// 只使用没有用量与限额的项目 ID，避免改动已有项目
+		if u, err := xfs.GetProjectUsage(ctx, id); err == nil &&
+			(u.UsedBytes != 0 || u.UsedInodes != 0 || u.HardLimitBytes != 0 || u.SoftLimitBytes != 0 || u.InodeHardLimit != 0 || u.InodeSoftLimit != 0) {
+			return fmt.Errorf("project %d has usage or limits, choose an unused ID range", id)
+		}
+		ids = append(ids, id)
+		if id == ^uint32(0) {
+			break
+		}
+	}
+
+	pipeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p := xfs.NewPipeline(pipeCtx, *batch)
+	results := []benchResult{
+		runBench(ctx, "exec", ids, *concurrency, benchOps{xfs.SetProjectQuotaWithXFSQuota, xfs.ClearProjectQuotaWithXFSQuota}),
+		runBench(ctx, "pipeline", ids, *concurrency, benchOps{p.SetLimits, p.Clear}),
+	}
+	for _, id := range ids {
+		xfs.ClearProjectQuotaWithXFSQuota(ctx, id)
+	}
+
+	if *asJSON {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if err := printBench(results); err != nil {
+		return err
+	}
+
+	var failures []failure
+	for _, r := range results {
+		if r.Errors > 0 {
+			failures = append(failures, failure{Item: r.Path, Error: fmt.Sprintf("%d errors, first: %s", r.Errors, r.FirstError)})
+		}
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(results), failures: failures}
+	}
+	return nil
+}
+
+// runBench 以 concurrency 个协程对 ids 中的每个项目执行一次容器生命周期，记录每次的耗时
+func runBench(ctx context.Context, path string, ids []uint32, concurrency int, ops benchOps) benchResult {
+	work := make(chan uint32)
+	var mutex sync.Mutex
+	var latencies []time.Duration
+	result := benchResult{Path: path, Containers: len(ids)}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range work {
+				begin := time.Now()
+				err := ops.setLimits(ctx, id, "1g", "2g", nil)
+				if err == nil {
+					err = ops.setLimits(ctx, id, "", "", &xfs.InodeLimits{Soft: 100000, Hard: 200000})
+				}
+				if err == nil {
+					err = ops.clear(ctx, id)
+				}
+				elapsed := time.Since(begin)
+
+				mutex.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					if result.Errors == 0 {
+						result.FirstError = err.Error()
+					}
+					result.Errors++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		work <- id
+	}
+	close(work)
+	wg.Wait()
+
+	total := time.Since(start)
+	result.Seconds = total.Seconds()
+	if total > 0 {
+		result.PerSecond = float64(len(ids)) / total.Seconds()
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.P50Millis = percentileMillis(latencies, 0.50)
+	result.P99Millis = percentileMillis(latencies, 0.99)
+	return result
+}
+
+// percentileMillis 返回已排序耗时的 p 分位数（毫秒）
+func percentileMillis(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return float64(sorted[i].Microseconds()) / 1000
+}
+
+func printBench(results []benchResult) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tCONTAINERS\tERRORS\tSECONDS\tPER SECOND\tP50 MS\tP99 MS")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.1f\t%.2f\t%.2f\n", r.Path, r.Containers, r.Errors, r.Seconds, r.PerSecond, r.P50Millis, r.P99Millis)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if exec, pipe := results[0], results[1]; exec.PerSecond > 0 && pipe.PerSecond > 0 {
+		fmt.Printf("Pipeline throughput is %.1fx the exec path.\n", pipe.PerSecond/exec.PerSecond)
+	}
+	if !xfs.QuotactlAvailable() {
+		fmt.Println("Warning: quotactl is unavailable here, the pipeline fell back to xfs_quota.")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+)
+
+func explain(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the explanation as JSON")
+	positional := parseInterspersed(fs, args)
+	if len(positional) != 1 {
+		return usageError("usage: conquotactl explain [--json] <container>")
+	}
+
+	var resp api.ExplainResponse
+	if err := c.Do("GET", "/v1/quotas/"+url.PathEscape(positional[0])+"/explain", nil, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp)
+	}
+
+	limits := fmt.Sprintf("soft %s, hard %s", orDash(resp.SoftLimit), orDash(resp.HardLimit))
+	if resp.LiftedUntil != nil {
+		limits += ", lifted until " + formatTime(*resp.LiftedUntil)
+	}
+	if resp.Pinned {
+		limits += ", pinned"
+	}
+	fmt.Printf("Container %s, project %d (%s)\n", resp.ContainerID, resp.ProjectID, limits)
+	if resp.Group != "" {
+		fmt.Printf("Shares the project of %s\n", resp.Group)
+	}
+	if resp.Warning != "" {
+		fmt.Printf("Warning: %s\n", resp.Warning)
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tKIND\tCOUNTED\tHOST PATH\tREASON")
+	for _, p := range resp.Paths {
+		counted := "no"
+		if p.Governed {
+			counted = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", orDash(p.Path), p.Kind, counted, orDash(p.HostPath), p.Reason)
+	}
+	return tw.Flush()
+}
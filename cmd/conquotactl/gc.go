@@ -0,0 +1,66 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func gc(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print what would be released without releasing it")
+	asJSON := fs.Bool("json", false, "print the plan or result as JSON")
+	fs.Parse(args)
+
+	var resp api.GCResponse
+	if err := c.Do("POST", "/v1/gc", api.GCRequest{DryRun: *dryRun}, &resp); err != nil {
+		return err
+	}
+	if err := printGC(resp, *asJSON); err != nil {
+		return err
+	}
+
+	var failures []failure
+	for _, it := range resp.Items {
+		if it.Error != "" {
+			item := it.ContainerID
+			if item == "" {
+				item = fmt.Sprint(it.ProjectID)
+			}
+			failures = append(failures, failure{Item: item, Error: it.Error})
+		}
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(resp.Items), failures: failures}
+	}
+	return nil
+}
+
+func printGC(resp api.GCResponse, asJSON bool) error {
+	if asJSON {
+		return printJSON(resp)
+	}
+	if len(resp.Items) == 0 {
+		fmt.Println("Nothing to collect: no orphaned quotas found.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tPROJECT\tCONTAINER\tDETAIL\tRESULT")
+	for _, it := range resp.Items {
+		result := "planned"
+		switch {
+		case it.Error != "":
+			result = "error: " + it.Error
+		case it.Done:
+			result = "released"
+		}
+		detail := it.Path
+		if it.Limits != "" {
+			detail = it.Limits
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", it.Kind, it.ProjectID, orDash(it.ContainerID), orDash(detail), result)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func historyLimits(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("history-limits", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the history as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return usageError("usage: conquotactl history-limits [--json] <container>")
+	}
+
+	var resp api.LimitHistoryResponse
+	if err := c.Do("GET", "/v1/quotas/"+url.PathEscape(fs.Arg(0))+"/history", nil, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp)
+	}
+
+	fmt.Printf("Container %s (project %d): soft %s, hard %s\n\n", resp.ContainerID, resp.ProjectID, orDash(resp.SoftLimit), orDash(resp.HardLimit))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSOURCE\tSOFT\tHARD")
+	for _, rec := range resp.History {
+		fmt.Fprintf(tw, "%s\t%s\t%s -> %s\t%s -> %s\n",
+			rec.Time.Local().Format(time.RFC3339), rec.Source,
+			orDash(rec.OldSoft), orDash(rec.NewSoft),
+			orDash(rec.OldHard), orDash(rec.NewHard))
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/xfs"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const imagesUsage = "usage: conquotactl images list [--json] | set [--image name] --soft size --hard size <digest> | delete <digest>"
+
+func imagesCommand(c *api.Client, args []string) error {
+	if len(args) == 0 {
+		return usageError(imagesUsage)
+	}
+	switch args[0] {
+	case "list":
+		return listImageOverrides(c, args[1:])
+	case "set":
+		return setImageOverride(c, args[1:])
+	case "delete":
+		if len(args) != 2 {
+			return usageError(imagesUsage)
+		}
+		var resp api.ImageOverridesResponse
+		if err := c.Do("DELETE", "/v1/image-overrides/"+url.PathEscape(args[1]), nil, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("Removed quota override of %s\n", args[1])
+		return nil
+	}
+	return usageError(imagesUsage)
+}
+
+func listImageOverrides(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("images list", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the overrides as JSON")
+	fs.Parse(args)
+
+	var resp api.ImageOverridesResponse
+	if err := c.Do("GET", "/v1/image-overrides", nil, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp.Overrides)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DIGEST\tIMAGE\tSOFT\tHARD\tSOURCE\tBREACHES\tUPDATED")
+	for _, o := range resp.Overrides {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", o.Digest, o.Image, o.SoftLimit, o.HardLimit,
+			o.Source, o.Breaches, o.UpdatedAt.Local().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func setImageOverride(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("images set", flag.ExitOnError)
+	image := fs.String("image", "", "image name, for display only")
+	soft := fs.String("soft", "", "soft limit, e.g. 8g")
+	hard := fs.String("hard", "", "hard limit, e.g. 10g")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *soft == "" || *hard == "" {
+		return usageError(imagesUsage)
+	}
+
+	var o xfs.ImageOverride
+	req := api.ImageOverrideRequest{Image: *image, Soft: *soft, Hard: *hard}
+	if err := c.Do("PUT", "/v1/image-overrides/"+url.PathEscape(fs.Arg(0)), req, &o); err != nil {
+		return err
+	}
+	fmt.Printf("Containers of %s now start with soft %s, hard %s\n", o.Digest, o.SoftLimit, o.HardLimit)
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+func liftLimits(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("lift", flag.ExitOnError)
+	duration := fs.Duration("for", 15*time.Minute, "how long the limits stay lifted")
+	restore := fs.Bool("restore", false, "restore lifted limits now")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return usageError("usage: conquotactl lift [--for duration | --restore] <container>")
+	}
+	path := "/v1/quotas/" + url.PathEscape(fs.Arg(0)) + "/lift"
+
+	if *restore {
+		var resp api.LimitsResponse
+		if err := c.Do("DELETE", path, nil, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("Restored limits of %s: soft %s, hard %s\n", resp.ContainerID, resp.Soft, resp.Hard)
+		return nil
+	}
+
+	var resp api.LiftResponse
+	req := api.LiftRequest{DurationSeconds: int(duration.Seconds())}
+	if err := c.Do("POST", path, req, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Lifted limits of %s until %s (restoring soft %s, hard %s)\n",
+		resp.ContainerID, resp.Until.Local().Format(time.RFC3339), resp.Soft, resp.Hard)
+	return nil
+}
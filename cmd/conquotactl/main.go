@@ -0,0 +1,101 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command 为 conquotactl 子命令
+type command struct {
+	usage string
+	run   func(c *api.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"accounting":      {usage: "accounting [--from date] [--to date] [--namespace ns] [--json]", run: showAccounting},
+	"bench-backend":   {usage: "bench-backend --id-min n --id-max n [--concurrency n] [--batch n] [--json]", run: benchBackend},
+	"apply-policy":    {usage: "apply-policy --policy <file> [--json]", run: applyPolicy},
+	"diff":            {usage: "diff --policy <file> [--json]", run: diffPolicy},
+	"evaluate":        {usage: "evaluate --policy <file> [--containers <file>] [--save <file>] [--json]", run: evaluatePolicy},
+	"explain":         {usage: "explain [--json] <container>", run: explain},
+	"gc":              {usage: "gc [--dry-run] [--json]", run: gc},
+	"get":             {usage: "get [--json] <container>", run: getQuota},
+	"history-limits":  {usage: "history-limits [--json] <container>", run: historyLimits},
+	"lift":            {usage: "lift [--for duration | --restore] <container>", run: liftLimits},
+	"images":          {usage: "images list [--json] | set [--image name] --soft size --hard size <digest> | delete <digest>", run: imagesCommand},
+	"inspect-mounts":  {usage: "inspect-mounts [--namespace ns] <container>", run: inspectMounts},
+	"list":            {usage: "list [--namespace ns] [--json]", run: listQuotas},
+	"rebalance":       {usage: "rebalance start --id-min n --id-max n [--dry-run] [--wait] | status [--json] | pause | resume [--wait] | rollback [--wait] | finish", run: rebalanceCommand},
+	"release":         {usage: "release <container>", run: releaseQuota},
+	"report":          {usage: "report [--namespace ns] [--bytes] [--json]", run: report},
+	"resync":          {usage: "resync [--dry-run] [--json]", run: resync},
+	"state":           {usage: "state fsck [--repair] [--file path [--id-min n --id-max n]] [--json]", run: stateCommand},
+	"set":             {usage: "set <container> [--soft size] [--hard size]", run: setQuota},
+	"unpin":           {usage: "unpin <container>", run: unpinQuota},
+	"top":             {usage: "top [--interval 2s] [--namespace ns] [--sort percent|used|hard|name] [-n count] [--once]", run: top},
+	"selftest":        {usage: "selftest [--path dir]... [--json]", run: selftest},
+	"verify":          {usage: "verify [--repair] [--json]", run: verify},
+	"validate-config": {usage: "validate-config --config <file>", run: validateConfig},
+	"pool":            {usage: "pool status [--json]", run: poolCommand},
+	"promote":         {usage: "promote", run: promote},
+}
+
+func main() {
+	addr := flag.String("addr", envOr("CONQUOTAS_ADDR", "http://127.0.0.1:9101"), "Admin API address of the daemon")
+	summaryFile := flag.String("summary-file", "", "Write the outcome of the command as JSON to this file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	err := cmd.run(api.NewClient(*addr), flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+	result := outcome(flag.Arg(0), err)
+	if *summaryFile != "" {
+		if err := writeSummary(*summaryFile, result); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+	}
+	os.Exit(result.ExitCode)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: conquotactl [--addr url] [--summary-file path] <command> [args]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/snapshot"
+	"flag"
+	"net/url"
+)
+
+func inspectMounts(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("inspect-mounts", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "containerd namespace of the container")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return usageError("usage: conquotactl inspect-mounts [--namespace ns] <container>")
+	}
+
+	path := "/v1/containers/" + url.PathEscape(fs.Arg(0)) + "/mounts"
+	if *namespace != "" {
+		path += "?namespace=" + url.QueryEscape(*namespace)
+	}
+	var info snapshot.MountInfo
+	if err := c.Do("GET", path, nil, &info); err != nil {
+		return err
+	}
+	return printJSON(info)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 稳定的退出码，供部署流水线按结果分支
+const (
+	exitOK = 0
+	// exitFatal 表示命令未能执行，如无法连接守护进程、输入无效
+	exitFatal = 1
+	// exitUsage 表示命令行参数错误
+	exitUsage = 2
+	// exitPartial 表示命令已执行，但其中部分条目失败
+	exitPartial = 3
+)
+
+// failure 为单个失败的条目
+type failure struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// partialError 表示命令已执行但部分条目失败，退出码为 exitPartial
+type partialError struct {
+	total    int
+	failures []failure
+}
+
+func (e *partialError) Error() string {
+	return fmt.Sprintf("%d of %d failed", len(e.failures), e.total)
+}
+
+// usageError 表示子命令的参数有误，内容为用法说明或具体原因，退出码为 exitUsage
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// summary 为 --summary-file 写入的结果摘要
+type summary struct {
+	Command  string    `json:"command"`
+	Status   string    `json:"status"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	Failures []failure `json:"failures,omitempty"`
+}
+
+// outcome 将命令的返回值归类为退出码与摘要
+func outcome(name string, err error) summary {
+	s := summary{Command: name, Status: "ok", ExitCode: exitOK}
+	if err == nil {
+		return s
+	}
+	s.Error = err.Error()
+	var partial *partialError
+	if errors.As(err, &partial) {
+		s.Status, s.ExitCode, s.Failures = "partial", exitPartial, partial.failures
+		return s
+	}
+	var usage usageError
+	if errors.As(err, &usage) {
+		s.Status, s.ExitCode = "usage", exitUsage
+		return s
+	}
+	s.Status, s.ExitCode = "fatal", exitFatal
+	return s
+}
+
+// writeSummary 原子写入结果摘要
+func writeSummary(path string, s summary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write summary file: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
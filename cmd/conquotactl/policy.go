@@ -0,0 +1,157 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/policy"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func diffPolicy(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	path := fs.String("policy", "", "policy file (YAML or JSON)")
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	fs.Parse(args)
+	if *path == "" || fs.NArg() != 0 {
+		return usageError("usage: conquotactl diff --policy <file> [--json]")
+	}
+
+	p, err := policy.Load(*path)
+	if err != nil {
+		return err
+	}
+	var resp api.PolicyDiffResponse
+	if err := c.Do("POST", "/v1/policy/diff", p, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp)
+	}
+	if len(resp.Changes) == 0 {
+		fmt.Println("No changes, node matches the policy.")
+		return nil
+	}
+	printChanges(resp.Changes)
+	return nil
+}
+
+func printChanges(changes []policy.Change) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tNAMESPACE\tRULE\tCURRENT\tDESIRED\tSTATUS")
+	for _, ch := range changes {
+		status := ""
+		switch {
+		case ch.Error != "":
+			status = "error: " + ch.Error
+		case ch.RolledBack:
+			status = "rolled back"
+		case ch.Applied:
+			status = "applied"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s/%s\t%s/%s\t%s\n",
+			ch.ContainerID, ch.Namespace, ch.Rule, ch.OldSoft, ch.OldHard, ch.NewSoft, ch.NewHard, status)
+	}
+	tw.Flush()
+}
+
+func applyPolicy(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("apply-policy", flag.ExitOnError)
+	path := fs.String("policy", "", "policy file (YAML or JSON)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+	if *path == "" || fs.NArg() != 0 {
+		return usageError("usage: conquotactl apply-policy --policy <file> [--json]")
+	}
+
+	p, err := policy.Load(*path)
+	if err != nil {
+		return err
+	}
+	var resp api.PolicyApplyResponse
+	err = c.Do("POST", "/v1/policy/apply", p, &resp)
+	if *asJSON {
+		if perr := printJSON(resp); perr != nil {
+			return perr
+		}
+	} else if len(resp.Changes) > 0 {
+		printChanges(resp.Changes)
+	} else if err == nil {
+		fmt.Println("No changes, node matches the policy.")
+	}
+	if err != nil && resp.Error != "" {
+		// 策略已执行但有修改失败，已应用的修改被回滚
+		var failures []failure
+		for _, ch := range resp.Changes {
+			if ch.Error != "" {
+				failures = append(failures, failure{Item: ch.ContainerID, Error: ch.Error})
+			}
+		}
+		if len(failures) == 0 {
+			failures = append(failures, failure{Error: resp.Error})
+		}
+		return &partialError{total: len(resp.Changes), failures: failures}
+	}
+	return err
+}
+
+// evaluatePolicy 在本地计算策略对每个容器的决定，容器元数据取自 --containers 文件或守护进程，
+// 便于 CI 在合并前对记录的元数据验证策略修改
+func evaluatePolicy(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	path := fs.String("policy", "", "policy file (YAML or JSON)")
+	containers := fs.String("containers", "", "recorded container metadata (JSON from GET /v1/policy/targets); default is the live node")
+	save := fs.String("save", "", "write the container metadata used to this file")
+	asJSON := fs.Bool("json", false, "print the decisions as JSON")
+	fs.Parse(args)
+	if *path == "" || fs.NArg() != 0 {
+		return usageError("usage: conquotactl evaluate --policy <file> [--containers <file>] [--save <file>] [--json]")
+	}
+
+	p, err := policy.Load(*path)
+	if err != nil {
+		return err
+	}
+	var targets api.PolicyTargetsResponse
+	if *containers != "" {
+		data, err := os.ReadFile(*containers)
+		if err != nil {
+			return fmt.Errorf("failed to read container metadata: %v", err)
+		}
+		if err := json.Unmarshal(data, &targets); err != nil {
+			return fmt.Errorf("failed to parse container metadata: %v", err)
+		}
+	} else if err := c.Do("GET", "/v1/policy/targets", nil, &targets); err != nil {
+		return err
+	}
+	if *save != "" {
+		data, err := json.MarshalIndent(targets, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*save, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write container metadata: %v", err)
+		}
+	}
+
+	decisions := make([]policy.Decision, 0, len(targets.Containers))
+	for _, meta := range targets.Containers {
+		decisions = append(decisions, policy.EvaluatePolicy(p, meta))
+	}
+	if *asJSON {
+		return printJSON(decisions)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tNAMESPACE\tACTION\tRULE\tCURRENT\tDESIRED\tREASON")
+	for _, d := range decisions {
+		desired := "-"
+		if d.NewHard != "" {
+			desired = d.NewSoft + "/" + d.NewHard
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s/%s\t%s\t%s\n",
+			d.ContainerID, orDash(d.Namespace), d.Action, orDash(d.Rule), orDash(d.OldSoft), orDash(d.OldHard), desired, orDash(d.Reason))
+	}
+	return tw.Flush()
+}
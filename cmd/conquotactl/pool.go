@@ -0,0 +1,37 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/xfs"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func poolCommand(c *api.Client, args []string) error {
+	if len(args) == 0 || args[0] != "status" {
+		return usageError("usage: conquotactl pool status [--json]")
+	}
+	fs := flag.NewFlagSet("pool status", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the statistics as JSON")
+	fs.Parse(args[1:])
+
+	var stats xfs.PoolStats
+	if err := c.Do("GET", "/v1/pool", nil, &stats); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(stats)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Range:\t%d-%d (%d IDs)\n", stats.Min, stats.Max, stats.Size)
+	fmt.Fprintf(tw, "Used:\t%d (peak %d)\n", stats.Used, stats.PeakUsed)
+	fmt.Fprintf(tw, "Free:\t%d\n", stats.Free)
+	fmt.Fprintf(tw, "Largest free run:\t%d\n", stats.LargestFreeRun)
+	fmt.Fprintf(tw, "Allocations:\t%d (%d in the last hour)\n", stats.Allocations, stats.AllocationsPerHour)
+	fmt.Fprintf(tw, "Releases:\t%d\n", stats.Releases)
+	fmt.Fprintf(tw, "Recommended size:\t%d\n", stats.RecommendedSize)
+	return tw.Flush()
+}
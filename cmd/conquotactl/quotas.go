@@ -0,0 +1,177 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func listQuotas(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "only list quotas of this containerd namespace")
+	asJSON := fs.Bool("json", false, "print the quotas as JSON")
+	fs.Parse(args)
+
+	path := "/v1/quotas"
+	if *namespace != "" {
+		path += "?namespace=" + url.QueryEscape(*namespace)
+	}
+	var resp api.QuotaListResponse
+	if err := c.Do("GET", path, nil, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp.Quotas)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tNAMESPACE\tPROJECT\tSOFT\tHARD\tGROUP\tCREATED")
+	for _, q := range resp.Quotas {
+		soft, hard := orDash(q.SoftLimit), orDash(q.HardLimit)
+		if q.LiftedUntil != nil {
+			hard += " (lifted)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", q.ContainerID, orDash(q.Namespace), q.ProjectID,
+			soft, hard, orDash(q.Group), formatTime(q.CreatedAt))
+	}
+	return tw.Flush()
+}
+
+func getQuota(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the quota and usage as JSON")
+	positional := parseInterspersed(fs, args)
+	if len(positional) != 1 {
+		return usageError("usage: conquotactl get [--json] <container>")
+	}
+	id := url.PathEscape(positional[0])
+
+	var quota api.QuotaResponse
+	if err := c.Do("GET", "/v1/quotas/"+id, nil, &quota); err != nil {
+		return err
+	}
+	// 用量查询失败（如容器所在文件系统不可读）时仍显示配额
+	var usage api.UsageResponse
+	usageErr := c.Do("GET", "/v1/quotas/"+id+"/usage", nil, &usage)
+	if *asJSON {
+		out := struct {
+			Quota api.QuotaResponse  `json:"quota"`
+			Usage *api.UsageResponse `json:"usage,omitempty"`
+		}{Quota: quota}
+		if usageErr == nil {
+			out.Usage = &usage
+		}
+		return printJSON(out)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Container:\t%s\n", quota.ContainerID)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", orDash(quota.Namespace))
+	fmt.Fprintf(tw, "Project:\t%d\n", quota.ProjectID)
+	if quota.Group != "" {
+		fmt.Fprintf(tw, "Group:\t%s\n", quota.Group)
+	}
+	fmt.Fprintf(tw, "Upperdir:\t%s\n", orDash(quota.Upperdir))
+	fmt.Fprintf(tw, "Soft:\t%s\n", orDash(quota.SoftLimit))
+	fmt.Fprintf(tw, "Hard:\t%s\n", orDash(quota.HardLimit))
+	if quota.Class != "" {
+		fmt.Fprintf(tw, "Class:\t%s\n", quota.Class)
+	}
+	if quota.InodeSoft > 0 || quota.InodeHard > 0 {
+		fmt.Fprintf(tw, "Inodes:\tsoft %d, hard %d\n", quota.InodeSoft, quota.InodeHard)
+	}
+	if quota.LiftedUntil != nil {
+		fmt.Fprintf(tw, "Lifted until:\t%s\n", formatTime(*quota.LiftedUntil))
+	}
+	if quota.Pinned {
+		fmt.Fprintf(tw, "Pinned:\tyes, policy and resize leave the limits alone\n")
+	}
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(quota.CreatedAt))
+	if usageErr != nil {
+		fmt.Fprintf(tw, "Used:\tunavailable (%v)\n", usageErr)
+	} else {
+		fmt.Fprintf(tw, "Used:\t%s (%.1f%% of hard), %d inodes\n", formatBytes(usage.UsedBytes), usage.Percent, usage.UsedInodes)
+	}
+	return tw.Flush()
+}
+
+func setQuota(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	soft := fs.String("soft", "", "soft limit, e.g. 15g; keeps the current one when omitted")
+	hard := fs.String("hard", "", "hard limit, e.g. 20g; keeps the current one when omitted")
+	positional := parseInterspersed(fs, args)
+	if len(positional) != 1 || (*soft == "" && *hard == "") {
+		return usageError("usage: conquotactl set <container> [--soft size] [--hard size]")
+	}
+	id := url.PathEscape(positional[0])
+
+	req := api.LimitsRequest{Soft: *soft, Hard: *hard}
+	if req.Soft == "" || req.Hard == "" {
+		var quota api.QuotaResponse
+		if err := c.Do("GET", "/v1/quotas/"+id, nil, &quota); err != nil {
+			return err
+		}
+		if req.Soft == "" {
+			req.Soft = quota.SoftLimit
+		}
+		if req.Hard == "" {
+			req.Hard = quota.HardLimit
+		}
+	}
+
+	var resp api.LimitsResponse
+	if err := c.Do("PUT", "/v1/quotas/"+id, req, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Set limits of %s: soft %s, hard %s (pinned, undo with conquotactl unpin)\n", resp.ContainerID, resp.Soft, resp.Hard)
+	return nil
+}
+
+func unpinQuota(c *api.Client, args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: conquotactl unpin <container>")
+	}
+	var resp api.QuotaResponse
+	if err := c.Do("DELETE", "/v1/quotas/"+url.PathEscape(args[0])+"/pin", nil, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Unpinned limits of %s: soft %s, hard %s, policy and resize apply again\n", resp.ContainerID, resp.SoftLimit, resp.HardLimit)
+	return nil
+}
+
+func releaseQuota(c *api.Client, args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: conquotactl release <container>")
+	}
+	var resp api.QuotaResponse
+	if err := c.Do("DELETE", "/v1/quotas/"+url.PathEscape(args[0]), nil, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Released quota of %s (project %d)\n", resp.ContainerID, resp.ProjectID)
+	return nil
+}
+
+// parseInterspersed 解析参数，允许参数出现在标志之前，如 set <container> --hard 20g
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
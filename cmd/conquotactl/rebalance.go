@@ -0,0 +1,118 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const rebalanceUsage = "usage: conquotactl rebalance start --id-min n --id-max n [--dry-run] [--wait] | status [--json] | pause | resume [--wait] | rollback [--wait] | finish"
+
+// rebalanceWaitInterval 为 --wait 时查询进度的间隔
+const rebalanceWaitInterval = 2 * time.Second
+
+func rebalanceCommand(c *api.Client, args []string) error {
+	if len(args) == 0 {
+		return usageError(rebalanceUsage)
+	}
+	switch args[0] {
+	case "start":
+		return startRebalance(c, args[1:])
+	case "status":
+		fs := flag.NewFlagSet("rebalance status", flag.ExitOnError)
+		asJSON := fs.Bool("json", false, "print the progress as JSON")
+		fs.Parse(args[1:])
+		var resp api.RebalanceResponse
+		if err := c.Do("GET", "/v1/rebalance", nil, &resp); err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(resp)
+		}
+		return printRebalance(resp)
+	case "finish":
+		var resp api.RebalanceResponse
+		if err := c.Do("DELETE", "/v1/rebalance", nil, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("Finished rebalance to %d-%d (%s)\n", resp.IDMin, resp.IDMax, resp.State)
+		return nil
+	case "pause", "resume", "rollback":
+		fs := flag.NewFlagSet("rebalance "+args[0], flag.ExitOnError)
+		wait := fs.Bool("wait", false, "wait until the rebalance stops running and print the result")
+		fs.Parse(args[1:])
+		var resp api.RebalanceResponse
+		if err := c.Do("POST", "/v1/rebalance/"+args[0], nil, &resp); err != nil {
+			return err
+		}
+		if *wait {
+			return waitRebalance(c)
+		}
+		printRebalanceSummary(resp)
+		return nil
+	}
+	return usageError(rebalanceUsage)
+}
+
+func startRebalance(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("rebalance start", flag.ExitOnError)
+	idMin := fs.Uint("id-min", 0, "first project ID of the new range")
+	idMax := fs.Uint("id-max", 0, "last project ID of the new range")
+	dryRun := fs.Bool("dry-run", false, "only show which projects would move")
+	wait := fs.Bool("wait", false, "wait until the rebalance stops running and print the result")
+	fs.Parse(args)
+	if *idMin == 0 || *idMax == 0 {
+		return usageError(rebalanceUsage)
+	}
+
+	var resp api.RebalanceResponse
+	req := api.RebalanceRequest{IDMin: uint32(*idMin), IDMax: uint32(*idMax), DryRun: *dryRun}
+	if err := c.Do("POST", "/v1/rebalance", req, &resp); err != nil {
+		return err
+	}
+	if *dryRun || !*wait {
+		return printRebalance(resp)
+	}
+	return waitRebalance(c)
+}
+
+// waitRebalance 周期打印进度，直到迁移不再运行，然后打印每个容器的结果
+func waitRebalance(c *api.Client) error {
+	for {
+		var resp api.RebalanceResponse
+		if err := c.Do("GET", "/v1/rebalance", nil, &resp); err != nil {
+			return err
+		}
+		if resp.State != "running" && resp.State != "rolling_back" {
+			return printRebalance(resp)
+		}
+		printRebalanceSummary(resp)
+		time.Sleep(rebalanceWaitInterval)
+	}
+}
+
+func printRebalanceSummary(resp api.RebalanceResponse) {
+	fmt.Printf("Rebalance to %d-%d: %s, %d projects, %d moved, %d failed, %d remaining\n",
+		resp.IDMin, resp.IDMax, resp.State, resp.Projects, resp.Moved, resp.Failed, resp.Remaining)
+}
+
+func printRebalance(resp api.RebalanceResponse) error {
+	printRebalanceSummary(resp)
+	if len(resp.Containers) == 0 {
+		return nil
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tOLD ID\tNEW ID\tSTATUS\tERROR")
+	for _, ct := range resp.Containers {
+		newID := "-"
+		if ct.NewID != 0 {
+			newID = fmt.Sprint(ct.NewID)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", ct.ContainerID, ct.OldID, newID, ct.Status, orDash(ct.Error))
+	}
+	return tw.Flush()
+}
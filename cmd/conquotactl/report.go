@@ -0,0 +1,63 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// report 打印所有已管理容器的镜像、项目 ID、限额与实时用量，适合由 cron 定期生成容量报告
+func report(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "only report containers of this containerd namespace")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	rawBytes := fs.Bool("bytes", false, "print sizes in bytes instead of human-readable units")
+	fs.Parse(args)
+
+	path := "/v1/report"
+	if *namespace != "" {
+		path += "?namespace=" + url.QueryEscape(*namespace)
+	}
+	var resp api.ReportResponse
+	if err := c.Do("GET", path, nil, &resp); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp)
+	}
+
+	size := func(b uint64) string {
+		if *rawBytes {
+			return strconv.FormatUint(b, 10)
+		}
+		return limitOrDash(b)
+	}
+	count := func(n uint64) string {
+		if n == 0 {
+			return "-"
+		}
+		return strconv.FormatUint(n, 10)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tNAMESPACE\tIMAGE\tPROJECT\tSOFT\tHARD\tUSED\tINODE SOFT\tINODE HARD\tINODES")
+	var used, hard, inodes uint64
+	for _, u := range resp.Containers {
+		usedBytes, usedInodes := size(u.UsedBytes), strconv.FormatUint(u.UsedInodes, 10)
+		if u.UsageMissing {
+			usedBytes, usedInodes = "-", "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", u.ContainerID, orDash(u.Namespace), orDash(u.Image),
+			u.ProjectID, size(u.SoftLimitBytes), size(u.HardLimitBytes), usedBytes,
+			count(u.InodeSoftLimit), count(u.InodeHardLimit), usedInodes)
+		used += u.UsedBytes
+		hard += u.HardLimitBytes
+		inodes += u.UsedInodes
+	}
+	fmt.Fprintf(tw, "TOTAL (%d)\t\t\t\t\t%s\t%s\t\t\t%d\n", len(resp.Containers), size(hard), size(used), inodes)
+	return tw.Flush()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func resync(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("resync", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the plan without executing it")
+	asJSON := fs.Bool("json", false, "print the plan or result as JSON")
+	fs.Parse(args)
+
+	var resp api.ResyncResponse
+	if err := c.Do("POST", "/v1/resync", api.ResyncRequest{DryRun: *dryRun}, &resp); err != nil {
+		return err
+	}
+	if err := printResync(resp, *asJSON); err != nil {
+		return err
+	}
+
+	var failures []failure
+	for _, a := range resp.Actions {
+		if a.Error != "" {
+			failures = append(failures, failure{Item: a.ContainerID, Error: a.Error})
+		}
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(resp.Actions), failures: failures}
+	}
+	return nil
+}
+
+func printResync(resp api.ResyncResponse, asJSON bool) error {
+	if asJSON {
+		return printJSON(resp)
+	}
+	if len(resp.Actions) == 0 {
+		fmt.Println("Nothing to do: state, filesystem and containerd agree.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tCONTAINER\tNAMESPACE\tPROJECT\tREASON\tRESULT")
+	for _, a := range resp.Actions {
+		result := "planned"
+		switch {
+		case a.Error != "":
+			result = "error: " + a.Error
+		case a.Done:
+			result = "done"
+		}
+		project := "-"
+		if a.ProjectID != 0 {
+			project = fmt.Sprint(a.ProjectID)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", a.Action, a.ContainerID, a.Namespace, project, a.Reason, result)
+	}
+	return tw.Flush()
+}
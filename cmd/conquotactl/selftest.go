@@ -0,0 +1,127 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/quota"
+	"RootfsQuota/pkg/xfs"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// stringList 为可重复的字符串参数
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func validateConfig(_ *api.Client, args []string) error {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	path := fs.String("config", "", "configuration file to validate")
+	fs.Parse(args)
+	if *path == "" || fs.NArg() != 0 {
+		return usageError("usage: conquotactl validate-config --config <file>")
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	if _, err := config.Parse(data); err != nil {
+		return err
+	}
+	fmt.Println("Configuration is valid.")
+	return nil
+}
+
+// check 为 selftest 的单项检查结果
+type check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+func selftest(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	var paths stringList
+	fs.Var(&paths, "path", "directory whose filesystem must support project quotas (repeatable)")
+	asJSON := fs.Bool("json", false, "print the checks as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		return usageError("usage: conquotactl selftest [--path dir]... [--json]")
+	}
+
+	// 守护进程不可达时其余检查没有意义，按致命错误退出
+	var standby api.StandbyResponse
+	if err := c.Do("GET", "/v1/standby", nil, &standby); err != nil {
+		return fmt.Errorf("admin API is unreachable: %v", err)
+	}
+	checks := []check{{Name: "admin-api", OK: true, Detail: "reachable"}}
+	if standby.Standby {
+		checks = append(checks, check{Name: "standby", OK: false, Detail: "instance is in standby and does not set quotas"})
+	} else {
+		checks = append(checks, check{Name: "standby", OK: true, Detail: "instance is active"})
+	}
+
+	var stats xfs.PoolStats
+	if err := c.Do("GET", "/v1/pool", nil, &stats); err != nil {
+		checks = append(checks, check{Name: "project-ids", Detail: err.Error()})
+	} else {
+		checks = append(checks, check{Name: "project-ids", OK: stats.Free > 0, Detail: fmt.Sprintf("%d of %d free", stats.Free, stats.Size)})
+	}
+
+	var plan api.ResyncResponse
+	if err := c.Do("POST", "/v1/resync", api.ResyncRequest{DryRun: true}, &plan); err != nil {
+		checks = append(checks, check{Name: "consistency", Detail: err.Error()})
+	} else if len(plan.Actions) > 0 {
+		checks = append(checks, check{Name: "consistency", Detail: fmt.Sprintf("resync would take %d actions", len(plan.Actions))})
+	} else {
+		checks = append(checks, check{Name: "consistency", OK: true, Detail: "state, filesystem and containerd agree"})
+	}
+
+	for _, path := range paths {
+		name := "filesystem:" + path
+		if b, err := quota.Detect(path); err != nil {
+			checks = append(checks, check{Name: name, Detail: err.Error()})
+		} else {
+			checks = append(checks, check{Name: name, OK: true, Detail: b.Name() + " project quotas"})
+		}
+	}
+
+	if err := printChecks(checks, *asJSON); err != nil {
+		return err
+	}
+	var failures []failure
+	for _, ch := range checks {
+		if !ch.OK {
+			failures = append(failures, failure{Item: ch.Name, Error: ch.Detail})
+		}
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(checks), failures: failures}
+	}
+	return nil
+}
+
+func printChecks(checks []check, asJSON bool) error {
+	if asJSON {
+		return printJSON(checks)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT\tDETAIL")
+	for _, ch := range checks {
+		result := "ok"
+		if !ch.OK {
+			result = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", ch.Name, result, ch.Detail)
+	}
+	return tw.Flush()
+}
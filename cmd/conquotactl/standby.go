@@ -0,0 +1,18 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"fmt"
+)
+
+func promote(c *api.Client, args []string) error {
+	if len(args) != 0 {
+		return usageError("usage: conquotactl promote")
+	}
+	var resp api.StandbyResponse
+	if err := c.Do("POST", "/v1/standby/promote", nil, &resp); err != nil {
+		return err
+	}
+	fmt.Println("Instance is active.")
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/xfs"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func stateCommand(c *api.Client, args []string) error {
+	const usage = "usage: conquotactl state fsck [--repair] [--file path [--id-min n --id-max n]] [--json]"
+	if len(args) == 0 || args[0] != "fsck" {
+		return usageError(usage)
+	}
+	fs := flag.NewFlagSet("state fsck", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "repair the problems that can be fixed automatically and compact the state")
+	file := fs.String("file", "", "check this state file directly instead of the daemon's state; the daemon must be stopped")
+	idMin := fs.Uint("id-min", 0, "lower bound of the project ID range, with --file")
+	idMax := fs.Uint("id-max", 0, "upper bound of the project ID range, with --file")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args[1:])
+	if fs.NArg() != 0 || (*file == "" && (*idMin != 0 || *idMax != 0)) {
+		return usageError(usage)
+	}
+
+	var report xfs.FsckReport
+	var err error
+	if *file != "" {
+		report, err = xfs.FsckFile(*file, uint32(*idMin), uint32(*idMax), *repair)
+	} else {
+		err = c.Do("POST", "/v1/state/fsck", api.FsckRequest{Repair: *repair}, &report)
+	}
+	if err != nil {
+		return err
+	}
+	if err := printFsck(report, *asJSON); err != nil {
+		return err
+	}
+
+	var failures []failure
+	for _, f := range report.Findings {
+		if !f.Repaired {
+			failures = append(failures, failure{Item: f.Key, Error: f.Problem + ": " + f.Detail})
+		}
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(report.Findings), failures: failures}
+	}
+	return nil
+}
+
+func printFsck(report xfs.FsckReport, asJSON bool) error {
+	if asJSON {
+		return printJSON(report)
+	}
+	if len(report.Findings) == 0 {
+		fmt.Printf("State is consistent: %d entries checked.\n", report.Entries)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tPROBLEM\tDETAIL\tFIX")
+		for _, f := range report.Findings {
+			fix := orDash(f.Fix)
+			switch {
+			case f.Repaired:
+				fix = "repaired: " + f.Fix
+			case f.Fix == "":
+				fix = "manual"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Key, f.Problem, f.Detail, fix)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if report.Repair {
+		fmt.Printf("Compacted %d limit histories", report.Compacted)
+		if report.BytesBefore > 0 {
+			fmt.Printf(", state file %d -> %d bytes", report.BytesBefore, report.BytesAfter)
+		}
+		fmt.Println(".")
+	}
+	return nil
+}
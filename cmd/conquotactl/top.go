@@ -0,0 +1,308 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// top 的排序方式，按 s 键循环切换
+var topSorts = []string{"percent", "used", "hard", "name"}
+
+// 终端控制序列
+const (
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiHome       = "\x1b[H"
+	ansiClearBelow = "\x1b[J"
+	ansiClearLine  = "\x1b[K"
+	ansiReverse    = "\x1b[7m"
+	ansiRed        = "\x1b[31m"
+	ansiYellow     = "\x1b[33m"
+	ansiReset      = "\x1b[0m"
+)
+
+const topBarWidth = 20
+
+// topView 为 top 的显示选项
+type topView struct {
+	namespace string
+	sortBy    string
+	limit     int
+	color     bool
+}
+
+func top(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	interval := fs.Duration("interval", 2*time.Second, "refresh interval")
+	namespace := fs.String("namespace", "", "only show containers of this containerd namespace")
+	sortBy := fs.String("sort", "percent", "sort by "+strings.Join(topSorts, ", "))
+	limit := fs.Int("n", 0, "number of containers to show, 0 fits the terminal")
+	once := fs.Bool("once", false, "print one snapshot and exit, the default when stdout is not a terminal")
+	fs.Parse(args)
+	if *interval < 500*time.Millisecond {
+		return usageError("--interval must be at least 500ms")
+	}
+	if !slices.Contains(topSorts, *sortBy) {
+		return usageError("--sort must be one of " + strings.Join(topSorts, ", "))
+	}
+
+	view := topView{namespace: *namespace, sortBy: *sortBy, limit: *limit}
+	if *once || !isTerminal(os.Stdout) || !isTerminal(os.Stdin) {
+		snapshot, err := fetchUsage(c)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(renderTop(snapshot, view, 0))
+		return nil
+	}
+	view.color = true
+	return runTop(c, view, *interval)
+}
+
+// runTop 在备用屏幕中周期刷新，q 退出，s 切换排序，空格立即刷新
+func runTop(c *api.Client, view topView, interval time.Duration) error {
+	restore, err := rawMode(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer func() {
+		fmt.Print(ansiShowCursor + ansiMainScreen)
+		restore()
+	}()
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buf); err != nil || n == 0 {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGWINCH)
+	defer signal.Stop(sigCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var snapshot api.UsageSnapshotResponse
+	var fetchErr error
+	refresh := func() { snapshot, fetchErr = fetchUsage(c) }
+	draw := func() {
+		var out []byte
+		if fetchErr != nil {
+			out = []byte(fmt.Sprintf("conquotas top - %s\n\n%serror: %v%s\n",
+				time.Now().Format("15:04:05"), ansiRed, fetchErr, ansiReset))
+		} else {
+			out = renderTop(snapshot, view, terminalRows())
+		}
+		// 逐行清除行尾，避免上一帧较长的内容残留
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte(ansiClearLine+"\r\n"))
+		os.Stdout.Write(append(append([]byte(ansiHome), out...), ansiClearBelow...))
+	}
+
+	refresh()
+	draw()
+	for {
+		select {
+		case <-ticker.C:
+			refresh()
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch key {
+			case 'q', 'Q', 3:
+				return nil
+			case 's':
+				view.sortBy = topSorts[(slices.Index(topSorts, view.sortBy)+1)%len(topSorts)]
+			case ' ':
+				refresh()
+			}
+		case sig := <-sigCh:
+			if sig != syscall.SIGWINCH {
+				return nil
+			}
+		}
+		draw()
+	}
+}
+
+func fetchUsage(c *api.Client) (api.UsageSnapshotResponse, error) {
+	var resp api.UsageSnapshotResponse
+	err := c.Do("GET", "/v1/usage", nil, &resp)
+	return resp, err
+}
+
+// renderTop 生成一帧：汇总行与按排序方式排列的容器表，rows 为终端行数，0 表示不限制
+func renderTop(snapshot api.UsageSnapshotResponse, view topView, rows int) []byte {
+	var list []api.ContainerUsage
+	for _, u := range snapshot.Containers {
+		if view.namespace == "" || u.Namespace == view.namespace {
+			list = append(list, u)
+		}
+	}
+	sortUsage(list, view.sortBy)
+
+	var overSoft, near int
+	var used uint64
+	for _, u := range list {
+		used += u.UsedBytes
+		if u.SoftLimitBytes > 0 && u.UsedBytes > u.SoftLimitBytes {
+			overSoft++
+		}
+		if u.Percent >= 90 {
+			near++
+		}
+	}
+
+	var b bytes.Buffer
+	age := time.Since(snapshot.At).Round(time.Second)
+	fmt.Fprintf(&b, "conquotas top - %s  source: %s (%s old)  sort: %s\n",
+		time.Now().Format("15:04:05"), snapshot.Source, age, view.sortBy)
+	fmt.Fprintf(&b, "Containers: %d  used: %s  over soft: %d  at 90%%+ of hard: %d\n\n",
+		len(list), formatBytes(used), overSoft, near)
+
+	// 汇总两行、空行、表头，交互模式下末尾留一行按键提示
+	shown := len(list)
+	if rows > 0 {
+		shown = min(shown, max(rows-5, 0))
+	}
+	if view.limit > 0 {
+		shown = min(shown, view.limit)
+	}
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tNAMESPACE\tIMAGE\tUSED\tSOFT\tHARD\t%HARD\t\tINODES")
+	for _, u := range list[:shown] {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%.1f\t%s\t%d\n",
+			shortID(u.ContainerID, u.Group), orDash(u.Namespace), orDash(shortImage(u.Image)),
+			formatBytes(u.UsedBytes), limitOrDash(u.SoftLimitBytes), limitOrDash(u.HardLimitBytes),
+			u.Percent, usageBar(u.Percent), u.UsedInodes)
+	}
+	tw.Flush()
+
+	// 表格对齐后再着色，控制序列不计入列宽
+	for i, line := range strings.SplitAfter(table.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case !view.color:
+		case i == 0:
+			line = ansiReverse + strings.TrimSuffix(line, "\n") + ansiReset + "\n"
+		case list[i-1].Percent >= 90:
+			line = ansiRed + strings.TrimSuffix(line, "\n") + ansiReset + "\n"
+		case list[i-1].SoftLimitBytes > 0 && list[i-1].UsedBytes > list[i-1].SoftLimitBytes:
+			line = ansiYellow + strings.TrimSuffix(line, "\n") + ansiReset + "\n"
+		}
+		b.WriteString(line)
+	}
+	if shown < len(list) {
+		fmt.Fprintf(&b, "... %d more\n", len(list)-shown)
+	}
+	if view.color {
+		b.WriteString("q quit  s sort  space refresh\n")
+	}
+	return b.Bytes()
+}
+
+func sortUsage(list []api.ContainerUsage, by string) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch by {
+		case "used":
+			if a.UsedBytes != b.UsedBytes {
+				return a.UsedBytes > b.UsedBytes
+			}
+		case "hard":
+			if a.HardLimitBytes != b.HardLimitBytes {
+				return a.HardLimitBytes > b.HardLimitBytes
+			}
+		case "percent":
+			if a.Percent != b.Percent {
+				return a.Percent > b.Percent
+			}
+		}
+		return a.ContainerID < b.ContainerID
+	})
+}
+
+func usageBar(percent float64) string {
+	filled := int(percent / 100 * topBarWidth)
+	filled = min(max(filled, 0), topBarWidth)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", topBarWidth-filled) + "]"
+}
+
+// shortID 截短容器 ID，共享项目的容器附上分组
+func shortID(id, group string) string {
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	if group != "" {
+		id += " (" + group + ")"
+	}
+	return id
+}
+
+// shortImage 去掉镜像名中的仓库地址
+func shortImage(image string) string {
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		return image[i+1:]
+	}
+	return image
+}
+
+func limitOrDash(b uint64) string {
+	if b == 0 {
+		return "-"
+	}
+	return formatBytes(b)
+}
+
+// rawMode 关闭行缓冲与回显，使按键立即可读；保留 ISIG，Ctrl-C 仍产生 SIGINT
+func rawMode(fd int) (restore func(), err error) {
+	old, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %v", err)
+	}
+	raw := *old
+	raw.Lflag &^= unix.ICANON | unix.ECHO
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, fmt.Errorf("failed to set terminal to raw mode: %v", err)
+	}
+	return func() { unix.IoctlSetTermios(fd, unix.TCSETS, old) }, nil
+}
+
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	return err == nil
+}
+
+func terminalRows() int {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Row == 0 {
+		return 0
+	}
+	return int(ws.Row)
+}
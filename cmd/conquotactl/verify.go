@@ -0,0 +1,76 @@
+package main
+
+import (
+	"RootfsQuota/pkg/api"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// verify 对比状态文件、目录上的项目 ID 与内核限额，有未修复的偏差时以 exitPartial 退出
+func verify(c *api.Client, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "repair the drift that was found")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	var resp api.DriftResponse
+	var err error
+	if *repair {
+		err = c.Do("POST", "/v1/drift", api.DriftRequest{Repair: true}, &resp)
+	} else {
+		err = c.Do("GET", "/v1/drift", nil, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	if err := printDrift(resp, *asJSON); err != nil {
+		return err
+	}
+
+	var failures []failure
+	for _, d := range resp.Drift {
+		if d.Repaired {
+			continue
+		}
+		item := fmt.Sprintf("%s %d", d.Kind, d.ProjectID)
+		if d.Path != "" {
+			item += " " + d.Path
+		}
+		msg := d.Error
+		if msg == "" {
+			msg = fmt.Sprintf("expected %s, found %s", d.Expected, d.Actual)
+		}
+		failures = append(failures, failure{Item: item, Error: msg})
+	}
+	if len(failures) > 0 {
+		return &partialError{total: len(resp.Drift), failures: failures}
+	}
+	return nil
+}
+
+func printDrift(resp api.DriftResponse, asJSON bool) error {
+	if asJSON {
+		return printJSON(resp)
+	}
+	if len(resp.Drift) == 0 {
+		fmt.Printf("No drift: state file, project IDs and kernel limits agree (%d projects).\n", resp.Projects)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tPROJECT\tCONTAINERS\tPATH\tEXPECTED\tACTUAL\tRESULT")
+	for _, d := range resp.Drift {
+		result := "found"
+		switch {
+		case d.Error != "":
+			result = "error: " + d.Error
+		case d.Repaired:
+			result = "repaired"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", d.Kind, d.ProjectID, orDash(strings.Join(d.Containers, ",")),
+			orDash(d.Path), d.Expected, d.Actual, result)
+	}
+	return tw.Flush()
+}
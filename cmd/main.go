@@ -1,10 +1,14 @@
 package main
 
 import (
+	"RootfsQuota/pkg/config"
 	"RootfsQuota/pkg/handler"
 	"RootfsQuota/pkg/log"
+	"errors"
 	"flag"
 	"os"
+	"strings"
+	"syscall"
 
 	"go.uber.org/zap"
 )
@@ -12,18 +16,67 @@ import (
 func main() {
 	log.Info("RootfsQuota is starting...")
 
-	configPath := flag.String("config", "/etc/containerd-quota/config.json", "Path to configuration file")
+	configPath := flag.String("config", "/etc/containerd-quota/config.json", "Path or HTTP(S) URL of the configuration file")
+	configCache := flag.String("config-cache", "/var/lib/containerd-quota/config-cache.json", "Local copy of a remote configuration, used when the source is unreachable")
+	configCA := flag.String("config-ca", "", "CA bundle for verifying a remote configuration source")
+	configToken := flag.String("config-token-file", "", "File with a bearer token for a remote configuration source")
+	standby := flag.Bool("standby", false, "Process events without mutating anything until promoted via the admin API")
 	flag.Parse()
 
-	quota, err := handler.NewRFSQuota(*configPath)
+	var quota *handler.RFSQuota
+	var err error
+	if config.IsRemote(*configPath) {
+		var src *config.Source
+		src, err = config.NewSource(*configPath, config.SourceOptions{
+			CacheFile: *configCache,
+			CAFile:    *configCA,
+			TokenFile: *configToken,
+		})
+		if err == nil {
+			quota, err = handler.NewRFSQuotaFromSource(src)
+		}
+	} else {
+		quota, err = handler.NewRFSQuota(*configPath)
+	}
 	if err != nil {
 		log.Error("Failed to initialize RFSQuota", zap.Error(err))
 		os.Exit(1)
 	}
+	if *standby {
+		log.Info("Starting in standby mode")
+		quota.SetStandby(true)
+	}
 
-	if err := quota.Run(); err != nil {
+	err = quota.Run()
+	if errors.Is(err, handler.ErrConfigChanged) {
+		// 以相同参数重新执行自身，PID 不变，systemd 与监控无需感知；已提升的实例不再以备用模式启动
+		args := os.Args
+		if !quota.Standby() {
+			args = withoutStandby(args)
+		}
+		exe, exeErr := os.Executable()
+		if exeErr == nil {
+			exeErr = syscall.Exec(exe, args, os.Environ())
+		}
+		log.Error("Failed to restart with new configuration", zap.Error(exeErr))
+		os.Exit(1)
+	}
+	if err != nil {
 		log.Error("Service exited with error", zap.Error(err))
 		os.Exit(1)
 	}
 	log.Info("RootfsQuota shutdown gracefully")
 }
+
+// withoutStandby 去掉命令行中的 -standby 参数
+func withoutStandby(args []string) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "-") && (name == "standby" || strings.HasPrefix(name, "standby=")) {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+// Package accounting 将容器结束时的用量快照汇总为按天、按命名空间的用量账单（showback），持久化在本地文件
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DateFormat 为汇总日期的格式，按 UTC 划分
+const DateFormat = "2006-01-02"
+
+// bytesPerGB 为计算 GB-hours 时使用的 GB 大小
+const bytesPerGB = 1 << 30
+
+// Summary 为某命名空间在一天（UTC）内结束的容器的用量汇总
+type Summary struct {
+	Date       string `json:"date"`
+	Namespace  string `json:"namespace"`
+	Containers int    `json:"containers"`
+	// GBHours 为结束时用量乘以生命周期之和，是对 rootfs 占用的近似
+	GBHours      float64 `json:"gb_hours"`
+	MaxUsedBytes uint64  `json:"max_used_bytes"`
+}
+
+// Usage 为一个容器结束时的用量快照
+type Usage struct {
+	Namespace string
+	Ended     time.Time
+	Lifetime  time.Duration
+	UsedBytes uint64
+}
+
+// Ledger 为按天汇总的用量账单，并发安全
+type Ledger struct {
+	path string
+	// retention 为保留的天数，0 表示永久保留
+	retention int
+
+	mutex     sync.Mutex
+	summaries map[string]*Summary
+}
+
+// Open 加载（不存在时创建）账单文件
+func Open(path string, retentionDays int) (*Ledger, error) {
+	l := &Ledger{path: path, retention: retentionDays, summaries: make(map[string]*Summary)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounting file: %v", err)
+	}
+	var list []Summary
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse accounting file: %v", err)
+	}
+	for i := range list {
+		s := list[i]
+		l.summaries[key(s.Date, s.Namespace)] = &s
+	}
+	return l, nil
+}
+
+func key(date, namespace string) string {
+	return date + "/" + namespace
+}
+
+// Record 将快照计入结束当天的汇总，清理超出保留期的汇总并持久化
+func (l *Ledger) Record(u Usage) error {
+	if u.Ended.IsZero() {
+		u.Ended = time.Now()
+	}
+	date := u.Ended.UTC().Format(DateFormat)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	s, exists := l.summaries[key(date, u.Namespace)]
+	if !exists {
+		s = &Summary{Date: date, Namespace: u.Namespace}
+		l.summaries[key(date, u.Namespace)] = s
+	}
+	s.Containers++
+	s.GBHours += float64(u.UsedBytes) / bytesPerGB * u.Lifetime.Hours()
+	if u.UsedBytes > s.MaxUsedBytes {
+		s.MaxUsedBytes = u.UsedBytes
+	}
+
+	if l.retention > 0 {
+		cutoff := u.Ended.UTC().AddDate(0, 0, -l.retention).Format(DateFormat)
+		for k, s := range l.summaries {
+			if s.Date < cutoff {
+				delete(l.summaries, k)
+			}
+		}
+	}
+	return l.save()
+}
+
+// Summaries 返回 [from, to] 日期范围内的汇总，按日期与命名空间排序；
+// 空的 from、to 表示不限，namespace 为空时返回所有命名空间
+func (l *Ledger) Summaries(from, to, namespace string) []Summary {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	list := []Summary{}
+	for _, s := range l.summaries {
+		if (from != "" && s.Date < from) || (to != "" && s.Date > to) {
+			continue
+		}
+		if namespace != "" && s.Namespace != namespace {
+			continue
+		}
+		list = append(list, *s)
+	}
+	sortSummaries(list)
+	return list
+}
+
+func sortSummaries(list []Summary) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Date != list[j].Date {
+			return list[i].Date < list[j].Date
+		}
+		return list[i].Namespace < list[j].Namespace
+	})
+}
+
+// save 原子写入账单文件，调用方需持有锁
+func (l *Ledger) save() error {
+	list := make([]Summary, 0, len(l.summaries))
+	for _, s := range l.summaries {
+		list = append(list, *s)
+	}
+	sortSummaries(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create accounting directory: %v", err)
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write accounting file: %v", err)
+	}
+	return os.Rename(tmp, l.path)
+}
@@ -0,0 +1,51 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	nodeContainersDesc = prometheus.NewDesc("conquotas_cluster_node_containers",
+		"Number of managed containers reported by a node.", []string{"node"}, nil)
+	nodeHardBytesDesc = prometheus.NewDesc("conquotas_cluster_node_hard_limit_bytes",
+		"Sum of hard limits of managed containers on a node.", []string{"node"}, nil)
+	nodeUsedBytesDesc = prometheus.NewDesc("conquotas_cluster_node_used_bytes",
+		"Sum of used bytes of managed containers on a node.", []string{"node"}, nil)
+	nodeProjectIDsDesc = prometheus.NewDesc("conquotas_cluster_node_project_ids_used",
+		"Number of allocated project IDs on a node.", []string{"node"}, nil)
+	nodeAgeDesc = prometheus.NewDesc("conquotas_cluster_node_summary_age_seconds",
+		"Seconds since the aggregator last received a summary from the node.", []string{"node"}, nil)
+)
+
+// collector 在抓取时从汇聚服务读取各节点汇总
+type collector struct {
+	server *Server
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nodeContainersDesc
+	ch <- nodeHardBytesDesc
+	ch <- nodeUsedBytesDesc
+	ch <- nodeProjectIDsDesc
+	ch <- nodeAgeDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.server.mutex.RLock()
+	defer c.server.mutex.RUnlock()
+
+	now := time.Now()
+	for name, n := range c.server.nodes {
+		ch <- prometheus.MustNewConstMetric(nodeAgeDesc, prometheus.GaugeValue, now.Sub(n.ReceivedAt).Seconds(), name)
+		// 失联节点只导出汇总年龄，不再导出过时的用量
+		if c.server.stale(n, now) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(nodeContainersDesc, prometheus.GaugeValue, float64(len(n.Containers)), name)
+		ch <- prometheus.MustNewConstMetric(nodeHardBytesDesc, prometheus.GaugeValue, float64(n.TotalHardBytes), name)
+		ch <- prometheus.MustNewConstMetric(nodeUsedBytesDesc, prometheus.GaugeValue, float64(n.TotalUsedBytes), name)
+		ch <- prometheus.MustNewConstMetric(nodeProjectIDsDesc, prometheus.GaugeValue, float64(n.ProjectIDsUsed), name)
+	}
+}
@@ -0,0 +1,79 @@
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// Pusher 周期性地将节点汇总推送到汇聚服务
+type Pusher struct {
+	endpoint string
+	node     string
+	interval time.Duration
+	collect  func() NodeSummary
+	client   *http.Client
+}
+
+// NewPusher 创建推送器，collect 在每次推送时生成最新的汇总
+func NewPusher(baseURL, node string, interval time.Duration, collect func() NodeSummary) *Pusher {
+	return &Pusher{
+		endpoint: strings.TrimRight(baseURL, "/") + "/v1/nodes/" + url.PathEscape(node) + "/summary",
+		node:     node,
+		interval: interval,
+		collect:  collect,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run 推送直到上下文取消，单次失败只记录日志
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.push(ctx); err != nil {
+			log.Warn("Failed to push summary to aggregator", zap.String("endpoint", p.endpoint), zap.Error(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Pusher) push(ctx context.Context) error {
+	summary := p.collect()
+	summary.Node = p.node
+	summary.Timestamp = time.Now()
+
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("aggregator returned %s", resp.Status)
+	}
+	return nil
+}
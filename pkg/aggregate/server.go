@@ -0,0 +1,131 @@
+package aggregate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// Server 为集群汇聚服务，保存各节点最近一次推送的汇总
+type Server struct {
+	staleAfter time.Duration
+	nodes      map[string]NodeSummary
+	mutex      sync.RWMutex
+	mux        *http.ServeMux
+}
+
+// NewServer 创建汇聚服务，超过 staleAfter 未推送的节点视为失联
+func NewServer(staleAfter time.Duration) *Server {
+	s := &Server{
+		staleAfter: staleAfter,
+		nodes:      make(map[string]NodeSummary),
+		mux:        http.NewServeMux(),
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&collector{server: s})
+
+	s.mux.HandleFunc("POST /v1/nodes/{node}/summary", s.handlePush)
+	s.mux.HandleFunc("GET /v1/nodes", s.handleListNodes)
+	s.mux.HandleFunc("GET /v1/nodes/{node}", s.handleGetNode)
+	s.mux.HandleFunc("GET /v1/cluster/summary", s.handleCluster)
+	s.mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return s
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	var summary NodeSummary
+	if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+		http.Error(w, fmt.Sprintf("invalid summary: %v", err), http.StatusBadRequest)
+		return
+	}
+	summary.Node = r.PathValue("node")
+	// 节点时钟可能偏差，失联以本服务收到推送的时间判断
+	summary.ReceivedAt, summary.Stale = time.Now(), false
+	if summary.Timestamp.IsZero() {
+		summary.Timestamp = summary.ReceivedAt
+	}
+
+	s.mutex.Lock()
+	s.nodes[summary.Node] = summary
+	s.mutex.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListNodes(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	now := time.Now()
+	nodes := make([]NodeSummary, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		n.Containers = nil
+		n.Stale = s.stale(n, now)
+		nodes = append(nodes, n)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	writeJSON(w, nodes)
+}
+
+func (s *Server) handleGetNode(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	n, exists := s.nodes[r.PathValue("node")]
+	s.mutex.RUnlock()
+	if !exists {
+		http.Error(w, "node not found", http.StatusNotFound)
+		return
+	}
+	n.Stale = s.stale(n, time.Now())
+	writeJSON(w, n)
+}
+
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Cluster())
+}
+
+// Cluster 计算集群汇总，失联节点单独列出且不计入总量
+func (s *Server) Cluster() ClusterSummary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	c := ClusterSummary{GeneratedAt: now}
+	for name, n := range s.nodes {
+		if s.stale(n, now) {
+			c.StaleNodes = append(c.StaleNodes, name)
+			continue
+		}
+		c.Nodes++
+		c.Containers += len(n.Containers)
+		c.TotalHardBytes += n.TotalHardBytes
+		c.TotalUsedBytes += n.TotalUsedBytes
+	}
+	sort.Strings(c.StaleNodes)
+	return c
+}
+
+// stale 判断节点是否超过 staleAfter 未推送
+func (s *Server) stale(n NodeSummary, now time.Time) bool {
+	return now.Sub(n.ReceivedAt) > s.staleAfter
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Failed to write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,56 @@
+package aggregate
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func push(t *testing.T, s *Server, node string, summary NodeSummary) {
+	t.Helper()
+	body, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/v1/nodes/"+node+"/summary", bytes.NewReader(body)))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("push %s = %d %s", node, w.Code, w.Body)
+	}
+}
+
+func TestClusterStaleness(t *testing.T) {
+	s := NewServer(time.Minute)
+	// node clocks do not decide staleness, the receive time does
+	push(t, s, "behind", NodeSummary{Timestamp: time.Now().Add(-time.Hour), TotalUsedBytes: 1})
+	push(t, s, "ahead", NodeSummary{Timestamp: time.Now().Add(time.Hour), TotalUsedBytes: 2, ReceivedAt: time.Now().Add(time.Hour)})
+	push(t, s, "gone", NodeSummary{TotalUsedBytes: 4})
+	s.mutex.Lock()
+	gone := s.nodes["gone"]
+	gone.ReceivedAt = time.Now().Add(-2 * time.Minute)
+	s.nodes["gone"] = gone
+	s.mutex.Unlock()
+
+	c := s.Cluster()
+	if c.Nodes != 2 || c.TotalUsedBytes != 3 || !reflect.DeepEqual(c.StaleNodes, []string{"gone"}) {
+		t.Errorf("Cluster() = %+v, want 2 fresh nodes using 3 bytes and gone stale", c)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/v1/nodes", nil))
+	var nodes []NodeSummary
+	if err := json.NewDecoder(w.Body).Decode(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	stale := make(map[string]bool)
+	for _, n := range nodes {
+		stale[n.Node] = n.Stale
+	}
+	if want := map[string]bool{"ahead": false, "behind": false, "gone": true}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("GET /v1/nodes stale = %v, want %v", stale, want)
+	}
+}
@@ -0,0 +1,39 @@
+package aggregate
+
+import "time"
+
+// ContainerSummary 为单个容器的配额与用量
+type ContainerSummary struct {
+	ContainerID string `json:"container_id"`
+	ProjectID   uint32 `json:"project_id"`
+	SoftLimit   string `json:"soft_limit,omitempty"`
+	HardLimit   string `json:"hard_limit,omitempty"`
+	HardBytes   uint64 `json:"hard_bytes"`
+	UsedBytes   uint64 `json:"used_bytes"`
+}
+
+// NodeSummary 为节点推送给汇聚服务的配额汇总
+type NodeSummary struct {
+	Node string `json:"node"`
+	// Timestamp 为节点生成汇总的时间，以节点时钟为准，仅供参考
+	Timestamp time.Time `json:"timestamp"`
+	// ReceivedAt 为汇聚服务收到推送的时间，失联判断以此为准，节点推送的值被忽略
+	ReceivedAt time.Time `json:"received_at"`
+	// Stale 表示节点超过失联时间未推送，其汇总不计入集群总量
+	Stale           bool               `json:"stale,omitempty"`
+	ProjectIDsUsed  int                `json:"project_ids_used"`
+	ProjectIDsTotal int                `json:"project_ids_total"`
+	TotalHardBytes  uint64             `json:"total_hard_bytes"`
+	TotalUsedBytes  uint64             `json:"total_used_bytes"`
+	Containers      []ContainerSummary `json:"containers"`
+}
+
+// ClusterSummary 为所有节点汇总后的集群视图
+type ClusterSummary struct {
+	Nodes          int       `json:"nodes"`
+	StaleNodes     []string  `json:"stale_nodes,omitempty"`
+	Containers     int       `json:"containers"`
+	TotalHardBytes uint64    `json:"total_hard_bytes"`
+	TotalUsedBytes uint64    `json:"total_used_bytes"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"RootfsQuota/pkg/accounting"
+)
+
+// handleAccounting 返回用量账单，from、to 为 YYYY-MM-DD（UTC），namespace 为空时返回所有命名空间
+func (s *Server) handleAccounting(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to := query.Get("from"), query.Get("to")
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(accounting.DateFormat, date); err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid date %q, want YYYY-MM-DD", date)})
+			return
+		}
+	}
+	summaries, err := s.manager.Accounting(from, to, query.Get("namespace"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	defaultBatchConcurrency = 8
+	maxBatchConcurrency     = 64
+)
+
+func (s *Server) handleBatchSetLimits(w http.ResponseWriter, r *http.Request) {
+	var req BatchSetLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	results := make([]BatchResult, len(req.Items))
+	runBatch(len(req.Items), req.Concurrency, func(i int) {
+		item := req.Items[i]
+		results[i] = BatchResult{ContainerID: item.ContainerID}
+		if item.Soft == "" || item.Hard == "" {
+			results[i].Error = "soft and hard are required"
+			return
+		}
+		if err := s.manager.SetLimits(item.ContainerID, item.Soft, item.Hard); err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].OK = true
+	})
+	writeJSON(w, http.StatusOK, newBatchResponse(results))
+}
+
+func (s *Server) handleBatchRemove(w http.ResponseWriter, r *http.Request) {
+	var req BatchRemoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	sel, err := ParseSelector(req.Selector)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	ids, err := s.manager.MatchContainers(sel)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	results := make([]BatchResult, len(ids))
+	runBatch(len(ids), req.Concurrency, func(i int) {
+		results[i] = BatchResult{ContainerID: ids[i]}
+		if req.DryRun {
+			return
+		}
+		if err := s.manager.RemoveQuota(ids[i]); err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].OK = true
+	})
+	writeJSON(w, http.StatusOK, newBatchResponse(results))
+}
+
+// runBatch 以有限并发执行 n 个任务
+func runBatch(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if concurrency > maxBatchConcurrency {
+		concurrency = maxBatchConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func newBatchResponse(results []BatchResult) BatchResponse {
+	resp := BatchResponse{Results: results}
+	for _, r := range results {
+		if r.OK {
+			resp.Succeeded++
+		} else if r.Error != "" {
+			resp.Failed++
+		}
+	}
+	return resp
+}
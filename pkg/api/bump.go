@@ -0,0 +1,262 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// errBumpUnauthorized 表示确认请求未携带有效的确认凭据
+var errBumpUnauthorized = errors.New("missing or invalid bump confirmation token")
+
+// bumpProposal 为待确认的限额提升提案，baseHard 为提出时的硬限额（字节）
+type bumpProposal struct {
+	containerID string
+	soft        string
+	hard        string
+	baseHard    uint64
+	expiresAt   time.Time
+}
+
+// bumpWindow 为容器累计提升的统计窗口，baseHard 为窗口内首次提升前的硬限额（字节）
+type bumpWindow struct {
+	baseHard uint64
+	start    time.Time
+}
+
+// bumpStore 保存一次性确认令牌，令牌使用后或过期即失效；并记录各容器窗口内的累计提升
+type bumpStore struct {
+	mutex     sync.Mutex
+	proposals map[string]bumpProposal
+	windows   map[string]bumpWindow
+}
+
+func newBumpStore() *bumpStore {
+	return &bumpStore{proposals: make(map[string]bumpProposal), windows: make(map[string]bumpWindow)}
+}
+
+// growthLimit 返回容器在当前窗口内允许提升到的最大硬限额（字节），窗口不存在或已过期时以 baseHard 为基准
+func (b *bumpStore) growthLimit(id string, baseHard uint64, maxPercent int, window time.Duration, now time.Time) uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if w, ok := b.windows[id]; ok && now.Before(w.start.Add(window)) {
+		baseHard = w.baseHard
+	}
+	return uint64(math.Floor(float64(baseHard) * (1 + float64(maxPercent)/100)))
+}
+
+// recordGrowth 在提升生效后记录窗口，窗口内已有记录时保留原基准
+func (b *bumpStore) recordGrowth(id string, baseHard uint64, window time.Duration, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for other, w := range b.windows {
+		if !now.Before(w.start.Add(window)) {
+			delete(b.windows, other)
+		}
+	}
+	if _, ok := b.windows[id]; !ok {
+		b.windows[id] = bumpWindow{baseHard: baseHard, start: now}
+	}
+}
+
+func (b *bumpStore) add(p bumpProposal) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	for t, old := range b.proposals {
+		if now.After(old.expiresAt) {
+			delete(b.proposals, t)
+		}
+	}
+	b.proposals[token] = p
+	return token, nil
+}
+
+// take 取出并作废令牌
+func (b *bumpStore) take(token string) (bumpProposal, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	p, exists := b.proposals[token]
+	delete(b.proposals, token)
+	if !exists || time.Now().After(p.expiresAt) {
+		return bumpProposal{}, false
+	}
+	return p, true
+}
+
+// growthWindow 返回累计提升的统计窗口
+func (s *Server) growthWindow() time.Duration {
+	return time.Duration(s.cfg.Bump.GrowthWindowSeconds) * time.Second
+}
+
+// checkGrowth 检查提升后的硬限额是否超出窗口内的累计上限
+func (s *Server) checkGrowth(id string, baseHard uint64, hard string) error {
+	proposed, err := xfs.ParseSize(hard)
+	if err != nil {
+		return err
+	}
+	limit := s.bumps.growthLimit(id, baseHard, s.cfg.Bump.MaxGrowthPercent, s.growthWindow(), time.Now())
+	if proposed > limit {
+		return fmt.Errorf("%s would exceed the cumulative bump limit of %s (bump.max_growth_percent %d within %s)",
+			hard, xfs.FormatSize(limit), s.cfg.Bump.MaxGrowthPercent, s.growthWindow())
+	}
+	return nil
+}
+
+// checkConfirmToken 校验确认请求携带的 Bearer token，该凭据与提出提案的调用方分开持有，
+// 提案响应中的一次性令牌本身不足以使提升生效
+func (s *Server) checkConfirmToken(r *http.Request) error {
+	data, err := os.ReadFile(s.cfg.Bump.ConfirmTokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read bump.confirm_token_file: %v", err)
+	}
+	want := strings.TrimSpace(string(data))
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if want == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+		return errBumpUnauthorized
+	}
+	return nil
+}
+
+func (s *Server) handleBumpPropose(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.cfg.Bump.ConfirmTokenFile == "" {
+		writeJSON(w, http.StatusForbidden, ErrorResponse{Error: "limit bumps are disabled: bump.confirm_token_file is not set"})
+		return
+	}
+
+	var req BumpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.Percent <= 0 || req.Percent > s.cfg.Bump.MaxPercent {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("percent must be in (0, %d]", s.cfg.Bump.MaxPercent),
+		})
+		return
+	}
+
+	entry, exists := s.manager.GetEntry(id)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", ErrNotFound, id))
+		return
+	}
+	soft, hard, err := s.currentLimits(entry)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	factor := 1 + float64(req.Percent)/100
+	p := bumpProposal{
+		containerID: id,
+		soft:        xfs.FormatSize(uint64(math.Ceil(float64(soft) * factor))),
+		hard:        xfs.FormatSize(uint64(math.Ceil(float64(hard) * factor))),
+		baseHard:    hard,
+		expiresAt:   time.Now().Add(time.Duration(s.cfg.Bump.TokenTTLSeconds) * time.Second),
+	}
+	if err := s.checkGrowth(id, hard, p.hard); err != nil {
+		writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+		return
+	}
+	token, err := s.bumps.add(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Info("Limit bump proposed",
+		zap.String("container", id),
+		zap.String("soft", p.soft),
+		zap.String("hard", p.hard))
+	writeJSON(w, http.StatusCreated, BumpProposalResponse{
+		ContainerID:  id,
+		Token:        token,
+		CurrentSoft:  xfs.FormatSize(soft),
+		CurrentHard:  xfs.FormatSize(hard),
+		ProposedSoft: p.soft,
+		ProposedHard: p.hard,
+		ExpiresAt:    p.expiresAt,
+	})
+}
+
+func (s *Server) handleBumpConfirm(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.cfg.Bump.ConfirmTokenFile == "" {
+		writeJSON(w, http.StatusForbidden, ErrorResponse{Error: "limit bumps are disabled: bump.confirm_token_file is not set"})
+		return
+	}
+	if err := s.checkConfirmToken(r); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errBumpUnauthorized) {
+			status = http.StatusUnauthorized
+		}
+		writeJSON(w, status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	p, ok := s.bumps.take(r.PathValue("token"))
+	if !ok || p.containerID != id {
+		writeJSON(w, http.StatusForbidden, ErrorResponse{Error: "invalid or expired token"})
+		return
+	}
+	// 多个提案可能在同一窗口内先后确认，生效前按累计上限再检查一次
+	if err := s.checkGrowth(id, p.baseHard, p.hard); err != nil {
+		writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := s.manager.SetLimits(id, p.soft, p.hard); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.bumps.recordGrowth(id, p.baseHard, s.growthWindow(), time.Now())
+
+	log.Info("Limit bump applied",
+		zap.String("container", id),
+		zap.String("soft", p.soft),
+		zap.String("hard", p.hard))
+	writeJSON(w, http.StatusOK, LimitsResponse{ContainerID: id, Soft: p.soft, Hard: p.hard})
+}
+
+// currentLimits 返回容器当前限额（字节），状态中未记录时实时查询
+func (s *Server) currentLimits(entry xfs.Entry) (uint64, uint64, error) {
+	if entry.HardLimit != "" {
+		soft, err := xfs.ParseSize(entry.SoftLimit)
+		if err != nil {
+			return 0, 0, err
+		}
+		hard, err := xfs.ParseSize(entry.HardLimit)
+		if err != nil {
+			return 0, 0, err
+		}
+		return soft, hard, nil
+	}
+
+	_, usage, err := s.manager.QueryUsage(entry.ContainerID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return usage.SoftLimitBytes, usage.HardLimitBytes, nil
+}
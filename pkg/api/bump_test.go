@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"RootfsQuota/pkg/config"
+)
+
+func TestBumpGrowthLimit(t *testing.T) {
+	const gb = 1 << 30
+	b := newBumpStore()
+	now := time.Now()
+	window := time.Hour
+
+	if got := b.growthLimit("c1", 10*gb, 100, window, now); got != 20*gb {
+		t.Fatalf("growthLimit() without a window = %d, want %d", got, 20*gb)
+	}
+	b.recordGrowth("c1", 10*gb, window, now)
+	// later bumps are measured against the limit before the first one
+	b.recordGrowth("c1", 15*gb, window, now.Add(time.Minute))
+	if got := b.growthLimit("c1", 15*gb, 100, window, now.Add(time.Minute)); got != 20*gb {
+		t.Errorf("growthLimit() within the window = %d, want %d", got, 20*gb)
+	}
+	if got := b.growthLimit("c2", 15*gb, 100, window, now); got != 30*gb {
+		t.Errorf("growthLimit() of another container = %d, want %d", got, 30*gb)
+	}
+	if got := b.growthLimit("c1", 15*gb, 100, window, now.Add(window)); got != 30*gb {
+		t.Errorf("growthLimit() after the window = %d, want %d", got, 30*gb)
+	}
+}
+
+func TestCheckConfirmToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "confirm-token")
+	if err := os.WriteFile(path, []byte("approver\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{cfg: &config.Config{Bump: config.BumpConfig{ConfirmTokenFile: path}}}
+
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{name: "confirm token", header: "Bearer approver", ok: true},
+		{name: "no credential"},
+		{name: "wrong token", header: "Bearer proposer"},
+		{name: "not a bearer token", header: "approver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/quotas/c1/bump/abc", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			err := s.checkConfirmToken(r)
+			if tt.ok && err != nil || !tt.ok && !errors.Is(err, errBumpUnauthorized) {
+				t.Errorf("checkConfirmToken() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"RootfsQuota/pkg/listener"
+)
+
+// Client 为管理接口的 HTTP 客户端，供 conquotactl 等工具使用
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient 创建客户端，baseURL 如 http://127.0.0.1:9101；unix:///path 或 unix://@name 通过 Unix 套接字连接
+func NewClient(baseURL string) *Client {
+	client := &http.Client{Timeout: 60 * time.Second}
+	if strings.HasPrefix(baseURL, "unix://") {
+		addr := baseURL
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return listener.Dial(ctx, addr)
+			},
+		}
+		baseURL = "http://localhost"
+	} else if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    client,
+	}
+}
+
+// Do 发送请求，body 不为 nil 时编码为 JSON，响应解码到 out，失败响应的正文也会尝试解码到 out
+func (c *Client) Do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// 部分接口在失败时也返回详细结果，尽量同时解码到 out
+		data, _ := io.ReadAll(resp.Body)
+		if out != nil {
+			json.Unmarshal(data, out)
+		}
+		var e ErrorResponse
+		if err := json.Unmarshal(data, &e); err == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+func (s *Server) handleDebugEvents(w http.ResponseWriter, r *http.Request) {
+	mark, ok := s.manager.LastEvent()
+	if !ok {
+		writeJSON(w, http.StatusOK, EventMarkResponse{})
+		return
+	}
+	resp := EventMarkResponse{
+		Sequence:  mark.Sequence,
+		Timestamp: mark.Timestamp,
+		Topic:     mark.Topic,
+		Namespace: mark.Namespace,
+	}
+	if !mark.Timestamp.IsZero() {
+		resp.AgeSeconds = time.Since(mark.Timestamp).Seconds()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// handleDrift 处理偏差检查，GET 只检查，POST 按请求体决定是否修复
+func (s *Server) handleDrift(w http.ResponseWriter, r *http.Request) {
+	var req DriftRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+	}
+
+	resp, err := s.manager.CheckDrift(req.Repair)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
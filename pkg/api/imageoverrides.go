@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"RootfsQuota/pkg/xfs"
+)
+
+func (s *Server) handleListImageOverrides(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ImageOverridesResponse{Overrides: s.manager.ListImageOverrides()})
+}
+
+func (s *Server) handleSetImageOverride(w http.ResponseWriter, r *http.Request) {
+	digest := r.PathValue("digest")
+
+	var req ImageOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	soft, err := xfs.ParseSize(req.Soft)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid soft: %v", err)})
+		return
+	}
+	hard, err := xfs.ParseSize(req.Hard)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid hard: %v", err)})
+		return
+	}
+	if soft > hard {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "soft must not exceed hard"})
+		return
+	}
+
+	o, err := s.manager.SetImageOverride(digest, req.Image, req.Soft, req.Hard)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) handleRemoveImageOverride(w http.ResponseWriter, r *http.Request) {
+	if err := s.manager.RemoveImageOverride(r.PathValue("digest")); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ImageOverridesResponse{Overrides: s.manager.ListImageOverrides()})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func (s *Server) handleLift(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req LiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.DurationSeconds <= 0 || req.DurationSeconds > s.cfg.Lift.MaxSeconds {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("duration_seconds must be in (0, %d]", s.cfg.Lift.MaxSeconds),
+		})
+		return
+	}
+
+	until, err := s.manager.LiftLimits(id, time.Duration(req.DurationSeconds)*time.Second)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entry, _ := s.manager.GetEntry(id)
+	writeJSON(w, http.StatusOK, LiftResponse{ContainerID: id, Soft: entry.SoftLimit, Hard: entry.HardLimit, Until: until})
+}
+
+func (s *Server) handleRestoreLift(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	entry, err := s.manager.RestoreLimits(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, LimitsResponse{ContainerID: id, Soft: entry.SoftLimit, Hard: entry.HardLimit})
+}
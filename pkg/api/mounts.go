@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+)
+
+func (s *Server) handleInspectMounts(w http.ResponseWriter, r *http.Request) {
+	info, err := s.manager.InspectMounts(r.URL.Query().Get("namespace"), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"RootfsQuota/pkg/policy"
+)
+
+// decodePolicy 解码并校验请求体中的策略文档
+func decodePolicy(r *http.Request) (*policy.Policy, error) {
+	var p policy.Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Server) handlePolicyTargets(w http.ResponseWriter, r *http.Request) {
+	targets, err := s.manager.PolicyTargets()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PolicyTargetsResponse{Containers: targets})
+}
+
+func (s *Server) handlePolicyDiff(w http.ResponseWriter, r *http.Request) {
+	p, err := decodePolicy(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	changes, err := s.manager.DiffPolicy(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PolicyDiffResponse{Changes: changes})
+}
+
+func (s *Server) handlePolicyApply(w http.ResponseWriter, r *http.Request) {
+	p, err := decodePolicy(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	changes, err := s.manager.ApplyPolicy(p)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, PolicyApplyResponse{Changes: changes, Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, PolicyApplyResponse{Changes: changes})
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"RootfsQuota/pkg/xfs"
+)
+
+// newQuotaResponse 将状态记录转换为接口返回的配额
+func newQuotaResponse(entry xfs.Entry) QuotaResponse {
+	resp := QuotaResponse{
+		ContainerID: entry.ContainerID,
+		Namespace:   entry.Namespace,
+		ProjectID:   entry.ProjectID,
+		Group:       entry.Group,
+		Upperdir:    entry.Upperdir,
+		SoftLimit:   entry.SoftLimit,
+		HardLimit:   entry.HardLimit,
+		InodeSoft:   entry.InodeSoft,
+		InodeHard:   entry.InodeHard,
+		ImageDigest: entry.ImageDigest,
+		Class:       entry.Class,
+		CreatedAt:   entry.CreatedAt,
+		Pinned:      entry.Pinned,
+	}
+	if !entry.LiftedUntil.IsZero() {
+		until := entry.LiftedUntil
+		resp.LiftedUntil = &until
+	}
+	return resp
+}
+
+// handleListQuotas 返回所有已管理的配额，namespace 不为空时只返回该命名空间的
+func (s *Server) handleListQuotas(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	quotas := []QuotaResponse{}
+	for _, entry := range s.manager.ListEntries() {
+		if namespace != "" && entry.Namespace != namespace {
+			continue
+		}
+		quotas = append(quotas, newQuotaResponse(entry))
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].ContainerID < quotas[j].ContainerID })
+	writeJSON(w, http.StatusOK, QuotaListResponse{Quotas: quotas})
+}
+
+func (s *Server) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	entry, exists := s.manager.GetEntry(id)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", ErrNotFound, id))
+		return
+	}
+	resp := newQuotaResponse(entry)
+	resp.History = entry.LimitHistory
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSetQuota(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req LimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.Soft == "" || req.Hard == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "soft and hard are required"})
+		return
+	}
+
+	if err := s.manager.SetLimits(id, req.Soft, req.Hard); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, LimitsResponse{ContainerID: id, Soft: req.Soft, Hard: req.Hard})
+}
+
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.manager.Explain(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleUnpinQuota 取消手动设置的限额固定并返回更新后的记录
+func (s *Server) handleUnpinQuota(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.manager.UnpinLimits(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newQuotaResponse(entry))
+}
+
+// handleRemoveQuota 移除配额并返回移除前的记录
+func (s *Server) handleRemoveQuota(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	entry, exists := s.manager.GetEntry(id)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", ErrNotFound, id))
+		return
+	}
+	if err := s.manager.RemoveQuota(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newQuotaResponse(entry))
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	entry, usage, err := s.manager.QueryUsage(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := UsageResponse{
+		ContainerID:    entry.ContainerID,
+		ProjectID:      entry.ProjectID,
+		UsedBytes:      usage.UsedBytes,
+		UsedInodes:     usage.UsedInodes,
+		SoftLimitBytes: usage.SoftLimitBytes,
+		HardLimitBytes: usage.HardLimitBytes,
+		InodeSoftLimit: usage.InodeSoftLimit,
+		InodeHardLimit: usage.InodeHardLimit,
+	}
+	if usage.HardLimitBytes > 0 {
+		resp.Percent = float64(usage.UsedBytes) * 100 / float64(usage.HardLimitBytes)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUsageSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.manager.UsageSnapshot()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	report, err := s.manager.Report(r.URL.Query().Get("namespace"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleLimitHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	entry, exists := s.manager.GetEntry(id)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", ErrNotFound, id))
+		return
+	}
+	writeJSON(w, http.StatusOK, LimitHistoryResponse{
+		ContainerID: entry.ContainerID,
+		ProjectID:   entry.ProjectID,
+		SoftLimit:   entry.SoftLimit,
+		HardLimit:   entry.HardLimit,
+		History:     entry.LimitHistory,
+	})
+}
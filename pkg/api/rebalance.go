@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+func (s *Server) handleStartRebalance(w http.ResponseWriter, r *http.Request) {
+	var req RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.IDMin == 0 || req.IDMin >= req.IDMax || req.IDMax == math.MaxUint32 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("invalid range: id_min must be non-zero and below id_max, id_max below %d", uint32(math.MaxUint32)),
+		})
+		return
+	}
+	resp, err := s.manager.StartRebalance(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRebalanceStatus(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.manager.RebalanceStatus()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleControlRebalance(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := s.manager.ControlRebalance(action)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
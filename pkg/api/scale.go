@@ -0,0 +1,26 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+func (s *Server) handleScale(w http.ResponseWriter, r *http.Request) {
+	var req ScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if (req.Factor > 0) == req.ToDefaults {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "exactly one of factor or to_defaults is required"})
+		return
+	}
+
+	changes, err := s.manager.ScaleLimits(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ScaleResponse{DryRun: req.DryRun, Changes: changes})
+}
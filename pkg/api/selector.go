@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requirement 为单个标签条件
+type requirement struct {
+	key   string
+	value string
+	// op 取值 "="、"!=" 或 "exists"
+	op string
+}
+
+// Selector 为简化的标签选择器，语法如 "app=web,tier!=batch,gpu"
+type Selector struct {
+	requirements []requirement
+}
+
+// ParseSelector 解析标签选择器，空字符串不被接受以避免误匹配全部容器
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var req requirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			req = requirement{key: strings.TrimSpace(kv[0]), value: strings.TrimSpace(kv[1]), op: "!="}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			req = requirement{key: strings.TrimSpace(kv[0]), value: strings.TrimSpace(kv[1]), op: "="}
+		default:
+			req = requirement{key: part, op: "exists"}
+		}
+		if req.key == "" {
+			return Selector{}, fmt.Errorf("invalid selector term %q", part)
+		}
+		sel.requirements = append(sel.requirements, req)
+	}
+	if len(sel.requirements) == 0 {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+	return sel, nil
+}
+
+// Matches 判断标签是否满足所有条件
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s.requirements {
+		v, exists := labels[req.key]
+		switch req.op {
+		case "=":
+			if !exists || v != req.value {
+				return false
+			}
+		case "!=":
+			if exists && v == req.value {
+				return false
+			}
+		case "exists":
+			if !exists {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/accounting"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/listener"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/policy"
+	"RootfsQuota/pkg/snapshot"
+	"RootfsQuota/pkg/xfs"
+)
+
+// ErrNotFound 表示容器未被管理
+var ErrNotFound = errors.New("container not managed")
+
+// ErrStandby 表示实例处于备用模式，拒绝修改操作
+var ErrStandby = errors.New("instance is in standby mode, promote it first")
+
+// ErrNoRebalance 表示没有进行中或尚未结束的项目 ID 迁移
+var ErrNoRebalance = errors.New("no project ID rebalance in progress")
+
+// ErrConflict 表示操作与进行中的状态冲突，如已有迁移时再次开始迁移
+var ErrConflict = errors.New("conflict")
+
+// Manager 为管理接口依赖的配额操作，由 handler 实现
+type Manager interface {
+	// QueryUsage 实时查询容器的用量，不使用缓存
+	QueryUsage(containerID string) (xfs.Entry, xfs.ProjectUsage, error)
+	// UsageSnapshot 返回所有已管理容器的用量，优先使用后台轮询的快照
+	UsageSnapshot() (UsageSnapshotResponse, error)
+	// Report 实时读取所有项目的用量，与 containerd 中的镜像信息合并为容量报告，namespace 为空时包含所有命名空间
+	Report(namespace string) (ReportResponse, error)
+	// GetEntry 返回容器的状态记录
+	GetEntry(containerID string) (xfs.Entry, bool)
+	// ListEntries 返回所有状态记录，包括分组条目
+	ListEntries() []xfs.Entry
+	// SetLimits 修改容器的软/硬限制并持久化
+	SetLimits(containerID, soft, hard string) error
+	// UnpinLimits 取消手动设置的限额固定，之后由策略与 kubelet 扩缩容接管
+	UnpinLimits(containerID string) (xfs.Entry, error)
+	// Explain 说明容器的根文件系统、额外目录与挂载中哪些写入计入配额
+	Explain(containerID string) (ExplainResponse, error)
+	// LiftLimits 临时解除容器的限额，d 后自动恢复，返回到期时间
+	LiftLimits(containerID string, d time.Duration) (time.Time, error)
+	// RestoreLimits 提前恢复被临时解除的限额
+	RestoreLimits(containerID string) (xfs.Entry, error)
+	// ScaleLimits 按倍数或默认值批量调整所有限额，DryRun 时只返回计划
+	ScaleLimits(req ScaleRequest) ([]LimitChange, error)
+	// Resync 对比 containerd、文件系统与状态文件并修正差异，dryRun 时只返回计划
+	Resync(dryRun bool) ([]ResyncAction, error)
+	// CheckDrift 对比状态文件、目录上的项目 ID 与内核限额，repair 时修复偏差
+	CheckDrift(repair bool) (DriftResponse, error)
+	// CollectGarbage 回收遗留的项目：目录已不存在的条目、没有成员的分组与没有条目的限额，dryRun 时只返回计划
+	CollectGarbage(dryRun bool) ([]GCItem, error)
+	// FsckState 检查状态的一致性，repair 时修复可自动修复的问题并压缩
+	FsckState(repair bool) (xfs.FsckReport, error)
+	// MatchContainers 返回标签满足选择器的已管理容器
+	MatchContainers(sel Selector) ([]string, error)
+	// RemoveQuota 移除容器的配额并归还项目 ID
+	RemoveQuota(containerID string) error
+	// LastEvent 返回最近处理的 containerd 事件水位
+	LastEvent() (xfs.EventMark, bool)
+	// InspectMounts 解析容器快照的挂载信息，namespace 为空时使用状态记录或默认命名空间
+	InspectMounts(namespace, containerID string) (*snapshot.MountInfo, error)
+	// DiffPolicy 返回已管理容器中限额与策略不一致的部分
+	DiffPolicy(p *policy.Policy) ([]policy.Change, error)
+	// PolicyTargets 返回已管理容器参与策略计算的元数据
+	PolicyTargets() ([]policy.ContainerMeta, error)
+	// ApplyPolicy 将已管理容器收敛到策略，部分失败时回滚已应用的修改
+	ApplyPolicy(p *policy.Policy) ([]policy.Change, error)
+	// Accounting 返回 [from, to] 日期范围内按天、按命名空间汇总的用量账单
+	Accounting(from, to, namespace string) ([]accounting.Summary, error)
+	// StartRebalance 开始将所有已管理项目迁移到新的 ID 范围，DryRun 时只返回计划
+	StartRebalance(req RebalanceRequest) (RebalanceResponse, error)
+	// RebalanceStatus 返回进行中或尚未结束的迁移进度
+	RebalanceStatus() (RebalanceResponse, error)
+	// ControlRebalance 暂停、恢复、回滚或结束迁移，action 为 Rebalance* 常量之一
+	ControlRebalance(action string) (RebalanceResponse, error)
+	// PoolStats 返回项目 ID 池的使用统计
+	PoolStats() xfs.PoolStats
+	// ListImageOverrides 返回按镜像摘要记录的限额覆盖
+	ListImageOverrides() []xfs.ImageOverride
+	// SetImageOverride 手动设置镜像的限额覆盖
+	SetImageOverride(digest, image, soft, hard string) (xfs.ImageOverride, error)
+	// RemoveImageOverride 删除镜像的限额覆盖
+	RemoveImageOverride(digest string) error
+	// Version 返回构建版本与子系统开关
+	Version() VersionResponse
+	// Standby 判断实例是否处于备用模式
+	Standby() bool
+	// Promote 将备用实例提升为主实例
+	Promote() error
+}
+
+// Server 为管理 HTTP 接口
+type Server struct {
+	cfg     *config.Config
+	manager Manager
+	mux     *http.ServeMux
+	bumps   *bumpStore
+	table   []route
+}
+
+// NewServer 创建管理接口服务
+func NewServer(cfg *config.Config, manager Manager) *Server {
+	s := &Server{
+		cfg:     cfg,
+		manager: manager,
+		mux:     http.NewServeMux(),
+		bumps:   newBumpStore(),
+	}
+	s.routes()
+	return s
+}
+
+// route 为一个接口的路由及其在 OpenAPI 文档中的描述；request、response 为请求体与响应的示例值，
+// 只用于生成 Schema，为 nil 表示没有请求体或响应为任意 JSON
+type route struct {
+	method   string
+	path     string
+	summary  string
+	query    []string
+	request  interface{}
+	response interface{}
+	// status 为成功时的状态码，0 表示 200
+	status  int
+	handler http.HandlerFunc
+}
+
+func (s *Server) routes() {
+	s.table = []route{
+		{method: "GET", path: "/v1/quotas", summary: "List managed quotas", query: []string{"namespace"},
+			response: QuotaListResponse{}, handler: s.handleListQuotas},
+		{method: "GET", path: "/v1/quotas/{id}", summary: "Get a container's quota and limit history",
+			response: QuotaResponse{}, handler: s.handleGetQuota},
+		{method: "PUT", path: "/v1/quotas/{id}", summary: "Set a container's soft and hard limits",
+			request: LimitsRequest{}, response: LimitsResponse{}, handler: s.handleSetQuota},
+		{method: "GET", path: "/v1/quotas/{id}/explain", summary: "Explain which of a container's writes count toward its quota",
+			response: ExplainResponse{}, handler: s.handleExplain},
+		{method: "DELETE", path: "/v1/quotas/{id}/pin", summary: "Return a container's limits to policy and resize management",
+			response: QuotaResponse{}, handler: s.handleUnpinQuota},
+		{method: "DELETE", path: "/v1/quotas/{id}", summary: "Remove a container's quota and release its project ID",
+			response: QuotaResponse{}, handler: s.handleRemoveQuota},
+		{method: "GET", path: "/v1/usage", summary: "Get usage of every managed container from the latest poll",
+			response: UsageSnapshotResponse{}, handler: s.handleUsageSnapshot},
+		{method: "GET", path: "/v1/report", summary: "Get a capacity report joining containerd metadata with live usage", query: []string{"namespace"},
+			response: ReportResponse{}, handler: s.handleReport},
+		{method: "GET", path: "/v1/quotas/{id}/usage", summary: "Query a container's live usage",
+			response: UsageResponse{}, handler: s.handleUsage},
+		{method: "GET", path: "/v1/quotas/{id}/history", summary: "Get a container's recent limit changes",
+			response: LimitHistoryResponse{}, handler: s.handleLimitHistory},
+		{method: "POST", path: "/v1/quotas/{id}/bump", summary: "Propose raising a container's limits",
+			request: BumpRequest{}, response: BumpProposalResponse{}, status: http.StatusCreated, handler: s.handleBumpPropose},
+		{method: "POST", path: "/v1/quotas/{id}/bump/{token}", summary: "Apply a limit bump proposal",
+			response: LimitsResponse{}, handler: s.handleBumpConfirm},
+		{method: "POST", path: "/v1/quotas/{id}/lift", summary: "Temporarily lift a container's limits",
+			request: LiftRequest{}, response: LiftResponse{}, handler: s.handleLift},
+		{method: "DELETE", path: "/v1/quotas/{id}/lift", summary: "Restore lifted limits",
+			response: LimitsResponse{}, handler: s.handleRestoreLift},
+		{method: "POST", path: "/v1/quotas/scale", summary: "Scale or reset every managed limit",
+			request: ScaleRequest{}, response: ScaleResponse{}, handler: s.handleScale},
+		{method: "POST", path: "/v1/quotas/batch/limits", summary: "Set limits for many containers",
+			request: BatchSetLimitsRequest{}, response: BatchResponse{}, handler: s.handleBatchSetLimits},
+		{method: "POST", path: "/v1/quotas/batch/remove", summary: "Remove quotas of containers matching a label selector",
+			request: BatchRemoveRequest{}, response: BatchResponse{}, handler: s.handleBatchRemove},
+		{method: "POST", path: "/v1/reconcile", summary: "Reconcile containerd, project IDs and the state file",
+			request: ResyncRequest{}, response: ResyncResponse{}, handler: s.handleResync},
+		{method: "POST", path: "/v1/resync", summary: "Alias of /v1/reconcile",
+			request: ResyncRequest{}, response: ResyncResponse{}, handler: s.handleResync},
+		{method: "GET", path: "/v1/debug/events", summary: "Get the last processed containerd event",
+			response: EventMarkResponse{}, handler: s.handleDebugEvents},
+		{method: "GET", path: "/v1/containers/{id}/mounts", summary: "Inspect a container's rootfs mounts", query: []string{"namespace"},
+			response: snapshot.MountInfo{}, handler: s.handleInspectMounts},
+		{method: "GET", path: "/v1/policy/targets", summary: "Export the container metadata policies are evaluated against",
+			response: PolicyTargetsResponse{}, handler: s.handlePolicyTargets},
+		{method: "POST", path: "/v1/policy/diff", summary: "Compare managed containers against a policy",
+			request: policy.Policy{}, response: PolicyDiffResponse{}, handler: s.handlePolicyDiff},
+		{method: "POST", path: "/v1/policy/apply", summary: "Converge managed containers to a policy",
+			request: policy.Policy{}, response: PolicyApplyResponse{}, handler: s.handlePolicyApply},
+		{method: "GET", path: "/v1/pool", summary: "Get project ID pool statistics",
+			response: xfs.PoolStats{}, handler: s.handlePoolStatus},
+		{method: "POST", path: "/v1/rebalance", summary: "Start moving every managed project to a new project ID range",
+			request: RebalanceRequest{}, response: RebalanceResponse{}, handler: s.handleStartRebalance},
+		{method: "GET", path: "/v1/rebalance", summary: "Get the progress of the project ID rebalance",
+			response: RebalanceResponse{}, handler: s.handleRebalanceStatus},
+		{method: "POST", path: "/v1/rebalance/pause", summary: "Pause the rebalance after the project being moved",
+			response: RebalanceResponse{}, handler: s.handleControlRebalance(RebalancePause)},
+		{method: "POST", path: "/v1/rebalance/resume", summary: "Resume a paused rebalance and retry failed projects",
+			response: RebalanceResponse{}, handler: s.handleControlRebalance(RebalanceResume)},
+		{method: "POST", path: "/v1/rebalance/rollback", summary: "Move every rebalanced project back to its old ID",
+			response: RebalanceResponse{}, handler: s.handleControlRebalance(RebalanceRollback)},
+		{method: "DELETE", path: "/v1/rebalance", summary: "Finish the rebalance and release the old project IDs",
+			response: RebalanceResponse{}, handler: s.handleControlRebalance(RebalanceFinish)},
+		{method: "GET", path: "/v1/drift", summary: "Compare the state file with on-disk project IDs and kernel limits",
+			response: DriftResponse{}, handler: s.handleDrift},
+		{method: "POST", path: "/v1/drift", summary: "Compare the state file with on-disk project IDs and kernel limits and repair the drift",
+			request: DriftRequest{}, response: DriftResponse{}, handler: s.handleDrift},
+		{method: "POST", path: "/v1/state/fsck", summary: "Check the state for duplicate upperdirs, out-of-range IDs and incomplete entries; repair and compact on request",
+			request: FsckRequest{}, response: xfs.FsckReport{}, handler: s.handleFsck},
+		{method: "POST", path: "/v1/gc", summary: "Release orphaned quotas: entries whose upperdir is gone, empty groups and limits without an entry",
+			request: GCRequest{}, response: GCResponse{}, handler: s.handleGC},
+		{method: "GET", path: "/v1/accounting", summary: "Get daily per-namespace usage summaries", query: []string{"from", "to", "namespace"},
+			response: []accounting.Summary{}, handler: s.handleAccounting},
+		{method: "GET", path: "/v1/image-overrides", summary: "List per-image quota overrides",
+			response: ImageOverridesResponse{}, handler: s.handleListImageOverrides},
+		{method: "PUT", path: "/v1/image-overrides/{digest}", summary: "Set a manual per-image quota override",
+			request: ImageOverrideRequest{}, response: xfs.ImageOverride{}, handler: s.handleSetImageOverride},
+		{method: "DELETE", path: "/v1/image-overrides/{digest}", summary: "Delete a per-image quota override",
+			response: ImageOverridesResponse{}, handler: s.handleRemoveImageOverride},
+		{method: "GET", path: "/v1/standby", summary: "Get the standby state",
+			response: StandbyResponse{}, handler: s.handleStandbyStatus},
+		{method: "POST", path: "/v1/standby/promote", summary: "Promote a standby instance",
+			response: StandbyResponse{}, handler: s.handlePromote},
+		{method: "GET", path: "/v1/version", summary: "Get the build version and enabled features",
+			response: VersionResponse{}, handler: s.handleVersion},
+		{method: "GET", path: "/v1/openapi.json", summary: "Get this OpenAPI document",
+			handler: s.handleOpenAPI},
+	}
+	for _, rt := range s.table {
+		s.mux.HandleFunc(rt.method+" "+rt.path, rt.handler)
+	}
+}
+
+// Serve 开始监听，阻塞直到监听失败
+func (s *Server) Serve() error {
+	l, err := listener.Listen(s.cfg.AdminAddr)
+	if err != nil {
+		return err
+	}
+	log.Info("Serving admin API", zap.String("addr", s.cfg.AdminAddr))
+	return http.Serve(l, s.mux)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Failed to write response", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNoRebalance):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrStandby):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, ErrConflict):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,15 @@
+package api
+
+import "net/http"
+
+func (s *Server) handleStandbyStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, StandbyResponse{Standby: s.manager.Standby()})
+}
+
+func (s *Server) handlePromote(w http.ResponseWriter, r *http.Request) {
+	if err := s.manager.Promote(); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, StandbyResponse{Standby: s.manager.Standby()})
+}
@@ -0,0 +1,455 @@
+package api
+
+import (
+	"time"
+
+	"RootfsQuota/pkg/policy"
+	"RootfsQuota/pkg/xfs"
+)
+
+// ErrorResponse 为错误响应
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// UsageResponse 为单个容器的实时用量
+type UsageResponse struct {
+	ContainerID    string `json:"container_id"`
+	ProjectID      uint32 `json:"project_id"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	UsedInodes     uint64 `json:"used_inodes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	InodeSoftLimit uint64 `json:"inode_soft_limit"`
+	InodeHardLimit uint64 `json:"inode_hard_limit"`
+	// Percent 为已用字节占硬限制的百分比，未设置硬限制时为 0
+	Percent float64 `json:"percent"`
+}
+
+// 用量快照的来源
+const (
+	UsageSourcePoller = "poller"
+	UsageSourceLive   = "live"
+)
+
+// ContainerUsage 为快照中一个容器的用量，共享项目的容器为整个组的用量
+type ContainerUsage struct {
+	ContainerID    string `json:"container_id"`
+	Namespace      string `json:"namespace,omitempty"`
+	Image          string `json:"image,omitempty"`
+	Group          string `json:"group,omitempty"`
+	ProjectID      uint32 `json:"project_id"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	UsedInodes     uint64 `json:"used_inodes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	// Percent 为已用字节占硬限制的百分比，未设置硬限制时为 0
+	Percent float64 `json:"percent"`
+}
+
+// UsageSnapshotResponse 为所有已管理容器的用量。Source 为 poller 时取自后台轮询的快照，
+// 轮询未运行或快照已过期时为 live，即本次请求实时查询
+type UsageSnapshotResponse struct {
+	At         time.Time        `json:"at"`
+	Source     string           `json:"source"`
+	Containers []ContainerUsage `json:"containers"`
+}
+
+// ReportContainer 为容量报告中的一个容器：containerd 中的镜像与命名空间，加上实时读取的块与 inode 用量。
+// UsageMissing 表示报告中没有该项目，用量与限额均为 0
+type ReportContainer struct {
+	ContainerID    string `json:"container_id"`
+	Namespace      string `json:"namespace,omitempty"`
+	Image          string `json:"image,omitempty"`
+	Group          string `json:"group,omitempty"`
+	ProjectID      uint32 `json:"project_id"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	InodeSoftLimit uint64 `json:"inode_soft_limit"`
+	InodeHardLimit uint64 `json:"inode_hard_limit"`
+	UsedInodes     uint64 `json:"used_inodes"`
+	UsageMissing   bool   `json:"usage_missing,omitempty"`
+}
+
+// ReportResponse 为所有已管理容器的容量报告，按命名空间与容器 ID 排序
+type ReportResponse struct {
+	At         time.Time         `json:"at"`
+	Containers []ReportContainer `json:"containers"`
+}
+
+// RebalanceRequest 为项目 ID 迁移的目标范围，DryRun 时只返回计划
+type RebalanceRequest struct {
+	IDMin  uint32 `json:"id_min"`
+	IDMax  uint32 `json:"id_max"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// RebalanceResponse 为迁移进度，Containers 按容器列出，共享项目的容器迁移状态相同
+type RebalanceResponse struct {
+	IDMin      uint32               `json:"id_min"`
+	IDMax      uint32               `json:"id_max"`
+	State      string               `json:"state"`
+	StartedAt  time.Time            `json:"started_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	Projects   int                  `json:"projects"`
+	Moved      int                  `json:"moved"`
+	Failed     int                  `json:"failed"`
+	Remaining  int                  `json:"remaining"`
+	Containers []RebalanceContainer `json:"containers"`
+}
+
+// RebalanceContainer 为一个容器的迁移进度，NewID 在分配前为 0
+type RebalanceContainer struct {
+	ContainerID string `json:"container_id"`
+	OldID       uint32 `json:"old_id"`
+	NewID       uint32 `json:"new_id,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// 迁移的控制操作
+const (
+	RebalancePause    = "pause"
+	RebalanceResume   = "resume"
+	RebalanceRollback = "rollback"
+	RebalanceFinish   = "finish"
+)
+
+// QuotaResponse 为一个已管理的配额，History 只在查询单个容器时返回
+type QuotaResponse struct {
+	ContainerID string    `json:"container_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	ProjectID   uint32    `json:"project_id"`
+	Group       string    `json:"group,omitempty"`
+	Upperdir    string    `json:"upperdir"`
+	SoftLimit   string    `json:"soft_limit"`
+	HardLimit   string    `json:"hard_limit"`
+	InodeSoft   uint64    `json:"inode_soft,omitempty"`
+	InodeHard   uint64    `json:"inode_hard,omitempty"`
+	ImageDigest string    `json:"image_digest,omitempty"`
+	Class       string    `json:"class,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// LiftedUntil 为临时解除限额的到期时间，限额正常生效时省略
+	LiftedUntil *time.Time `json:"lifted_until,omitempty"`
+	// Pinned 为真表示限额由运维手动设置，策略与 kubelet 扩缩容不再修改
+	Pinned  bool              `json:"pinned,omitempty"`
+	History []xfs.LimitRecord `json:"history,omitempty"`
+}
+
+// QuotaListResponse 为所有已管理的配额，按容器 ID 排序
+type QuotaListResponse struct {
+	Quotas []QuotaResponse `json:"quotas"`
+}
+
+// LimitsRequest 为设置单个容器限额的请求
+type LimitsRequest struct {
+	Soft string `json:"soft"`
+	Hard string `json:"hard"`
+}
+
+// LimitHistoryResponse 为容器当前限额及最近的变更记录
+type LimitHistoryResponse struct {
+	ContainerID string            `json:"container_id"`
+	ProjectID   uint32            `json:"project_id"`
+	SoftLimit   string            `json:"soft_limit"`
+	HardLimit   string            `json:"hard_limit"`
+	History     []xfs.LimitRecord `json:"history"`
+}
+
+// BumpRequest 为限额提升提案请求
+type BumpRequest struct {
+	Percent int `json:"percent"`
+}
+
+// BumpProposalResponse 为限额提升提案，需使用 Token 确认后才会生效
+type BumpProposalResponse struct {
+	ContainerID  string    `json:"container_id"`
+	Token        string    `json:"token"`
+	CurrentSoft  string    `json:"current_soft"`
+	CurrentHard  string    `json:"current_hard"`
+	ProposedSoft string    `json:"proposed_soft"`
+	ProposedHard string    `json:"proposed_hard"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// LimitsResponse 为已生效的限额
+type LimitsResponse struct {
+	ContainerID string `json:"container_id"`
+	Soft        string `json:"soft"`
+	Hard        string `json:"hard"`
+}
+
+// LiftRequest 为临时解除限额请求
+type LiftRequest struct {
+	DurationSeconds int `json:"duration_seconds"`
+}
+
+// LiftResponse 为临时解除的结果，到期后恢复为 Soft/Hard
+type LiftResponse struct {
+	ContainerID string    `json:"container_id"`
+	Soft        string    `json:"soft"`
+	Hard        string    `json:"hard"`
+	Until       time.Time `json:"until"`
+}
+
+// 对账动作类型
+const (
+	ResyncAdopt  = "adopt"
+	ResyncCreate = "create"
+	ResyncRepair = "repair"
+	ResyncRemove = "remove"
+)
+
+// ResyncRequest 为全量对账请求，DryRun 时只返回计划
+type ResyncRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// ResyncAction 为对账计划中的单个动作及执行结果
+type ResyncAction struct {
+	Action      string `json:"action"`
+	ContainerID string `json:"container_id"`
+	Namespace   string `json:"namespace,omitempty"`
+	ProjectID   uint32 `json:"project_id,omitempty"`
+	Upperdir    string `json:"upperdir,omitempty"`
+	Reason      string `json:"reason"`
+	Done        bool   `json:"done"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ResyncResponse 为对账计划或结果
+type ResyncResponse struct {
+	DryRun  bool           `json:"dry_run"`
+	Actions []ResyncAction `json:"actions"`
+}
+
+// 偏差类型
+const (
+	// DriftProjectID 为目录上的项目 ID 与状态文件不一致
+	DriftProjectID = "project_id"
+	// DriftUnreadable 为无法读取目录上的项目 ID
+	DriftUnreadable = "unreadable"
+	// DriftMissingDir 为记录的目录已不存在，留给对账处理
+	DriftMissingDir = "missing_dir"
+	// DriftBlockLimits 为内核中的块限额与记录不一致
+	DriftBlockLimits = "block_limits"
+	// DriftInodeLimits 为内核中的 inode 限额与记录不一致
+	DriftInodeLimits = "inode_limits"
+	// DriftOrphanLimits 为范围内没有条目的项目 ID 在内核中仍有限额
+	DriftOrphanLimits = "orphan_limits"
+)
+
+// DriftRequest 为偏差检查请求，Repair 时修复能修复的偏差
+type DriftRequest struct {
+	Repair bool `json:"repair"`
+}
+
+// Drift 为状态文件、目录上的项目 ID 与内核限额之间的一处偏差
+type Drift struct {
+	Kind      string `json:"kind"`
+	ProjectID uint32 `json:"project_id"`
+	// Containers 为共享该项目的条目键
+	Containers []string `json:"containers,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	Expected   string   `json:"expected"`
+	Actual     string   `json:"actual"`
+	Repaired   bool     `json:"repaired"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// DriftResponse 为偏差检查结果，Projects 为检查的项目数
+type DriftResponse struct {
+	At       time.Time `json:"at"`
+	Repair   bool      `json:"repair"`
+	Projects int       `json:"projects"`
+	Drift    []Drift   `json:"drift"`
+}
+
+// 垃圾回收的对象类型
+const (
+	// GCStaleEntry 为可写层目录已不存在的条目，移除其配额并归还项目 ID
+	GCStaleEntry = "stale_entry"
+	// GCEmptyGroup 为已没有成员的共享项目分组
+	GCEmptyGroup = "empty_group"
+	// GCOrphanProject 为范围内没有条目却仍有限额的项目 ID
+	GCOrphanProject = "orphan_project"
+)
+
+// GCRequest 为垃圾回收请求，DryRun 时只返回计划
+type GCRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// FsckRequest 为状态检查请求，Repair 时修复可自动修复的问题并压缩状态
+type FsckRequest struct {
+	Repair bool `json:"repair"`
+}
+
+// GCItem 为一个回收对象及执行结果，ContainerID 为条目键，遗留项目为空
+type GCItem struct {
+	Kind        string `json:"kind"`
+	ProjectID   uint32 `json:"project_id"`
+	ContainerID string `json:"container_id,omitempty"`
+	Path        string `json:"path,omitempty"`
+	// Limits 为内核中的限额，仅遗留项目
+	Limits string `json:"limits,omitempty"`
+	Done   bool   `json:"done"`
+	Error  string `json:"error,omitempty"`
+}
+
+// GCResponse 为垃圾回收计划或结果
+type GCResponse struct {
+	DryRun bool     `json:"dry_run"`
+	Items  []GCItem `json:"items"`
+}
+
+// ScaleRequest 为批量调整限额请求，Factor 与 ToDefaults 二选一
+type ScaleRequest struct {
+	// Factor 为限额缩放倍数，如 1.5 表示扩大 50%
+	Factor float64 `json:"factor,omitempty"`
+	// ToDefaults 表示将所有限额重置为当前配置的默认值
+	ToDefaults bool `json:"to_defaults,omitempty"`
+	// IncludePinned 表示 ToDefaults 时也重置运维固定的限额，默认跳过并在结果中说明
+	IncludePinned bool `json:"include_pinned,omitempty"`
+	DryRun        bool `json:"dry_run,omitempty"`
+}
+
+// LimitChange 为单个项目的限额变更及执行结果
+type LimitChange struct {
+	ContainerID string `json:"container_id"`
+	ProjectID   uint32 `json:"project_id"`
+	OldSoft     string `json:"old_soft"`
+	OldHard     string `json:"old_hard"`
+	NewSoft     string `json:"new_soft"`
+	NewHard     string `json:"new_hard"`
+	Applied     bool   `json:"applied"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ScaleResponse 为批量调整的计划或结果
+type ScaleResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Changes []LimitChange `json:"changes"`
+}
+
+// BatchLimitItem 为批量设置中的单个容器
+type BatchLimitItem struct {
+	ContainerID string `json:"container_id"`
+	Soft        string `json:"soft"`
+	Hard        string `json:"hard"`
+}
+
+// BatchSetLimitsRequest 为批量设置限额请求
+type BatchSetLimitsRequest struct {
+	Items       []BatchLimitItem `json:"items"`
+	Concurrency int              `json:"concurrency,omitempty"`
+}
+
+// BatchRemoveRequest 为按标签选择器批量移除配额请求
+type BatchRemoveRequest struct {
+	Selector    string `json:"selector"`
+	Concurrency int    `json:"concurrency,omitempty"`
+	DryRun      bool   `json:"dry_run,omitempty"`
+}
+
+// BatchResult 为批量操作中单个容器的结果
+type BatchResult struct {
+	ContainerID string `json:"container_id"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BatchResponse 为批量操作的汇总结果
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// EventMarkResponse 为最近处理的事件水位，尚未处理任何事件时各字段为零值
+type EventMarkResponse struct {
+	Sequence   uint64    `json:"sequence"`
+	Timestamp  time.Time `json:"timestamp"`
+	Topic      string    `json:"topic,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	AgeSeconds float64   `json:"age_seconds"`
+}
+
+// PolicyTargetsResponse 为已管理容器的策略元数据，可保存后离线调用 policy.EvaluatePolicy
+type PolicyTargetsResponse struct {
+	Containers []policy.ContainerMeta `json:"containers"`
+}
+
+// PolicyDiffResponse 为策略与当前状态的差异，只包含需要修改的容器
+type PolicyDiffResponse struct {
+	Changes []policy.Change `json:"changes"`
+}
+
+// explain 中的路径类别
+const (
+	// ExplainRootfs 为容器根文件系统，写入落在可写层
+	ExplainRootfs = "rootfs"
+	// ExplainExtra 为纳入项目的额外目录（Pod 日志、emptyDir 或声明的可写目录）
+	ExplainExtra = "extra"
+	// ExplainTmpfs 为 tmpfs 挂载，写入占用内存
+	ExplainTmpfs = "tmpfs"
+	// ExplainBind 为绑定挂载的宿主机目录或文件
+	ExplainBind = "bind"
+)
+
+// ExplainPath 说明容器内一处可写位置是否计入配额
+type ExplainPath struct {
+	// Path 为容器内路径，未挂载到容器内的额外目录为空
+	Path     string `json:"path,omitempty"`
+	HostPath string `json:"host_path,omitempty"`
+	Kind     string `json:"kind"`
+	Governed bool   `json:"governed"`
+	Reason   string `json:"reason"`
+}
+
+// ExplainResponse 说明容器的哪些写入受配额约束
+type ExplainResponse struct {
+	ContainerID string     `json:"container_id"`
+	Namespace   string     `json:"namespace,omitempty"`
+	ProjectID   uint32     `json:"project_id"`
+	Group       string     `json:"group,omitempty"`
+	SoftLimit   string     `json:"soft_limit"`
+	HardLimit   string     `json:"hard_limit"`
+	LiftedUntil *time.Time `json:"lifted_until,omitempty"`
+	Pinned      bool       `json:"pinned,omitempty"`
+	// Warning 为无法读取容器挂载等不完整的原因
+	Warning string        `json:"warning,omitempty"`
+	Paths   []ExplainPath `json:"paths"`
+}
+
+// PolicyApplyResponse 为策略应用结果，失败时 Error 非空且已应用的修改被回滚
+type PolicyApplyResponse struct {
+	Changes []policy.Change `json:"changes"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// VersionResponse 为构建版本与子系统开关
+type VersionResponse struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	// Features 为每个子系统是否启用，见 config.Features
+	Features map[string]bool `json:"features"`
+}
+
+// StandbyResponse 为实例的备用状态
+type StandbyResponse struct {
+	Standby bool `json:"standby"`
+}
+
+// ImageOverridesResponse 为所有镜像限额覆盖
+type ImageOverridesResponse struct {
+	Overrides []xfs.ImageOverride `json:"overrides"`
+}
+
+// ImageOverrideRequest 为手动设置镜像限额覆盖的请求，Image 为可选的镜像名
+type ImageOverrideRequest struct {
+	Image string `json:"image,omitempty"`
+	Soft  string `json:"soft"`
+	Hard  string `json:"hard"`
+}
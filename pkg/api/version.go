@@ -0,0 +1,7 @@
+package api
+
+import "net/http"
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.manager.Version())
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 审计事件类型
+const (
+	EventQuotaRemoved = "quota_removed"
+	// EventQuotaOptOut 为容器通过标签请求退出配额管理，Reason 为 honored（已允许）或 denied（不在允许的命名空间内）
+	EventQuotaOptOut = "quota_opt_out"
+	// EventLimitClamped 为标签或注解申请的限额超出 label_overrides 的上下限而被调整，SoftLimit/HardLimit 为实际设置的限额，
+	// Reason 说明申请的限额与所做的调整
+	EventLimitClamped = "limit_clamped"
+)
+
+// Record 为一条审计记录，以 JSON Lines 形式追加写入
+type Record struct {
+	Time        time.Time `json:"time"`
+	Event       string    `json:"event"`
+	ContainerID string    `json:"container_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	ProjectID   uint32    `json:"project_id"`
+	Group       string    `json:"group,omitempty"`
+	SoftLimit   string    `json:"soft_limit,omitempty"`
+	HardLimit   string    `json:"hard_limit,omitempty"`
+	// UsedBytes/UsedInodes 为删除前最后一次采集的用量，分组成员记录的是整个分组的用量
+	UsedBytes  uint64 `json:"used_bytes"`
+	UsedInodes uint64 `json:"used_inodes"`
+	// LifetimeSeconds 为配额从设置到移除的时长，未知时为 0
+	LifetimeSeconds int64 `json:"lifetime_seconds,omitempty"`
+	// Pod 为容器所属的 Kubernetes Pod（namespace/name），未知时为空
+	Pod    string `json:"pod,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Logger 将审计记录追加到文件，并发安全
+type Logger struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewLogger 打开（必要时创建）审计日志文件
+func NewLogger(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return &Logger{file: file}, nil
+}
+
+// Write 追加一条记录，Time 为空时取当前时间
+func (l *Logger) Write(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭审计日志文件
+func (l *Logger) Close() error {
+	return l.file.Close()
+}
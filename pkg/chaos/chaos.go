@@ -0,0 +1,105 @@
+//go:build chaos
+
+// Package chaos injects faults into backend calls, event handling and state
+// saves for staging chaos tests. It is only compiled in with `-tags chaos`;
+// regular builds use the no-op hooks in nop.go.
+//
+// Faults are configured through the CONQUOTAS_CHAOS environment variable as a
+// comma separated list, e.g.
+//
+//	CONQUOTAS_CHAOS="fail=0.1,delay=5s,delay_rate=0.2,corrupt=0.05"
+//
+// fail is the probability that a backend call returns an error, delay and
+// delay_rate add a fixed delay to that fraction of events, and corrupt is the
+// probability that a state save writes a truncated file.
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// Enabled reports whether fault injection is compiled in.
+const Enabled = true
+
+type settings struct {
+	failRate    float64
+	delay       time.Duration
+	delayRate   float64
+	corruptRate float64
+}
+
+var current = parse(os.Getenv("CONQUOTAS_CHAOS"))
+
+func parse(spec string) settings {
+	var s settings
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "fail":
+			s.failRate, err = strconv.ParseFloat(value, 64)
+		case "delay":
+			s.delay, err = time.ParseDuration(value)
+		case "delay_rate":
+			s.delayRate, err = strconv.ParseFloat(value, 64)
+		case "corrupt":
+			s.corruptRate, err = strconv.ParseFloat(value, 64)
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			log.Warn("Ignoring invalid chaos setting", zap.String("setting", field), zap.Error(err))
+		}
+	}
+	if s.delay > 0 && s.delayRate == 0 {
+		s.delayRate = 1
+	}
+	log.Warn("Fault injection enabled",
+		zap.Float64("fail", s.failRate),
+		zap.Duration("delay", s.delay),
+		zap.Float64("delayRate", s.delayRate),
+		zap.Float64("corrupt", s.corruptRate))
+	return s
+}
+
+// Fail returns an injected error for the named backend operation with the
+// configured probability.
+func Fail(op string) error {
+	if rand.Float64() >= current.failRate {
+		return nil
+	}
+	log.Warn("Injecting backend failure", zap.String("op", op))
+	return fmt.Errorf("chaos: injected failure in %s", op)
+}
+
+// Delay blocks for the configured event delay with the configured probability.
+func Delay() {
+	if current.delay <= 0 || rand.Float64() >= current.delayRate {
+		return
+	}
+	log.Warn("Injecting event delay", zap.Duration("delay", current.delay))
+	time.Sleep(current.delay)
+}
+
+// Corrupt returns data truncated at a random offset with the configured
+// probability, simulating a torn state write.
+func Corrupt(data []byte) []byte {
+	if len(data) == 0 || rand.Float64() >= current.corruptRate {
+		return data
+	}
+	n := rand.Intn(len(data))
+	log.Warn("Injecting state corruption", zap.Int("size", len(data)), zap.Int("truncatedTo", n))
+	return data[:n]
+}
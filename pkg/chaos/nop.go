@@ -0,0 +1,15 @@
+//go:build !chaos
+
+package chaos
+
+// Enabled reports whether fault injection is compiled in.
+const Enabled = false
+
+// Fail never fails without the chaos build tag.
+func Fail(op string) error { return nil }
+
+// Delay does nothing without the chaos build tag.
+func Delay() {}
+
+// Corrupt returns data unchanged without the chaos build tag.
+func Corrupt(data []byte) []byte { return data }
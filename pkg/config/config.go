@@ -3,33 +3,738 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/maintenance"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
 
 	"go.uber.org/zap"
 )
 
 // Config 存储配置文件中的参数，部分字段采用嵌套结构
 type Config struct {
-	StateFilePath  string        `json:"state_file_path"`
-	Project        ProjectConfig `json:"project"`
-	MetricsPort    string        `json:"metrics_port"`
-	ContainerdSock string        `json:"containerd_sock"`
-	Quota          QuotaConfig   `json:"quota"`
-	Namespace      string        `json:"namespace"`
+	// StateBackend 为状态存储方式：file（默认）或 memory（不持久化，启动时从磁盘标记重建）
+	StateBackend   string           `json:"state_backend"`
+	StateFilePath  string           `json:"state_file_path"`
+	Project        ProjectConfig    `json:"project"`
+	MetricsPort    string           `json:"metrics_port"`
+	Pprof          bool             `json:"pprof"`
+	AdminAddr      string           `json:"admin_addr"`
+	ContainerdSock string           `json:"containerd_sock"`
+	Quota          QuotaConfig      `json:"quota"`
+	Namespace      string           `json:"namespace"`
+	Buildkit       BuildkitConfig   `json:"buildkit"`
+	Kata           KataConfig       `json:"kata"`
+	Event          EventConfig      `json:"event"`
+	Scheduler      SchedulerConfig  `json:"scheduler"`
+	Bump           BumpConfig       `json:"bump"`
+	Lift           LiftConfig       `json:"lift"`
+	Aggregator     AggregatorConfig `json:"aggregator"`
+	// QuotaScope 为配额作用域：container（默认，每个容器独立）、pod-ephemeral（每个 Pod 共享，含日志与 emptyDir）
+	// 或 pod（同一 Pod 沙箱的容器可写层共享）
+	QuotaScope   string             `json:"quota_scope"`
+	PodEphemeral PodEphemeralConfig `json:"pod_ephemeral"`
+	Verify       VerifyConfig       `json:"verify"`
+	QuotaState   QuotaStateConfig   `json:"quota_state"`
+	Nested       NestedConfig       `json:"nested"`
+	Emergency    EmergencyConfig    `json:"emergency"`
+	Capacity     CapacityConfig     `json:"capacity"`
+	Audit        AuditConfig        `json:"audit"`
+	LimitNotify  LimitNotifyConfig  `json:"limit_notify"`
+	Accounting   AccountingConfig   `json:"accounting"`
+	Stats        StatsConfig        `json:"stats"`
+	ExtraPaths   ExtraPathsConfig   `json:"extra_paths"`
+	EventSink    EventSinkConfig    `json:"event_sink"`
+	Mirror       MirrorConfig       `json:"mirror"`
+	OptOut       OptOutConfig       `json:"opt_out"`
+	Chat         ChatConfig         `json:"chat"`
+	Alerts       AlertsConfig       `json:"alerts"`
+	Images       ImagesConfig       `json:"images"`
+	UsagePoller  UsagePollerConfig  `json:"usage_poller"`
+	Templates    TemplatesConfig    `json:"templates"`
+	Maintenance  MaintenanceConfig  `json:"maintenance"`
+	Features     FeaturesConfig     `json:"features"`
+	// NamespaceQuotas 为按命名空间共享的总配额，命中的命名空间不再按容器独立设置配额
+	NamespaceQuotas map[string]NamespaceQuotaConfig `json:"namespace_quotas"`
+	Policy          PolicyConfig                    `json:"policy"`
+	Kubelet         KubeletConfig                   `json:"kubelet"`
+	ConfigSource    ConfigSourceConfig              `json:"config_source"`
+	MetadataCache   MetadataCacheConfig             `json:"metadata_cache"`
+	NodeAnnotation  NodeAnnotationConfig            `json:"node_annotation"`
+	CRIGate         CRIGateConfig                   `json:"cri_gate"`
+	LabelOverrides  LabelOverridesConfig            `json:"label_overrides"`
+	QuotaClasses    QuotaClassesConfig              `json:"quota_classes"`
+	// SnapshotterAliases 将自研快照器映射到已支持的快照器插件（如 "my-snap": "overlayfs"）
+	SnapshotterAliases map[string]string `json:"snapshotter_aliases"`
+	// UpperdirAllowlist 为允许设置配额的目录，为空时不限制；不在其中的可写层与 BuildKit 快照被跳过
+	UpperdirAllowlist []string `json:"upperdir_allowlist"`
+	// NamespaceDefaults 为按 containerd 命名空间（k8s.io、moby 等）取代顶层 quota 的按容器默认限额，
+	// 未设置的限额沿用顶层 quota；与 NamespaceQuotas 不同，容器仍各自使用独立的项目
+	NamespaceDefaults map[string]QuotaConfig `json:"namespace_defaults"`
+	// AsyncBackend 为实验性的异步后端流水线，默认关闭
+	AsyncBackend AsyncBackendConfig `json:"async_backend"`
+	// EphemeralStorage 将 Pod 规格中的 ephemeral-storage 限制换算为容器的块限额
+	EphemeralStorage EphemeralStorageConfig `json:"ephemeral_storage"`
+	// LifecycleWebhook 为外部系统推送工作负载启动、结束通知的接收端，默认关闭
+	LifecycleWebhook LifecycleWebhookConfig `json:"lifecycle_webhook"`
+	// Exclude 为排除规则，命中的容器永不设置配额
+	Exclude ExcludeConfig `json:"exclude"`
+	// PodAggregate 为 quota_scope 为 pod 时 Pod 共享项目的配置
+	PodAggregate PodAggregateConfig `json:"pod_aggregate"`
+}
+
+// ConfigSourceConfig 存储远程配置源（-config 为 HTTP(S) URL 时）的轮询配置
+type ConfigSourceConfig struct {
+	IntervalSeconds int `json:"interval_seconds"`
+}
+
+// AsyncBackendConfig 存储实验性异步后端的配置：开启后 XFS 限额由单个工作协程以 quotactl 成批写入，
+// 不再每次启动 xfs_quota；挂载表或设备节点不可用时回退到 xfs_quota
+type AsyncBackendConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxBatch 为一次加锁写入的最大请求数，默认 64
+	MaxBatch int `json:"max_batch"`
+}
+
+// LifecycleWebhookConfig 存储生命周期通知接收端的配置：不由 containerd 管理的工作负载（自研调度器、构建系统等）
+// 在启动前推送可写目录以预先设置配额，结束后推送以提前释放
+type LifecycleWebhookConfig struct {
+	Enabled bool `json:"enabled"`
+	// Listen 为接收端的监听地址，格式同 admin_addr，默认 unix:///run/conquotas/lifecycle.sock
+	Listen string `json:"listen"`
+	// TokenFile 为 Bearer token 文件，为空时不认证
+	TokenFile string `json:"token_file"`
+	// Namespace 为外部工作负载在状态与指标中的命名空间，默认 external
+	Namespace string `json:"namespace"`
+	// Quota 为通知未给出限额时的默认限额，未设置的字段使用 quota
+	Quota QuotaConfig `json:"quota"`
+}
+
+// KubeletConfig 存储读取 kubelet Pod 规格的配置，用于跟随原地扩缩容调整 ephemeral-storage 限额，URL 为空时不启用
+type KubeletConfig struct {
+	// URL 为 kubelet 的 Pod 列表接口，如 https://127.0.0.1:10250/pods
+	URL                   string `json:"url"`
+	TokenFile             string `json:"token_file"`
+	InsecureSkipVerify    bool   `json:"insecure_skip_verify"`
+	ResizeIntervalSeconds int    `json:"resize_interval_seconds"`
+}
+
+// EphemeralStorageConfig 存储 ephemeral-storage 限制到块限额的换算：硬限制为 limit*Ratio+Offset，
+// 软限制保持默认软、硬限制的比例。Enabled 为真时新容器按换算结果设置配额（需要 kubelet.url），
+// 原地扩缩容总是使用同一换算
+type EphemeralStorageConfig struct {
+	Enabled bool `json:"enabled"`
+	// Ratio 为乘数，默认 1
+	Ratio float64 `json:"ratio"`
+	// Offset 为加上的大小，可为负（如 "-512m"，为 Pod 日志与 emptyDir 留出余量），默认 0
+	Offset string `json:"offset"`
+}
+
+// OffsetBytes 返回 Offset 的字节数，负数表示减去
+func (c EphemeralStorageConfig) OffsetBytes() int64 {
+	offset, negative := strings.CutPrefix(c.Offset, "-")
+	if offset == "" {
+		return 0
+	}
+	n, err := xfs.ParseSize(offset)
+	if err != nil || n > math.MaxInt64 {
+		return 0
+	}
+	if negative {
+		return -int64(n)
+	}
+	return int64(n)
+}
+
+// PolicyConfig 存储声明式策略文件的监视配置，File 为空时不启用
+type PolicyConfig struct {
+	File            string `json:"file"`
+	IntervalSeconds int    `json:"interval_seconds"`
+}
+
+// NamespaceQuotaConfig 存储命名空间级共享配额，命名空间内所有容器 rootfs 共用一个项目 ID
+type NamespaceQuotaConfig struct {
+	Quota QuotaConfig `json:"quota"`
+	// Paths 为额外纳入命名空间项目的目录（如该命名空间专用的工作目录）
+	Paths []string `json:"paths"`
+}
+
+// AuditConfig 存储审计日志配置，Path 为空时不记录
+type AuditConfig struct {
+	Path string `json:"path"`
+}
+
+// AccountingConfig 存储按天、按命名空间的用量账单配置，Path 为空时不记录
+type AccountingConfig struct {
+	Path string `json:"path"`
+	// RetentionDays 为保留的天数，默认 90
+	RetentionDays int `json:"retention_days"`
+}
+
+// ExtraPathsConfig 存储容器声明的额外可写目录（绑定挂载的临时目录等）的配置，这些目录纳入容器的项目共享预算；
+// Allowlist 为空时不启用
+type ExtraPathsConfig struct {
+	// Key 为声明目录的容器标签或 OCI 注解名，值为逗号分隔的宿主机绝对路径，默认 conquotas.io/extra-paths
+	Key string `json:"key"`
+	// Allowlist 为允许纳入的目录，声明的目录必须位于其中
+	Allowlist []string `json:"allowlist"`
+}
+
+// EventSinkConfig 存储配额生命周期事件发布的配置，Type 为空时不发布
+type EventSinkConfig struct {
+	// Type 为消息系统：kafka（经 Kafka REST Proxy）或 nats
+	Type string `json:"type"`
+	// URL 为 REST Proxy 地址（http://kafka-rest:8082）或 NATS 地址（nats://nats:4222）
+	URL string `json:"url"`
+	// Topic 为 Kafka 主题或 NATS subject，默认 conquotas.quota
+	Topic    string `json:"topic"`
+	NodeName string `json:"node_name"`
+	// QueueSize 为待发布事件的队列长度，队列满时丢弃新事件，默认 1000
+	QueueSize int `json:"queue_size"`
+	// ExceededIntervalSeconds 为检查用量是否超出软限制的间隔，默认 60
+	ExceededIntervalSeconds int `json:"exceeded_interval_seconds"`
+}
+
+// 可单独启用或关闭的子系统，见 FeaturesConfig
+const (
+	// FeatureCollector 为后台用量轮询、按容器指标与汇聚推送
+	FeatureCollector = "collector"
+	// FeatureReconciler 为连接 containerd 时的状态同步、定期校验、项目配额状态检查、策略文件与 Pod 扩缩容跟随
+	FeatureReconciler = "reconciler"
+	// FeatureNotifier 为用量告警、聊天通知、事件接收端与限额变更通知
+	FeatureNotifier = "notifier"
+	// FeatureAPI 为管理接口与 kubelet 统计服务
+	FeatureAPI = "api"
+	// FeatureEnforcement 为新容器设置配额、BuildKit 与嵌套容器扫描及告急时停止容器
+	FeatureEnforcement = "enforcement"
+)
+
+// Features 为所有子系统，按启动日志与 /version 中的顺序排列
+var Features = []string{FeatureCollector, FeatureReconciler, FeatureNotifier, FeatureAPI, FeatureEnforcement}
+
+// FeaturesConfig 为子系统开关，如 {"notifier": false}；未列出的子系统默认启用
+type FeaturesConfig map[string]bool
+
+// Enabled 判断子系统是否启用
+func (f FeaturesConfig) Enabled(name string) bool {
+	enabled, set := f[name]
+	return !set || enabled
+}
+
+// Active 返回已启用的子系统
+func (f FeaturesConfig) Active() []string {
+	var active []string
+	for _, name := range Features {
+		if f.Enabled(name) {
+			active = append(active, name)
+		}
+	}
+	return active
+}
+
+// MaintenanceConfig 存储维护窗口的配置：窗口内暂停或放宽破坏性的处理动作（告急时停止可牺牲容器），
+// 检查、上报与通知照常进行
+type MaintenanceConfig struct {
+	// Timezone 为解释 schedule 的 IANA 时区，如 "Asia/Shanghai"，默认本地时区
+	Timezone string              `json:"timezone"`
+	Windows  []MaintenanceWindow `json:"windows"`
+}
+
+// MaintenanceWindow 为一个按 cron 表达式重复的维护窗口
+type MaintenanceWindow struct {
+	Name string `json:"name"`
+	// Schedule 为窗口开始时间的五段式 cron 表达式，如 "0 2 * * *"
+	Schedule        string `json:"schedule"`
+	DurationMinutes int    `json:"duration_minutes"`
+	// Action 为 suppress（不停止容器）或 relax（可用空间低于 relaxed_critical_percent 时才停止），默认 suppress
+	Action                 string  `json:"action"`
+	RelaxedCriticalPercent float64 `json:"relaxed_critical_percent"`
+}
+
+// TemplatesConfig 存储通知的 Go 模板，未设置的模板使用内置格式；可引用的字段见 notify.TemplateData
+type TemplatesConfig struct {
+	// UsageAlert 为用量告警的日志消息与 webhook 请求体
+	UsageAlert NotificationTemplate `json:"usage_alert"`
+	// HardLimit 为达到硬限制时的聊天消息
+	HardLimit NotificationTemplate `json:"hard_limit"`
+}
+
+// NotificationTemplate 为一种通知的模板
+type NotificationTemplate struct {
+	// Message 为日志或聊天消息的文本
+	Message string `json:"message"`
+	// Payload 为 webhook 请求体，ContentType 为其内容类型，默认 application/json
+	Payload     string `json:"payload"`
+	ContentType string `json:"content_type"`
+}
+
+// UsagePollerConfig 存储后台用量轮询的配置。轮询以一次 report 读取所有项目的用量并附上容器元数据，
+// 告警、聊天通知、事件接收端、状态镜像、汇聚推送与按容器指标都读取同一份快照
+type UsagePollerConfig struct {
+	// IntervalSeconds 为轮询间隔，默认 30
+	IntervalSeconds int `json:"interval_seconds"`
+	// Labels 为附加到快照与按容器指标的容器标签
+	Labels []string `json:"labels"`
+	// ContainerMetrics 为真时按容器导出用量指标
+	ContainerMetrics bool `json:"container_metrics"`
+	// MetricLabels 为按容器指标的标签集合：container 只有 container_id，pod 另加所属命名空间、分组与 Pod，
+	// full（默认）另加镜像与 labels 中的标签
+	MetricLabels string `json:"metric_labels"`
+}
+
+// 按容器指标的标签集合
+const (
+	MetricLabelsContainer = "container"
+	MetricLabelsPod       = "pod"
+	MetricLabelsFull      = "full"
+)
+
+// MirrorConfig 存储状态只读镜像的配置，Path 为空时不维护镜像
+type MirrorConfig struct {
+	Path string `json:"path"`
+	// IntervalSeconds 为检查状态变化的间隔，状态未变化时不重写镜像，默认 5
+	IntervalSeconds int `json:"interval_seconds"`
+	// UsageIntervalSeconds 为刷新镜像中用量的间隔，默认 60
+	UsageIntervalSeconds int `json:"usage_interval_seconds"`
+}
+
+// OptOutConfig 存储容器通过标签退出配额管理的配置，两个命名空间列表都为空时不允许退出
+type OptOutConfig struct {
+	// Label 为退出标签，值为 "false" 表示请求退出，默认 conquotas.io/enforce
+	Label string `json:"label"`
+	// Namespaces 为允许退出的 containerd 命名空间
+	Namespaces []string `json:"namespaces"`
+	// PodNamespaces 为允许退出的 Kubernetes 命名空间，按 io.kubernetes.pod.namespace 标签匹配
+	PodNamespaces []string `json:"pod_namespaces"`
+}
+
+// ExcludeConfig 存储排除规则：命中任一规则的容器（系统容器、Pod 沙箱、可信的基础设施工作负载等）不设置配额。
+// 与 opt_out 不同，排除由运维在配置中决定，容器无法自行请求
+type ExcludeConfig struct {
+	// Rules 按顺序匹配，首个命中的规则生效
+	Rules []ExcludeRule `json:"rules"`
+}
+
+// ExcludeRule 为一条排除规则，设置的条件须全部满足，至少设置一个条件
+type ExcludeRule struct {
+	// Name 为规则名，用于日志与指标，默认 rules[<序号>]
+	Name string `json:"name"`
+	// Namespaces 为 containerd 命名空间
+	Namespaces []string `json:"namespaces"`
+	// PodNamespaces 为 Kubernetes 命名空间，按 io.kubernetes.pod.namespace 标签匹配
+	PodNamespaces []string `json:"pod_namespaces"`
+	// Selector 为容器标签选择器，语法同 limit_notify.selector，如 io.cri-containerd.kind=sandbox
+	Selector string `json:"selector"`
+	// Image 为 path.Match 语法的镜像引用通配模式，如 registry.k8s.io/*
+	Image string `json:"image"`
+	// NameRegex 为匹配整个容器名的正则表达式，容器名取 io.kubernetes.container.name 标签，没有时为容器 ID
+	NameRegex string `json:"name_regex"`
+}
+
+// ChatConfig 存储聊天工具告警的配置，Targets 为空时不发送
+type ChatConfig struct {
+	Targets  []ChatTarget `json:"targets"`
+	NodeName string       `json:"node_name"`
+	// IntervalSeconds 为检查容器是否达到硬限制的间隔，默认 60
+	IntervalSeconds int `json:"interval_seconds"`
+	// PoolPercent 为项目 ID 池使用率告警阈值（百分比），默认 90
+	PoolPercent float64 `json:"pool_percent"`
+}
+
+// ChatTarget 为一个聊天机器人
+type ChatTarget struct {
+	// Provider 为聊天工具：slack、feishu 或 dingtalk
+	Provider   string `json:"provider"`
+	WebhookURL string `json:"webhook_url"`
+	// Secret 为飞书签名校验或钉钉加签的密钥，未开启时留空
+	Secret string `json:"secret"`
+}
+
+// 用量告警的配额类别
+const (
+	AlertClassContainer = "container"
+	AlertClassBuildkit  = "buildkit"
+	AlertClassPod       = "pod"
+	AlertClassNamespace = "namespace"
+)
+
+// AlertsConfig 存储用量告警的配置：用量超过硬限制的阈值百分比时记录日志、计入指标并发送到 webhook
+type AlertsConfig struct {
+	Enabled bool `json:"enabled"`
+	// IntervalSeconds 为比较用量与限额的间隔，默认 60
+	IntervalSeconds int `json:"interval_seconds"`
+	// Thresholds 为硬限制的百分比阈值，默认 [80, 95]
+	Thresholds []float64 `json:"thresholds"`
+	// CooldownSeconds 为同一项目在同一阈值上重复告警的最小间隔，默认 3600；超过更高阈值时立即告警
+	CooldownSeconds int    `json:"cooldown_seconds"`
+	WebhookURL      string `json:"webhook_url"`
+	NodeName        string `json:"node_name"`
+	// Classes 按配额类别（container、buildkit、pod、namespace）覆盖阈值与冷却时间
+	Classes map[string]AlertClassConfig `json:"classes"`
+}
+
+// ImagesConfig 存储按镜像覆盖默认限额的配置。覆盖记录保存在状态文件中，可通过管理接口编辑；
+// Learn 时容器结束时达到硬限制的镜像自动记录放大后的限额，供该镜像之后创建的容器使用
+type ImagesConfig struct {
+	Learn bool `json:"learn"`
+	// Factor 为学习时限额的放大倍数，默认 1.5
+	Factor float64 `json:"factor"`
+	// MaxHard 为学习得到的硬限制上限，为空表示不限制
+	MaxHard string `json:"max_hard"`
+	// Rules 为按镜像引用匹配的默认块限额，按顺序首个命中的规则生效；按摘要记录的覆盖优先于规则
+	Rules []ImageRule `json:"rules"`
+}
+
+// ImageRule 为按镜像引用匹配的块限额，Pattern 与 Regex 二选一，Soft 为空时与 Hard 相同
+type ImageRule struct {
+	// Pattern 为 path.Match 语法的通配模式，如 registry.local/ml/*，* 不匹配 /
+	Pattern string `json:"pattern"`
+	// Regex 为匹配整个镜像引用的正则表达式
+	Regex string `json:"regex"`
+	Soft  string `json:"soft"`
+	Hard  string `json:"hard"`
+}
+
+// AlertClassConfig 为某一配额类别的告警覆盖配置，未设置的字段沿用全局配置
+type AlertClassConfig struct {
+	Thresholds      []float64 `json:"thresholds"`
+	CooldownSeconds int       `json:"cooldown_seconds"`
+}
+
+// StatsConfig 存储 CRI 风格文件系统统计 gRPC 服务配置，Addr 为空时不启用
+type StatsConfig struct {
+	// Addr 为 listener.Listen 格式的监听地址，如 unix:///path 或 TCP 地址
+	Addr string `json:"addr"`
+	// ImageFsPath 为镜像存储所在的目录，默认 /var/lib/containerd
+	ImageFsPath string `json:"image_fs_path"`
+}
+
+// CapacityConfig 存储节点配额预算配置，预留空间（containerd 元数据、镜像等）永不分配给容器配额
+type CapacityConfig struct {
+	// Reserve 为固定预留大小，如 "50g"
+	Reserve string `json:"reserve"`
+	// ReservePercent 为按文件系统容量百分比预留，与 Reserve 取较大者
+	ReservePercent float64 `json:"reserve_percent"`
+	// OvercommitRatio 为预算超分比例，默认 1 表示不超分
+	OvercommitRatio float64 `json:"overcommit_ratio"`
+}
+
+// Enabled 判断是否启用节点预算计算
+func (c CapacityConfig) Enabled() bool {
+	return c.Reserve != "" || c.ReservePercent > 0 || c.OvercommitRatio > 0
+}
+
+// VerifyConfig 存储配额生效深度校验配置：抽样容器并写入超出剩余额度的临时文件
+type VerifyConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"interval_seconds"`
+	SampleSize      int  `json:"sample_size"`
+	// MaxWriteMB 为单次探测允许写入的上限，剩余额度更大的容器不做探测
+	MaxWriteMB int `json:"max_write_mb"`
+}
+
+// QuotaStateConfig 存储文件系统配额开关检查配置，默认启用：发现项目配额统计或限制被关闭时标记为不健康
+type QuotaStateConfig struct {
+	Disabled        bool `json:"disabled"`
+	IntervalSeconds int  `json:"interval_seconds"`
+}
+
+// MetadataCacheConfig 存储 containerd 容器元数据缓存配置，默认启用：镜像、标签、运行时与快照键
+// 查询一次后缓存，由容器事件失效
+type MetadataCacheConfig struct {
+	Disabled bool `json:"disabled"`
+	// TTLSeconds 为缓存条目的最长保留时间，错过事件时以此兜底
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// CRI 门控在配额未能及时设置时的处理方式
+const (
+	// FailurePolicyFail 删除刚创建的容器并向 kubelet 返回错误，kubelet 会重试创建
+	FailurePolicyFail = "fail"
+	// FailurePolicyIgnore 照常返回，配额由之后的容器事件设置
+	FailurePolicyIgnore = "ignore"
+)
+
+// CRIGateConfig 存储 CRI 代理配置：kubelet 连接代理的套接字，CreateContainer 在配额设置完成后才返回
+type CRIGateConfig struct {
+	Enabled bool `json:"enabled"`
+	// Listen 为代理的监听地址，格式同 admin_addr，默认 unix:///run/conquotas/cri.sock
+	Listen string `json:"listen"`
+	// Upstream 为运行时的 CRI 套接字，默认为 containerd_sock
+	Upstream string `json:"upstream"`
+	// Namespace 为 CRI 容器所在的 containerd 命名空间，默认 k8s.io
+	Namespace      string `json:"namespace"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+	// FailurePolicy 为 fail（默认）或 ignore
+	FailurePolicy string `json:"failure_policy"`
+}
+
+// NodeAnnotationConfig 存储节点注解配置：周期将配额执行状态的摘要写入本节点 Node 对象的注解，
+// 以集群内 ServiceAccount 身份运行，需要 patch nodes 的权限
+type NodeAnnotationConfig struct {
+	Enabled  bool   `json:"enabled"`
+	NodeName string `json:"node_name"`
+	// Key 为注解名，默认 conquotas.io/enforcement
+	Key             string `json:"key"`
+	IntervalSeconds int    `json:"interval_seconds"`
+}
+
+// QuotaClassesConfig 存储命名的配额类别（如 small、large），容器通过标签或 OCI 注解选择类别，
+// 取代顶层 quota 作为默认限额；Classes 为空时不启用
+type QuotaClassesConfig struct {
+	// Label 为选择类别的标签或注解名，默认 conquotas.io/class，标签优先
+	Label string `json:"label"`
+	// Default 为未选择类别或所选类别未定义时使用的类别，为空时使用顶层 quota
+	Default string `json:"default"`
+	// Classes 为类别名到限额的映射，未设置的限额沿用顶层 quota
+	Classes map[string]QuotaConfig `json:"classes"`
+}
+
+// LabelOverridesConfig 存储按容器标签或 OCI 注解覆盖默认块限额的配置，工作负载无需修改守护进程配置即可申请更大的配额
+type LabelOverridesConfig struct {
+	Enabled bool `json:"enabled"`
+	// SoftKey、HardKey 为标签或注解名，默认 conquotas.io/soft 与 conquotas.io/hard，标签优先
+	SoftKey string `json:"soft_key"`
+	HardKey string `json:"hard_key"`
+	// MaxHard 为允许申请的硬限制上限，超出时按上限设置，为空表示不限制
+	MaxHard string `json:"max_hard"`
+	// MinLimit 为申请的软、硬限制的下限，低于它时按下限设置，避免误配置的极小限额使容器立即写满，为空表示不限制
+	MinLimit string `json:"min_limit"`
+}
+
+// NestedConfig 存储嵌套容器（DinD 等）快照目录巡检配置：检查容器内引擎的快照目录是否带有容器的项目 ID
+type NestedConfig struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"interval_seconds"`
+	// MaxEntries 为每个内层快照目录最多检查的文件数
+	MaxEntries int `json:"max_entries"`
+}
+
+// EmergencyConfig 存储磁盘空间告急处理配置：可用空间低于 critical_percent 时上报用量最大的容器，
+// 并可停止标记为可牺牲的容器，避免节点被写满
+type EmergencyConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path 为检查可用空间的文件系统上的任一路径
+	Path            string  `json:"path"`
+	CriticalPercent float64 `json:"critical_percent"`
+	// ResolvePercent 为解除告急所需的可用空间百分比，默认为 critical_percent 的两倍
+	ResolvePercent  float64 `json:"resolve_percent"`
+	IntervalSeconds int     `json:"interval_seconds"`
+	// TopN 为上报的容器数量
+	TopN             int    `json:"top_n"`
+	WebhookURL       string `json:"webhook_url"`
+	KubernetesEvents bool   `json:"kubernetes_events"`
+	NodeName         string `json:"node_name"`
+	// StopSelector 为可牺牲容器的标签选择器（如 "conquotas.io/sacrificial=true"），为空时不停止任何容器
+	StopSelector string `json:"stop_selector"`
+}
+
+// LimitNotifyConfig 存储限额变更后通知容器内工作负载的配置，file 与 signal 均为空时不通知
+type LimitNotifyConfig struct {
+	// File 为容器内的通知文件绝对路径，限额变更时写入新限额的 JSON，所在目录需已存在
+	File string `json:"file"`
+	// Signal 为限额变更后发送给容器 init 进程的信号（如 "SIGUSR1"）
+	Signal string `json:"signal"`
+	// Selector 为接收通知的容器标签选择器（如 "conquotas.io/notify=true"），为空时通知所有容器
+	Selector string `json:"selector"`
+}
+
+// 状态存储方式
+const (
+	StateBackendFile   = "file"
+	StateBackendMemory = "memory"
+)
+
+// 配额作用域
+const (
+	ScopeContainer    = "container"
+	ScopePodEphemeral = "pod-ephemeral"
+	ScopePod          = "pod"
+)
+
+// PodEphemeralConfig 存储 Pod 级临时存储共享预算配置，
+// rootfs、容器日志目录与 emptyDir 共用同一项目 ID 和预算
+type PodEphemeralConfig struct {
+	Quota       QuotaConfig `json:"quota"`
+	KubeletRoot string      `json:"kubelet_root"`
+	PodLogDir   string      `json:"pod_log_dir"`
+}
+
+// PodAggregateConfig 存储 Pod 级可写层共享配额的配置：同一沙箱的容器使用同一项目 ID，
+// 限额约束 Pod 所有容器可写层的总和，日志目录与 emptyDir 不计入
+type PodAggregateConfig struct {
+	// Quota 为每个 Pod 的限额，未设置的字段使用 quota
+	Quota QuotaConfig `json:"quota"`
+	// SandboxKey 为记录沙箱 ID 的容器标签或 OCI 注解名，标签优先，默认 io.kubernetes.cri.sandbox-id
+	SandboxKey string `json:"sandbox_key"`
+}
+
+// AggregatorConfig 存储向集群汇聚服务推送汇总的配置，URL 为空时不推送
+type AggregatorConfig struct {
+	URL             string `json:"url"`
+	NodeName        string `json:"node_name"`
+	IntervalSeconds int    `json:"interval_seconds"`
+}
+
+// BumpConfig 存储限额提升提案相关配置
+type BumpConfig struct {
+	// MaxPercent 为单次提案允许提升的最大百分比
+	MaxPercent      int `json:"max_percent"`
+	TokenTTLSeconds int `json:"token_ttl_seconds"`
+	// ConfirmTokenFile 为确认提案所需的 Bearer token 文件，与提出提案的调用方分开持有；为空时不接受提案
+	ConfirmTokenFile string `json:"confirm_token_file"`
+	// MaxGrowthPercent 为窗口内经提案累计提升的最大百分比，以窗口内首次提升前的硬限额为基准，默认 100
+	MaxGrowthPercent int `json:"max_growth_percent"`
+	// GrowthWindowSeconds 为累计提升的统计窗口，默认 86400
+	GrowthWindowSeconds int `json:"growth_window_seconds"`
+}
+
+// LiftConfig 存储临时解除限额相关配置
+type LiftConfig struct {
+	// MaxSeconds 为单次解除允许的最长时间
+	MaxSeconds int `json:"max_seconds"`
+}
+
+// SchedulerConfig 存储配额操作的并发与优先级配置，见 pkg/sched
+type SchedulerConfig struct {
+	// Concurrency 为同时执行的操作数上限，默认 1 即逐个执行
+	Concurrency int `json:"concurrency"`
+	// Priorities 为从高到低的优先级顺序，取值 admin、create-high、create、delete、reconcile
+	Priorities []string `json:"priorities"`
+	// PriorityNamespaces 中的容器的创建事件使用 create-high 优先级，按 Kubernetes 命名空间或 containerd 命名空间匹配
+	PriorityNamespaces []string `json:"priority_namespaces"`
+	// PriorityRuntimeClasses 中的容器的创建事件使用 create-high 优先级，按容器的 containerd 运行时匹配，
+	// 可写完整名称（io.containerd.kata.v2）或简称（kata）
+	PriorityRuntimeClasses []string `json:"priority_runtime_classes"`
+}
+
+// EventConfig 存储单个事件处理的超时与重试配置
+type EventConfig struct {
+	TimeoutSeconds int `json:"timeout_seconds"`
+	MaxRetries     int `json:"max_retries"`
 }
 
 // ProjectConfig 存储项目 ID 范围相关配置
 type ProjectConfig struct {
 	IDMin uint32 `json:"id_min"`
 	IDMax uint32 `json:"id_max"`
+	// WarnMarginPercent 为告警余量：峰值使用量超过范围的 (100 - warn_margin_percent)% 时告警，默认 20
+	WarnMarginPercent float64 `json:"warn_margin_percent"`
+	// ReservedScanPaths 为其他工具（Docker overlay2、LXD 等）分配项目 ID 的目录，启动时扫描以避免冲突
+	ReservedScanPaths []string `json:"reserved_scan_paths"`
 }
 
 // QuotaConfig 存储默认配额相关配置
 type QuotaConfig struct {
 	DefaultSoft string `json:"default_soft"`
 	DefaultHard string `json:"default_hard"`
+	// DefaultInodeSoft、DefaultInodeHard 为 inode 数限额，0 表示不限制；soft 为 0 时与 hard 相同
+	DefaultInodeSoft uint64 `json:"default_inode_soft"`
+	DefaultInodeHard uint64 `json:"default_inode_hard"`
+	// DefaultRealtimeSoft、DefaultRealtimeHard 为 XFS 实时子卷上的块限额，为空时不设置；soft 为空时与 hard 相同
+	DefaultRealtimeSoft string `json:"default_rt_soft"`
+	DefaultRealtimeHard string `json:"default_rt_hard"`
+}
+
+// inherit 用 parent 补全未设置的限额
+func (c *QuotaConfig) inherit(parent QuotaConfig) {
+	if c.DefaultSoft == "" {
+		c.DefaultSoft = parent.DefaultSoft
+	}
+	if c.DefaultHard == "" {
+		c.DefaultHard = parent.DefaultHard
+	}
+	if c.DefaultInodeSoft == 0 && c.DefaultInodeHard == 0 {
+		c.DefaultInodeSoft = parent.DefaultInodeSoft
+		c.DefaultInodeHard = parent.DefaultInodeHard
+	}
+	if c.DefaultRealtimeSoft == "" && c.DefaultRealtimeHard == "" {
+		c.DefaultRealtimeSoft = parent.DefaultRealtimeSoft
+		c.DefaultRealtimeHard = parent.DefaultRealtimeHard
+	}
+}
+
+// validateBlockLimits 校验块限额可解析且软限制不超过硬限制
+func (c *QuotaConfig) validateBlockLimits(name string) error {
+	soft, err := xfs.ParseSize(c.DefaultSoft)
+	if err != nil {
+		return fmt.Errorf("invalid %s.default_soft: %v", name, err)
+	}
+	hard, err := xfs.ParseSize(c.DefaultHard)
+	if err != nil {
+		return fmt.Errorf("invalid %s.default_hard: %v", name, err)
+	}
+	if soft > hard {
+		return fmt.Errorf("%s.default_soft must not exceed default_hard", name)
+	}
+	return nil
+}
+
+// validateExtraLimits 补全并校验 inode 与实时子卷限额
+func (c *QuotaConfig) validateExtraLimits(name string) error {
+	if c.DefaultInodeSoft == 0 {
+		c.DefaultInodeSoft = c.DefaultInodeHard
+	}
+	if c.DefaultInodeHard != 0 && c.DefaultInodeSoft > c.DefaultInodeHard {
+		return fmt.Errorf("%s.default_inode_soft must not exceed default_inode_hard", name)
+	}
+
+	if c.DefaultRealtimeHard == "" {
+		if c.DefaultRealtimeSoft != "" {
+			return fmt.Errorf("%s.default_rt_soft requires default_rt_hard", name)
+		}
+		return nil
+	}
+	if c.DefaultRealtimeSoft == "" {
+		c.DefaultRealtimeSoft = c.DefaultRealtimeHard
+	}
+	soft, err := xfs.ParseSize(c.DefaultRealtimeSoft)
+	if err != nil {
+		return fmt.Errorf("invalid %s.default_rt_soft: %v", name, err)
+	}
+	hard, err := xfs.ParseSize(c.DefaultRealtimeHard)
+	if err != nil {
+		return fmt.Errorf("invalid %s.default_rt_hard: %v", name, err)
+	}
+	if hard != 0 && soft > hard {
+		return fmt.Errorf("%s.default_rt_soft must not exceed default_rt_hard", name)
+	}
+	return nil
+}
+
+// BuildkitConfig 存储 BuildKit 构建快照配额相关配置
+type BuildkitConfig struct {
+	Enabled bool `json:"enabled"`
+	// Namespace 为 BuildKit containerd worker 使用的命名空间
+	Namespace string `json:"namespace"`
+	// SnapshotDirs 为独立 buildkitd（OCI worker）的快照目录，按目录扫描
+	SnapshotDirs        []string    `json:"snapshot_dirs"`
+	ScanIntervalSeconds int         `json:"scan_interval_seconds"`
+	Quota               QuotaConfig `json:"quota"`
+}
+
+// KataConfig 存储虚拟机运行时（Kata Containers）的配置：沙箱在宿主机上的共享目录（virtio-fs 共享给虚拟机的目录）
+// 纳入沙箱容器的项目，使虚拟机中的容器同样受磁盘限额约束；Enabled 为假时不启用
+type KataConfig struct {
+	Enabled bool `json:"enabled"`
+	// Runtimes 为识别为 Kata 的 containerd 运行时，可写完整名称（io.containerd.kata.v2）或简称（kata），
+	// 默认 kata、kata-qemu、kata-clh、kata-fc 与 kata-dragonball
+	Runtimes []string `json:"runtimes"`
+	// SharedDir 为沙箱共享目录的父目录，每个沙箱的目录为 <shared_dir>/<沙箱 ID>，默认 /run/kata-containers/shared/sandboxes；
+	// 必须与容器可写层位于同一文件系统
+	SharedDir string `json:"shared_dir"`
+	// Quota 为沙箱容器（含共享目录）的限额，未设置的字段使用 quota
+	Quota QuotaConfig `json:"quota"`
 }
 
 // LoadConfig 从指定路径加载配置文件
@@ -38,19 +743,45 @@ func LoadConfig(filePath string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Loaded configuration", zap.String("file", filePath))
+	return cfg, nil
+}
 
+// Parse 解析并校验配置内容，填充默认值
+func Parse(data []byte) (*Config, error) {
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
 	// 验证必填字段
-	if cfg.StateFilePath == "" {
+	switch cfg.StateBackend {
+	case "":
+		cfg.StateBackend = StateBackendFile
+	case StateBackendFile, StateBackendMemory:
+	default:
+		return nil, fmt.Errorf("invalid state_backend: %s", cfg.StateBackend)
+	}
+	if cfg.StateBackend == StateBackendFile && cfg.StateFilePath == "" {
 		return nil, fmt.Errorf("state_file_path is required")
 	}
 	if cfg.Project.IDMin == 0 || cfg.Project.IDMax == 0 || cfg.Project.IDMin >= cfg.Project.IDMax {
 		return nil, fmt.Errorf("invalid project.id range: min=%d, max=%d", cfg.Project.IDMin, cfg.Project.IDMax)
 	}
+	// 4294967295 即 (uint32)-1，在 quotactl 中表示无效 ID
+	if cfg.Project.IDMax == math.MaxUint32 {
+		return nil, fmt.Errorf("invalid project.id range: max=%d overflows, must be below %d", cfg.Project.IDMax, uint32(math.MaxUint32))
+	}
+	if cfg.Project.WarnMarginPercent == 0 {
+		cfg.Project.WarnMarginPercent = 20
+	}
+	if cfg.Project.WarnMarginPercent < 0 || cfg.Project.WarnMarginPercent >= 100 {
+		return nil, fmt.Errorf("invalid project.warn_margin_percent: %v, must be between 0 and 100", cfg.Project.WarnMarginPercent)
+	}
 	if cfg.ContainerdSock == "" {
 		return nil, fmt.Errorf("containerd_sock is required")
 	}
@@ -65,10 +796,619 @@ func LoadConfig(filePath string) (*Config, error) {
 	if cfg.Quota.DefaultHard == "" {
 		cfg.Quota.DefaultHard = "10g" // 允许为空，后续逻辑可处理
 	}
+	if err := cfg.Quota.validateExtraLimits("quota"); err != nil {
+		return nil, err
+	}
 	if cfg.Namespace == "" {
 		cfg.Namespace = "default" // 设置默认值
 	}
 
-	log.Info("Loaded configuration", zap.String("file", filePath))
+	if cfg.Event.TimeoutSeconds <= 0 {
+		cfg.Event.TimeoutSeconds = 60
+	}
+	if cfg.Event.MaxRetries < 0 {
+		return nil, fmt.Errorf("event.max_retries must not be negative")
+	}
+	if cfg.Event.MaxRetries == 0 {
+		cfg.Event.MaxRetries = 3
+	}
+
+	if cfg.Scheduler.Concurrency <= 0 {
+		cfg.Scheduler.Concurrency = 1
+	}
+	seen := make(map[string]bool)
+	for _, name := range cfg.Scheduler.Priorities {
+		if _, err := sched.ParsePriority(name); err != nil {
+			return nil, fmt.Errorf("invalid scheduler.priorities: %v", err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid scheduler.priorities: %q listed twice", name)
+		}
+		seen[name] = true
+	}
+
+	if cfg.Bump.MaxPercent <= 0 {
+		cfg.Bump.MaxPercent = 50
+	}
+	if cfg.Bump.TokenTTLSeconds <= 0 {
+		cfg.Bump.TokenTTLSeconds = 600
+	}
+	if cfg.Bump.MaxGrowthPercent <= 0 {
+		cfg.Bump.MaxGrowthPercent = 100
+	}
+	if cfg.Bump.GrowthWindowSeconds <= 0 {
+		cfg.Bump.GrowthWindowSeconds = 86400
+	}
+	if cfg.Lift.MaxSeconds <= 0 {
+		cfg.Lift.MaxSeconds = 3600
+	}
+
+	if cfg.Aggregator.URL != "" {
+		if cfg.Aggregator.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("aggregator.node_name is required: %v", err)
+			}
+			cfg.Aggregator.NodeName = hostname
+		}
+		if cfg.Aggregator.IntervalSeconds <= 0 {
+			cfg.Aggregator.IntervalSeconds = 60
+		}
+	}
+
+	switch cfg.QuotaScope {
+	case "":
+		cfg.QuotaScope = ScopeContainer
+	case ScopeContainer, ScopePodEphemeral, ScopePod:
+	default:
+		return nil, fmt.Errorf("invalid quota_scope: %s", cfg.QuotaScope)
+	}
+	if cfg.QuotaScope == ScopePodEphemeral {
+		cfg.PodEphemeral.Quota.inherit(cfg.Quota)
+		if err := cfg.PodEphemeral.Quota.validateExtraLimits("pod_ephemeral.quota"); err != nil {
+			return nil, err
+		}
+		if cfg.PodEphemeral.KubeletRoot == "" {
+			cfg.PodEphemeral.KubeletRoot = "/var/lib/kubelet"
+		}
+		if cfg.PodEphemeral.PodLogDir == "" {
+			cfg.PodEphemeral.PodLogDir = "/var/log/pods"
+		}
+	}
+	if cfg.QuotaScope == ScopePod {
+		cfg.PodAggregate.Quota.inherit(cfg.Quota)
+		if err := cfg.PodAggregate.Quota.validateExtraLimits("pod_aggregate.quota"); err != nil {
+			return nil, err
+		}
+		if cfg.PodAggregate.SandboxKey == "" {
+			cfg.PodAggregate.SandboxKey = "io.kubernetes.cri.sandbox-id"
+		}
+	}
+
+	if cfg.Verify.Enabled {
+		if cfg.Verify.IntervalSeconds <= 0 {
+			cfg.Verify.IntervalSeconds = 3600
+		}
+		if cfg.Verify.SampleSize <= 0 {
+			cfg.Verify.SampleSize = 5
+		}
+		if cfg.Verify.MaxWriteMB <= 0 {
+			cfg.Verify.MaxWriteMB = 64
+		}
+	}
+
+	if cfg.ConfigSource.IntervalSeconds <= 0 {
+		cfg.ConfigSource.IntervalSeconds = 60
+	}
+
+	if cfg.QuotaState.IntervalSeconds <= 0 {
+		cfg.QuotaState.IntervalSeconds = 60
+	}
+
+	if cfg.MetadataCache.TTLSeconds <= 0 {
+		cfg.MetadataCache.TTLSeconds = 600
+	}
+
+	if cfg.CRIGate.Enabled {
+		if cfg.CRIGate.Listen == "" {
+			cfg.CRIGate.Listen = "unix:///run/conquotas/cri.sock"
+		}
+		if cfg.CRIGate.Upstream == "" {
+			cfg.CRIGate.Upstream = cfg.ContainerdSock
+		}
+		if cfg.CRIGate.Namespace == "" {
+			cfg.CRIGate.Namespace = "k8s.io"
+		}
+		if cfg.CRIGate.TimeoutSeconds <= 0 {
+			cfg.CRIGate.TimeoutSeconds = 10
+		}
+		switch cfg.CRIGate.FailurePolicy {
+		case "":
+			cfg.CRIGate.FailurePolicy = FailurePolicyFail
+		case FailurePolicyFail, FailurePolicyIgnore:
+		default:
+			return nil, fmt.Errorf("cri_gate.failure_policy must be %q or %q", FailurePolicyFail, FailurePolicyIgnore)
+		}
+	}
+
+	if cfg.NodeAnnotation.Enabled {
+		if cfg.NodeAnnotation.Key == "" {
+			cfg.NodeAnnotation.Key = "conquotas.io/enforcement"
+		}
+		if cfg.NodeAnnotation.IntervalSeconds <= 0 {
+			cfg.NodeAnnotation.IntervalSeconds = 60
+		}
+		if cfg.NodeAnnotation.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("node_annotation.node_name is required: %v", err)
+			}
+			cfg.NodeAnnotation.NodeName = hostname
+		}
+	}
+
+	if len(cfg.QuotaClasses.Classes) > 0 {
+		if cfg.QuotaClasses.Label == "" {
+			cfg.QuotaClasses.Label = "conquotas.io/class"
+		}
+		for name, class := range cfg.QuotaClasses.Classes {
+			class.inherit(cfg.Quota)
+			if err := class.validateBlockLimits("quota_classes.classes." + name); err != nil {
+				return nil, err
+			}
+			if err := class.validateExtraLimits("quota_classes.classes." + name); err != nil {
+				return nil, err
+			}
+			cfg.QuotaClasses.Classes[name] = class
+		}
+	}
+	if def := cfg.QuotaClasses.Default; def != "" {
+		if _, ok := cfg.QuotaClasses.Classes[def]; !ok {
+			return nil, fmt.Errorf("quota_classes.default %q is not a defined class", def)
+		}
+	}
+
+	if cfg.AsyncBackend.MaxBatch < 0 {
+		return nil, fmt.Errorf("async_backend.max_batch must not be negative")
+	}
+	if cfg.AsyncBackend.MaxBatch == 0 {
+		cfg.AsyncBackend.MaxBatch = xfs.DefaultPipelineBatch
+	}
+
+	if cfg.LabelOverrides.Enabled {
+		if cfg.LabelOverrides.SoftKey == "" {
+			cfg.LabelOverrides.SoftKey = "conquotas.io/soft"
+		}
+		if cfg.LabelOverrides.HardKey == "" {
+			cfg.LabelOverrides.HardKey = "conquotas.io/hard"
+		}
+		if cfg.LabelOverrides.MaxHard != "" {
+			if _, err := xfs.ParseSize(cfg.LabelOverrides.MaxHard); err != nil {
+				return nil, fmt.Errorf("invalid label_overrides.max_hard: %v", err)
+			}
+		}
+		if cfg.LabelOverrides.MinLimit != "" {
+			min, err := xfs.ParseSize(cfg.LabelOverrides.MinLimit)
+			if err != nil {
+				return nil, fmt.Errorf("invalid label_overrides.min_limit: %v", err)
+			}
+			if max, _ := xfs.ParseSize(cfg.LabelOverrides.MaxHard); cfg.LabelOverrides.MaxHard != "" && min > max {
+				return nil, fmt.Errorf("label_overrides.min_limit %s exceeds max_hard %s", cfg.LabelOverrides.MinLimit, cfg.LabelOverrides.MaxHard)
+			}
+		}
+	}
+
+	if cfg.Emergency.Enabled {
+		if cfg.Emergency.Path == "" {
+			cfg.Emergency.Path = "/var/lib/containerd"
+		}
+		if cfg.Emergency.CriticalPercent <= 0 {
+			cfg.Emergency.CriticalPercent = 5
+		}
+		if cfg.Emergency.ResolvePercent <= 0 {
+			cfg.Emergency.ResolvePercent = 2 * cfg.Emergency.CriticalPercent
+		}
+		if cfg.Emergency.ResolvePercent < cfg.Emergency.CriticalPercent || cfg.Emergency.ResolvePercent >= 100 {
+			return nil, fmt.Errorf("emergency.resolve_percent must be between critical_percent and 100")
+		}
+		if cfg.Emergency.IntervalSeconds <= 0 {
+			cfg.Emergency.IntervalSeconds = 30
+		}
+		if cfg.Emergency.TopN <= 0 {
+			cfg.Emergency.TopN = 5
+		}
+		if cfg.Emergency.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("emergency.node_name is required: %v", err)
+			}
+			cfg.Emergency.NodeName = hostname
+		}
+	}
+
+	if cfg.Accounting.Path != "" && cfg.Accounting.RetentionDays <= 0 {
+		cfg.Accounting.RetentionDays = 90
+	}
+
+	if cfg.EventSink.Type != "" {
+		if cfg.EventSink.Type != "kafka" && cfg.EventSink.Type != "nats" {
+			return nil, fmt.Errorf("invalid event_sink.type %q: must be kafka or nats", cfg.EventSink.Type)
+		}
+		if cfg.EventSink.URL == "" {
+			return nil, fmt.Errorf("event_sink.url is required")
+		}
+		if cfg.EventSink.Topic == "" {
+			cfg.EventSink.Topic = "conquotas.quota"
+		}
+		if cfg.EventSink.QueueSize <= 0 {
+			cfg.EventSink.QueueSize = 1000
+		}
+		if cfg.EventSink.ExceededIntervalSeconds <= 0 {
+			cfg.EventSink.ExceededIntervalSeconds = 60
+		}
+		if cfg.EventSink.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("event_sink.node_name is required: %v", err)
+			}
+			cfg.EventSink.NodeName = hostname
+		}
+	}
+
+	if cfg.OptOut.Label == "" {
+		cfg.OptOut.Label = "conquotas.io/enforce"
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Exclude.Rules {
+		rule := &cfg.Exclude.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		name := "exclude." + rule.Name
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate exclude rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if len(rule.Namespaces) == 0 && len(rule.PodNamespaces) == 0 && rule.Selector == "" && rule.Image == "" && rule.NameRegex == "" {
+			return nil, fmt.Errorf("%s: at least one of namespaces, pod_namespaces, selector, image or name_regex is required", name)
+		}
+		if rule.Image != "" {
+			if _, err := path.Match(rule.Image, ""); err != nil {
+				return nil, fmt.Errorf("invalid %s.image: %v", name, err)
+			}
+		}
+		if rule.NameRegex != "" {
+			if _, err := regexp.Compile("^(?:" + rule.NameRegex + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid %s.name_regex: %v", name, err)
+			}
+		}
+	}
+
+	if cfg.Alerts.Enabled {
+		if cfg.Alerts.IntervalSeconds <= 0 {
+			cfg.Alerts.IntervalSeconds = 60
+		}
+		if cfg.Alerts.Thresholds == nil {
+			cfg.Alerts.Thresholds = []float64{80, 95}
+		}
+		if cfg.Alerts.CooldownSeconds <= 0 {
+			cfg.Alerts.CooldownSeconds = 3600
+		}
+		if err := validateThresholds("alerts.thresholds", cfg.Alerts.Thresholds); err != nil {
+			return nil, err
+		}
+		if cfg.Alerts.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("alerts.node_name is required: %v", err)
+			}
+			cfg.Alerts.NodeName = hostname
+		}
+		for class, override := range cfg.Alerts.Classes {
+			switch class {
+			case AlertClassContainer, AlertClassBuildkit, AlertClassPod, AlertClassNamespace:
+			default:
+				return nil, fmt.Errorf("unknown alerts.classes key %q", class)
+			}
+			if override.Thresholds != nil {
+				if err := validateThresholds("alerts.classes."+class+".thresholds", override.Thresholds); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if cfg.UsagePoller.IntervalSeconds <= 0 {
+		cfg.UsagePoller.IntervalSeconds = 30
+	}
+	for _, label := range cfg.UsagePoller.Labels {
+		if label == "" {
+			return nil, fmt.Errorf("usage_poller.labels must not contain empty names")
+		}
+	}
+	switch cfg.UsagePoller.MetricLabels {
+	case "":
+		cfg.UsagePoller.MetricLabels = MetricLabelsFull
+	case MetricLabelsContainer, MetricLabelsPod, MetricLabelsFull:
+	default:
+		return nil, fmt.Errorf("invalid usage_poller.metric_labels %q: must be container, pod or full", cfg.UsagePoller.MetricLabels)
+	}
+
+	if cfg.Maintenance.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Maintenance.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid maintenance.timezone: %v", err)
+		}
+	}
+	for i := range cfg.Maintenance.Windows {
+		w := &cfg.Maintenance.Windows[i]
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("window-%d", i)
+		}
+		if _, err := maintenance.ParseSchedule(w.Schedule); err != nil {
+			return nil, fmt.Errorf("invalid maintenance.windows[%d].schedule: %v", i, err)
+		}
+		duration := time.Duration(w.DurationMinutes) * time.Minute
+		if duration <= 0 || duration > maintenance.MaxDuration {
+			return nil, fmt.Errorf("maintenance.windows[%d].duration_minutes must be in (0, %d]", i, int(maintenance.MaxDuration.Minutes()))
+		}
+		switch w.Action {
+		case "":
+			w.Action = maintenance.ActionSuppress
+		case maintenance.ActionSuppress:
+		case maintenance.ActionRelax:
+			if w.RelaxedCriticalPercent <= 0 || w.RelaxedCriticalPercent >= 100 {
+				return nil, fmt.Errorf("maintenance.windows[%d].relaxed_critical_percent must be in (0, 100) for action relax", i)
+			}
+		default:
+			return nil, fmt.Errorf("unknown maintenance.windows[%d].action %q", i, w.Action)
+		}
+	}
+
+	for name := range cfg.Features {
+		if !slices.Contains(Features, name) {
+			return nil, fmt.Errorf("unknown features.%s, must be one of %v", name, Features)
+		}
+	}
+
+	if cfg.Templates.UsageAlert.Payload != "" && cfg.Templates.UsageAlert.ContentType == "" {
+		cfg.Templates.UsageAlert.ContentType = "application/json"
+	}
+	if cfg.Templates.HardLimit.Payload != "" {
+		return nil, fmt.Errorf("templates.hard_limit.payload is not supported, chat messages only use message")
+	}
+
+	if cfg.Images.Learn {
+		if cfg.Images.Factor == 0 {
+			cfg.Images.Factor = 1.5
+		}
+		if cfg.Images.Factor <= 1 {
+			return nil, fmt.Errorf("images.factor must be greater than 1")
+		}
+		if cfg.Images.MaxHard != "" {
+			if _, err := xfs.ParseSize(cfg.Images.MaxHard); err != nil {
+				return nil, fmt.Errorf("invalid images.max_hard: %v", err)
+			}
+		}
+	}
+
+	for i, rule := range cfg.Images.Rules {
+		name := fmt.Sprintf("images.rules[%d]", i)
+		if (rule.Pattern == "") == (rule.Regex == "") {
+			return nil, fmt.Errorf("%s: exactly one of pattern or regex is required", name)
+		}
+		if rule.Pattern != "" {
+			if _, err := path.Match(rule.Pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid %s.pattern: %v", name, err)
+			}
+		}
+		if rule.Regex != "" {
+			if _, err := regexp.Compile("^(?:" + rule.Regex + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid %s.regex: %v", name, err)
+			}
+		}
+		if rule.Soft == "" {
+			rule.Soft = rule.Hard
+		}
+		hard, err := xfs.ParseSize(rule.Hard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.hard: %v", name, err)
+		}
+		soft, err := xfs.ParseSize(rule.Soft)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.soft: %v", name, err)
+		}
+		if soft > hard {
+			return nil, fmt.Errorf("%s.soft must not exceed hard", name)
+		}
+		cfg.Images.Rules[i] = rule
+	}
+
+	if len(cfg.Chat.Targets) > 0 {
+		for i, t := range cfg.Chat.Targets {
+			if t.Provider == "" || t.WebhookURL == "" {
+				return nil, fmt.Errorf("chat.targets[%d]: provider and webhook_url are required", i)
+			}
+		}
+		if cfg.Chat.IntervalSeconds <= 0 {
+			cfg.Chat.IntervalSeconds = 60
+		}
+		if cfg.Chat.PoolPercent <= 0 {
+			cfg.Chat.PoolPercent = 90
+		}
+		if cfg.Chat.PoolPercent > 100 {
+			return nil, fmt.Errorf("chat.pool_percent must be at most 100")
+		}
+		if cfg.Chat.NodeName == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("chat.node_name is required: %v", err)
+			}
+			cfg.Chat.NodeName = hostname
+		}
+	}
+
+	if cfg.Mirror.Path != "" {
+		if !filepath.IsAbs(cfg.Mirror.Path) || filepath.Clean(cfg.Mirror.Path) == filepath.Clean(cfg.StateFilePath) {
+			return nil, fmt.Errorf("mirror.path must be an absolute path other than state_file_path")
+		}
+		if cfg.Mirror.IntervalSeconds <= 0 {
+			cfg.Mirror.IntervalSeconds = 5
+		}
+		if cfg.Mirror.UsageIntervalSeconds <= 0 {
+			cfg.Mirror.UsageIntervalSeconds = 60
+		}
+	}
+
+	if cfg.Stats.Addr != "" && cfg.Stats.ImageFsPath == "" {
+		cfg.Stats.ImageFsPath = "/var/lib/containerd"
+	}
+
+	if cfg.LimitNotify.File != "" && !filepath.IsAbs(cfg.LimitNotify.File) {
+		return nil, fmt.Errorf("limit_notify.file must be an absolute path")
+	}
+
+	if cfg.Nested.Enabled {
+		if cfg.Nested.IntervalSeconds <= 0 {
+			cfg.Nested.IntervalSeconds = 300
+		}
+		if cfg.Nested.MaxEntries <= 0 {
+			cfg.Nested.MaxEntries = 10000
+		}
+	}
+
+	if cfg.Capacity.Enabled() {
+		if cfg.Capacity.Reserve != "" {
+			if _, err := xfs.ParseSize(cfg.Capacity.Reserve); err != nil {
+				return nil, fmt.Errorf("invalid capacity.reserve: %v", err)
+			}
+		}
+		if cfg.Capacity.ReservePercent < 0 || cfg.Capacity.ReservePercent >= 100 {
+			return nil, fmt.Errorf("capacity.reserve_percent must be in [0, 100)")
+		}
+		if cfg.Capacity.OvercommitRatio < 0 {
+			return nil, fmt.Errorf("capacity.overcommit_ratio must not be negative")
+		}
+		if cfg.Capacity.OvercommitRatio == 0 {
+			cfg.Capacity.OvercommitRatio = 1
+		}
+	}
+
+	for ns, limits := range cfg.NamespaceDefaults {
+		limits.inherit(cfg.Quota)
+		if err := limits.validateBlockLimits("namespace_defaults." + ns); err != nil {
+			return nil, err
+		}
+		if err := limits.validateExtraLimits("namespace_defaults." + ns); err != nil {
+			return nil, err
+		}
+		cfg.NamespaceDefaults[ns] = limits
+	}
+
+	for ns, nsq := range cfg.NamespaceQuotas {
+		nsq.Quota.inherit(cfg.Quota)
+		if err := nsq.Quota.validateExtraLimits("namespace_quotas." + ns + ".quota"); err != nil {
+			return nil, err
+		}
+		cfg.NamespaceQuotas[ns] = nsq
+	}
+
+	if cfg.Policy.File != "" && cfg.Policy.IntervalSeconds <= 0 {
+		cfg.Policy.IntervalSeconds = 30
+	}
+
+	if cfg.Kubelet.URL != "" && cfg.Kubelet.ResizeIntervalSeconds <= 0 {
+		cfg.Kubelet.ResizeIntervalSeconds = 60
+	}
+	if cfg.EphemeralStorage.Enabled && cfg.Kubelet.URL == "" {
+		return nil, fmt.Errorf("ephemeral_storage requires kubelet.url")
+	}
+	if cfg.EphemeralStorage.Ratio < 0 {
+		return nil, fmt.Errorf("ephemeral_storage.ratio must not be negative")
+	}
+	if cfg.EphemeralStorage.Ratio == 0 {
+		cfg.EphemeralStorage.Ratio = 1
+	}
+	if offset := strings.TrimPrefix(cfg.EphemeralStorage.Offset, "-"); offset != "" {
+		if n, err := xfs.ParseSize(offset); err != nil || n > math.MaxInt64 {
+			return nil, fmt.Errorf("invalid ephemeral_storage.offset %q", cfg.EphemeralStorage.Offset)
+		}
+	}
+
+	if len(cfg.ExtraPaths.Allowlist) > 0 && cfg.ExtraPaths.Key == "" {
+		cfg.ExtraPaths.Key = "conquotas.io/extra-paths"
+	}
+	for i, dir := range cfg.ExtraPaths.Allowlist {
+		if !filepath.IsAbs(dir) || filepath.Clean(dir) == "/" {
+			return nil, fmt.Errorf("invalid extra_paths.allowlist: %q must be an absolute path other than /", dir)
+		}
+		cfg.ExtraPaths.Allowlist[i] = filepath.Clean(dir)
+	}
+
+	for i, dir := range cfg.UpperdirAllowlist {
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("invalid upperdir_allowlist: %q is not an absolute path", dir)
+		}
+		cfg.UpperdirAllowlist[i] = filepath.Clean(dir)
+	}
+
+	if cfg.Buildkit.Enabled {
+		if cfg.Buildkit.Namespace == "" {
+			cfg.Buildkit.Namespace = "buildkit"
+		}
+		if cfg.Buildkit.ScanIntervalSeconds <= 0 {
+			cfg.Buildkit.ScanIntervalSeconds = 30
+		}
+		cfg.Buildkit.Quota.inherit(cfg.Quota)
+		if err := cfg.Buildkit.Quota.validateExtraLimits("buildkit.quota"); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.LifecycleWebhook.Enabled {
+		// 通知中的目录由外部系统决定，必须限定在 upperdir_allowlist 内
+		if len(cfg.UpperdirAllowlist) == 0 {
+			return nil, fmt.Errorf("invalid lifecycle_webhook: upperdir_allowlist must be set when enabled")
+		}
+		if cfg.LifecycleWebhook.Listen == "" {
+			cfg.LifecycleWebhook.Listen = "unix:///run/conquotas/lifecycle.sock"
+		}
+		if cfg.LifecycleWebhook.Namespace == "" {
+			cfg.LifecycleWebhook.Namespace = "external"
+		}
+		cfg.LifecycleWebhook.Quota.inherit(cfg.Quota)
+		if err := cfg.LifecycleWebhook.Quota.validateExtraLimits("lifecycle_webhook.quota"); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Kata.Enabled {
+		if len(cfg.Kata.Runtimes) == 0 {
+			cfg.Kata.Runtimes = []string{"kata", "kata-qemu", "kata-clh", "kata-fc", "kata-dragonball"}
+		}
+		if cfg.Kata.SharedDir == "" {
+			cfg.Kata.SharedDir = "/run/kata-containers/shared/sandboxes"
+		}
+		if !filepath.IsAbs(cfg.Kata.SharedDir) {
+			return nil, fmt.Errorf("invalid kata.shared_dir: %q is not an absolute path", cfg.Kata.SharedDir)
+		}
+		cfg.Kata.SharedDir = filepath.Clean(cfg.Kata.SharedDir)
+		cfg.Kata.Quota.inherit(cfg.Quota)
+		if err := cfg.Kata.Quota.validateExtraLimits("kata.quota"); err != nil {
+			return nil, err
+		}
+	}
+
 	return &cfg, nil
 }
+
+// validateThresholds 校验百分比阈值位于 (0, 100] 内，并按升序排列
+func validateThresholds(name string, thresholds []float64) error {
+	for _, t := range thresholds {
+		if t <= 0 || t > 100 {
+			return fmt.Errorf("invalid %s: %v must be between 0 and 100", name, t)
+		}
+	}
+	sort.Float64s(thresholds)
+	return nil
+}
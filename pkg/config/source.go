@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// maxConfigSize 为远程配置的大小上限
+const maxConfigSize = 4 << 20
+
+// IsRemote 判断配置路径是否为 HTTP(S) URL
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// SourceOptions 为远程配置源的选项
+type SourceOptions struct {
+	// CacheFile 为本地缓存路径，配置源不可达时使用上次成功获取的配置；为空时不缓存
+	CacheFile string
+	// CAFile 为校验配置服务器证书的 CA，为空时使用系统 CA
+	CAFile string
+	// TokenFile 为 Bearer token 文件，为空时不认证
+	TokenFile string
+}
+
+// Source 为通过 HTTP(S) 分发的配置，按 ETag 轮询变化并在本地缓存最近一次有效的配置
+type Source struct {
+	url   string
+	opts  SourceOptions
+	token string
+	http  *http.Client
+
+	mutex sync.Mutex
+	etag  string
+	data  []byte
+}
+
+// NewSource 创建远程配置源
+func NewSource(url string, opts SourceOptions) (*Source, error) {
+	tlsConfig := &tls.Config{}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config source CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	s := &Source{
+		url:  url,
+		opts: opts,
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+	if opts.TokenFile != "" {
+		data, err := os.ReadFile(opts.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config source token: %v", err)
+		}
+		s.token = strings.TrimSpace(string(data))
+	}
+	return s, nil
+}
+
+// URL 返回配置源地址
+func (s *Source) URL() string {
+	return s.url
+}
+
+// Load 获取并解析配置，配置源不可达或返回无效配置时回退到本地缓存
+func (s *Source) Load(ctx context.Context) (*Config, error) {
+	if _, err := s.Poll(ctx); err != nil {
+		cached, cacheErr := s.loadCache()
+		if cacheErr != nil {
+			return nil, fmt.Errorf("failed to fetch config from %s: %v (no usable cache: %v)", s.url, err, cacheErr)
+		}
+		log.Warn("Config source unavailable, using cached config",
+			zap.String("url", s.url), zap.String("cache", s.opts.CacheFile), zap.Error(err))
+		cfg, err := Parse(cached)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cached config: %v", err)
+		}
+		s.mutex.Lock()
+		s.data = cached
+		s.mutex.Unlock()
+		return cfg, nil
+	}
+
+	s.mutex.Lock()
+	data := s.data
+	s.mutex.Unlock()
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Loaded configuration", zap.String("url", s.url))
+	return cfg, nil
+}
+
+// Poll 以条件请求检查配置是否变化，只有内容有效且与当前不同时返回 true；
+// 服务器不支持 ETag 时按内容比较，避免每次轮询都视为变化
+func (s *Source) Poll(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	s.mutex.Lock()
+	etag := s.etag
+	s.mutex.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("config source returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize+1))
+	if err != nil {
+		return false, fmt.Errorf("failed to read config source response: %v", err)
+	}
+	if len(data) > maxConfigSize {
+		return false, fmt.Errorf("config exceeds %d bytes", maxConfigSize)
+	}
+	if _, err := Parse(data); err != nil {
+		return false, fmt.Errorf("invalid config from source: %v", err)
+	}
+
+	s.mutex.Lock()
+	changed := !bytes.Equal(s.data, data)
+	s.etag = resp.Header.Get("ETag")
+	s.data = data
+	s.mutex.Unlock()
+
+	if changed {
+		if err := s.saveCache(data, s.etag); err != nil {
+			log.Warn("Failed to cache config", zap.String("cache", s.opts.CacheFile), zap.Error(err))
+		}
+	}
+	return changed, nil
+}
+
+// loadCache 读取本地缓存的配置及其 ETag
+func (s *Source) loadCache() ([]byte, error) {
+	if s.opts.CacheFile == "" {
+		return nil, fmt.Errorf("no cache file configured")
+	}
+	data, err := os.ReadFile(s.opts.CacheFile)
+	if err != nil {
+		return nil, err
+	}
+	if etag, err := os.ReadFile(s.opts.CacheFile + ".etag"); err == nil {
+		s.mutex.Lock()
+		s.etag = strings.TrimSpace(string(etag))
+		s.mutex.Unlock()
+	}
+	return data, nil
+}
+
+// saveCache 原子写入缓存，配置中可能含有凭据，仅属主可读
+func (s *Source) saveCache(data []byte, etag string) error {
+	if s.opts.CacheFile == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.opts.CacheFile), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.opts.CacheFile, data); err != nil {
+		return err
+	}
+	if etag == "" {
+		os.Remove(s.opts.CacheFile + ".etag")
+		return nil
+	}
+	return writeFileAtomic(s.opts.CacheFile+".etag", []byte(etag+"\n"))
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
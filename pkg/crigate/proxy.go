@@ -0,0 +1,204 @@
+// Package crigate 为 kubelet 与容器运行时之间的 CRI 代理：请求按原始字节透传，
+// CreateContainer 在返回前等待容器的配额设置完成，确保容器启动时已受限额约束
+package crigate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"RootfsQuota/pkg/listener"
+)
+
+// CRI v1 中被拦截的方法
+const (
+	methodCreateContainer = "/runtime.v1.RuntimeService/CreateContainer"
+	methodRemoveContainer = "/runtime.v1.RuntimeService/RemoveContainer"
+)
+
+// GateFunc 在 CreateContainer 成功后调用，返回 nil 时才把响应交给 kubelet
+type GateFunc func(ctx context.Context, containerID string) error
+
+// Proxy 为 CRI 代理
+type Proxy struct {
+	listen   string
+	upstream *grpc.ClientConn
+	gate     GateFunc
+	server   *grpc.Server
+}
+
+// New 创建代理，listen 为 listener.Listen 的地址格式，upstream 为运行时的 CRI 套接字路径
+func New(listen, upstream string, gate GateFunc) (*Proxy, error) {
+	conn, err := grpc.Dial("unix://"+upstream,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial CRI runtime %s: %v", upstream, err)
+	}
+	p := &Proxy{listen: listen, upstream: conn, gate: gate}
+	p.server = grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(p.handle))
+	return p, nil
+}
+
+// Serve 监听并代理请求，ctx 结束时停止
+func (p *Proxy) Serve(ctx context.Context) error {
+	l, err := listener.Listen(p.listen)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		p.server.GracefulStop()
+		p.upstream.Close()
+	}()
+	if err := p.server.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// handle 处理所有方法，CreateContainer 之外的方法按流透传
+func (p *Proxy) handle(_ any, stream grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "method not found in stream")
+	}
+	ctx := stream.Context()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = metadata.NewOutgoingContext(ctx, md.Copy())
+	}
+	if method == methodCreateContainer {
+		return p.createContainer(ctx, stream)
+	}
+	return p.forward(ctx, method, stream)
+}
+
+// createContainer 转发 CreateContainer，等待配额设置后再返回；配额未设置时删除刚创建的容器并返回错误，
+// kubelet 会重试创建
+func (p *Proxy) createContainer(ctx context.Context, stream grpc.ServerStream) error {
+	var req, resp []byte
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	if err := p.upstream.Invoke(ctx, methodCreateContainer, &req, &resp); err != nil {
+		return err
+	}
+	containerID := stringField(resp, 1)
+	if containerID == "" {
+		return stream.SendMsg(&resp)
+	}
+	if err := p.gate(ctx, containerID); err != nil {
+		// kubelet 可能已取消请求，清理使用独立的上下文
+		remove := protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), containerID)
+		var discard []byte
+		if rmErr := p.upstream.Invoke(context.WithoutCancel(ctx), methodRemoveContainer, &remove, &discard); rmErr != nil {
+			return status.Errorf(codes.Unavailable, "quota not applied: %v; failed to remove container %s: %v", err, containerID, rmErr)
+		}
+		return status.Errorf(codes.Unavailable, "quota not applied: %v", err)
+	}
+	return stream.SendMsg(&resp)
+}
+
+// forward 在两端之间透传消息、响应头与尾部元数据，适用于一元与流式方法
+func (p *Proxy) forward(ctx context.Context, method string, server grpc.ServerStream) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client, err := p.upstream.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			var msg []byte
+			if err := server.RecvMsg(&msg); err != nil {
+				if err == io.EOF {
+					client.CloseSend()
+				} else {
+					cancel()
+				}
+				return
+			}
+			if err := client.SendMsg(&msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	headerSent := false
+	for {
+		var msg []byte
+		err := client.RecvMsg(&msg)
+		if !headerSent {
+			if md, hErr := client.Header(); hErr == nil {
+				server.SendHeader(md)
+			}
+			headerSent = true
+		}
+		if err != nil {
+			server.SetTrailer(client.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := server.SendMsg(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// stringField 返回消息中编号为 num 的字符串字段，CRI 响应无需完整解码
+func stringField(msg []byte, num protowire.Number) string {
+	for len(msg) > 0 {
+		n, typ, l := protowire.ConsumeTag(msg)
+		if l < 0 {
+			return ""
+		}
+		msg = msg[l:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeString(msg)
+			if l < 0 {
+				return ""
+			}
+			return v
+		}
+		l = protowire.ConsumeFieldValue(n, typ, msg)
+		if l < 0 {
+			return ""
+		}
+		msg = msg[l:]
+	}
+	return ""
+}
+
+// rawCodec 不解码消息，按原始字节收发
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name 与 proto 编解码器同名，保持请求的 content-type 不变
+func (rawCodec) Name() string { return "proto" }
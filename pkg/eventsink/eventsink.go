@@ -0,0 +1,145 @@
+// Package eventsink 将配额生命周期事件（设置、移除、超出软限制）发布到 Kafka 或 NATS，
+// 供中心平台跟踪全集群各容器的磁盘分配
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+)
+
+// 事件类型
+const (
+	QuotaSet      = "QuotaSet"
+	QuotaRemoved  = "QuotaRemoved"
+	QuotaExceeded = "QuotaExceeded"
+)
+
+// 支持的消息系统
+const (
+	TypeKafka = "kafka"
+	TypeNATS  = "nats"
+)
+
+// publishTimeout 为单条消息发布的超时时间
+const publishTimeout = 10 * time.Second
+
+// Event 为一条配额生命周期事件，以 JSON 发布
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	Node        string    `json:"node"`
+	ContainerID string    `json:"container_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	ProjectID   uint32    `json:"project_id"`
+	Group       string    `json:"group,omitempty"`
+	SoftLimit   string    `json:"soft_limit,omitempty"`
+	HardLimit   string    `json:"hard_limit,omitempty"`
+	// UsedBytes 为事件发生时的用量，QuotaSet 事件不采集用量
+	UsedBytes      uint64 `json:"used_bytes,omitempty"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes,omitempty"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes,omitempty"`
+}
+
+// Publisher 将一条消息发布到主题，key 用于 Kafka 分区
+type Publisher interface {
+	Publish(ctx context.Context, key string, data []byte) error
+	Close() error
+}
+
+// Sink 在后台协程中异步发布事件，队列满时丢弃新事件，不阻塞事件处理
+type Sink struct {
+	node      string
+	publisher Publisher
+	queue     chan Event
+	done      chan struct{}
+}
+
+// New 按类型创建发布器，topic 为 Kafka 主题或 NATS subject
+func New(typ, url, topic, node string, queueSize int) (*Sink, error) {
+	var publisher Publisher
+	switch typ {
+	case TypeKafka:
+		publisher = NewKafkaREST(url, topic)
+	case TypeNATS:
+		p, err := NewNATS(url, topic)
+		if err != nil {
+			return nil, err
+		}
+		publisher = p
+	default:
+		return nil, fmt.Errorf("unknown event sink type %q", typ)
+	}
+	return &Sink{
+		node:      node,
+		publisher: publisher,
+		queue:     make(chan Event, queueSize),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Publish 将事件加入发布队列，Time 为空时取当前时间
+func (s *Sink) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Node = s.node
+	select {
+	case s.queue <- e:
+	default:
+		metrics.EventSinkDropped.Inc()
+		log.Warn("Event sink queue is full, dropping event",
+			zap.String("type", e.Type), zap.String("container", e.ContainerID))
+	}
+}
+
+// Run 发布队列中的事件，直到 ctx 结束；结束前尽量发布已排队的事件
+func (s *Sink) Run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		select {
+		case e := <-s.queue:
+			s.send(ctx, e)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-s.queue:
+					s.send(context.Background(), e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Sink) send(ctx context.Context, e Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	if err := s.publisher.Publish(ctx, e.ContainerID, data); err != nil {
+		metrics.EventSinkErrors.Inc()
+		log.Warn("Failed to publish quota event",
+			zap.String("type", e.Type), zap.String("container", e.ContainerID), zap.Error(err))
+		return
+	}
+	metrics.EventSinkPublished.WithLabelValues(e.Type).Inc()
+}
+
+// Close 等待 Run 退出后关闭发布器
+func (s *Sink) Close(wait time.Duration) error {
+	select {
+	case <-s.done:
+	case <-time.After(wait):
+	}
+	return s.publisher.Close()
+}
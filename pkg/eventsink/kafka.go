@@ -0,0 +1,82 @@
+package eventsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaREST 通过 Kafka REST Proxy（v2 API，Confluent REST Proxy 及兼容实现）发布消息，无需引入 Kafka 客户端
+type KafkaREST struct {
+	url    string
+	client *http.Client
+}
+
+// NewKafkaREST 创建发布器，baseURL 为 REST Proxy 地址，如 http://kafka-rest:8082
+func NewKafkaREST(baseURL, topic string) *KafkaREST {
+	return &KafkaREST{
+		url:    strings.TrimRight(baseURL, "/") + "/topics/" + url.PathEscape(topic),
+		client: &http.Client{Timeout: publishTimeout},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaResponse 为 REST Proxy 的响应，单条记录失败时 HTTP 状态仍为 200
+type kafkaResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish 发布一条 JSON 消息，容器 ID 作为消息键，同一容器的事件进入同一分区
+func (k *KafkaREST) Publish(ctx context.Context, key string, data []byte) error {
+	body, err := json.Marshal(kafkaRequest{Records: []kafkaRecord{{Key: key, Value: data}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy returned %s", resp.Status)
+	}
+	// 无法解析的响应体按成功处理，只有明确的记录错误才视为失败
+	var result kafkaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil
+	}
+	for _, o := range result.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected record: %s", o.Error)
+		}
+	}
+	return nil
+}
+
+// Close 释放空闲连接
+func (k *KafkaREST) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/snapshot"
+	"RootfsQuota/pkg/xfs"
+)
+
+// QueryUsage 实时查询容器项目的用量与限额
+func (q *RFSQuota) QueryUsage(containerID string) (xfs.Entry, xfs.ProjectUsage, error) {
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return xfs.Entry{}, xfs.ProjectUsage{}, fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+
+	usage, err := q.projectBackend(entry.ProjectID).GetUsage(q.ctx, entry.ProjectID)
+	if err != nil {
+		return entry, xfs.ProjectUsage{}, err
+	}
+	return entry, usage, nil
+}
+
+// GetEntry 返回容器的状态记录
+func (q *RFSQuota) GetEntry(containerID string) (xfs.Entry, bool) {
+	return q.stateManager.GetEntry(containerID)
+}
+
+// ListEntries 返回所有状态记录
+func (q *RFSQuota) ListEntries() []xfs.Entry {
+	return q.stateManager.ListEntries()
+}
+
+// 限额变更来源，记录在条目的变更历史中
+const (
+	limitSourceCreate   = "create"
+	limitSourceAdmin    = "admin"
+	limitSourceScale    = "scale"
+	limitSourcePolicy   = "policy"
+	limitSourceRollback = "policy-rollback"
+	limitSourceResize   = "resize"
+)
+
+// SetLimits 修改已管理容器的软/硬限制，并记录到状态文件
+func (q *RFSQuota) SetLimits(containerID, soft, hard string) error {
+	return q.setLimits(q.ctx, containerID, soft, hard, limitSourceAdmin, sched.Admin)
+}
+
+// setLimits 按 prio 调度修改限额，并以 source 记录变更来源
+func (q *RFSQuota) setLimits(ctx context.Context, containerID, soft, hard, source string, prio sched.Priority) error {
+	return q.sched.Do(ctx, containerID, prio, func(ctx context.Context) error {
+		return q.doSetLimits(ctx, containerID, soft, hard, source)
+	})
+}
+
+func (q *RFSQuota) doSetLimits(ctx context.Context, containerID, soft, hard, source string) error {
+	if q.standby.Load() {
+		return api.ErrStandby
+	}
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+
+	// 调大限额同样占用节点预算，检查到记录新限额期间持有预算锁
+	unlock := q.lockBudget()
+	defer unlock()
+	if err := q.checkBudgetIncrease(q.budgetPath(entry), entry.HardLimit, hard); err != nil {
+		return err
+	}
+
+	// 临时解除期间只更新记录，到期恢复时写入新限额
+	if entry.LiftedUntil.IsZero() {
+		if err := q.setProjectQuota(ctx, entry.ProjectID, soft, hard, false); err != nil {
+			q.noteFilesystemError(err, entry.Upperdir)
+			return err
+		}
+	}
+
+	// 共享项目的所有条目（分组及其成员）限额一致
+	var changed []xfs.Entry
+	for _, other := range q.stateManager.ListEntries() {
+		if other.ProjectID != entry.ProjectID {
+			continue
+		}
+		if _, err := q.stateManager.UpdateEntry(other.ContainerID, func(e *xfs.Entry) {
+			e.SetLimits(soft, hard, source)
+			if source == limitSourceAdmin {
+				e.Pinned = true
+			}
+		}); err != nil {
+			return err
+		}
+		if updated, ok := q.stateManager.GetEntry(other.ContainerID); ok && updated.Upperdir != "" {
+			changed = append(changed, updated)
+		}
+	}
+	q.notifyLimitChanges(changed, source)
+	return nil
+}
+
+// UnpinLimits 取消手动设置的限额固定，共享项目的所有条目一并取消，之后由策略与 kubelet 扩缩容接管
+func (q *RFSQuota) UnpinLimits(containerID string) (xfs.Entry, error) {
+	if q.standby.Load() {
+		return xfs.Entry{}, api.ErrStandby
+	}
+	var entry xfs.Entry
+	err := q.sched.Do(q.ctx, containerID, sched.Admin, func(ctx context.Context) error {
+		var exists bool
+		if entry, exists = q.stateManager.GetEntry(containerID); !exists {
+			return fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+		}
+		for _, other := range q.entriesOf(entry.ProjectID) {
+			if !other.Pinned {
+				continue
+			}
+			if _, err := q.stateManager.UpdateEntry(other.ContainerID, func(e *xfs.Entry) { e.Pinned = false }); err != nil {
+				return err
+			}
+		}
+		entry.Pinned = false
+		return nil
+	})
+	return entry, err
+}
+
+// MatchContainers 返回 containerd 标签满足选择器的已管理容器
+func (q *RFSQuota) MatchContainers(sel api.Selector) ([]string, error) {
+	if q.client == nil {
+		return nil, fmt.Errorf("not connected to containerd")
+	}
+
+	var ids []string
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" || strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || isExternalKey(entry.ContainerID) {
+			continue
+		}
+		labels := q.containerLabels(entry)
+		if labels == nil {
+			continue
+		}
+		if sel.Matches(labels) {
+			ids = append(ids, entry.ContainerID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// RemoveQuota 移除已管理容器的配额
+func (q *RFSQuota) RemoveQuota(containerID string) error {
+	if q.standby.Load() {
+		return api.ErrStandby
+	}
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+	if err := q.sched.Do(q.ctx, containerID, sched.Admin, func(ctx context.Context) error {
+		return q.removeQuota(ctx, containerID, entry.ProjectID)
+	}); err != nil {
+		return err
+	}
+	log.Info("Quota removed via admin API",
+		zap.String("container", containerID),
+		zap.Uint32("projectID", entry.ProjectID))
+	return nil
+}
+
+// LastEvent 返回最近处理的事件水位
+func (q *RFSQuota) LastEvent() (xfs.EventMark, bool) {
+	return q.stateManager.LastEvent()
+}
+
+// InspectMounts 解析容器快照的挂载信息
+func (q *RFSQuota) InspectMounts(namespace, containerID string) (*snapshot.MountInfo, error) {
+	if q.resolver == nil {
+		return nil, fmt.Errorf("not connected to containerd")
+	}
+	if namespace == "" {
+		namespace = q.cfg.Namespace
+		if entry, exists := q.stateManager.GetEntry(containerID); exists && entry.Namespace != "" {
+			namespace = entry.Namespace
+		}
+	}
+	return q.resolver.Resolve(q.namespaceContext(namespace), containerID)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// tagOwner 在目录上记录归属，供内存状态模式或状态文件丢失时重建映射
+func (q *RFSQuota) tagOwner(ctx context.Context, path, key, group string) {
+	if err := xfs.SetOwnerTag(path, key, group); err != nil {
+		log.Ctx(ctx).Warn("Failed to tag directory owner", zap.String("path", path), zap.String("key", key), zap.Error(err))
+	}
+	if epoch := q.stateManager.Epoch(); epoch != 0 {
+		if err := xfs.SetEpochTag(path, epoch); err != nil {
+			log.Ctx(ctx).Warn("Failed to tag directory epoch", zap.String("path", path), zap.Error(err))
+		}
+	}
+}
+
+// adoptable 判断目录是否带有 key 的归属标记与范围内的项目 ID，不做修改
+func (q *RFSQuota) adoptable(ctx context.Context, key, upperdir string) (uint32, string, bool) {
+	owner, group, err := xfs.GetOwnerTag(upperdir)
+	if err != nil || owner != key {
+		return 0, "", false
+	}
+	projID, err := xfs.GetProjectIDFromXFS(ctx, upperdir)
+	if err != nil || projID < q.cfg.Project.IDMin || projID > q.cfg.Project.IDMax {
+		return 0, "", false
+	}
+	return projID, group, true
+}
+
+// adoptExisting 根据目录上的项目 ID 与归属标记恢复状态，成功时无需重新分配项目 ID
+func (q *RFSQuota) adoptExisting(ctx context.Context, namespace, key, upperdir string) bool {
+	projID, group, ok := q.adoptable(ctx, key, upperdir)
+	if !ok {
+		return false
+	}
+	usage, err := q.projectBackend(projID).GetUsage(ctx, projID)
+	if err != nil {
+		log.Ctx(ctx).Warn("Failed to read limits of tagged project", zap.String("key", key), zap.Uint32("projectID", projID), zap.Error(err))
+		return false
+	}
+
+	entry := xfs.Entry{
+		ContainerID: key,
+		Namespace:   namespace,
+		ProjectID:   projID,
+		Upperdir:    upperdir,
+		SoftLimit:   xfs.FormatSize(usage.SoftLimitBytes),
+		HardLimit:   xfs.FormatSize(usage.HardLimitBytes),
+		InodeSoft:   usage.InodeSoftLimit,
+		InodeHard:   usage.InodeHardLimit,
+		Group:       group,
+	}
+
+	q.groupMutex.Lock()
+	defer q.groupMutex.Unlock()
+
+	q.projectIDPool.MarkUsed(projID)
+	if group != "" {
+		if _, exists := q.stateManager.GetEntry(group); !exists {
+			groupEntry := entry
+			groupEntry.ContainerID = group
+			groupEntry.Namespace = ""
+			groupEntry.Upperdir = ""
+			groupEntry.Group = ""
+			if err := q.stateManager.PutEntry(groupEntry); err != nil {
+				return false
+			}
+		}
+	}
+	if err := q.stateManager.PutEntry(entry); err != nil {
+		return false
+	}
+
+	log.Ctx(ctx).Info("Adopted existing quota from directory tags",
+		zap.String("key", key),
+		zap.String("group", group),
+		zap.Uint32("projectID", projID))
+	return true
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/aggregate"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// collectSummary 生成推送给汇聚服务的节点汇总
+func (q *RFSQuota) collectSummary() aggregate.NodeSummary {
+	summary := aggregate.NodeSummary{
+		ProjectIDsUsed:  q.projectIDPool.Used(),
+		ProjectIDsTotal: q.projectIDPool.Size(),
+	}
+
+	// 用量取自后台轮询的快照，避免每次推送调用 xfs_quota
+	var usages map[uint32]xfs.ProjectUsage
+	if snapshot, err := q.latestUsage(); err == nil {
+		usages = snapshot.Projects
+	} else {
+		log.Warn("Failed to query usage for summary", zap.Error(err))
+	}
+
+	for _, entry := range q.stateManager.ListEntries() {
+		c := aggregate.ContainerSummary{
+			ContainerID: entry.ContainerID,
+			ProjectID:   entry.ProjectID,
+			SoftLimit:   entry.SoftLimit,
+			HardLimit:   entry.HardLimit,
+		}
+		if usage, ok := usages[entry.ProjectID]; ok {
+			c.UsedBytes = usage.UsedBytes
+			c.HardBytes = usage.HardLimitBytes
+		}
+		summary.TotalHardBytes += c.HardBytes
+		summary.TotalUsedBytes += c.UsedBytes
+		summary.Containers = append(summary.Containers, c)
+	}
+	return summary
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/xfs"
+)
+
+// usageAlert 为发送到 webhook 的用量告警
+type usageAlert struct {
+	Node        string    `json:"node,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	ContainerID string    `json:"container_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	// Labels 为 usage_poller.labels 中列出的容器标签
+	Labels         map[string]string `json:"labels,omitempty"`
+	ProjectID      uint32            `json:"project_id"`
+	Class          string            `json:"class"`
+	Threshold      float64           `json:"threshold"`
+	Percent        float64           `json:"percent"`
+	UsedBytes      uint64            `json:"used_bytes"`
+	HardLimitBytes uint64            `json:"hard_limit_bytes"`
+	// TmpfsPaths 为容器内的 tmpfs 挂载，其中的写入不计入用量，腾出空间需清理其他目录
+	TmpfsPaths []string `json:"tmpfs_paths,omitempty"`
+}
+
+// alertState 为项目最近一次告警的阈值与时间
+type alertState struct {
+	threshold float64
+	at        time.Time
+}
+
+// alertClass 返回条目的配额类别，分组条目按分组类型区分
+func alertClass(entry xfs.Entry) string {
+	switch {
+	case strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix):
+		return config.AlertClassBuildkit
+	case strings.HasPrefix(entry.ContainerID, podGroupPrefix), strings.HasPrefix(entry.ContainerID, sandboxGroupPrefix):
+		return config.AlertClassPod
+	case strings.HasPrefix(entry.ContainerID, nsGroupPrefix):
+		return config.AlertClassNamespace
+	}
+	return config.AlertClassContainer
+}
+
+// alertRules 返回类别生效的阈值与冷却时间
+func (q *RFSQuota) alertRules(class string) ([]float64, time.Duration) {
+	cfg := q.cfg.Alerts
+	thresholds, cooldown := cfg.Thresholds, cfg.CooldownSeconds
+	if override, ok := cfg.Classes[class]; ok {
+		if override.Thresholds != nil {
+			thresholds = override.Thresholds
+		}
+		if override.CooldownSeconds > 0 {
+			cooldown = override.CooldownSeconds
+		}
+	}
+	return thresholds, time.Duration(cooldown) * time.Second
+}
+
+// crossedThreshold 返回用量百分比超过的最高阈值，未超过任何阈值时返回 0；thresholds 已按升序排列
+func crossedThreshold(percent float64, thresholds []float64) float64 {
+	crossed := 0.0
+	for _, t := range thresholds {
+		if percent >= t {
+			crossed = t
+		}
+	}
+	return crossed
+}
+
+// runUsageAlerts 周期比较各项目用量与硬限制：超过更高的阈值时立即告警，停留在同一阈值时每个冷却时间
+// 重复一次；回落到所有阈值以下并经过冷却时间后清除记录。分组成员不单独告警，由分组条目代表整个项目
+func (q *RFSQuota) runUsageAlerts() {
+	ticker := time.NewTicker(time.Duration(q.cfg.Alerts.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	var webhook *notify.Webhook
+	if q.cfg.Alerts.WebhookURL != "" {
+		webhook = notify.NewWebhook(q.cfg.Alerts.WebhookURL)
+	}
+	states := make(map[uint32]alertState)
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+		if q.standby.Load() {
+			continue
+		}
+		snapshot, err := q.latestUsage()
+		if err != nil {
+			log.Warn("Failed to query usage for alerts", zap.Error(err))
+			continue
+		}
+		usages := snapshot.Projects
+
+		now := time.Now()
+		over := make(map[string]int)
+		live := make(map[uint32]bool)
+		for _, entry := range q.stateManager.ListEntries() {
+			usage, ok := usages[entry.ProjectID]
+			if entry.Group != "" || !ok || usage.HardLimitBytes == 0 {
+				continue
+			}
+			live[entry.ProjectID] = true
+			class := alertClass(entry)
+			thresholds, cooldown := q.alertRules(class)
+			percent := float64(usage.UsedBytes) / float64(usage.HardLimitBytes) * 100
+			crossed := crossedThreshold(percent, thresholds)
+			state, alerted := states[entry.ProjectID]
+			if crossed == 0 {
+				if alerted && now.Sub(state.at) >= cooldown {
+					delete(states, entry.ProjectID)
+				}
+				continue
+			}
+			over[fmt.Sprint(crossed)]++
+			if alerted && crossed <= state.threshold && now.Sub(state.at) < cooldown {
+				continue
+			}
+			states[entry.ProjectID] = alertState{threshold: crossed, at: now}
+			q.fireUsageAlert(webhook, usageAlert{
+				Node:           q.cfg.Alerts.NodeName,
+				Timestamp:      now,
+				ContainerID:    entry.ContainerID,
+				Namespace:      q.entryNamespace(entry),
+				Image:          snapshot.Containers[entry.ContainerID].Image,
+				Labels:         snapshot.Containers[entry.ContainerID].Labels,
+				ProjectID:      entry.ProjectID,
+				Class:          class,
+				Threshold:      crossed,
+				Percent:        percent,
+				UsedBytes:      usage.UsedBytes,
+				HardLimitBytes: usage.HardLimitBytes,
+				TmpfsPaths:     q.containerTmpfs(entry),
+			}, entry, usage)
+		}
+		for id := range states {
+			if !live[id] {
+				delete(states, id)
+			}
+		}
+		metrics.UsageOverThreshold.Reset()
+		for threshold, n := range over {
+			metrics.UsageOverThreshold.WithLabelValues(threshold).Set(float64(n))
+		}
+	}
+}
+
+// containerTmpfs 返回条目对应容器的 tmpfs 目录，分组与 BuildKit 条目或读取失败时返回 nil
+func (q *RFSQuota) containerTmpfs(entry xfs.Entry) []string {
+	if alertClass(entry) != config.AlertClassContainer || q.client == nil {
+		return nil
+	}
+	r, err := q.lookupContainer(q.namespaceContext(q.entryNamespace(entry)), entry.ContainerID)
+	if err != nil {
+		return nil
+	}
+	return r.tmpfsPaths()
+}
+
+// fireUsageAlert 记录并发送告警，配置了 templates.usage_alert 时以模板生成日志消息与 webhook 请求体
+func (q *RFSQuota) fireUsageAlert(webhook *notify.Webhook, alert usageAlert, entry xfs.Entry, usage xfs.ProjectUsage) {
+	threshold := fmt.Sprint(alert.Threshold)
+	metrics.UsageAlerts.WithLabelValues(alert.Class, threshold).Inc()
+
+	message := "Rootfs usage passed alert threshold"
+	t := q.templates.usageAlert
+	var data notify.TemplateData
+	if t.message != nil || t.payload != nil {
+		data = q.templateData(templateUsageAlert, fmt.Sprintf("usage passed %s%% of the hard limit", threshold), entry, usage)
+		data.Usage.Threshold = alert.Threshold
+		message = render(t.message, data, message)
+	}
+	log.Warn(message,
+		zap.String("container", alert.ContainerID),
+		zap.String("namespace", alert.Namespace),
+		zap.String("class", alert.Class),
+		zap.Float64("threshold", alert.Threshold),
+		zap.Float64("percent", alert.Percent),
+		zap.Uint64("usedBytes", alert.UsedBytes),
+		zap.Uint64("hardLimitBytes", alert.HardLimitBytes),
+		zap.Strings("tmpfsPaths", alert.TmpfsPaths))
+	if webhook == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(q.ctx, notifyTimeout)
+		defer cancel()
+		var err error
+		if body := render(t.payload, data, ""); body != "" {
+			err = webhook.PostBody(ctx, t.contentType, []byte(body))
+		} else {
+			err = webhook.Post(ctx, alert)
+		}
+		if err != nil {
+			log.Warn("Failed to send usage alert", zap.String("container", alert.ContainerID), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/accounting"
+	"RootfsQuota/pkg/audit"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// recordFinalUsage 在移除配额前采集最终用量并写入审计日志与用量账单，返回采集到的用量供事件发布使用；
+// 失败不影响移除，此时返回 nil
+func (q *RFSQuota) recordFinalUsage(ctx context.Context, key string) *xfs.ProjectUsage {
+	if q.audit == nil && q.accounting == nil && q.sink == nil && !q.cfg.Images.Learn {
+		return nil
+	}
+	entry, exists := q.stateManager.GetEntry(key)
+	if !exists {
+		return nil
+	}
+
+	rec := audit.Record{
+		Event:       audit.EventQuotaRemoved,
+		ContainerID: entry.ContainerID,
+		Namespace:   entry.Namespace,
+		ProjectID:   entry.ProjectID,
+		Group:       entry.Group,
+		SoftLimit:   entry.SoftLimit,
+		HardLimit:   entry.HardLimit,
+	}
+	if !entry.CreatedAt.IsZero() {
+		rec.LifetimeSeconds = int64(time.Since(entry.CreatedAt).Seconds())
+	}
+	usage, err := q.projectBackend(entry.ProjectID).GetUsage(ctx, entry.ProjectID)
+	if err == nil {
+		rec.UsedBytes = usage.UsedBytes
+		rec.UsedInodes = usage.UsedInodes
+	} else {
+		log.Ctx(ctx).Warn("Failed to capture final usage", zap.String("key", key), zap.Error(err))
+	}
+
+	if q.audit != nil {
+		if err := q.audit.Write(rec); err != nil {
+			log.Ctx(ctx).Warn("Failed to write audit record", zap.String("key", key), zap.Error(err))
+		}
+	}
+	if q.accounting != nil && err == nil {
+		q.recordAccounting(ctx, entry, usage.UsedBytes, time.Duration(rec.LifetimeSeconds)*time.Second)
+	}
+	if err != nil {
+		return nil
+	}
+	return &usage
+}
+
+// recordAccounting 将容器的最终用量计入所属命名空间当天的账单；
+// 分组成员共享一个项目，按当前成员数均摊分组用量，避免重复计算
+func (q *RFSQuota) recordAccounting(ctx context.Context, entry xfs.Entry, used uint64, lifetime time.Duration) {
+	if entry.Group != "" {
+		members := 0
+		for _, other := range q.stateManager.ListEntries() {
+			if other.Group == entry.Group {
+				members++
+			}
+		}
+		if members > 1 {
+			used /= uint64(members)
+		}
+	}
+	namespace := q.entryNamespace(entry)
+	if strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) {
+		namespace = q.cfg.Buildkit.Namespace
+	}
+	if err := q.accounting.Record(accounting.Usage{
+		Namespace: namespace,
+		Ended:     time.Now(),
+		Lifetime:  lifetime,
+		UsedBytes: used,
+	}); err != nil {
+		log.Ctx(ctx).Warn("Failed to record usage accounting", zap.String("key", entry.ContainerID), zap.Error(err))
+	}
+}
+
+// Accounting 返回日期范围内按命名空间汇总的用量账单
+func (q *RFSQuota) Accounting(from, to, namespace string) ([]accounting.Summary, error) {
+	if q.accounting == nil {
+		return nil, fmt.Errorf("usage accounting is disabled, set accounting.path")
+	}
+	return q.accounting.Summaries(from, to, namespace), nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"RootfsQuota/pkg/quota"
+)
+
+// errNotAllowed 表示目录不在 upperdir_allowlist 内
+var errNotAllowed = errors.New("path is outside upperdir_allowlist")
+
+// skipQuota 判断错误是否表示目录不应设置配额（文件系统不支持项目配额、不在允许列表内、容器已退出配额管理或命中排除规则），
+// 此时跳过而不是失败
+func skipQuota(err error) bool {
+	return errors.Is(err, quota.ErrUnsupported) || errors.Is(err, errNotAllowed) || errors.Is(err, errOptedOut) || errors.Is(err, errExcluded)
+}
+
+// checkUpperdir 在分配项目 ID 前校验目录：必须是存在的目录、位于允许列表内且所在文件系统支持项目配额，
+// 注定失败的尝试不再占用并归还项目 ID
+func (q *RFSQuota) checkUpperdir(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("upperdir %s is not an absolute path", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat upperdir: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("upperdir %s is not a directory", path)
+	}
+	if !q.upperdirAllowed(path) {
+		return fmt.Errorf("%w: %s", errNotAllowed, path)
+	}
+	_, err = quota.Detect(path)
+	return err
+}
+
+// upperdirAllowed 判断目录（解析符号链接后）是否位于 upperdir_allowlist 内，未配置时总是允许
+func (q *RFSQuota) upperdirAllowed(path string) bool {
+	if len(q.cfg.UpperdirAllowlist) == 0 {
+		return true
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	path = filepath.Clean(path)
+	for _, dir := range q.cfg.UpperdirAllowlist {
+		if dir == "/" || path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// setProjectID 按目录所在文件系统选择配额后端并设置项目 ID，记录项目所用的后端
+func (q *RFSQuota) setProjectID(ctx context.Context, path string, projID uint32) error {
+	if err := q.checkFence(path); err != nil {
+		return err
+	}
+	backend, err := quota.Detect(path)
+	if err != nil {
+		return err
+	}
+	q.backends.Store(projID, backend)
+	return backend.SetProjectID(ctx, path, projID)
+}
+
+// projectBackend 返回项目所用的配额后端：优先使用设置项目 ID 时记录的，其次按条目的可写层探测，
+// 均不可得（如重启后目录已删除）时使用默认后端
+func (q *RFSQuota) projectBackend(projID uint32) quota.QuotaBackend {
+	if backend, ok := q.backends.Load(projID); ok {
+		return backend.(quota.QuotaBackend)
+	}
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.ProjectID != projID || entry.Upperdir == "" {
+			continue
+		}
+		if backend, err := quota.Detect(entry.Upperdir); err == nil {
+			q.backends.Store(projID, backend)
+			return backend
+		}
+	}
+	return quota.Default()
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+)
+
+// buildkitKeyPrefix 为独立 buildkitd 快照在状态文件中的键前缀，避免与容器 ID 冲突
+const buildkitKeyPrefix = "buildkit:"
+
+// isBuildkitNamespace 判断事件是否来自 BuildKit containerd worker 的命名空间
+func (q *RFSQuota) isBuildkitNamespace(namespace string) bool {
+	return q.cfg.Buildkit.Enabled && namespace != "" && namespace == q.cfg.Buildkit.Namespace
+}
+
+// runBuildkitScanner 周期扫描独立 buildkitd 的快照目录，为新快照设置配额并回收已删除快照的配额
+func (q *RFSQuota) runBuildkitScanner() {
+	interval := time.Duration(q.cfg.Buildkit.ScanIntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Info("Watching buildkit snapshot directories",
+		zap.Strings("dirs", q.cfg.Buildkit.SnapshotDirs),
+		zap.Duration("interval", interval))
+
+	for {
+		q.scanBuildkitSnapshots()
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) scanBuildkitSnapshots() {
+	if q.degraded.Active() || q.standby.Load() {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, root := range q.cfg.Buildkit.SnapshotDirs {
+		dirs, err := listBuildkitSnapshots(root)
+		if err != nil {
+			log.Error("Failed to scan buildkit snapshots", zap.String("dir", root), zap.Error(err))
+			continue
+		}
+		for _, dir := range dirs {
+			key := buildkitKeyPrefix + dir
+			seen[key] = true
+			if _, exists := q.stateManager.GetEntry(key); exists {
+				continue
+			}
+			var projID uint32
+			adopted := false
+			err := q.sched.Do(log.WithFields(q.ctx, zap.String("dir", dir)), key, sched.Reconcile, func(ctx context.Context) error {
+				if adopted = q.adoptExisting(ctx, "", key, dir); adopted {
+					return nil
+				}
+				if err := q.checkUpperdir(dir); err != nil {
+					countQuotaOp(quotaOpSet, err)
+					return err
+				}
+				var err error
+				projID, err = q.applyQuota(ctx, "", key, dir, q.cfg.Buildkit.Quota, "", "")
+				countQuotaOp(quotaOpSet, err)
+				if err == nil {
+					q.publishQuotaSet(key)
+				}
+				return err
+			})
+			ctx := log.WithFields(q.ctx, zap.String("dir", dir))
+			if adopted {
+				continue
+			}
+			if skipQuota(err) {
+				log.Ctx(ctx).Debug("Skipping buildkit snapshot that cannot get a project quota", zap.Error(err))
+				continue
+			}
+			if err != nil {
+				log.Ctx(ctx).Error("Failed to set buildkit snapshot quota", zap.Error(err))
+				continue
+			}
+			log.Ctx(ctx).Info("Buildkit snapshot quota set successfully", zap.Uint32("projectID", projID))
+		}
+	}
+
+	for _, entry := range q.stateManager.ListEntries() {
+		if !strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || seen[entry.ContainerID] {
+			continue
+		}
+		if _, err := os.Stat(entry.Upperdir); err == nil {
+			continue
+		}
+		ctx := log.WithFields(q.ctx, zap.String("dir", entry.Upperdir), zap.Uint32("projectID", entry.ProjectID))
+		if err := q.sched.Do(ctx, entry.ContainerID, sched.Reconcile, func(ctx context.Context) error {
+			return q.removeQuota(ctx, entry.ContainerID, entry.ProjectID)
+		}); err != nil {
+			log.Ctx(ctx).Error("Failed to remove buildkit snapshot quota", zap.Error(err))
+			continue
+		}
+		log.Ctx(ctx).Info("Buildkit snapshot quota removed successfully")
+	}
+}
+
+// listBuildkitSnapshots 列出快照根目录下每个快照的可写目录（<root>/<id>/fs）
+func listBuildkitSnapshots(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		fsDir := filepath.Join(root, entry.Name(), "fs")
+		if info, err := os.Stat(fsDir); err == nil && info.IsDir() {
+			dirs = append(dirs, fsDir)
+		}
+	}
+	return dirs, nil
+}
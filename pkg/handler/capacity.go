@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/xfs"
+)
+
+// nodeBudget 计算节点可分配给容器配额的总字节数：
+// (文件系统容量 - 预留空间) * 超分比例，预留空间从不计入配额
+func (q *RFSQuota) nodeBudget(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	total := st.Blocks * uint64(st.Bsize)
+
+	var reserve uint64
+	if q.cfg.Capacity.Reserve != "" {
+		parsed, err := xfs.ParseSize(q.cfg.Capacity.Reserve)
+		if err != nil {
+			return 0, err
+		}
+		reserve = parsed
+	}
+	if pct := uint64(float64(total) * q.cfg.Capacity.ReservePercent / 100); pct > reserve {
+		reserve = pct
+	}
+	if reserve >= total {
+		return 0, nil
+	}
+	return uint64(float64(total-reserve) * q.cfg.Capacity.OvercommitRatio), nil
+}
+
+// committedBytes 返回已分配的硬限制总和，共享项目只计一次
+func (q *RFSQuota) committedBytes() uint64 {
+	var committed uint64
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Group != "" || entry.HardLimit == "" {
+			continue
+		}
+		if hard, err := xfs.ParseSize(entry.HardLimit); err == nil {
+			committed += hard
+		}
+	}
+	return committed
+}
+
+// lockBudget 在启用节点预算时获取预算锁并返回解锁函数。预算检查与新限额写入状态文件之间须一直持有，
+// 否则并发的创建或调整会基于同一份已分配总量通过检查
+func (q *RFSQuota) lockBudget() func() {
+	if !q.cfg.Capacity.Enabled() {
+		return func() {}
+	}
+	q.budgetMutex.Lock()
+	return q.budgetMutex.Unlock
+}
+
+// fitToBudget 检查新配额是否超出节点预算，超出时收缩到剩余预算，预算耗尽时拒绝；调用方须持有预算锁
+func (q *RFSQuota) fitToBudget(ctx context.Context, path, soft, hard string) (string, string, error) {
+	if !q.cfg.Capacity.Enabled() {
+		return soft, hard, nil
+	}
+
+	budget, err := q.nodeBudget(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to compute node budget: %v", err)
+	}
+	committed := q.committedBytes()
+	metrics.NodeBudgetBytes.Set(float64(budget))
+	metrics.NodeCommittedBytes.Set(float64(committed))
+
+	hardBytes, err := xfs.ParseSize(hard)
+	if err != nil {
+		return "", "", err
+	}
+	softBytes, err := xfs.ParseSize(soft)
+	if err != nil {
+		return "", "", err
+	}
+	if committed+hardBytes <= budget {
+		return soft, hard, nil
+	}
+	if committed >= budget {
+		return "", "", fmt.Errorf("node quota budget exhausted: committed %s of %s", xfs.FormatSize(committed), xfs.FormatSize(budget))
+	}
+
+	remaining := budget - committed
+	if softBytes > remaining {
+		softBytes = remaining
+	}
+	log.Ctx(ctx).Warn("Quota exceeds remaining node budget, shrinking",
+		zap.String("path", path),
+		zap.String("requested", hard),
+		zap.String("granted", xfs.FormatSize(remaining)))
+	return xfs.FormatSize(softBytes), xfs.FormatSize(remaining), nil
+}
+
+// checkBudgetIncrease 检查将项目的硬限制从 oldHard 调大到 newHard 后是否超出节点预算，调小时总是允许；
+// 调用方须持有预算锁
+func (q *RFSQuota) checkBudgetIncrease(path, oldHard, newHard string) error {
+	if !q.cfg.Capacity.Enabled() || path == "" {
+		return nil
+	}
+	newBytes, err := xfs.ParseSize(newHard)
+	if err != nil {
+		return err
+	}
+	oldBytes, _ := xfs.ParseSize(oldHard)
+	if newBytes <= oldBytes {
+		return nil
+	}
+
+	budget, err := q.nodeBudget(path)
+	if err != nil {
+		return fmt.Errorf("failed to compute node budget: %v", err)
+	}
+	committed := q.committedBytes()
+	metrics.NodeBudgetBytes.Set(float64(budget))
+	metrics.NodeCommittedBytes.Set(float64(committed))
+	if committed-min(committed, oldBytes)+newBytes > budget {
+		return fmt.Errorf("node quota budget exceeded: raising %s to %s needs %s more, %s of %s committed",
+			xfs.FormatSize(oldBytes), newHard, xfs.FormatSize(newBytes-oldBytes), xfs.FormatSize(committed), xfs.FormatSize(budget))
+	}
+	return nil
+}
+
+// budgetPath 返回检查条目预算时所在文件系统的路径：条目的可写层，分组条目取成员的可写层或其额外目录
+func (q *RFSQuota) budgetPath(entry xfs.Entry) string {
+	if entry.Upperdir != "" {
+		return entry.Upperdir
+	}
+	for _, other := range q.entriesOf(entry.ProjectID) {
+		if other.Upperdir != "" {
+			return other.Upperdir
+		}
+	}
+	if len(entry.Paths) > 0 {
+		return entry.Paths[0]
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/xfs"
+)
+
+// hardLimitSlackPercent 为判定达到硬限制时允许的余量，块分配粒度使用量通常停在略低于硬限制处
+const hardLimitSlackPercent = 1
+
+// chatTarget 为一个已创建的聊天机器人
+type chatTarget struct {
+	provider string
+	chat     notify.Chat
+}
+
+func newChatTargets(cfg config.ChatConfig) ([]chatTarget, error) {
+	var targets []chatTarget
+	for i, t := range cfg.Targets {
+		chat, err := notify.NewChat(t.Provider, t.WebhookURL, t.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat.targets[%d]: %v", i, err)
+		}
+		targets = append(targets, chatTarget{provider: t.Provider, chat: chat})
+	}
+	return targets, nil
+}
+
+// notifyChat 在后台向所有聊天机器人发送消息，发送失败只记录日志；备用实例不发送
+func (q *RFSQuota) notifyChat(text string) {
+	if len(q.chats) == 0 || q.standby.Load() {
+		return
+	}
+	text = fmt.Sprintf("[%s] %s", q.cfg.Chat.NodeName, text)
+	for _, t := range q.chats {
+		go func(t chatTarget) {
+			ctx, cancel := context.WithTimeout(q.ctx, notifyTimeout)
+			defer cancel()
+			if err := t.chat.Send(ctx, text); err != nil {
+				log.Warn("Failed to send chat notification", zap.String("provider", t.provider), zap.Error(err))
+			}
+		}(t)
+	}
+}
+
+// notifyPoolExhaustion 在项目 ID 池使用率达到 chat.pool_percent 时通知一次，回落后再次达到时重新通知
+func (q *RFSQuota) notifyPoolExhaustion(stats xfs.PoolStats, alerted bool) bool {
+	if len(q.chats) == 0 || stats.Size == 0 {
+		return false
+	}
+	percent := float64(stats.Used) / float64(stats.Size) * 100
+	exhausted := percent >= q.cfg.Chat.PoolPercent
+	if exhausted && !alerted {
+		q.notifyChat(fmt.Sprintf("Project ID pool is nearly exhausted: %d of %d IDs in use (%.0f%%), new containers will fail to get quotas once it is full. Consider widening project.id_min/id_max (recommended size %d).",
+			stats.Used, stats.Size, percent, stats.RecommendedSize))
+	}
+	return exhausted
+}
+
+// runHardLimitMonitor 周期读取所有项目的用量，项目达到硬限制时通知一次，回落后再次达到时重新通知；
+// 分组成员不单独通知，由分组条目代表整个项目
+func (q *RFSQuota) runHardLimitMonitor() {
+	ticker := time.NewTicker(time.Duration(q.cfg.Chat.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	full := make(map[uint32]bool)
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+		if q.standby.Load() {
+			continue
+		}
+		snapshot, err := q.latestUsage()
+		if err != nil {
+			log.Warn("Failed to query usage for chat notifications", zap.Error(err))
+			continue
+		}
+		usages := snapshot.Projects
+
+		current := make(map[uint32]bool)
+		for _, entry := range q.stateManager.ListEntries() {
+			if entry.Group != "" {
+				continue
+			}
+			usage, ok := usages[entry.ProjectID]
+			if !ok || !atHardLimit(usage) {
+				continue
+			}
+			current[entry.ProjectID] = true
+			if !full[entry.ProjectID] {
+				var text string
+				if t := q.templates.hardLimit.message; t != nil {
+					text = render(t, q.templateData(templateHardLimit, "usage reached the hard limit", entry, usage), "")
+				}
+				if text == "" {
+					text = q.hardLimitMessage(entry, usage)
+				}
+				q.notifyChat(text)
+			}
+		}
+		full = current
+	}
+}
+
+// atHardLimit 判断项目用量是否达到硬限制（允许 hardLimitSlackPercent 的余量）
+func atHardLimit(usage xfs.ProjectUsage) bool {
+	if usage.HardLimitBytes == 0 {
+		return false
+	}
+	return usage.UsedBytes*100 >= usage.HardLimitBytes*(100-hardLimitSlackPercent)
+}
+
+func (q *RFSQuota) hardLimitMessage(entry xfs.Entry, usage xfs.ProjectUsage) string {
+	subject := "Container " + entry.ContainerID
+	if isGroupKey(entry.ContainerID) {
+		subject = "Shared project " + entry.ContainerID
+	} else if labels := q.containerLabels(entry); labels[labelPodName] != "" {
+		subject += fmt.Sprintf(" (pod %s/%s)", labels[labelPodNamespace], labels[labelPodName])
+	}
+	return fmt.Sprintf("%s in namespace %s hit its rootfs hard limit: %d MiB used of %d MiB, further writes fail with ENOSPC.",
+		subject, q.entryNamespace(entry), usage.UsedBytes>>20, usage.HardLimitBytes>>20)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/crigate"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+)
+
+// CRI 门控的结果
+const (
+	gateApplied  = "applied"
+	gateSkipped  = "skipped"
+	gateRejected = "rejected"
+	gateAllowed  = "allowed"
+)
+
+// runCRIGate 运行 CRI 代理，kubelet 的 CreateContainer 在容器配额设置后才返回
+func (q *RFSQuota) runCRIGate() {
+	cfg := q.cfg.CRIGate
+	proxy, err := crigate.New(cfg.Listen, cfg.Upstream, q.gateContainer)
+	if err != nil {
+		log.Error("CRI gate failed to start", zap.Error(err))
+		return
+	}
+	log.Info("CRI gate listening", zap.String("listen", cfg.Listen), zap.String("upstream", cfg.Upstream),
+		zap.String("failurePolicy", cfg.FailurePolicy))
+	if err := proxy.Serve(q.ctx); err != nil {
+		log.Error("CRI gate failed", zap.Error(err))
+	}
+}
+
+// gateContainer 在 CRI 创建容器之后、任务创建之前设置配额；超时或失败时按 failure_policy 拒绝或放行。
+// req 为 kubelet 的请求上下文，kubelet 取消请求时停止等待
+func (q *RFSQuota) gateContainer(req context.Context, containerID string) error {
+	cfg := q.cfg.CRIGate
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(q.namespaceContext(cfg.Namespace), time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+	stop := context.AfterFunc(req, cancel)
+	defer stop()
+	ctx = log.WithFields(ctx, zap.String("container", containerID), zap.String("action", "cri-gate"))
+
+	err := q.sched.Do(ctx, containerID, sched.CreateHigh, func(ctx context.Context) error {
+		if q.resolver == nil {
+			return fmt.Errorf("not connected to containerd")
+		}
+		upperdir, err := q.resolver.Upperdir(ctx, containerID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve upperdir: %v", err)
+		}
+		return q.setContainerQuota(ctx, cfg.Namespace, containerID, upperdir)
+	})
+	metrics.CRIGateLatency.Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		result := gateSkipped
+		if _, exists := q.stateManager.GetEntry(containerID); exists {
+			result = gateApplied
+		}
+		metrics.CRIGateResults.WithLabelValues(result).Inc()
+		return nil
+	}
+	if ctx.Err() != nil && req.Err() == nil {
+		err = fmt.Errorf("timed out after %ds: %v", cfg.TimeoutSeconds, err)
+	}
+	if cfg.FailurePolicy == config.FailurePolicyIgnore {
+		metrics.CRIGateResults.WithLabelValues(gateAllowed).Inc()
+		log.Ctx(ctx).Warn("Quota not applied before container creation returned, allowing", zap.Error(err))
+		return nil
+	}
+	metrics.CRIGateResults.WithLabelValues(gateRejected).Inc()
+	log.Ctx(ctx).Error("Quota not applied, rejecting container creation", zap.Error(err))
+	q.abandonGated(containerID)
+	return err
+}
+
+// abandonGated 移除被拒绝的容器可能已部分设置的配额，容器随后由代理删除
+func (q *RFSQuota) abandonGated(containerID string) {
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return
+	}
+	ctx, cancel := context.WithTimeout(q.namespaceContext(q.cfg.CRIGate.Namespace), notifyTimeout)
+	defer cancel()
+	ctx = log.WithFields(ctx, zap.String("container", containerID))
+	if err := q.sched.Do(ctx, containerID, sched.Admin, func(ctx context.Context) error {
+		return q.removeQuota(ctx, containerID, entry.ProjectID)
+	}); err != nil {
+		log.Ctx(ctx).Warn("Failed to remove quota of rejected container", zap.Error(err))
+	}
+}
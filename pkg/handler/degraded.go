@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+const (
+	// healthFilesystem 为文件系统可写性的健康状况名称
+	healthFilesystem = "filesystem"
+
+	degradedProbeMin = 5 * time.Second
+	degradedProbeMax = 5 * time.Minute
+)
+
+// degradedState 记录文件系统只读时的降级状态
+type degradedState struct {
+	mutex  sync.Mutex
+	active bool
+	path   string
+	since  time.Time
+	// pendingDeletes 记录降级期间收到的删除事件，恢复后统一清理
+	pendingDeletes map[string]bool
+}
+
+func newDegradedState() *degradedState {
+	return &degradedState{pendingDeletes: make(map[string]bool)}
+}
+
+// Active 判断是否处于降级模式
+func (d *degradedState) Active() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.active
+}
+
+// deferDelete 在降级期间记录待清理的容器
+func (d *degradedState) deferDelete(containerID string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.pendingDeletes[containerID] = true
+}
+
+// noteFilesystemError 在操作因只读或 I/O 错误失败时进入降级模式，被更新的实例隔离时转入备用模式
+func (q *RFSQuota) noteFilesystemError(err error, path string) {
+	q.noteFenced(err)
+	if !xfs.IsReadOnlyError(err) {
+		return
+	}
+
+	d := q.degraded
+	d.mutex.Lock()
+	if d.active {
+		d.mutex.Unlock()
+		return
+	}
+	d.active = true
+	d.path = path
+	d.since = time.Now()
+	d.mutex.Unlock()
+
+	log.Error("Filesystem is read-only or failing, entering degraded mode",
+		zap.String("path", path), zap.Error(err))
+	q.health.Set(healthFilesystem, false, "filesystem read-only or I/O error at "+path)
+	go q.watchFilesystemRecovery()
+}
+
+// watchFilesystemRecovery 以递增的间隔探测文件系统，恢复可写后退出降级模式
+func (q *RFSQuota) watchFilesystemRecovery() {
+	d := q.degraded
+	d.mutex.Lock()
+	path := d.path
+	d.mutex.Unlock()
+
+	interval := degradedProbeMin
+	for {
+		select {
+		case <-time.After(interval):
+		case <-q.ctx.Done():
+			return
+		}
+
+		writable, err := xfs.IsWritable(path)
+		if err == nil && writable {
+			break
+		}
+		if interval *= 2; interval > degradedProbeMax {
+			interval = degradedProbeMax
+		}
+	}
+
+	d.mutex.Lock()
+	pending := d.pendingDeletes
+	d.pendingDeletes = make(map[string]bool)
+	d.active = false
+	since := d.since
+	d.mutex.Unlock()
+
+	log.Info("Filesystem is writable again, leaving degraded mode",
+		zap.String("path", path), zap.Duration("degradedFor", time.Since(since)))
+	q.health.Set(healthFilesystem, true, "")
+	q.recoverFromDegraded(pending)
+}
+
+// recoverFromDegraded 清理降级期间删除的容器，并重新同步运行中容器的配额
+func (q *RFSQuota) recoverFromDegraded(pendingDeletes map[string]bool) {
+	for containerID := range pendingDeletes {
+		entry, exists := q.stateManager.GetEntry(containerID)
+		if !exists {
+			continue
+		}
+		ctx := log.WithFields(q.ctx, zap.String("container", containerID))
+		if err := q.sched.Do(ctx, containerID, sched.Delete, func(ctx context.Context) error {
+			return q.removeQuota(ctx, containerID, entry.ProjectID)
+		}); err != nil {
+			log.Error("Failed to remove deferred quota", zap.String("container", containerID), zap.Error(err))
+		}
+	}
+
+	if q.client == nil {
+		return
+	}
+	if err := q.syncState(); err != nil {
+		log.Error("State sync after recovery failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// CheckDrift 逐个项目对比状态文件、目录上的项目 ID（xfs_io stat）与内核中的块和 inode 限额，
+// 并找出范围内没有条目却仍有限额的项目 ID。repair 时在项目的队列上重新检查并修复；
+// 记录的目录已不存在的条目只报告，由对账移除
+func (q *RFSQuota) CheckDrift(repair bool) (api.DriftResponse, error) {
+	if repair {
+		if q.standby.Load() {
+			return api.DriftResponse{}, api.ErrStandby
+		}
+		if q.degraded.Active() {
+			return api.DriftResponse{}, fmt.Errorf("filesystem is degraded, refusing to repair drift")
+		}
+		if r, ok := q.stateManager.Rebalance(); ok && (r.State == xfs.RebalanceRunning || r.State == xfs.RebalanceRollingBack) {
+			return api.DriftResponse{}, fmt.Errorf("%w: project ID rebalance is %s", api.ErrConflict, r.State)
+		}
+	}
+
+	usages, err := projectUsages(q.ctx)
+	if err != nil {
+		return api.DriftResponse{}, err
+	}
+	projects := make(map[uint32][]xfs.Entry)
+	for _, entry := range q.stateManager.ListEntries() {
+		projects[entry.ProjectID] = append(projects[entry.ProjectID], entry)
+	}
+	ids := make([]uint32, 0, len(projects))
+	for id := range projects {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// 迁移中的项目目录正在改标，跳过
+	moving := make(map[uint32]bool)
+	if r, ok := q.stateManager.Rebalance(); ok {
+		for _, m := range r.Projects {
+			if m.Status == xfs.MoveMoving {
+				moving[m.OldID], moving[m.NewID] = true, true
+			}
+		}
+	}
+
+	resp := api.DriftResponse{At: time.Now(), Repair: repair, Projects: len(ids), Drift: []api.Drift{}}
+	for _, projID := range ids {
+		if moving[projID] {
+			continue
+		}
+		entries := projects[projID]
+		drift := q.projectDrift(q.ctx, projID, entries, usages[projID])
+		if repair && len(drift) > 0 {
+			drift = q.repairProjectDrift(projID, entryKeys(entries)[0], drift)
+		}
+		resp.Drift = append(resp.Drift, drift...)
+	}
+
+	for _, id := range q.orphanProjects(usages) {
+		d := api.Drift{
+			Kind:      api.DriftOrphanLimits,
+			ProjectID: id,
+			Expected:  "no limits",
+			Actual:    usageLimits(usages[id]),
+		}
+		if repair {
+			if err := q.clearOrphan(id); err != nil {
+				d.Error = err.Error()
+			} else {
+				d.Repaired = true
+			}
+		}
+		resp.Drift = append(resp.Drift, d)
+	}
+
+	repaired := 0
+	for _, d := range resp.Drift {
+		if d.Repaired {
+			repaired++
+		}
+	}
+	log.Info("Drift check completed", zap.Int("projects", resp.Projects),
+		zap.Int("drift", len(resp.Drift)), zap.Int("repaired", repaired))
+	return resp, nil
+}
+
+// projectDrift 检查一个项目的目录与内核限额，不做修改
+func (q *RFSQuota) projectDrift(ctx context.Context, projID uint32, entries []xfs.Entry, usage xfs.ProjectUsage) []api.Drift {
+	var drift []api.Drift
+	keys := entryKeys(entries)
+	for _, entry := range entries {
+		for _, dir := range append([]string{entry.Upperdir}, entry.Paths...) {
+			if dir == "" {
+				continue
+			}
+			d := api.Drift{ProjectID: projID, Containers: []string{entry.ContainerID}, Path: dir, Expected: fmt.Sprint(projID)}
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				d.Kind, d.Actual = api.DriftMissingDir, "missing"
+				drift = append(drift, d)
+				continue
+			}
+			got, err := xfs.GetProjectIDFromXFS(ctx, dir)
+			switch {
+			case err != nil:
+				d.Kind, d.Actual = api.DriftUnreadable, err.Error()
+				drift = append(drift, d)
+			case got != projID:
+				d.Kind, d.Actual = api.DriftProjectID, fmt.Sprint(got)
+				drift = append(drift, d)
+			}
+		}
+	}
+
+	base := projectBase(entries)
+	soft, hard, ok := expectedBlockLimits(base)
+	if ok && (!limitMatches(soft, usage.SoftLimitBytes) || !limitMatches(hard, usage.HardLimitBytes)) {
+		drift = append(drift, api.Drift{
+			Kind:       api.DriftBlockLimits,
+			ProjectID:  projID,
+			Containers: keys,
+			Expected:   blockLimits(soft, hard),
+			Actual:     blockLimits(usage.SoftLimitBytes, usage.HardLimitBytes),
+		})
+	}
+	if base.InodeSoft != usage.InodeSoftLimit || base.InodeHard != usage.InodeHardLimit {
+		drift = append(drift, api.Drift{
+			Kind:       api.DriftInodeLimits,
+			ProjectID:  projID,
+			Containers: keys,
+			Expected:   inodeLimits(base.InodeSoft, base.InodeHard),
+			Actual:     inodeLimits(usage.InodeSoftLimit, usage.InodeHardLimit),
+		})
+	}
+	return drift
+}
+
+// repairProjectDrift 在项目的队列上重新检查并修复偏差，重新检查的结果取代 found；
+// 项目在此期间已移除时不再报告
+func (q *RFSQuota) repairProjectDrift(projID uint32, key string, found []api.Drift) []api.Drift {
+	var drift []api.Drift
+	err := q.sched.Do(q.ctx, key, sched.Admin, func(ctx context.Context) error {
+		// 分组成员的加入与移除需等待修复完成
+		q.groupMutex.Lock()
+		defer q.groupMutex.Unlock()
+
+		entries := q.entriesOf(projID)
+		if len(entries) == 0 {
+			return nil
+		}
+		// 内核中没有该项目的记录时按无限额处理，写入记录的限额
+		usage, _ := q.projectBackend(projID).GetUsage(ctx, projID)
+		drift = q.projectDrift(ctx, projID, entries, usage)
+		for i := range drift {
+			q.repairDrift(ctx, entries, &drift[i])
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range found {
+			found[i].Error = err.Error()
+		}
+		return found
+	}
+	return drift
+}
+
+// repairDrift 修复单处偏差，结果记录在 d 中
+func (q *RFSQuota) repairDrift(ctx context.Context, entries []xfs.Entry, d *api.Drift) {
+	base := projectBase(entries)
+	var err error
+	switch d.Kind {
+	case api.DriftMissingDir:
+		d.Error = "directory no longer exists, left to resync"
+		return
+	case api.DriftProjectID, api.DriftUnreadable:
+		if err = q.setProjectID(ctx, d.Path, d.ProjectID); err != nil {
+			q.noteFilesystemError(err, d.Path)
+			break
+		}
+		for _, entry := range entries {
+			if entry.Upperdir == d.Path {
+				q.tagOwner(ctx, d.Path, entry.ContainerID, entry.Group)
+			}
+		}
+	case api.DriftBlockLimits:
+		soft, hard := base.SoftLimit, base.HardLimit
+		if !base.LiftedUntil.IsZero() {
+			soft, hard = "0", "0"
+		}
+		err = q.setProjectQuota(ctx, d.ProjectID, soft, hard, true)
+	case api.DriftInodeLimits:
+		if err = q.checkProjectFence(d.ProjectID); err == nil {
+			err = q.projectBackend(d.ProjectID).SetInodeLimits(ctx, d.ProjectID, base.InodeSoft, base.InodeHard)
+		}
+	}
+	if err != nil {
+		d.Error = err.Error()
+		log.Ctx(ctx).Error("Failed to repair drift", zap.String("kind", d.Kind),
+			zap.Uint32("projectID", d.ProjectID), zap.String("path", d.Path), zap.Error(err))
+		return
+	}
+	d.Repaired = true
+	log.Ctx(ctx).Info("Drift repaired", zap.String("kind", d.Kind), zap.Uint32("projectID", d.ProjectID),
+		zap.String("path", d.Path), zap.String("expected", d.Expected), zap.String("actual", d.Actual))
+}
+
+// orphanProjects 返回项目 ID 范围内在内核中仍有限额、却没有条目也未分配的项目，
+// 项目 ID 迁移涉及的 ID 除外
+func (q *RFSQuota) orphanProjects(usages map[uint32]xfs.ProjectUsage) []uint32 {
+	known := make(map[uint32]bool)
+	for _, entry := range q.stateManager.ListEntries() {
+		known[entry.ProjectID] = true
+	}
+	if r, ok := q.stateManager.Rebalance(); ok {
+		for _, m := range r.Projects {
+			known[m.OldID], known[m.NewID] = true, true
+		}
+	}
+
+	minID, maxID := q.projectIDPool.Range()
+	var orphans []uint32
+	for id, u := range usages {
+		if id < minID || id > maxID || known[id] || q.projectIDPool.InUse(id) {
+			continue
+		}
+		if u.SoftLimitBytes != 0 || u.HardLimitBytes != 0 || u.InodeSoftLimit != 0 || u.InodeHardLimit != 0 {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+	return orphans
+}
+
+// errOrphanAllocated 表示遗留项目的 ID 在检查之后已被分配
+var errOrphanAllocated = errors.New("project ID was allocated since the check")
+
+// clearOrphan 清除遗留项目的限额，清除期间该 ID 不会被分配
+func (q *RFSQuota) clearOrphan(projID uint32) error {
+	if !q.projectIDPool.Claim(projID) {
+		return errOrphanAllocated
+	}
+	defer q.projectIDPool.Release(projID)
+	if len(q.entriesOf(projID)) > 0 {
+		return errOrphanAllocated
+	}
+	if err := q.checkProjectFence(projID); err != nil {
+		return err
+	}
+	if err := q.projectBackend(projID).ClearProject(q.ctx, projID); err != nil {
+		return err
+	}
+	q.applied.Forget(projID)
+	q.backends.Delete(projID)
+	log.Info("Orphaned project limits cleared", zap.Uint32("projectID", projID))
+	return nil
+}
+
+// expectedBlockLimits 返回条目应在内核中生效的块限额，临时解除期间为无限额；
+// 限额无法解析时不检查
+func expectedBlockLimits(entry xfs.Entry) (uint64, uint64, bool) {
+	if !entry.LiftedUntil.IsZero() {
+		return 0, 0, true
+	}
+	soft, errSoft := xfs.ParseSize(entry.SoftLimit)
+	hard, errHard := xfs.ParseSize(entry.HardLimit)
+	if errSoft != nil || errHard != nil {
+		return 0, 0, false
+	}
+	return soft, hard, true
+}
+
+// limitMatches 比较记录的限额与内核中的值，内核以 1KiB 为单位保存块限额
+func limitMatches(want, got uint64) bool {
+	if want > got {
+		return want-got < 1024
+	}
+	return got-want < 1024
+}
+
+func blockLimits(soft, hard uint64) string {
+	return fmt.Sprintf("soft %s, hard %s", xfs.FormatSize(soft), xfs.FormatSize(hard))
+}
+
+func inodeLimits(soft, hard uint64) string {
+	return fmt.Sprintf("inode soft %d, inode hard %d", soft, hard)
+}
+
+// usageLimits 描述内核中项目的块与 inode 限额
+func usageLimits(u xfs.ProjectUsage) string {
+	return blockLimits(u.SoftLimitBytes, u.HardLimitBytes) + ", " + inodeLimits(u.InodeSoftLimit, u.InodeHardLimit)
+}
+
+// entryKeys 返回条目键，按字典序排列
+func entryKeys(entries []xfs.Entry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.ContainerID)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/version"
+	"RootfsQuota/pkg/xfs"
+)
+
+// dumpEntry 为状态转储中的一条记录，只保留排查用到的字段
+type dumpEntry struct {
+	ContainerID string `json:"container_id"`
+	Namespace   string `json:"namespace,omitempty"`
+	ProjectID   uint32 `json:"project_id"`
+	Upperdir    string `json:"upperdir,omitempty"`
+	Group       string `json:"group,omitempty"`
+	SoftLimit   string `json:"soft_limit"`
+	HardLimit   string `json:"hard_limit"`
+}
+
+// runStateDump 在收到 SIGUSR1 时将内存中的状态写入日志，用于排查卡住的进程
+func (q *RFSQuota) runStateDump() {
+	for {
+		select {
+		case <-q.dumpCh:
+		case <-q.ctx.Done():
+			return
+		}
+		q.dumpState()
+	}
+}
+
+// dumpState 以两条结构化日志输出状态摘要与所有条目，只读取状态，不等待调度器
+func (q *RFSQuota) dumpState() {
+	now := time.Now()
+	fields := []zap.Field{
+		zap.String("version", version.Version),
+		zap.Bool("standby", q.standby.Load()),
+		zap.Bool("degraded", q.degraded.Active()),
+		zap.Bool("connected", q.client != nil),
+		zap.Any("pool", q.projectIDPool.Stats()),
+		zap.Any("scheduler", q.sched.Stats()),
+		zap.Int("retryQueue", len(q.retryCh)),
+		zap.Int64("eventsPending", q.eventsPending.Load()),
+		zap.Any("health", q.health.Conditions()),
+	}
+	if beat := q.loopBeat.Load(); beat != 0 {
+		fields = append(fields, zap.Duration("sinceLoopBeat", now.Sub(time.Unix(0, beat))))
+	}
+	if progress := q.eventProgress.Load(); progress != 0 {
+		fields = append(fields, zap.Duration("sinceEventProgress", now.Sub(time.Unix(0, progress))))
+	}
+	if mark, ok := q.stateManager.LastEvent(); ok {
+		fields = append(fields, zap.Any("lastEvent", mark), zap.Duration("sinceLastEvent", now.Sub(mark.Timestamp)))
+	}
+	if r, ok := q.stateManager.Rebalance(); ok {
+		fields = append(fields, zap.String("rebalance", r.State))
+	}
+	if q.meta != nil {
+		fields = append(fields, zap.Int("metadataCache", q.meta.size()))
+	}
+	unmanaged, failed := q.enforcement.counts()
+	fields = append(fields, zap.Int("unmanaged", unmanaged), zap.Int("failed", failed))
+
+	entries := q.stateManager.ListEntries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ContainerID < entries[j].ContainerID })
+	dump := make([]dumpEntry, 0, len(entries))
+	for _, entry := range entries {
+		dump = append(dump, newDumpEntry(entry))
+	}
+	log.Info("State dump", append(fields, zap.Int("entries", len(entries)))...)
+	log.Info("State dump entries", zap.Any("entries", dump))
+}
+
+func newDumpEntry(entry xfs.Entry) dumpEntry {
+	return dumpEntry{
+		ContainerID: entry.ContainerID,
+		Namespace:   entry.Namespace,
+		ProjectID:   entry.ProjectID,
+		Upperdir:    entry.Upperdir,
+		Group:       entry.Group,
+		SoftLimit:   entry.SoftLimit,
+		HardLimit:   entry.HardLimit,
+	}
+}
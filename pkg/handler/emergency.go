@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/xfs"
+	"RootfsQuota/pkg/xfs/report"
+)
+
+const (
+	// healthDisk 为文件系统可用空间的健康状况名称
+	healthDisk = "disk"
+	// notifyTimeout 为单次告警发送的超时时间
+	notifyTimeout = 10 * time.Second
+)
+
+// 告急报告的状态
+const (
+	emergencyCritical = "critical"
+	emergencyResolved = "resolved"
+)
+
+// emergency 为磁盘空间告急处理的状态与通知渠道，只由监控协程访问
+type emergency struct {
+	active bool
+	// stop 为可牺牲容器的选择器，nil 表示不停止容器
+	stop    *api.Selector
+	stopped map[string]bool
+	webhook *notify.Webhook
+	events  *notify.NodeEvents
+}
+
+// emergencyReport 为发送到 webhook 的告急报告
+type emergencyReport struct {
+	Node        string               `json:"node"`
+	Timestamp   time.Time            `json:"timestamp"`
+	State       string               `json:"state"`
+	Path        string               `json:"path"`
+	FreeBytes   uint64               `json:"free_bytes"`
+	TotalBytes  uint64               `json:"total_bytes"`
+	FreePercent float64              `json:"free_percent"`
+	Top         []emergencyContainer `json:"top,omitempty"`
+	// Stopped 为本次停止的可牺牲容器
+	Stopped []string `json:"stopped,omitempty"`
+	// HeldBy 为暂停停止容器的维护窗口
+	HeldBy string `json:"held_by,omitempty"`
+}
+
+// emergencyContainer 为告急报告中按用量排序的容器，超过软限制的排在前面
+type emergencyContainer struct {
+	ContainerID    string `json:"container_id"`
+	Namespace      string `json:"namespace,omitempty"`
+	ProjectID      uint32 `json:"project_id"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	OverSoft       bool   `json:"over_soft"`
+}
+
+func newEmergency(cfg config.EmergencyConfig) (*emergency, error) {
+	em := &emergency{stopped: make(map[string]bool)}
+	if cfg.StopSelector != "" {
+		sel, err := api.ParseSelector(cfg.StopSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emergency.stop_selector: %v", err)
+		}
+		em.stop = &sel
+	}
+	if cfg.WebhookURL != "" {
+		em.webhook = notify.NewWebhook(cfg.WebhookURL)
+	}
+	if cfg.KubernetesEvents {
+		events, err := notify.NewNodeEvents(cfg.NodeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up kubernetes events: %v", err)
+		}
+		em.events = events
+	}
+	return em, nil
+}
+
+// runEmergencyMonitor 周期检查文件系统可用空间，低于告急阈值时上报并停止可牺牲容器
+func (q *RFSQuota) runEmergencyMonitor() {
+	ticker := time.NewTicker(time.Duration(q.cfg.Emergency.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		q.checkDiskSpace()
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) checkDiskSpace() {
+	cfg := q.cfg.Emergency
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(cfg.Path, &st); err != nil {
+		log.Warn("Failed to check free space", zap.String("path", cfg.Path), zap.Error(err))
+		return
+	}
+	total := st.Blocks * uint64(st.Bsize)
+	free := st.Bavail * uint64(st.Bsize)
+	if total == 0 {
+		return
+	}
+	percent := float64(free) / float64(total) * 100
+	metrics.FilesystemFreeBytes.Set(float64(free))
+
+	rep := emergencyReport{
+		Node:        cfg.NodeName,
+		Timestamp:   time.Now(),
+		Path:        cfg.Path,
+		FreeBytes:   free,
+		TotalBytes:  total,
+		FreePercent: percent,
+	}
+	em := q.emergency
+	switch {
+	case !em.active && percent < cfg.CriticalPercent:
+		em.active = true
+		metrics.EmergencyActive.Set(1)
+		message := fmt.Sprintf("%.1f%% free on %s, below critical threshold %.1f%%", percent, cfg.Path, cfg.CriticalPercent)
+		q.health.Set(healthDisk, false, message)
+		if q.standby.Load() {
+			return
+		}
+		rep.State = emergencyCritical
+		rep.Top = q.topContainers(cfg.TopN)
+		if window, held := q.enforcementHeld(percent); held {
+			rep.HeldBy = window
+			log.Warn("Maintenance window active, not stopping sacrificial containers", zap.String("window", window))
+		} else {
+			rep.Stopped = q.stopSacrificial()
+		}
+		log.Error("CRITICAL: filesystem almost full, entering emergency mode",
+			zap.String("path", cfg.Path),
+			zap.Float64("freePercent", percent),
+			zap.Any("top", rep.Top),
+			zap.Strings("stopped", rep.Stopped))
+		q.notifyEmergency(rep, notify.EventWarning, "RootfsDiskCritical", message+topSummary(rep.Top))
+	case em.active && percent >= cfg.ResolvePercent:
+		em.active = false
+		em.stopped = make(map[string]bool)
+		metrics.EmergencyActive.Set(0)
+		q.health.Set(healthDisk, true, "")
+		if q.standby.Load() {
+			return
+		}
+		rep.State = emergencyResolved
+		log.Info("Free space recovered, leaving emergency mode",
+			zap.String("path", cfg.Path), zap.Float64("freePercent", percent))
+		q.notifyEmergency(rep, notify.EventNormal, "RootfsDiskRecovered",
+			fmt.Sprintf("%.1f%% free on %s, emergency resolved", percent, cfg.Path))
+	case em.active && !q.standby.Load():
+		// 告急期间新启动的可牺牲容器同样停止，维护窗口内暂停，窗口结束后补做
+		if _, held := q.enforcementHeld(percent); held {
+			return
+		}
+		if stopped := q.stopSacrificial(); len(stopped) > 0 {
+			log.Warn("Stopped sacrificial containers during emergency", zap.Strings("stopped", stopped))
+		}
+	}
+}
+
+// topContainers 返回用量最大的 n 个项目，超过软限制的优先，共享项目只列一次
+func (q *RFSQuota) topContainers(n int) []emergencyContainer {
+	list, err := xfs.ListProjectUsage(q.ctx)
+	if err != nil {
+		log.Warn("Failed to query usage for emergency report", zap.Error(err))
+		return nil
+	}
+	usages := make(map[uint32]report.Usage)
+	for _, u := range list {
+		if _, seen := usages[u.ProjectID]; !seen {
+			usages[u.ProjectID] = u
+		}
+	}
+
+	entries := q.stateManager.ListEntries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ContainerID < entries[j].ContainerID })
+	seen := make(map[uint32]bool)
+	var top []emergencyContainer
+	for _, entry := range entries {
+		usage, ok := usages[entry.ProjectID]
+		if !ok || seen[entry.ProjectID] {
+			continue
+		}
+		seen[entry.ProjectID] = true
+		top = append(top, emergencyContainer{
+			ContainerID:    entry.ContainerID,
+			Namespace:      q.entryNamespace(entry),
+			ProjectID:      entry.ProjectID,
+			UsedBytes:      usage.UsedBytes,
+			SoftLimitBytes: usage.SoftLimitBytes,
+			HardLimitBytes: usage.HardLimitBytes,
+			OverSoft:       usage.SoftLimitBytes > 0 && usage.UsedBytes > usage.SoftLimitBytes,
+		})
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].OverSoft != top[j].OverSoft {
+			return top[i].OverSoft
+		}
+		return top[i].UsedBytes > top[j].UsedBytes
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// stopSacrificial 以 SIGKILL 停止标签匹配 stop_selector 且仍在运行的容器，返回本次停止的容器。
+// 停止只阻止继续写入，可写层的空间在编排系统删除容器后才会释放
+func (q *RFSQuota) stopSacrificial() []string {
+	em := q.emergency
+	if em.stop == nil || q.client == nil {
+		return nil
+	}
+	var stopped []string
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" || isGroupKey(entry.ContainerID) || strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || em.stopped[entry.ContainerID] {
+			continue
+		}
+		labels := q.containerLabels(entry)
+		if labels == nil || !em.stop.Matches(labels) {
+			continue
+		}
+		ctx := q.namespaceContext(q.entryNamespace(entry))
+		if err := q.killTask(ctx, entry.ContainerID); err != nil {
+			log.Error("Failed to stop sacrificial container", zap.String("container", entry.ContainerID), zap.Error(err))
+			continue
+		}
+		em.stopped[entry.ContainerID] = true
+		metrics.EmergencyStops.Inc()
+		stopped = append(stopped, entry.ContainerID)
+	}
+	return stopped
+}
+
+func (q *RFSQuota) killTask(ctx context.Context, containerID string) error {
+	container, err := q.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return task.Kill(ctx, syscall.SIGKILL)
+}
+
+// notifyEmergency 将报告发送到 webhook 并为节点创建 Kubernetes 事件，失败只记录日志
+func (q *RFSQuota) notifyEmergency(rep emergencyReport, eventType, reason, message string) {
+	ctx, cancel := context.WithTimeout(q.ctx, notifyTimeout)
+	defer cancel()
+	if em := q.emergency; em.webhook != nil {
+		if err := em.webhook.Post(ctx, rep); err != nil {
+			log.Warn("Failed to send emergency webhook", zap.Error(err))
+		}
+	}
+	if em := q.emergency; em.events != nil {
+		if err := em.events.Emit(ctx, eventType, reason, message); err != nil {
+			log.Warn("Failed to emit emergency event", zap.Error(err))
+		}
+	}
+}
+
+// topSummary 将用量最大的容器格式化为事件消息的后缀
+func topSummary(top []emergencyContainer) string {
+	if len(top) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(top))
+	for _, c := range top {
+		parts = append(parts, fmt.Sprintf("%s (%d MiB)", c.ContainerID, c.UsedBytes>>20))
+	}
+	return "; largest: " + strings.Join(parts, ", ")
+}
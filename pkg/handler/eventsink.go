@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/eventsink"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// sinkCloseTimeout 为退出时等待已排队事件发布完成的时间
+const sinkCloseTimeout = 5 * time.Second
+
+// publishQuotaEvent 发布条目的配额事件，未配置事件接收端时不做任何事；usage 为 nil 时不带用量
+func (q *RFSQuota) publishQuotaEvent(typ string, entry xfs.Entry, usage *xfs.ProjectUsage) {
+	if q.sink == nil {
+		return
+	}
+	e := eventsink.Event{
+		Type:        typ,
+		ContainerID: entry.ContainerID,
+		Namespace:   q.entryNamespace(entry),
+		ProjectID:   entry.ProjectID,
+		Group:       entry.Group,
+		SoftLimit:   entry.SoftLimit,
+		HardLimit:   entry.HardLimit,
+	}
+	if usage != nil {
+		e.UsedBytes = usage.UsedBytes
+		e.SoftLimitBytes = usage.SoftLimitBytes
+		e.HardLimitBytes = usage.HardLimitBytes
+	}
+	q.sink.Publish(e)
+}
+
+// publishQuotaSet 在配额设置成功后发布 QuotaSet 事件
+func (q *RFSQuota) publishQuotaSet(key string) {
+	if entry, exists := q.stateManager.GetEntry(key); exists {
+		q.publishQuotaEvent(eventsink.QuotaSet, entry, nil)
+	}
+}
+
+// runExceededMonitor 周期读取所有项目的用量，项目用量超出软限制（无软限制时为达到硬限制）时发布一次
+// QuotaExceeded 事件，回落后再次超出时重新发布；分组成员不单独发布，由分组条目代表整个项目
+func (q *RFSQuota) runExceededMonitor() {
+	ticker := time.NewTicker(time.Duration(q.cfg.EventSink.ExceededIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	exceeded := make(map[uint32]bool)
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+		if q.standby.Load() {
+			continue
+		}
+		snapshot, err := q.latestUsage()
+		if err != nil {
+			log.Warn("Failed to query usage for exceeded events", zap.Error(err))
+			continue
+		}
+		usages := snapshot.Projects
+
+		current := make(map[uint32]bool)
+		for _, entry := range q.stateManager.ListEntries() {
+			if entry.Group != "" {
+				continue
+			}
+			usage, ok := usages[entry.ProjectID]
+			if !ok || !overLimit(usage) {
+				continue
+			}
+			current[entry.ProjectID] = true
+			if !exceeded[entry.ProjectID] {
+				q.publishQuotaEvent(eventsink.QuotaExceeded, entry, &usage)
+			}
+		}
+		exceeded = current
+	}
+}
+
+// overLimit 判断项目用量是否超出软限制，未设置软限制时以硬限制为准
+func overLimit(usage xfs.ProjectUsage) bool {
+	if usage.SoftLimitBytes > 0 {
+		return usage.UsedBytes > usage.SoftLimitBytes
+	}
+	return usage.HardLimitBytes > 0 && usage.UsedBytes >= usage.HardLimitBytes
+}
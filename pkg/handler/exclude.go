@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+)
+
+// errExcluded 表示容器命中了配置的排除规则
+var errExcluded = errors.New("container matches an exclude rule")
+
+// excludeRule 为编译后的排除规则
+type excludeRule struct {
+	config.ExcludeRule
+	sel    *api.Selector
+	nameRe *regexp.Regexp
+}
+
+func newExcludeRules(rules []config.ExcludeRule) ([]excludeRule, error) {
+	compiled := make([]excludeRule, 0, len(rules))
+	for _, rule := range rules {
+		r := excludeRule{ExcludeRule: rule}
+		if rule.Selector != "" {
+			sel, err := api.ParseSelector(rule.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+			}
+			r.sel = &sel
+		}
+		if rule.NameRegex != "" {
+			re, err := regexp.Compile("^(?:" + rule.NameRegex + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %v", rule.Name, err)
+			}
+			r.nameRe = re
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// needsContainer 判断规则是否需要容器的元数据
+func (r excludeRule) needsContainer() bool {
+	return len(r.PodNamespaces) > 0 || r.sel != nil || r.Image != "" || r.nameRe != nil
+}
+
+// matches 判断容器是否满足规则的所有条件；info 为 nil 表示元数据不可用，此时需要元数据的规则不命中
+func (r excludeRule) matches(namespace, containerID string, info *containerRecord) bool {
+	if len(r.Namespaces) > 0 && !slices.Contains(r.Namespaces, namespace) {
+		return false
+	}
+	if !r.needsContainer() {
+		return true
+	}
+	if info == nil {
+		return false
+	}
+	if len(r.PodNamespaces) > 0 && !slices.Contains(r.PodNamespaces, info.Labels[labelPodNamespace]) {
+		return false
+	}
+	if r.sel != nil && !r.sel.Matches(info.Labels) {
+		return false
+	}
+	if r.Image != "" {
+		if ok, _ := path.Match(r.Image, info.Image); !ok {
+			return false
+		}
+	}
+	if r.nameRe != nil {
+		name := info.Labels[labelContainerName]
+		if name == "" {
+			name = containerID
+		}
+		if !r.nameRe.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// excludedBy 返回容器命中的首个排除规则名，未命中时返回空；容器元数据只在规则需要时读取
+func (q *RFSQuota) excludedBy(ctx context.Context, namespace, containerID string) string {
+	var info *containerRecord
+	loaded := false
+	for _, rule := range q.excludeRules {
+		if rule.needsContainer() && !loaded {
+			loaded = true
+			if q.client != nil {
+				if r, err := q.lookupContainer(ctx, containerID); err != nil {
+					log.Ctx(ctx).Debug("Failed to load container for exclude rules", zap.Error(err))
+				} else {
+					info = &r
+				}
+			}
+		}
+		if rule.matches(namespace, containerID, info) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// checkExclude 在容器命中排除规则时返回 errExcluded，ctx 须带有容器所在的命名空间
+func (q *RFSQuota) checkExclude(ctx context.Context, namespace, containerID string) error {
+	if len(q.excludeRules) == 0 {
+		return nil
+	}
+	rule := q.excludedBy(ctx, namespace, containerID)
+	if rule == "" {
+		return nil
+	}
+	metrics.QuotaExclusions.WithLabelValues(rule).Inc()
+	return fmt.Errorf("%w %s", errExcluded, rule)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/xfs"
+)
+
+// Explain 说明容器的哪些写入计入配额：根文件系统落在可写层，纳入项目的额外目录同样计入，
+// tmpfs 占用内存，绑定挂载按宿主机路径上的项目 ID 判断
+func (q *RFSQuota) Explain(containerID string) (api.ExplainResponse, error) {
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists || isGroupKey(containerID) {
+		return api.ExplainResponse{}, fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+	base := entry
+	if entry.Group != "" {
+		if group, ok := q.stateManager.GetEntry(entry.Group); ok {
+			base = group
+		}
+	}
+	resp := api.ExplainResponse{
+		ContainerID: containerID,
+		Namespace:   entry.Namespace,
+		ProjectID:   entry.ProjectID,
+		Group:       entry.Group,
+		SoftLimit:   base.SoftLimit,
+		HardLimit:   base.HardLimit,
+		Pinned:      base.Pinned,
+	}
+	if !base.LiftedUntil.IsZero() {
+		until := base.LiftedUntil
+		resp.LiftedUntil = &until
+	}
+	resp.Paths = append(resp.Paths, api.ExplainPath{
+		Path:     "/",
+		HostPath: entry.Upperdir,
+		Kind:     api.ExplainRootfs,
+		Governed: true,
+		Reason:   "writes to the root filesystem land in the upperdir",
+	})
+
+	// 共享项目中分组条目的目录（Pod 日志、emptyDir）同样属于该容器的项目
+	var projectPaths []string
+	for _, other := range q.entriesOf(entry.ProjectID) {
+		projectPaths = append(projectPaths, other.Paths...)
+	}
+	mounted := make(map[string]bool)
+
+	var mounts []specMount
+	if strings.HasPrefix(containerID, buildkitKeyPrefix) {
+		resp.Warning = "BuildKit snapshot, no container spec"
+	} else if isExternalKey(containerID) {
+		resp.Warning = "external workload reported by the lifecycle webhook, no container spec"
+	} else if r, err := q.lookupContainer(q.namespaceContext(q.entryNamespace(entry)), containerID); err != nil {
+		resp.Warning = fmt.Sprintf("failed to read container mounts: %v", err)
+	} else {
+		mounts = r.Mounts
+	}
+	for _, m := range mounts {
+		p := api.ExplainPath{Path: m.Destination, Kind: api.ExplainBind, HostPath: m.Source}
+		switch {
+		case m.Type == "tmpfs":
+			p.Kind, p.HostPath = api.ExplainTmpfs, ""
+			p.Reason = "memory-backed tmpfs, counted against the memory limit instead"
+		case m.ReadOnly:
+			p.Reason = "mounted read-only"
+		case underAny(m.Source, projectPaths):
+			mounted[m.Source] = true
+			p.Governed, p.Reason = true, "host directory is part of the project"
+		default:
+			p.Governed, p.Reason = q.explainBind(m.Source, entry.ProjectID)
+		}
+		resp.Paths = append(resp.Paths, p)
+	}
+
+	sort.Strings(projectPaths)
+	for _, path := range projectPaths {
+		if mounted[path] {
+			continue
+		}
+		resp.Paths = append(resp.Paths, api.ExplainPath{
+			HostPath: path,
+			Kind:     api.ExplainExtra,
+			Governed: true,
+			Reason:   "added to the project (pod logs, emptyDir or declared writable path)",
+		})
+	}
+	return resp, nil
+}
+
+// explainBind 按宿主机路径上的项目 ID 判断绑定挂载的写入是否计入项目
+func (q *RFSQuota) explainBind(source string, projID uint32) (bool, string) {
+	got, err := xfs.GetProjectIDFromXFS(q.ctx, source)
+	switch {
+	case err != nil:
+		return false, fmt.Sprintf("project ID unreadable, likely not on a project quota filesystem: %v", err)
+	case got == projID:
+		return true, "host path carries the container's project ID"
+	case got == 0:
+		return false, "host path has no project ID"
+	}
+	return false, fmt.Sprintf("host path belongs to project %d", got)
+}
+
+// underAny 判断 path 是否为 dirs 中某个目录或其子路径
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// declaredExtraPaths 读取容器通过标签或 OCI 注解声明的额外可写目录，标签优先；未启用或读取失败时返回 nil
+func (q *RFSQuota) declaredExtraPaths(ctx context.Context, containerID string) []string {
+	cfg := q.cfg.ExtraPaths
+	if len(cfg.Allowlist) == 0 || q.client == nil || strings.HasPrefix(containerID, buildkitKeyPrefix) {
+		return nil
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		log.Ctx(ctx).Debug("Failed to load container for extra paths", zap.Error(err))
+		return nil
+	}
+	value := info.Labels[cfg.Key]
+	if value == "" {
+		value = info.Annotations[cfg.Key]
+	}
+
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// addExtraPaths 将容器声明的额外目录纳入其项目，返回成功纳入的目录；未通过校验或设置失败的目录跳过并告警
+func (q *RFSQuota) addExtraPaths(ctx context.Context, containerID, upperdir string, projID uint32) []string {
+	var added []string
+	for _, path := range q.declaredExtraPaths(ctx, containerID) {
+		if err := q.checkExtraPath(ctx, path, upperdir, projID); err != nil {
+			log.Ctx(ctx).Warn("Skipping declared extra path", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		if err := q.setProjectID(ctx, path, projID); err != nil {
+			log.Ctx(ctx).Warn("Failed to add extra path to project", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		added = append(added, path)
+	}
+	if len(added) > 0 {
+		log.Ctx(ctx).Info("Extra paths added to project", zap.Strings("paths", added), zap.Uint32("projectID", projID))
+	}
+	return added
+}
+
+// checkExtraPath 校验额外目录：必须位于 extra_paths.allowlist 内并满足 checkProjectDir
+func (q *RFSQuota) checkExtraPath(ctx context.Context, path, upperdir string, projID uint32) error {
+	if err := xfs.ValidatePath(path); err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %v", err)
+	}
+	allowed := false
+	for _, dir := range q.cfg.ExtraPaths.Allowlist {
+		if resolved == dir || strings.HasPrefix(resolved, dir+"/") {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%s is outside extra_paths.allowlist", resolved)
+	}
+	return checkProjectDir(ctx, resolved, upperdir, projID)
+}
+
+// checkProjectDir 校验纳入容器项目的目录：已存在、与可写层位于同一文件系统（项目限额按文件系统生效，
+// 否则不共享预算），且不属于其他项目
+func checkProjectDir(ctx context.Context, resolved, upperdir string, projID uint32) error {
+	var st, upper syscall.Stat_t
+	if err := syscall.Stat(resolved, &st); err != nil {
+		return fmt.Errorf("failed to stat path: %v", err)
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		return fmt.Errorf("%s is not a directory", resolved)
+	}
+	if err := syscall.Stat(upperdir, &upper); err != nil {
+		return fmt.Errorf("failed to stat upperdir: %v", err)
+	}
+	if st.Dev != upper.Dev {
+		return fmt.Errorf("%s is not on the filesystem of the upperdir", resolved)
+	}
+
+	current, err := xfs.GetProjectIDFromXFS(ctx, resolved)
+	if err != nil {
+		return err
+	}
+	if current != 0 && current != projID {
+		return fmt.Errorf("%s already belongs to project %d", resolved, current)
+	}
+	return nil
+}
+
+// releaseExtraPaths 在配额移除时将条目额外目录的项目 ID 归零，避免项目 ID 复用后被记入新容器；
+// 分组条目的目录（Pod 日志目录、emptyDir 等）在共享项目释放时归零
+func (q *RFSQuota) releaseExtraPaths(ctx context.Context, entry xfs.Entry) {
+	for _, path := range entry.Paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if owner, ok := q.upperdirOwner(entry.ContainerID, path); ok {
+			log.Ctx(ctx).Warn("Extra path overlaps another container's upperdir, not resetting its project ID",
+				zap.String("path", path), zap.String("owner", owner))
+			continue
+		}
+		if err := q.projectBackend(entry.ProjectID).SetProjectID(ctx, path, 0); err != nil {
+			log.Ctx(ctx).Warn("Failed to reset project ID of extra path", zap.String("path", path), zap.Error(err))
+		}
+	}
+}
+
+// upperdirOwner 返回可写层位于 path 之下或包含 path 的其他条目，这些目录的项目 ID 由该条目管理，不能随 key 一并归零
+func (q *RFSQuota) upperdirOwner(key, path string) (string, bool) {
+	for _, other := range q.stateManager.ListEntries() {
+		if other.ContainerID == key || other.Upperdir == "" {
+			continue
+		}
+		if pathWithin(path, other.Upperdir) || pathWithin(other.Upperdir, path) {
+			return other.ContainerID, true
+		}
+	}
+	return "", false
+}
+
+// pathWithin 判断 path 是否为 dir 或位于 dir 之下
+func pathWithin(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	return path == dir || dir == "/" || strings.HasPrefix(path, dir+"/")
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/version"
+)
+
+// logFeatures 在启动时记录已启用与已关闭的子系统
+func (q *RFSQuota) logFeatures() {
+	var disabled []string
+	for _, name := range config.Features {
+		if !q.cfg.Features.Enabled(name) {
+			disabled = append(disabled, name)
+		}
+	}
+	log.Info("Active features",
+		zap.String("version", version.Version),
+		zap.Strings("enabled", q.cfg.Features.Active()),
+		zap.Strings("disabled", disabled))
+	if !q.cfg.Features.Enabled(config.FeatureCollector) && q.cfg.Features.Enabled(config.FeatureNotifier) &&
+		(q.cfg.Alerts.Enabled || len(q.chats) > 0 || q.sink != nil) {
+		log.Warn("Collector disabled, usage alerts and notifications have no usage snapshot to evaluate")
+	}
+	if q.cfg.AsyncBackend.Enabled {
+		log.Warn("Experimental async backend enabled, XFS limits are written with quotactl in batches",
+			zap.Int("maxBatch", q.cfg.AsyncBackend.MaxBatch))
+	}
+}
+
+// Version 返回构建版本与子系统开关
+func (q *RFSQuota) Version() api.VersionResponse {
+	features := make(map[string]bool, len(config.Features))
+	for _, name := range config.Features {
+		features[name] = q.cfg.Features.Enabled(name)
+	}
+	return api.VersionResponse{
+		Version:   version.Version,
+		GoVersion: runtime.Version(),
+		Features:  features,
+	}
+}
+
+// versionHandler 在指标端口上提供 /version，关闭 api 时也可查询
+func (q *RFSQuota) versionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(q.Version()); err != nil {
+			log.Error("Failed to write response", zap.Error(err))
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// healthFence 为隔离纪元的健康状况名称
+const healthFence = "fence"
+
+// beginEpoch 在成为主实例时取得新的隔离纪元，仍在运行的旧实例此后的修改会被拒绝
+func (q *RFSQuota) beginEpoch() error {
+	epoch, err := q.stateManager.AdvanceEpoch()
+	if err != nil {
+		return fmt.Errorf("failed to advance fencing epoch: %w", err)
+	}
+	q.health.Set(healthFence, true, "")
+	log.Info("Fencing epoch acquired", zap.Uint64("epoch", epoch))
+	return nil
+}
+
+// checkFence 在修改目录前检查其纪元标记，目录已被更新的实例标记时拒绝修改
+func (q *RFSQuota) checkFence(path string) error {
+	epoch := q.stateManager.Epoch()
+	if epoch == 0 {
+		return nil
+	}
+	tagged, err := xfs.GetEpochTag(path)
+	if err != nil || tagged <= epoch {
+		return nil
+	}
+	err = fmt.Errorf("%w: %s was tagged at epoch %d, ours is %d", xfs.ErrFenced, path, tagged, epoch)
+	q.noteFenced(err)
+	return err
+}
+
+// checkProjectFence 在写入项目限额或清除项目前检查状态文件的纪元与项目可写层的纪元标记，
+// 避免旧实例覆盖新实例刚写入的限额
+func (q *RFSQuota) checkProjectFence(projID uint32) error {
+	if err := q.stateManager.CheckEpoch(); err != nil {
+		q.noteFenced(err)
+		return err
+	}
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.ProjectID == projID && entry.Upperdir != "" {
+			return q.checkFence(entry.Upperdir)
+		}
+	}
+	return nil
+}
+
+// noteFenced 在发现更新的实例已接管时转入备用模式，不再做任何修改，直到被重新提升
+func (q *RFSQuota) noteFenced(err error) {
+	if !errors.Is(err, xfs.ErrFenced) || q.standby.Swap(true) {
+		return
+	}
+	q.stateManager.SetReadOnly(true)
+	q.health.Set(healthFence, false, err.Error())
+	log.Error("CRITICAL: a newer daemon instance took over, refusing further mutations and entering standby", zap.Error(err))
+}
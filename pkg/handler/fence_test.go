@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/health"
+	"RootfsQuota/pkg/xfs"
+)
+
+// fakeBackend records the quota calls made through it.
+type fakeBackend struct {
+	mu     sync.Mutex
+	tags   map[string]uint32
+	limits map[uint32][2]string
+	calls  []string
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{tags: map[string]uint32{}, limits: map[uint32][2]string{}}
+}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) SetProjectID(ctx context.Context, path string, projid uint32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tags[path] = projid
+	b.calls = append(b.calls, "tag")
+	return nil
+}
+
+func (b *fakeBackend) SetLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limits[projid] = [2]string{soft, hard}
+	b.calls = append(b.calls, "limits")
+	return nil
+}
+
+func (b *fakeBackend) SetInodeLimits(ctx context.Context, projid uint32, soft, hard uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, "inodes")
+	return nil
+}
+
+func (b *fakeBackend) SetRealtimeLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, "realtime")
+	return nil
+}
+
+func (b *fakeBackend) GetUsage(ctx context.Context, projid uint32) (xfs.ProjectUsage, error) {
+	return xfs.ProjectUsage{}, nil
+}
+
+func (b *fakeBackend) ClearProject(ctx context.Context, projid uint32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.limits, projid)
+	b.calls = append(b.calls, "clear")
+	return nil
+}
+
+func (b *fakeBackend) callCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.calls)
+}
+
+// fencedDaemon returns a daemon sharing the state file at path that has
+// acquired a fencing epoch, with all projects on backend.
+func fencedDaemon(t *testing.T, path string, backend *fakeBackend, projIDs ...uint32) *RFSQuota {
+	t.Helper()
+	sm, err := xfs.NewStateManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &RFSQuota{
+		cfg:           &config.Config{StateFilePath: path},
+		stateManager:  sm,
+		ctx:           context.Background(),
+		health:        health.NewStatus(),
+		applied:       xfs.NewAppliedLimits(),
+		projectIDPool: xfs.NewProjectIDPool(1000, 2000),
+	}
+	for _, id := range projIDs {
+		q.backends.Store(id, backend)
+	}
+	if err := q.beginEpoch(); err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestFenceLimitWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	backend := newFakeBackend()
+	ctx := context.Background()
+
+	old := fencedDaemon(t, path, backend, 1001)
+	if err := old.stateManager.PutEntry(xfs.Entry{ContainerID: "c1", ProjectID: 1001, InodeHard: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.setProjectQuota(ctx, 1001, "1g", "2g", false); err != nil {
+		t.Fatalf("setProjectQuota() before takeover = %v", err)
+	}
+
+	// a second instance takes over while the first one is still running
+	current := fencedDaemon(t, path, backend, 1001)
+	if current.stateManager.Epoch() <= old.stateManager.Epoch() {
+		t.Fatalf("new epoch %d is not above %d", current.stateManager.Epoch(), old.stateManager.Epoch())
+	}
+	if err := current.setProjectQuota(ctx, 1001, "3g", "4g", false); err != nil {
+		t.Fatalf("setProjectQuota() of the new instance = %v", err)
+	}
+
+	calls := backend.callCount()
+	entry, _ := old.stateManager.GetEntry("c1")
+	for name, write := range map[string]func() error{
+		"block limits": func() error { return old.setProjectQuota(ctx, 1001, "1g", "2g", true) },
+		"extra limits": func() error { return old.setExtraLimits(ctx, entry) },
+		"release":      func() error { return old.releaseProject(ctx, "c1", 1001) },
+	} {
+		if err := write(); !errors.Is(err, xfs.ErrFenced) {
+			t.Errorf("%s by the old instance = %v, want %v", name, err, xfs.ErrFenced)
+		}
+	}
+	if got := backend.callCount(); got != calls {
+		t.Errorf("old instance made %d backend calls after the takeover", got-calls)
+	}
+	if got := backend.limits[1001]; got != [2]string{"3g", "4g"} {
+		t.Errorf("limits = %q, want the new instance's [3g 4g]", got)
+	}
+	if !old.standby.Load() {
+		t.Error("fenced instance did not enter standby")
+	}
+	if _, exists := current.stateManager.GetEntry("c1"); !exists {
+		t.Error("fenced release removed the entry")
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// FsckState 检查内存中状态的一致性，repair 时修复可自动修复的问题并压缩后保存；
+// 移除的条目的项目 ID 不归还，其遗留限额由垃圾回收清除
+func (q *RFSQuota) FsckState(repair bool) (xfs.FsckReport, error) {
+	if repair {
+		if q.standby.Load() {
+			return xfs.FsckReport{}, api.ErrStandby
+		}
+		if r, ok := q.stateManager.Rebalance(); ok && (r.State == xfs.RebalanceRunning || r.State == xfs.RebalanceRollingBack) {
+			return xfs.FsckReport{}, fmt.Errorf("%w: project ID rebalance is %s", api.ErrConflict, r.State)
+		}
+	}
+	minID, maxID := q.projectIDPool.Range()
+	report, err := q.stateManager.Fsck(minID, maxID, repair)
+	if err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return report, fmt.Errorf("failed to save repaired state: %v", err)
+	}
+	if repair {
+		repaired := 0
+		for _, f := range report.Findings {
+			if f.Repaired {
+				repaired++
+			}
+		}
+		log.Info("State repaired", zap.Int("findings", len(report.Findings)), zap.Int("repaired", repaired),
+			zap.Int("compacted", report.Compacted))
+	}
+	return report, nil
+}
+
+// checkLoadedState 在启动时对加载的状态做一次只读检查，发现的问题只记录警告，由运维通过 state fsck 修复
+func (q *RFSQuota) checkLoadedState() {
+	minID, maxID := q.projectIDPool.Range()
+	report, _ := q.stateManager.Fsck(minID, maxID, false)
+	for _, f := range report.Findings {
+		log.Warn("State check found a problem", zap.String("key", f.Key), zap.String("problem", f.Problem),
+			zap.String("detail", f.Detail), zap.String("fix", f.Fix))
+	}
+	if len(report.Findings) > 0 {
+		log.Warn("State file has problems, run conquotactl state fsck --repair", zap.Int("findings", len(report.Findings)),
+			zap.Int("entries", report.Entries))
+	}
+}
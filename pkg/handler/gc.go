@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/quota"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// CollectGarbage 回收遗留的配额：可写层目录已不存在的条目移除配额并归还项目 ID，没有成员的分组释放共享项目，
+// 范围内没有条目却仍有限额的项目 ID 清除限额。只对照文件系统与内核，不查询 containerd；
+// 容器已删除但目录仍在的条目由对账处理。dryRun 时只返回计划
+func (q *RFSQuota) CollectGarbage(dryRun bool) ([]api.GCItem, error) {
+	if !dryRun {
+		if q.standby.Load() {
+			return nil, api.ErrStandby
+		}
+		if q.degraded.Active() {
+			return nil, fmt.Errorf("filesystem is degraded, refusing to collect garbage")
+		}
+		if r, ok := q.stateManager.Rebalance(); ok && (r.State == xfs.RebalanceRunning || r.State == xfs.RebalanceRollingBack) {
+			return nil, fmt.Errorf("%w: project ID rebalance is %s", api.ErrConflict, r.State)
+		}
+	}
+
+	usages, err := projectUsages(q.ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := q.planGC(usages)
+	if dryRun {
+		return items, nil
+	}
+
+	failed := 0
+	for i := range items {
+		it := &items[i]
+		if it.Kind == api.GCOrphanProject {
+			err = q.clearOrphan(it.ProjectID)
+		} else {
+			ctx := log.WithFields(q.ctx, zap.String("container", it.ContainerID), zap.String("action", it.Kind))
+			err = q.sched.Do(ctx, it.ContainerID, sched.Admin, func(ctx context.Context) error {
+				return q.executeGC(ctx, *it)
+			})
+		}
+		if err != nil {
+			it.Error = err.Error()
+			failed++
+			log.Error("Garbage collection failed", zap.String("kind", it.Kind),
+				zap.String("key", it.ContainerID), zap.Uint32("projectID", it.ProjectID), zap.Error(err))
+			continue
+		}
+		it.Done = true
+	}
+	log.Info("Garbage collection completed", zap.Int("items", len(items)), zap.Int("failed", failed))
+	return items, nil
+}
+
+// planGC 找出遗留的条目、分组与项目，不做修改
+func (q *RFSQuota) planGC(usages map[uint32]xfs.ProjectUsage) []api.GCItem {
+	entries := q.stateManager.ListEntries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ContainerID < entries[j].ContainerID })
+
+	// 有成员（包括过期成员）的分组不列出，最后一个成员移除时分组随之释放
+	members := make(map[string]bool)
+	var items []api.GCItem
+	for _, entry := range entries {
+		if entry.Group != "" {
+			members[entry.Group] = true
+		}
+		if isGroupKey(entry.ContainerID) || entry.Upperdir == "" {
+			continue
+		}
+		if upperdirGone(entry.Upperdir) {
+			items = append(items, api.GCItem{
+				Kind:        api.GCStaleEntry,
+				ProjectID:   entry.ProjectID,
+				ContainerID: entry.ContainerID,
+				Path:        entry.Upperdir,
+			})
+		}
+	}
+	for _, entry := range entries {
+		if !isGroupKey(entry.ContainerID) || members[entry.ContainerID] {
+			continue
+		}
+		items = append(items, api.GCItem{
+			Kind:        api.GCEmptyGroup,
+			ProjectID:   entry.ProjectID,
+			ContainerID: entry.ContainerID,
+		})
+	}
+	for _, id := range q.orphanProjects(usages) {
+		items = append(items, api.GCItem{
+			Kind:      api.GCOrphanProject,
+			ProjectID: id,
+			Limits:    usageLimits(usages[id]),
+		})
+	}
+	return items
+}
+
+// executeGC 在条目的队列上重新确认并回收
+func (q *RFSQuota) executeGC(ctx context.Context, it api.GCItem) error {
+	entry, exists := q.stateManager.GetEntry(it.ContainerID)
+	if !exists {
+		return nil
+	}
+	switch it.Kind {
+	case api.GCStaleEntry:
+		if !upperdirGone(entry.Upperdir) {
+			return fmt.Errorf("upperdir %s exists again", entry.Upperdir)
+		}
+		if err := q.removeQuota(ctx, entry.ContainerID, entry.ProjectID); err != nil {
+			return err
+		}
+		log.Ctx(ctx).Info("Stale quota removed", zap.String("upperdir", entry.Upperdir), zap.Uint32("projectID", entry.ProjectID))
+		return nil
+	case api.GCEmptyGroup:
+		q.groupMutex.Lock()
+		defer q.groupMutex.Unlock()
+		for _, other := range q.stateManager.ListEntries() {
+			if other.Group == entry.ContainerID {
+				return fmt.Errorf("group has members again")
+			}
+		}
+		if err := q.releaseProject(ctx, entry.ContainerID, entry.ProjectID); err != nil {
+			return err
+		}
+		log.Ctx(ctx).Info("Empty shared quota released", zap.Uint32("projectID", entry.ProjectID))
+		return nil
+	}
+	return fmt.Errorf("unknown garbage collection kind %q", it.Kind)
+}
+
+// upperdirGone 判断可写层目录已被删除：目录不存在，且最近的存在的上级目录位于支持项目配额的文件系统上。
+// 快照所在的文件系统未挂载时所有目录都不存在，此时不视为删除
+func upperdirGone(path string) bool {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return false
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			_, err := quota.Detect(dir)
+			return err == nil
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// groupSpec 描述一种共享项目（Pod、Pod 沙箱、命名空间）：分组键与首个成员到达时创建项目的方式
+type groupSpec struct {
+	// key 为分组条目的键，由分组前缀与 Pod UID、沙箱 ID 或命名空间组成
+	key string
+	// kind 用于日志，如 "Pod ephemeral"
+	kind string
+	// limits 返回创建项目时使用的限额，只在分组不存在时调用
+	limits func(ctx context.Context) config.QuotaConfig
+	// budgetPath 为检查节点预算时所在文件系统的路径，为空时使用成员的可写层
+	budgetPath string
+	// paths 将分组的额外目录纳入项目并返回成功纳入的目录，为 nil 表示没有额外目录
+	paths func(ctx context.Context, projID uint32) []string
+}
+
+// joinGroup 将容器 rootfs 加入共享项目，分组首个容器到达时按 spec 创建项目
+func (q *RFSQuota) joinGroup(ctx context.Context, namespace string, spec groupSpec, containerID, upperdir string) (uint32, error) {
+	q.groupMutex.Lock()
+	defer q.groupMutex.Unlock()
+
+	group, exists := q.stateManager.GetEntry(spec.key)
+	if !exists {
+		var err error
+		if group, err = q.createGroup(ctx, namespace, spec, upperdir); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := q.setProjectID(ctx, upperdir, group.ProjectID); err != nil {
+		q.noteFilesystemError(err, upperdir)
+		return 0, err
+	}
+	q.tagOwner(ctx, upperdir, containerID, spec.key)
+
+	member := xfs.Entry{
+		ContainerID:  containerID,
+		Namespace:    namespace,
+		ProjectID:    group.ProjectID,
+		Upperdir:     upperdir,
+		SoftLimit:    group.SoftLimit,
+		HardLimit:    group.HardLimit,
+		InodeSoft:    group.InodeSoft,
+		InodeHard:    group.InodeHard,
+		RealtimeSoft: group.RealtimeSoft,
+		RealtimeHard: group.RealtimeHard,
+		Group:        spec.key,
+		Paths:        q.addExtraPaths(ctx, containerID, upperdir, group.ProjectID),
+		CreatedAt:    time.Now(),
+	}
+	if err := q.stateManager.PutEntry(member); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return 0, err
+	}
+	return group.ProjectID, nil
+}
+
+// createGroup 为分组分配项目 ID、设置共享限额并纳入额外目录，upperdir 为首个成员的可写层
+func (q *RFSQuota) createGroup(ctx context.Context, namespace string, spec groupSpec, upperdir string) (xfs.Entry, error) {
+	limits := spec.limits(ctx)
+	unlock := q.lockBudget()
+	defer unlock()
+	budgetPath := spec.budgetPath
+	if budgetPath == "" {
+		budgetPath = upperdir
+	}
+	soft, hard, err := q.fitToBudget(ctx, budgetPath, limits.DefaultSoft, limits.DefaultHard)
+	if err != nil {
+		return xfs.Entry{}, err
+	}
+
+	projID, err := q.projectIDPool.Allocate()
+	if err != nil {
+		return xfs.Entry{}, err
+	}
+
+	if err := q.setProjectQuota(ctx, projID, soft, hard, false); err != nil {
+		q.projectIDPool.Release(projID)
+		q.noteFilesystemError(err, upperdir)
+		return xfs.Entry{}, err
+	}
+
+	var paths []string
+	if spec.paths != nil {
+		paths = spec.paths(ctx, projID)
+	}
+
+	group := xfs.Entry{
+		ContainerID: spec.key,
+		Namespace:   namespace,
+		ProjectID:   projID,
+		Paths:       paths,
+		CreatedAt:   time.Now(),
+	}
+	setClassLimits(&group, limits)
+	if err := q.setExtraLimits(ctx, group); err != nil {
+		q.projectIDPool.Release(projID)
+		q.noteFilesystemError(err, upperdir)
+		return xfs.Entry{}, err
+	}
+	group.SetLimits(soft, hard, limitSourceCreate)
+	if err := q.stateManager.PutEntry(group); err != nil {
+		q.projectIDPool.Release(projID)
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return xfs.Entry{}, err
+	}
+
+	log.Ctx(ctx).Info(spec.kind+" quota created",
+		zap.String("group", spec.key),
+		zap.Uint32("projectID", projID),
+		zap.Strings("paths", paths))
+	return group, nil
+}
+
+// podLimits 返回 Pod 共享项目的限额：开启 ephemeral_storage 且 Pod 各容器都设置了限制时按其总和换算，否则为 limits
+func (q *RFSQuota) podLimits(ctx context.Context, podUID string, limits config.QuotaConfig) config.QuotaConfig {
+	if !q.cfg.EphemeralStorage.Enabled || q.kubelet == nil {
+		return limits
+	}
+	if spec, ok := q.kubeletPod(ctx, podUID); ok {
+		if limit, ok := spec.PodEphemeralLimit(); ok {
+			return q.ephemeralScaled(ctx, limit, limits)
+		}
+	}
+	return limits
+}
@@ -2,21 +2,40 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"os/signal"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/api/events"
 	e "github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/mount"
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/typeurl/v2"
 	"go.uber.org/zap"
 
+	"RootfsQuota/pkg/accounting"
+	"RootfsQuota/pkg/aggregate"
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/audit"
 	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/eventsink"
+	"RootfsQuota/pkg/health"
+	"RootfsQuota/pkg/kubelet"
 	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/maintenance"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/quota"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/snapshot"
+	"RootfsQuota/pkg/stats"
 	"RootfsQuota/pkg/xfs"
 )
 
@@ -25,9 +44,62 @@ type RFSQuota struct {
 	stateManager  *xfs.StateManager
 	projectIDPool *xfs.ProjectIDPool
 	client        *containerd.Client
+	resolver      *snapshot.MountResolver
 	ctx           context.Context
 	cancel        context.CancelFunc
 	sigCh         chan os.Signal
+	health        *health.Status
+	degraded      *degradedState
+	sched         *sched.Scheduler
+	retryCh       chan queuedEvent
+	audit         *audit.Logger
+	accounting    *accounting.Ledger
+	applied       *xfs.AppliedLimits
+	kubelet       *kubelet.Client
+	lifts         *liftTimers
+	emergency     *emergency
+	limitNotify   *limitNotifier
+	sink          *eventsink.Sink
+	chats         []chatTarget
+	templates     notifyTemplates
+	maintenance   *maintenance.Calendar
+	usage         usagePoller
+	// meta 为容器元数据缓存，禁用时为 nil
+	meta *metaCache
+	// enforcement 记录未纳入管理与永久失败的容器，用于节点注解
+	enforcement *enforcementTracker
+	// annotator 写入节点注解，未启用时为 nil
+	annotator *notify.NodeAnnotator
+	// reconcileCh 接收触发完整对账的 SIGUSR2，容量为 1，对账期间的重复信号合并为一次
+	reconcileCh chan os.Signal
+	// dumpCh 接收将内存状态写入日志的 SIGUSR1
+	dumpCh chan os.Signal
+	// imageRules 为编译后的 images.rules
+	imageRules []imageRule
+	// excludeRules 为编译后的 exclude.rules
+	excludeRules []excludeRule
+	// backends 记录项目 ID 所在文件系统的配额后端（quota.QuotaBackend）
+	backends sync.Map
+	// source 为远程配置源，本地配置文件时为 nil
+	source *config.Source
+	// configChanged 为真表示因配置源变化而退出，需要重新加载
+	configChanged atomic.Bool
+	// standby 为真时只观察事件，不做任何修改
+	standby atomic.Bool
+	// loopBeat 为事件循环最近一次心跳的时间（UnixNano），未在监听事件时为 0
+	loopBeat atomic.Int64
+	// eventsPending 为已分发但尚未处理结束的事件数
+	eventsPending atomic.Int64
+	// eventProgress 为最近一次事件处理结束、或待处理事件从无到有的时间（UnixNano）
+	eventProgress atomic.Int64
+	// optOuts 记录本进程内已处理过退出请求的容器（容器 ID -> 处理结果），避免重复审计
+	optOuts sync.Map
+	// groupMutex 串行化共享项目（Pod 分组）的创建与释放
+	groupMutex sync.Mutex
+	// budgetMutex 串行化节点预算的检查与限额记录，见 lockBudget
+	budgetMutex sync.Mutex
+	// rebalancing 为真表示项目 ID 迁移的后台任务正在运行
+	rebalancing atomic.Bool
 }
 
 func NewRFSQuota(configPath string) (*RFSQuota, error) {
@@ -36,12 +108,33 @@ func NewRFSQuota(configPath string) (*RFSQuota, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newRFSQuota(cfg)
+}
 
-	// 初始化状态管理器
-	stateManager, err := xfs.NewStateManager(cfg.StateFilePath)
+// NewRFSQuotaFromSource 从远程配置源加载配置，源上的配置变化时 Run 返回 ErrConfigChanged
+func NewRFSQuotaFromSource(src *config.Source) (*RFSQuota, error) {
+	cfg, err := src.Load(context.Background())
 	if err != nil {
 		return nil, err
 	}
+	q, err := newRFSQuota(cfg)
+	if err != nil {
+		return nil, err
+	}
+	q.source = src
+	return q, nil
+}
+
+func newRFSQuota(cfg *config.Config) (*RFSQuota, error) {
+	var err error
+	// 初始化状态管理器
+	stateManager := xfs.NewMemoryStateManager()
+	if cfg.StateBackend == config.StateBackendFile {
+		stateManager, err = xfs.NewStateManager(cfg.StateFilePath)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// 初始化项目ID池
 	projectIDPool := xfs.NewProjectIDPool(cfg.Project.IDMin, cfg.Project.IDMax)
@@ -52,71 +145,317 @@ func NewRFSQuota(configPath string) (*RFSQuota, error) {
 	// 设置默认命名空间
 	ctx = namespaces.WithNamespace(ctx, cfg.Namespace)
 
-	return &RFSQuota{
+	order := sched.DefaultOrder
+	if len(cfg.Scheduler.Priorities) > 0 {
+		order = nil
+		for _, name := range cfg.Scheduler.Priorities {
+			p, _ := sched.ParsePriority(name)
+			order = append(order, p)
+		}
+	}
+
+	q := &RFSQuota{
 		cfg:           cfg,
 		stateManager:  stateManager,
 		projectIDPool: projectIDPool,
 		ctx:           ctx,
 		cancel:        cancel,
 		sigCh:         make(chan os.Signal, 1),
-	}, nil
+		reconcileCh:   make(chan os.Signal, 1),
+		dumpCh:        make(chan os.Signal, 1),
+		health:        health.NewStatus(),
+		degraded:      newDegradedState(),
+		retryCh:       make(chan queuedEvent, 1024),
+		applied:       xfs.NewAppliedLimits(),
+		sched:         sched.New(cfg.Scheduler.Concurrency, order),
+		lifts:         newLiftTimers(),
+		enforcement:   newEnforcementTracker(),
+	}
+	if cfg.AsyncBackend.Enabled {
+		quota.UseXFSPipeline(xfs.NewPipeline(ctx, cfg.AsyncBackend.MaxBatch))
+	}
+	if !cfg.MetadataCache.Disabled {
+		q.meta = newMetaCache(time.Duration(cfg.MetadataCache.TTLSeconds) * time.Second)
+	}
+	if cfg.Audit.Path != "" {
+		q.audit, err = audit.NewLogger(cfg.Audit.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Accounting.Path != "" {
+		if q.accounting, err = accounting.Open(cfg.Accounting.Path, cfg.Accounting.RetentionDays); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Emergency.Enabled {
+		if q.emergency, err = newEmergency(cfg.Emergency); err != nil {
+			return nil, err
+		}
+	}
+	notifier := cfg.Features.Enabled(config.FeatureNotifier)
+	if notifier {
+		if q.limitNotify, err = newLimitNotifier(cfg.LimitNotify); err != nil {
+			return nil, err
+		}
+		if q.chats, err = newChatTargets(cfg.Chat); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.NodeAnnotation.Enabled && notifier {
+		if q.annotator, err = notify.NewNodeAnnotator(cfg.NodeAnnotation.NodeName); err != nil {
+			return nil, fmt.Errorf("failed to set up node annotation: %v", err)
+		}
+	}
+	if q.templates, err = newNotifyTemplates(cfg.Templates); err != nil {
+		return nil, err
+	}
+	if q.imageRules, err = newImageRules(cfg.Images.Rules); err != nil {
+		return nil, fmt.Errorf("invalid images.rules: %v", err)
+	}
+	if q.excludeRules, err = newExcludeRules(cfg.Exclude.Rules); err != nil {
+		return nil, fmt.Errorf("invalid exclude.rules: %v", err)
+	}
+	if q.maintenance, err = newMaintenanceCalendar(cfg.Maintenance); err != nil {
+		return nil, fmt.Errorf("invalid maintenance: %v", err)
+	}
+	if sink := cfg.EventSink; sink.Type != "" && notifier {
+		if q.sink, err = eventsink.New(sink.Type, sink.URL, sink.Topic, sink.NodeName, sink.QueueSize); err != nil {
+			return nil, fmt.Errorf("invalid event_sink: %v", err)
+		}
+	}
+	for name, target := range cfg.SnapshotterAliases {
+		if err := snapshot.Alias(name, target); err != nil {
+			return nil, fmt.Errorf("invalid snapshotter alias %q: %v", name, err)
+		}
+	}
+	if q.kubelet, err = newKubeletClient(cfg.Kubelet.URL, cfg.Kubelet.TokenFile, cfg.Kubelet.InsecureSkipVerify); err != nil {
+		return nil, err
+	}
+	q.preflightProjectIDs()
+	q.loadRebalance()
+	q.checkLoadedState()
+	return q, nil
 }
 
-func (q *RFSQuota) Run() error {
+func (q *RFSQuota) Run() (err error) {
 	defer q.cleanup()
+	defer func() {
+		if err == nil && q.configChanged.Load() {
+			err = ErrConfigChanged
+		}
+	}()
 	signal.Notify(q.sigCh, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(q.reconcileCh, syscall.SIGUSR2)
+	signal.Notify(q.dumpCh, syscall.SIGUSR1)
+	q.logFeatures()
 	go q.handleSignals()
+	go q.runReconcileSignal()
+	go q.runStateDump()
+	go q.runEventMarkFlusher()
+	go q.runPoolMonitor()
+	if !q.standby.Load() {
+		if err := q.beginEpoch(); err != nil {
+			log.Error("Running without fencing", zap.Error(err))
+			q.noteFilesystemError(err, q.cfg.StateFilePath)
+		}
+		q.resumeLifts()
+	}
+
+	if mark, ok := q.stateManager.LastEvent(); ok {
+		log.Info("Resuming after last processed event",
+			zap.Uint64("sequence", mark.Sequence),
+			zap.Time("timestamp", mark.Timestamp),
+			zap.String("topic", mark.Topic))
+	}
+
+	if q.cfg.MetricsPort != "" {
+		handlers := q.probeHandlers()
+		if q.cfg.Pprof {
+			maps.Copy(handlers, metrics.PprofHandlers())
+		}
+		go func() {
+			if err := metrics.Serve(q.cfg.MetricsPort, handlers); err != nil {
+				log.Error("Metrics server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	features := q.cfg.Features
+	if q.cfg.AdminAddr != "" && features.Enabled(config.FeatureAPI) {
+		go func() {
+			if err := api.NewServer(q.cfg, q).Serve(); err != nil {
+				log.Error("Admin API server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	if q.cfg.Stats.Addr != "" && features.Enabled(config.FeatureAPI) {
+		go func() {
+			if err := stats.NewServer(q.cfg.Stats.Addr, q.cfg.Stats.ImageFsPath, q).Serve(q.ctx); err != nil {
+				log.Error("Stats server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	if q.cfg.Aggregator.URL != "" && features.Enabled(config.FeatureCollector) {
+		interval := time.Duration(q.cfg.Aggregator.IntervalSeconds) * time.Second
+		pusher := aggregate.NewPusher(q.cfg.Aggregator.URL, q.cfg.Aggregator.NodeName, interval, q.collectSummary)
+		go pusher.Run(q.ctx)
+	}
+
+	if q.cfg.Verify.Enabled && features.Enabled(config.FeatureReconciler) {
+		go q.runVerifySweep()
+	}
+
+	if !q.cfg.QuotaState.Disabled && features.Enabled(config.FeatureReconciler) {
+		go q.runQuotaStateCheck()
+	}
+
+	if q.cfg.Nested.Enabled && features.Enabled(config.FeatureEnforcement) {
+		go q.runNestedScan()
+	}
+
+	if q.source != nil {
+		go q.runConfigSourceWatcher()
+	}
+
+	if q.emergency != nil && features.Enabled(config.FeatureEnforcement) {
+		go q.runEmergencyMonitor()
+	}
 
-	// 主循环
+	if q.maintenance != nil {
+		go q.runMaintenanceMonitor()
+	}
+
+	if q.cfg.Policy.File != "" && features.Enabled(config.FeatureReconciler) {
+		go q.runPolicyWatcher()
+	}
+
+	if q.kubelet != nil && features.Enabled(config.FeatureReconciler) {
+		go q.runResizeWatcher()
+	}
+
+	if q.cfg.Buildkit.Enabled && len(q.cfg.Buildkit.SnapshotDirs) > 0 && features.Enabled(config.FeatureEnforcement) {
+		go q.runBuildkitScanner()
+	}
+
+	if q.usagePollerNeeded() {
+		go q.runUsagePoller()
+	}
+
+	if q.sink != nil {
+		go q.sink.Run(q.ctx)
+		go q.runExceededMonitor()
+	}
+
+	if q.cfg.Mirror.Path != "" {
+		go q.runStateMirror()
+	}
+
+	if len(q.chats) > 0 {
+		go q.runHardLimitMonitor()
+	}
+
+	if q.cfg.Alerts.Enabled && features.Enabled(config.FeatureNotifier) {
+		go q.runUsageAlerts()
+	}
+
+	if q.annotator != nil {
+		go q.runNodeAnnotation()
+	}
+
+	if q.cfg.LifecycleWebhook.Enabled && features.Enabled(config.FeatureEnforcement) {
+		go q.runLifecycleWebhook()
+	}
+
+	// kubelet 的所有 CRI 调用都经过代理，备用模式与关闭 enforcement 时同样需要运行
+	if q.cfg.CRIGate.Enabled {
+		go q.runCRIGate()
+	}
+
+	// 主循环：等待 containerd socket 出现后连接，失败时按退避重试，重复的失败只记录调试日志
+	q.markNotReady("not connected to containerd")
+	failures := 0
 	for {
+		if !q.waitForContainerdSocket() {
+			return nil
+		}
+		connected, err := q.startEventListener()
+		if q.ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			failures = 0
+		}
+		q.markNotReady("disconnected from containerd")
+		delay := connectBackoff(failures)
+		if failures == 0 || delay == connectBackoffMax {
+			log.Warn("Event listener failed, retrying", zap.Duration("backoff", delay), zap.Error(err))
+		} else {
+			log.Debug("Event listener failed, retrying", zap.Duration("backoff", delay), zap.Error(err))
+		}
+		failures++
 		select {
+		case <-time.After(delay):
 		case <-q.ctx.Done():
 			return nil
-		default:
-			if err := q.startEventListener(); err != nil {
-				log.Error("Event listener failed, retrying", zap.Error(err))
-				select {
-				case <-time.After(5 * time.Second):
-					continue
-				case <-q.ctx.Done():
-				}
-				return nil
-			}
 		}
 	}
 }
 
-func (q *RFSQuota) startEventListener() error {
+// startEventListener 连接 containerd、同步状态并处理事件直到订阅中断，connected 表示是否曾经连接成功
+func (q *RFSQuota) startEventListener() (connected bool, err error) {
 	client, err := containerd.New(q.cfg.ContainerdSock, containerd.WithTimeout(10*time.Second))
 	if err != nil {
-		return err
+		return false, err
+	}
+	if q.client != nil {
+		metrics.ContainerdReconnects.Inc()
 	}
 	q.client = client
+	// 断开期间可能错过容器事件
+	if q.meta != nil {
+		q.meta.reset()
+	}
+	q.resolver = snapshot.NewCachedMountResolver(client, q.containerSnapshot)
 
 	// 同步状态
-	if err := q.syncState(); err != nil {
-		log.Error("State sync failed", zap.Error(err))
+	if q.cfg.Features.Enabled(config.FeatureReconciler) {
+		if err := q.syncState(); err != nil {
+			log.Error("State sync failed", zap.Error(err))
+		}
 	}
 
 	// 订阅事件
 	eventsCh, errCh := client.Subscribe(q.ctx)
 	log.Info("Listening for containerd events...")
+	q.markReady()
+
+	q.loopBeat.Store(time.Now().UnixNano())
+	defer q.loopBeat.Store(0)
+	heartbeat := time.NewTicker(loopBeatInterval)
+	defer heartbeat.Stop()
 
 	for {
 		select {
+		case <-heartbeat.C:
+			q.loopBeat.Store(time.Now().UnixNano())
 		case envelope := <-eventsCh:
-			if err := q.handleEvent(envelope); err != nil {
-				log.Error("Failed to handle event", zap.Error(err))
-			}
+			q.observeContainerEvent(envelope)
+			q.dispatchEvent(queuedEvent{envelope: envelope})
+		case ev := <-q.retryCh:
+			q.dispatchEvent(ev)
 		case err := <-errCh:
-			return err
+			return true, err
 		case <-q.ctx.Done():
-			return nil
+			return true, nil
 		}
 	}
 }
 
-func (q *RFSQuota) handleEvent(envelope *e.Envelope) error {
+// handleEvent 处理单个事件，ctx 为事件所属命名空间的上下文并附带日志字段
+func (q *RFSQuota) handleEvent(ctx context.Context, envelope *e.Envelope) error {
 	event, err := typeurl.UnmarshalAny(envelope.Event)
 	if err != nil {
 		return err
@@ -124,80 +463,327 @@ func (q *RFSQuota) handleEvent(envelope *e.Envelope) error {
 
 	switch e := event.(type) {
 	case *events.TaskCreate:
-		return q.handleTaskCreate(e)
+		if q.degraded.Active() {
+			// 恢复后由 syncState 补齐配额
+			log.Ctx(ctx).Warn("Degraded mode, deferring quota setup")
+			return nil
+		}
+		return q.handleTaskCreate(ctx, envelope.Namespace, e)
 	case *events.TaskDelete:
-		return q.handleTaskDelete(e)
+		if q.degraded.Active() {
+			log.Ctx(ctx).Warn("Degraded mode, deferring quota removal")
+			q.degraded.deferDelete(e.ContainerID)
+			return nil
+		}
+		return q.handleTaskDelete(ctx, e)
+	case *events.ContainerUpdate:
+		q.handleContainerUpdate(ctx, e.ID)
 	}
 	return nil
 }
 
-func (q *RFSQuota) handleTaskCreate(e *events.TaskCreate) error {
-	upperdir := strings.TrimPrefix(e.Rootfs[0].Options[1], "upperdir=")
+func (q *RFSQuota) handleTaskCreate(ctx context.Context, namespace string, e *events.TaskCreate) error {
+	mounts := make([]mount.Mount, 0, len(e.Rootfs))
+	for _, m := range e.Rootfs {
+		mounts = append(mounts, mount.Mount{Type: m.Type, Source: m.Source, Options: m.Options})
+	}
+	upperdir := snapshot.ParseMounts(mounts).Upperdir
+	if upperdir == "" && q.resolver != nil {
+		// 事件中不含快照器名称，无法识别时按容器的快照器插件解析
+		upperdir, _ = q.resolver.Upperdir(ctx, e.ContainerID)
+	}
+	if upperdir == "" {
+		return fmt.Errorf("upperdir not found in rootfs mounts of container %s", e.ContainerID)
+	}
+	return q.setContainerQuota(ctx, namespace, e.ContainerID, upperdir)
+}
+
+// setContainerQuota 为新容器设置配额，已按同一可写层设置过时直接返回；
+// 不需要配额的容器（不支持、不允许或已退出）返回 nil 且不写入条目
+func (q *RFSQuota) setContainerQuota(ctx context.Context, namespace, containerID, upperdir string) error {
+	// 超时重试时前一次处理可能已经完成，CRI 门控也可能已在任务创建前设置
+	if entry, exists := q.stateManager.GetEntry(containerID); exists && entry.Upperdir == upperdir {
+		return nil
+	}
 
-	projID, err := q.projectIDPool.Allocate()
+	ctx = log.WithFields(ctx, zap.String("upperdir", upperdir))
+	if pod, ok := q.lookupPod(namespace, containerID); ok {
+		ctx = log.WithFields(ctx, zap.String("pod", pod.Namespace+"/"+pod.Name))
+	}
+
+	if q.standby.Load() {
+		log.Ctx(ctx).Info("Standby, not setting quota")
+		return nil
+	}
+	if !q.cfg.Features.Enabled(config.FeatureEnforcement) {
+		log.Ctx(ctx).Info("Enforcement disabled, not setting quota")
+		return nil
+	}
+
+	projID, err := q.ensureQuota(ctx, namespace, containerID, upperdir)
+	if errors.Is(err, errExcluded) {
+		log.Ctx(ctx).Info("Container matches an exclude rule, not setting quota", zap.Error(err))
+		return nil
+	}
+	if skipQuota(err) {
+		log.Ctx(ctx).Warn("Skipping container whose upperdir cannot get a project quota", zap.Error(err))
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	if err := xfs.SetProjectIDWithXFSQuota(upperdir, projID); err != nil {
+	q.clearFailed(namespace, containerID)
+	log.Ctx(ctx).Info("Quota set successfully", zap.Uint32("projectID", projID))
+	return nil
+}
+
+// ensureQuota 按配置的作用域为容器 rootfs 设置配额
+func (q *RFSQuota) ensureQuota(ctx context.Context, namespace, containerID, upperdir string) (projID uint32, err error) {
+	defer func() {
+		countQuotaOp(quotaOpSet, err)
+		q.enforcement.observe(containerID, err)
+		if err == nil {
+			q.publishQuotaSet(containerID)
+		}
+	}()
+	if err := q.checkExclude(ctx, namespace, containerID); err != nil {
+		return 0, err
+	}
+	if err := q.checkOptOut(ctx, namespace, containerID); err != nil {
+		return 0, err
+	}
+	if err := q.checkUpperdir(upperdir); err != nil {
+		return 0, err
+	}
+	if nsq, ok := q.cfg.NamespaceQuotas[namespace]; ok {
+		return q.applyNamespaceQuota(ctx, namespace, nsq, containerID, upperdir)
+	}
+	if q.cfg.QuotaScope == config.ScopePodEphemeral {
+		if pod, ok := q.lookupPod(namespace, containerID); ok {
+			return q.applyPodQuota(ctx, namespace, pod, containerID, upperdir)
+		}
+	}
+
+	if q.isBuildkitNamespace(namespace) {
+		return q.applyQuota(ctx, namespace, containerID, upperdir, q.cfg.Buildkit.Quota, "", "")
+	}
+	if sharedDir, ok := q.kataSharedDir(ctx, containerID); ok {
+		return q.applyKataQuota(ctx, namespace, containerID, upperdir, sharedDir)
+	}
+	if q.cfg.QuotaScope == config.ScopePod {
+		if sandboxID, pod, ok := q.lookupSandbox(ctx, containerID); ok {
+			return q.applySandboxQuota(ctx, namespace, sandboxID, pod, containerID, upperdir)
+		}
+	}
+	limits, class := q.classLimits(ctx, namespace, containerID)
+	limits, digest := q.imageLimits(ctx, containerID, limits)
+	limits = q.ephemeralLimits(ctx, containerID, limits)
+	limits = q.labelLimits(ctx, namespace, containerID, limits)
+	return q.applyQuota(ctx, namespace, containerID, upperdir, limits, digest, class)
+}
+
+// applyQuota 为目录分配项目 ID、设置限额并记录状态，失败时归还项目 ID；imageDigest 为容器的镜像摘要，
+// class 为选择的配额类别，均可为空
+func (q *RFSQuota) applyQuota(ctx context.Context, namespace, key, upperdir string, limits config.QuotaConfig, imageDigest, class string) (uint32, error) {
+	unlock := q.lockBudget()
+	defer unlock()
+	soft, hard, err := q.fitToBudget(ctx, upperdir, limits.DefaultSoft, limits.DefaultHard)
+	if err != nil {
+		return 0, err
+	}
+
+	projID, err := q.projectIDPool.Allocate()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := q.setProjectID(ctx, upperdir, projID); err != nil {
 		q.projectIDPool.Release(projID)
-		return err
+		q.noteFilesystemError(err, upperdir)
+		return 0, err
 	}
+	q.tagOwner(ctx, upperdir, key, "")
 
-	if err := xfs.SetProjectQuotaWithXFSQuota(projID, q.cfg.Quota.DefaultSoft, q.cfg.Quota.DefaultSoft); err != nil {
+	if err := q.setProjectQuota(ctx, projID, soft, hard, false); err != nil {
 		q.projectIDPool.Release(projID)
-		return err
+		q.noteFilesystemError(err, upperdir)
+		return 0, err
 	}
 
-	if err := q.stateManager.AddEntry(e.ContainerID, projID, upperdir); err != nil {
+	entry := xfs.Entry{
+		ContainerID: key,
+		Namespace:   namespace,
+		ProjectID:   projID,
+		Upperdir:    upperdir,
+		ImageDigest: imageDigest,
+		Class:       class,
+		CreatedAt:   time.Now(),
+	}
+	setClassLimits(&entry, limits)
+	if err := q.setExtraLimits(ctx, entry); err != nil {
 		q.projectIDPool.Release(projID)
+		q.noteFilesystemError(err, upperdir)
+		return 0, err
+	}
+	entry.SetLimits(soft, hard, limitSourceCreate)
+	entry.Paths = q.addExtraPaths(ctx, key, upperdir, projID)
+	if err := q.stateManager.PutEntry(entry); err != nil {
+		q.projectIDPool.Release(projID)
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return 0, err
+	}
+	return projID, nil
+}
+
+// setProjectQuota 设置项目限额，与上次成功设置的限额相同时跳过，force 时总是写入
+func (q *RFSQuota) setProjectQuota(ctx context.Context, projID uint32, soft, hard string, force bool) error {
+	if err := q.checkProjectFence(projID); err != nil {
+		return err
+	}
+	written, err := q.applied.Apply(ctx, projID, soft, hard, force, q.projectBackend(projID).SetLimits)
+	if !written && err == nil {
+		metrics.LimitWritesSkipped.Inc()
+	}
+	return err
+}
+
+// setClassLimits 将类别配置的 inode 与实时子卷限额记录到条目
+func setClassLimits(entry *xfs.Entry, limits config.QuotaConfig) {
+	entry.InodeSoft, entry.InodeHard = limits.DefaultInodeSoft, limits.DefaultInodeHard
+	entry.RealtimeSoft, entry.RealtimeHard = limits.DefaultRealtimeSoft, limits.DefaultRealtimeHard
+}
+
+// setExtraLimits 写入条目记录的 inode 与实时子卷限额，未设置的不写入，保留块限额不变
+func (q *RFSQuota) setExtraLimits(ctx context.Context, entry xfs.Entry) error {
+	if entry.InodeSoft == 0 && entry.InodeHard == 0 && entry.RealtimeHard == "" {
+		return nil
+	}
+	if err := q.checkProjectFence(entry.ProjectID); err != nil {
 		return err
 	}
+	backend := q.projectBackend(entry.ProjectID)
+	if entry.InodeSoft != 0 || entry.InodeHard != 0 {
+		if err := backend.SetInodeLimits(ctx, entry.ProjectID, entry.InodeSoft, entry.InodeHard); err != nil {
+			return err
+		}
+	}
+	if entry.RealtimeHard != "" {
+		if err := backend.SetRealtimeLimits(ctx, entry.ProjectID, entry.RealtimeSoft, entry.RealtimeHard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	log.Info("Quota set successfully",
-		zap.String("container", e.ContainerID),
-		zap.Uint32("projectID", projID))
+// removeQuota 移除条目的配额，分组成员仅在分组为空时释放共享项目
+func (q *RFSQuota) removeQuota(ctx context.Context, key string, projID uint32) (err error) {
+	defer func() { countQuotaOp(quotaOpRemove, err) }()
+	usage := q.recordFinalUsage(ctx, key)
+	entry, exists := q.stateManager.GetEntry(key)
+	if exists && !isGroupKey(key) {
+		q.releaseExtraPaths(ctx, entry)
+	}
+	if exists {
+		defer func() {
+			if err == nil {
+				q.publishQuotaEvent(eventsink.QuotaRemoved, entry, usage)
+				q.learnImageOverride(ctx, entry, usage)
+			}
+		}()
+	}
+	if exists && entry.Group != "" {
+		return q.removeGroupMember(ctx, entry)
+	}
+	return q.releaseProject(ctx, key, projID)
+}
+
+// 配额操作指标的 operation 标签
+const (
+	quotaOpSet    = "set"
+	quotaOpRemove = "remove"
+)
+
+// countQuotaOp 统计配额操作的结果，跳过的目录（见 skipQuota）既不算成功也不算失败
+func countQuotaOp(op string, err error) {
+	switch {
+	case err == nil:
+		if op == quotaOpSet {
+			metrics.QuotasSet.Inc()
+		} else {
+			metrics.QuotasRemoved.Inc()
+		}
+	case skipQuota(err):
+	default:
+		metrics.QuotaErrors.WithLabelValues(op).Inc()
+	}
+}
+
+// releaseProject 清除项目限额、删除状态并归还项目 ID，分组条目先将其目录的项目 ID 归零
+func (q *RFSQuota) releaseProject(ctx context.Context, key string, projID uint32) error {
+	if entry, exists := q.stateManager.GetEntry(key); exists && isGroupKey(key) {
+		q.releaseExtraPaths(ctx, entry)
+	}
+	if err := q.checkProjectFence(projID); err != nil {
+		return err
+	}
+	if err := q.projectBackend(projID).ClearProject(ctx, projID); err != nil {
+		if entry, exists := q.stateManager.GetEntry(key); exists {
+			q.noteFilesystemError(err, entry.Upperdir)
+		}
+		return err
+	}
+
+	if err := q.stateManager.RemoveEntry(key); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return err
+	}
+
+	q.applied.Forget(projID)
+	q.backends.Delete(projID)
+	q.lifts.cancel(projID)
+	q.projectIDPool.Release(projID)
 	return nil
 }
 
-func (q *RFSQuota) handleTaskDelete(e *events.TaskDelete) error {
-	upperdir, err := xfs.GetSnapshotUpperdir(q.ctx, q.client, e.ContainerID)
+func (q *RFSQuota) handleTaskDelete(ctx context.Context, e *events.TaskDelete) error {
+	q.optOuts.Delete(e.ContainerID)
+	q.enforcement.forget(e.ContainerID)
+	upperdir, err := q.resolver.Upperdir(ctx, e.ContainerID)
 	if err != nil {
 		return err
 	}
 
 	var projID uint32
 	if _, err := os.Stat(upperdir); err == nil {
-		projID, err = GetProjectID(e.ContainerID, upperdir, q.stateManager)
+		projID, err = GetProjectID(ctx, e.ContainerID, upperdir, q.stateManager)
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := xfs.SetProjectQuotaWithXFSQuota(projID, "0", "0"); err != nil {
-		return err
+	ctx = log.WithFields(ctx, zap.Uint32("projectID", projID))
+	if q.standby.Load() {
+		log.Ctx(ctx).Info("Standby, not removing quota")
+		return nil
 	}
 
-	if err := q.stateManager.RemoveEntry(e.ContainerID); err != nil {
+	if err := q.removeQuota(ctx, e.ContainerID, projID); err != nil {
 		return err
 	}
 
-	q.projectIDPool.Release(projID)
-	log.Info("Quota removed successfully",
-		zap.String("container", e.ContainerID),
-		zap.Uint32("projectID", projID))
+	log.Ctx(ctx).Info("Quota removed successfully")
 	return nil
 }
 
 func (q *RFSQuota) syncState() error {
-	containers, err := q.client.Containers(q.ctx)
+	ids, err := q.listContainers(q.ctx)
 	if err != nil {
 		return err
 	}
 
-	for _, c := range containers {
-		id := c.ID()
-		upperdir, err := xfs.GetSnapshotUpperdir(q.ctx, q.client, id)
+	for _, id := range ids {
+		upperdir, err := q.resolver.Upperdir(q.ctx, id)
 		if err != nil {
 			continue
 		}
@@ -207,31 +793,38 @@ func (q *RFSQuota) syncState() error {
 		}
 
 		if _, exists := q.stateManager.GetEntry(id); !exists {
-			if err := q.restoreQuota(id, upperdir); err != nil {
+			ctx := log.WithFields(q.ctx, zap.String("container", id))
+			if err := q.sched.Do(ctx, id, sched.Reconcile, func(ctx context.Context) error {
+				return q.restoreQuota(ctx, id, upperdir)
+			}); err != nil {
 				log.Error("Failed to restore quota", zap.String("container", id), zap.Error(err))
+				q.markFailed(q.cfg.Namespace, id, err)
+				continue
 			}
+			q.clearFailed(q.cfg.Namespace, id)
 		}
 	}
 	return nil
 }
 
-func (q *RFSQuota) restoreQuota(containerID, upperdir string) error {
-	projID, err := q.projectIDPool.Allocate()
-	if err != nil {
-		return err
+func (q *RFSQuota) restoreQuota(ctx context.Context, containerID, upperdir string) error {
+	if q.standby.Load() {
+		log.Ctx(ctx).Info("Standby, not restoring quota")
+		return nil
 	}
-
-	if err := xfs.SetProjectIDWithXFSQuota(upperdir, projID); err != nil {
-		q.projectIDPool.Release(projID)
-		return err
+	if !q.cfg.Features.Enabled(config.FeatureEnforcement) {
+		log.Ctx(ctx).Info("Enforcement disabled, not restoring quota")
+		return nil
 	}
-
-	if err := xfs.SetProjectQuotaWithXFSQuota(projID, q.cfg.Quota.DefaultSoft, q.cfg.Quota.DefaultHard); err != nil {
-		q.projectIDPool.Release(projID)
-		return err
+	if q.adoptExisting(ctx, q.cfg.Namespace, containerID, upperdir) {
+		return nil
 	}
-
-	return q.stateManager.AddEntry(containerID, projID, upperdir)
+	_, err := q.ensureQuota(ctx, q.cfg.Namespace, containerID, upperdir)
+	if skipQuota(err) {
+		log.Ctx(ctx).Warn("Skipping container whose upperdir cannot get a project quota", zap.Error(err))
+		return nil
+	}
+	return err
 }
 
 func (q *RFSQuota) handleSignals() {
@@ -241,8 +834,17 @@ func (q *RFSQuota) handleSignals() {
 }
 
 func (q *RFSQuota) cleanup() {
+	if err := q.stateManager.Flush(); err != nil {
+		log.Warn("Failed to persist event watermark", zap.Error(err))
+	}
 	if q.client != nil {
 		q.client.Close()
 	}
+	if q.audit != nil {
+		q.audit.Close()
+	}
+	if q.sink != nil {
+		q.sink.Close(sinkCloseTimeout)
+	}
 	log.Sync()
 }
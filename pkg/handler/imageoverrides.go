@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// containerImage 返回容器的镜像名与镜像摘要，无法解析时摘要为空
+func (q *RFSQuota) containerImage(ctx context.Context, containerID string) (string, string) {
+	if q.client == nil {
+		return "", ""
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil || info.Image == "" {
+		return "", ""
+	}
+	img, err := q.client.ImageService().Get(ctx, info.Image)
+	if err != nil {
+		log.Ctx(ctx).Debug("Failed to resolve image digest", zap.String("image", info.Image), zap.Error(err))
+		return info.Image, ""
+	}
+	return info.Image, img.Target.Digest.String()
+}
+
+// imageRule 为编译后的镜像规则
+type imageRule struct {
+	config.ImageRule
+	re *regexp.Regexp
+}
+
+func newImageRules(rules []config.ImageRule) ([]imageRule, error) {
+	compiled := make([]imageRule, 0, len(rules))
+	for i, rule := range rules {
+		r := imageRule{ImageRule: rule}
+		if rule.Regex != "" {
+			re, err := regexp.Compile("^(?:" + rule.Regex + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i, err)
+			}
+			r.re = re
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+func (r imageRule) matches(image string) bool {
+	if r.re != nil {
+		return r.re.MatchString(image)
+	}
+	ok, _ := path.Match(r.Pattern, image)
+	return ok
+}
+
+// String 返回规则的模式，用于日志
+func (r imageRule) String() string {
+	if r.re != nil {
+		return r.Regex
+	}
+	return r.Pattern
+}
+
+// imageLimits 以首个匹配镜像引用的 images.rules 规则、再以镜像摘要的限额覆盖取代默认块限额，
+// 返回使用的限额与镜像摘要
+func (q *RFSQuota) imageLimits(ctx context.Context, containerID string, limits config.QuotaConfig) (config.QuotaConfig, string) {
+	image, digest := q.containerImage(ctx, containerID)
+	if image != "" {
+		for _, rule := range q.imageRules {
+			if rule.matches(image) {
+				log.Ctx(ctx).Info("Using image quota rule", zap.String("image", image), zap.Stringer("rule", rule),
+					zap.String("soft", rule.Soft), zap.String("hard", rule.Hard))
+				limits.DefaultSoft, limits.DefaultHard = rule.Soft, rule.Hard
+				break
+			}
+		}
+	}
+	if digest == "" {
+		return limits, ""
+	}
+	if o, exists := q.stateManager.GetImageOverride(digest); exists {
+		log.Ctx(ctx).Info("Using image quota override",
+			zap.String("image", image), zap.String("digest", digest), zap.String("source", o.Source),
+			zap.String("soft", o.SoftLimit), zap.String("hard", o.HardLimit))
+		limits.DefaultSoft, limits.DefaultHard = o.SoftLimit, o.HardLimit
+	}
+	return limits, digest
+}
+
+// learnImageOverride 在容器结束时用量达到硬限制时，为其镜像记录按 images.factor 放大的限额；
+// 手动设置的覆盖不会被修改
+func (q *RFSQuota) learnImageOverride(ctx context.Context, entry xfs.Entry, usage *xfs.ProjectUsage) {
+	if !q.cfg.Images.Learn || entry.ImageDigest == "" || entry.Group != "" || usage == nil || !atHardLimit(*usage) {
+		return
+	}
+	o, exists := q.stateManager.GetImageOverride(entry.ImageDigest)
+	if exists && o.Source == xfs.OverrideManual {
+		return
+	}
+	soft, err := q.scaleImageLimit(entry.SoftLimit)
+	if err != nil {
+		log.Ctx(ctx).Warn("Failed to derive image quota override", zap.Error(err))
+		return
+	}
+	hard, err := q.scaleImageLimit(entry.HardLimit)
+	if err != nil {
+		log.Ctx(ctx).Warn("Failed to derive image quota override", zap.Error(err))
+		return
+	}
+
+	image, _ := q.containerImage(ctx, entry.ContainerID)
+	if image == "" {
+		image = o.Image
+	}
+	o = xfs.ImageOverride{
+		Digest:    entry.ImageDigest,
+		Image:     image,
+		SoftLimit: soft,
+		HardLimit: hard,
+		Source:    xfs.OverrideLearned,
+		Breaches:  o.Breaches + 1,
+		UpdatedAt: time.Now(),
+	}
+	if err := q.stateManager.PutImageOverride(o); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		log.Ctx(ctx).Warn("Failed to save image quota override", zap.Error(err))
+		return
+	}
+	log.Ctx(ctx).Info("Learned image quota override",
+		zap.String("digest", o.Digest), zap.String("soft", soft), zap.String("hard", hard), zap.Int("breaches", o.Breaches))
+}
+
+// scaleImageLimit 将限额放大 images.factor 倍，不超过 images.max_hard
+func (q *RFSQuota) scaleImageLimit(limit string) (string, error) {
+	bytes, err := xfs.ParseSize(limit)
+	if err != nil {
+		return "", err
+	}
+	scaled := uint64(float64(bytes) * q.cfg.Images.Factor)
+	if q.cfg.Images.MaxHard != "" {
+		max, err := xfs.ParseSize(q.cfg.Images.MaxHard)
+		if err != nil {
+			return "", err
+		}
+		if scaled > max {
+			scaled = max
+		}
+	}
+	return xfs.FormatSize(scaled), nil
+}
+
+// ListImageOverrides 返回所有镜像限额覆盖
+func (q *RFSQuota) ListImageOverrides() []xfs.ImageOverride {
+	return q.stateManager.ListImageOverrides()
+}
+
+// SetImageOverride 手动设置镜像的限额覆盖，之后该镜像创建的容器使用此限额，且不再由学习修改
+func (q *RFSQuota) SetImageOverride(digest, image, soft, hard string) (xfs.ImageOverride, error) {
+	if q.standby.Load() {
+		return xfs.ImageOverride{}, api.ErrStandby
+	}
+	old, _ := q.stateManager.GetImageOverride(digest)
+	if image == "" {
+		image = old.Image
+	}
+	o := xfs.ImageOverride{
+		Digest:    digest,
+		Image:     image,
+		SoftLimit: soft,
+		HardLimit: hard,
+		Source:    xfs.OverrideManual,
+		Breaches:  old.Breaches,
+		UpdatedAt: time.Now(),
+	}
+	if err := q.stateManager.PutImageOverride(o); err != nil {
+		return xfs.ImageOverride{}, err
+	}
+	log.Info("Image quota override set", zap.String("digest", digest), zap.String("soft", soft), zap.String("hard", hard))
+	return o, nil
+}
+
+// RemoveImageOverride 删除镜像的限额覆盖，已创建的容器不受影响
+func (q *RFSQuota) RemoveImageOverride(digest string) error {
+	if q.standby.Load() {
+		return api.ErrStandby
+	}
+	removed, err := q.stateManager.RemoveImageOverride(digest)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: image override %s", api.ErrNotFound, digest)
+	}
+	log.Info("Image quota override removed", zap.String("digest", digest))
+	return nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/snapshot"
+	"RootfsQuota/pkg/xfs"
+)
+
+const (
+	// labelCRIKind 为 CRI 插件标记容器类型的标签，沙箱（pause）容器为 sandbox
+	labelCRIKind   = "io.cri-containerd.kind"
+	criKindSandbox = "sandbox"
+)
+
+// kataSharedDir 返回 Kata 沙箱容器在宿主机上的共享目录，非 Kata 运行时或非沙箱容器返回 false。
+// 同一 Pod 的其他容器的可写层经共享目录中的绑定挂载提供给虚拟机，已由各自的项目约束
+func (q *RFSQuota) kataSharedDir(ctx context.Context, containerID string) (string, bool) {
+	if !q.cfg.Kata.Enabled || q.client == nil {
+		return "", false
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		log.Ctx(ctx).Debug("Failed to load container for Kata detection", zap.Error(err))
+		return "", false
+	}
+	if info.Labels[labelCRIKind] != criKindSandbox || !q.isKataRuntime(info.Runtime) {
+		return "", false
+	}
+	return filepath.Join(q.cfg.Kata.SharedDir, containerID), true
+}
+
+// isKataSandbox 判断条目是否为 Kata 沙箱容器，其额外目录中含有自己的共享目录
+func (q *RFSQuota) isKataSandbox(entry xfs.Entry) bool {
+	if !q.cfg.Kata.Enabled {
+		return false
+	}
+	sharedDir := filepath.Join(q.cfg.Kata.SharedDir, entry.ContainerID)
+	for _, path := range entry.Paths {
+		if path == sharedDir {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *RFSQuota) isKataRuntime(runtime string) bool {
+	for _, name := range q.cfg.Kata.Runtimes {
+		if name == runtime || name == shortRuntimeName(runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyKataQuota 以 kata.quota 为沙箱容器设置配额，并将其共享目录纳入同一项目；
+// 打标不进入共享目录中的挂载点，其他容器的可写层保留各自的项目 ID。共享目录不可用时只约束沙箱容器的可写层并告警
+func (q *RFSQuota) applyKataQuota(ctx context.Context, namespace, containerID, upperdir, sharedDir string) (uint32, error) {
+	projID, err := q.applyQuota(ctx, namespace, containerID, upperdir, q.cfg.Kata.Quota, "", "")
+	if err != nil {
+		return 0, err
+	}
+	ctx = log.WithFields(ctx, zap.String("sharedDir", sharedDir))
+	if err := checkKataSharedDir(ctx, sharedDir, upperdir, projID); err != nil {
+		log.Ctx(ctx).Warn("Kata shared directory not added to sandbox project", zap.Error(err))
+		return projID, nil
+	}
+	if err := q.setProjectID(ctx, sharedDir, projID); err != nil {
+		log.Ctx(ctx).Warn("Failed to add Kata shared directory to sandbox project", zap.Error(err))
+		return projID, nil
+	}
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return projID, nil
+	}
+	entry.Paths = append(entry.Paths, sharedDir)
+	if err := q.stateManager.PutEntry(entry); err != nil {
+		return projID, err
+	}
+	log.Ctx(ctx).Info("Kata shared directory added to sandbox project", zap.Uint32("projectID", projID))
+	return projID, nil
+}
+
+// checkKataSharedDir 检查共享目录所在的挂载：默认位置通常在 tmpfs 上，此时无法设置项目配额，
+// 需将 Kata 的共享目录配置到可写层所在的文件系统
+func checkKataSharedDir(ctx context.Context, dir, upperdir string, projID uint32) error {
+	if err := xfs.ValidatePath(dir); err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %v", err)
+	}
+	if fs := snapshot.FilesystemType(resolved); fs == "tmpfs" {
+		return fmt.Errorf("%s is on tmpfs, point kata.shared_dir at a directory on the upperdir's filesystem", resolved)
+	}
+	return checkProjectDir(ctx, resolved, upperdir, projID)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/audit"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// labelLimits 以容器标签或 OCI 注解申请的限额取代默认块限额；未申请、读取失败或申请无效时返回 limits
+func (q *RFSQuota) labelLimits(ctx context.Context, namespace, containerID string, limits config.QuotaConfig) config.QuotaConfig {
+	cfg := q.cfg.LabelOverrides
+	if !cfg.Enabled || q.client == nil || strings.HasPrefix(containerID, buildkitKeyPrefix) {
+		return limits
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		log.Ctx(ctx).Debug("Failed to load container for label quota override", zap.Error(err))
+		return limits
+	}
+	soft, hard := labelValue(info, cfg.SoftKey), labelValue(info, cfg.HardKey)
+	if soft == "" && hard == "" {
+		return limits
+	}
+
+	newSoft, newHard, clamped, err := q.checkLabelLimits(soft, hard, limits.DefaultHard)
+	if err != nil {
+		log.Ctx(ctx).Warn("Ignoring invalid label quota override, using defaults", zap.Error(err))
+		return limits
+	}
+	if clamped != "" {
+		log.Ctx(ctx).Warn("Clamped label quota override", zap.String("soft", newSoft), zap.String("hard", newHard), zap.String("reason", clamped))
+		q.auditClamp(ctx, namespace, containerID, info.Labels, newSoft, newHard, fmt.Sprintf("requested soft %q, hard %q: %s", soft, hard, clamped))
+	} else {
+		log.Ctx(ctx).Info("Using label quota override", zap.String("soft", newSoft), zap.String("hard", newHard))
+	}
+	limits.DefaultSoft, limits.DefaultHard = newSoft, newHard
+	return limits
+}
+
+// checkLabelLimits 补全并校验申请的限额：只给出硬限制时软限制与之相同，只给出软限制时硬限制取默认值；
+// 硬限制超过 label_overrides.max_hard 时按上限设置，软限制随之收紧；低于 min_limit 的限额提高到下限。
+// 申请为 0 的限额在 XFS 中表示不限制，直接拒绝。clamped 说明所做的调整，未调整时为空
+func (q *RFSQuota) checkLabelLimits(soft, hard, defaultHard string) (string, string, string, error) {
+	cfg := q.cfg.LabelOverrides
+	for _, requested := range []string{soft, hard} {
+		if requested == "" {
+			continue
+		}
+		if size, err := xfs.ParseSize(requested); err == nil && size == 0 {
+			return "", "", "", fmt.Errorf("limit %q would disable the quota", requested)
+		}
+	}
+	if hard == "" {
+		hard = defaultHard
+	}
+	hardBytes, err := xfs.ParseSize(hard)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid hard limit: %v", err)
+	}
+	var clamped []string
+	if cfg.MaxHard != "" {
+		if maxBytes, err := xfs.ParseSize(cfg.MaxHard); err == nil && hardBytes > maxBytes {
+			hard, hardBytes = cfg.MaxHard, maxBytes
+			clamped = append(clamped, "hard lowered to max_hard "+cfg.MaxHard)
+		}
+	}
+	softBytes := hardBytes
+	if soft == "" {
+		soft = hard
+	} else if softBytes, err = xfs.ParseSize(soft); err != nil {
+		return "", "", "", fmt.Errorf("invalid soft limit: %v", err)
+	}
+	if softBytes > hardBytes {
+		if len(clamped) == 0 {
+			return "", "", "", fmt.Errorf("soft %s exceeds hard %s", soft, hard)
+		}
+		soft, softBytes = hard, hardBytes
+	}
+	if cfg.MinLimit != "" {
+		if minBytes, err := xfs.ParseSize(cfg.MinLimit); err == nil {
+			if hardBytes < minBytes {
+				hard = cfg.MinLimit
+				clamped = append(clamped, "hard raised to min_limit "+cfg.MinLimit)
+			}
+			if softBytes < minBytes {
+				soft = cfg.MinLimit
+				clamped = append(clamped, "soft raised to min_limit "+cfg.MinLimit)
+			}
+		}
+	}
+	return soft, hard, strings.Join(clamped, ", "), nil
+}
+
+// auditClamp 记录被调整的标签限额申请
+func (q *RFSQuota) auditClamp(ctx context.Context, namespace, containerID string, labels map[string]string, soft, hard, reason string) {
+	if q.audit == nil {
+		return
+	}
+	rec := audit.Record{
+		Event:       audit.EventLimitClamped,
+		ContainerID: containerID,
+		Namespace:   namespace,
+		SoftLimit:   soft,
+		HardLimit:   hard,
+		Reason:      reason,
+	}
+	if ns := labels[labelPodNamespace]; ns != "" {
+		rec.Pod = ns + "/" + labels[labelPodName]
+	}
+	if err := q.audit.Write(rec); err != nil {
+		log.Ctx(ctx).Warn("Failed to write audit record", zap.String("key", containerID), zap.Error(err))
+	}
+}
+
+// labelValue 返回容器标签或 OCI 注解的值，标签优先
+func labelValue(info containerRecord, key string) string {
+	if value := info.Labels[key]; value != "" {
+		return value
+	}
+	return info.Annotations[key]
+}
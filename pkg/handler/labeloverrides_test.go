@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"testing"
+
+	"RootfsQuota/pkg/config"
+)
+
+func TestCheckLabelLimits(t *testing.T) {
+	q := &RFSQuota{cfg: &config.Config{LabelOverrides: config.LabelOverridesConfig{
+		Enabled:  true,
+		MaxHard:  "50g",
+		MinLimit: "1g",
+	}}}
+	tests := []struct {
+		name       string
+		soft, hard string
+		wantSoft   string
+		wantHard   string
+		clamped    bool
+		wantErr    bool
+	}{
+		{name: "within clamps", soft: "8g", hard: "10g", wantSoft: "8g", wantHard: "10g"},
+		{name: "hard only", hard: "10g", wantSoft: "10g", wantHard: "10g"},
+		{name: "soft only takes the default hard", soft: "15g", wantSoft: "15g", wantHard: "20g"},
+		{name: "soft above hard", soft: "12g", hard: "10g", wantErr: true},
+		{name: "soft above default hard", soft: "30g", wantErr: true},
+		{name: "zero hard", hard: "0", wantErr: true},
+		{name: "zero hard with unit", soft: "1g", hard: "0g", wantErr: true},
+		{name: "zero soft", soft: "0", hard: "10g", wantErr: true},
+		{name: "invalid hard", hard: "lots", wantErr: true},
+		{name: "invalid soft", soft: "some", hard: "10g", wantErr: true},
+		{name: "above max_hard", hard: "100g", wantSoft: "50g", wantHard: "50g", clamped: true},
+		{name: "soft above max_hard", soft: "80g", hard: "100g", wantSoft: "50g", wantHard: "50g", clamped: true},
+		{name: "below min_limit", soft: "100m", hard: "500m", wantSoft: "1g", wantHard: "1g", clamped: true},
+		{name: "soft below min_limit", soft: "100m", hard: "10g", wantSoft: "1g", wantHard: "10g", clamped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			soft, hard, clamped, err := q.checkLabelLimits(tt.soft, tt.hard, "20g")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("checkLabelLimits(%q, %q) = %q, %q, want an error", tt.soft, tt.hard, soft, hard)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkLabelLimits(%q, %q): %v", tt.soft, tt.hard, err)
+			}
+			if soft != tt.wantSoft || hard != tt.wantHard || (clamped != "") != tt.clamped {
+				t.Errorf("checkLabelLimits(%q, %q) = %q, %q, clamped %q, want %q, %q, clamped %v",
+					tt.soft, tt.hard, soft, hard, clamped, tt.wantSoft, tt.wantHard, tt.clamped)
+			}
+		})
+	}
+}
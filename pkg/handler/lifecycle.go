@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/lifecycle"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// externalKeyPrefix 为生命周期通知推送的外部工作负载在状态文件中的键前缀，避免与容器 ID 冲突
+const externalKeyPrefix = "external:"
+
+// isExternalKey 判断条目是否为外部工作负载，这类条目没有对应的 containerd 容器
+func isExternalKey(key string) bool {
+	return strings.HasPrefix(key, externalKeyPrefix)
+}
+
+// 生命周期通知指标的 result 标签
+const (
+	lifecycleApplied  = "applied"
+	lifecycleExisting = "existing"
+	lifecycleRemoved  = "removed"
+	lifecycleRejected = "rejected"
+	lifecycleFailed   = "failed"
+)
+
+// runLifecycleWebhook 运行生命周期通知接收端
+func (q *RFSQuota) runLifecycleWebhook() {
+	cfg := q.cfg.LifecycleWebhook
+	var token string
+	if cfg.TokenFile != "" {
+		data, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			log.Error("Lifecycle webhook failed to start", zap.Error(fmt.Errorf("failed to read token file: %v", err)))
+			return
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if err := lifecycle.NewServer(cfg.Listen, token, q).Serve(q.ctx); err != nil {
+		log.Error("Lifecycle webhook failed", zap.Error(err))
+	}
+}
+
+// StartWorkload 为外部工作负载的目录设置配额，重复的通知返回已有配额
+func (q *RFSQuota) StartWorkload(ctx context.Context, req lifecycle.StartRequest) (workload lifecycle.Workload, err error) {
+	defer func() { countLifecycle("start", workload, err) }()
+	if q.standby.Load() {
+		return lifecycle.Workload{}, api.ErrStandby
+	}
+	if q.degraded.Active() {
+		return lifecycle.Workload{}, fmt.Errorf("filesystem is degraded, not setting quota")
+	}
+
+	key := externalKeyPrefix + req.ID
+	ctx = log.WithFields(ctx, zap.String("workload", req.ID), zap.String("dir", req.Dir), zap.String("action", "lifecycle-start"))
+	err = q.sched.Do(ctx, key, sched.CreateHigh, func(ctx context.Context) error {
+		if entry, exists := q.stateManager.GetEntry(key); exists {
+			if entry.Upperdir != req.Dir {
+				return fmt.Errorf("%w: workload %s already has a quota on %s", api.ErrConflict, req.ID, entry.Upperdir)
+			}
+			workload = externalWorkload(entry)
+			workload.Existing = true
+			return nil
+		}
+		if owner, ok := q.quotaOwner(req.Dir); ok {
+			return fmt.Errorf("%w: %s overlaps the quota of %s", api.ErrConflict, req.Dir, owner)
+		}
+		if err := q.checkUpperdir(req.Dir); err != nil {
+			countQuotaOp(quotaOpSet, err)
+			if skipQuota(err) {
+				return fmt.Errorf("%w: %v", lifecycle.ErrUnsupported, err)
+			}
+			return err
+		}
+		limits := q.cfg.LifecycleWebhook.Quota
+		if req.HardLimit != "" {
+			limits.DefaultSoft, limits.DefaultHard = req.SoftLimit, req.HardLimit
+		}
+		_, err := q.applyQuota(ctx, q.cfg.LifecycleWebhook.Namespace, key, req.Dir, limits, "", "")
+		countQuotaOp(quotaOpSet, err)
+		if err != nil {
+			return err
+		}
+		if req.HardLimit != "" {
+			// 通知中给出的限额由外部系统决定，策略收敛不再修改
+			if _, err := q.stateManager.UpdateEntry(key, func(e *xfs.Entry) { e.Pinned = true }); err != nil {
+				log.Ctx(ctx).Warn("Failed to pin requested limits", zap.Error(err))
+			}
+		}
+		q.publishQuotaSet(key)
+		entry, _ := q.stateManager.GetEntry(key)
+		workload = externalWorkload(entry)
+		return nil
+	})
+	if err != nil {
+		log.Ctx(ctx).Error("Failed to set external workload quota", zap.Error(err))
+		return lifecycle.Workload{}, err
+	}
+	if !workload.Existing {
+		log.Ctx(ctx).Info("External workload quota set successfully", zap.Uint32("projectID", workload.ProjectID))
+	}
+	return workload, nil
+}
+
+// quotaOwner 查找目录与其 upperdir 或额外目录重叠（相同、位于其下或包含它）的条目：
+// 重叠的目录会被重新打上项目 ID，把其他条目的数据计入外部工作负载，结束时还会被清零
+func (q *RFSQuota) quotaOwner(dir string) (string, bool) {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	for _, other := range q.stateManager.ListEntries() {
+		for _, path := range append([]string{other.Upperdir}, other.Paths...) {
+			if path != "" && (pathWithin(dir, path) || pathWithin(path, dir)) {
+				return other.ContainerID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// FinishWorkload 移除外部工作负载的配额并归还项目 ID
+func (q *RFSQuota) FinishWorkload(ctx context.Context, id string) (workload lifecycle.Workload, err error) {
+	defer func() { countLifecycle("finish", workload, err) }()
+	if q.standby.Load() {
+		return lifecycle.Workload{}, api.ErrStandby
+	}
+
+	key := externalKeyPrefix + id
+	entry, exists := q.stateManager.GetEntry(key)
+	if !exists {
+		return lifecycle.Workload{}, fmt.Errorf("%w: workload %s", api.ErrNotFound, id)
+	}
+	ctx = log.WithFields(ctx, zap.String("workload", id), zap.String("dir", entry.Upperdir), zap.String("action", "lifecycle-finish"))
+	if q.degraded.Active() {
+		// 与降级期间的删除事件相同，恢复后统一清理
+		q.degraded.deferDelete(key)
+		log.Ctx(ctx).Warn("Filesystem is degraded, deferring quota removal")
+		return externalWorkload(entry), nil
+	}
+	if err := q.sched.Do(ctx, key, sched.Delete, func(ctx context.Context) error {
+		return q.removeQuota(ctx, key, entry.ProjectID)
+	}); err != nil {
+		log.Ctx(ctx).Error("Failed to remove external workload quota", zap.Error(err))
+		return lifecycle.Workload{}, err
+	}
+	log.Ctx(ctx).Info("External workload quota removed successfully", zap.Uint32("projectID", entry.ProjectID))
+	return externalWorkload(entry), nil
+}
+
+// ListWorkloads 返回已设置配额的外部工作负载，按 ID 排序
+func (q *RFSQuota) ListWorkloads() []lifecycle.Workload {
+	workloads := []lifecycle.Workload{}
+	for _, entry := range q.stateManager.ListEntries() {
+		if isExternalKey(entry.ContainerID) {
+			workloads = append(workloads, externalWorkload(entry))
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].ID < workloads[j].ID })
+	return workloads
+}
+
+func externalWorkload(entry xfs.Entry) lifecycle.Workload {
+	return lifecycle.Workload{
+		ID:        strings.TrimPrefix(entry.ContainerID, externalKeyPrefix),
+		Dir:       entry.Upperdir,
+		ProjectID: entry.ProjectID,
+		SoftLimit: entry.SoftLimit,
+		HardLimit: entry.HardLimit,
+	}
+}
+
+// countLifecycle 统计生命周期通知的处理结果，请求本身有误（冲突、未记录、不支持）计为 rejected
+func countLifecycle(event string, workload lifecycle.Workload, err error) {
+	result := lifecycleApplied
+	switch {
+	case err == nil && workload.Existing:
+		result = lifecycleExisting
+	case err == nil && event == "finish":
+		result = lifecycleRemoved
+	case err == nil:
+	case errors.Is(err, api.ErrConflict), errors.Is(err, api.ErrNotFound), errors.Is(err, api.ErrStandby), errors.Is(err, lifecycle.ErrUnsupported):
+		result = lifecycleRejected
+	default:
+		result = lifecycleFailed
+	}
+	metrics.LifecycleNotifications.WithLabelValues(event, result).Inc()
+}
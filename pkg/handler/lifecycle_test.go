@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"testing"
+
+	"RootfsQuota/pkg/xfs"
+)
+
+func TestQuotaOwner(t *testing.T) {
+	q := &RFSQuota{stateManager: xfs.NewMemoryStateManager()}
+	for _, entry := range []xfs.Entry{
+		{ContainerID: "c1", ProjectID: 4301, Upperdir: "/var/lib/containerd/s/1/fs"},
+		{ContainerID: podGroupPrefix + "uid", ProjectID: 4302, Paths: []string{"/var/log/pods/ns_pod_uid"}},
+	} {
+		if err := q.stateManager.PutEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir   string
+		owner string
+	}{
+		{dir: "/var/lib/containerd/s/1/fs", owner: "c1"},
+		{dir: "/var/lib/containerd/s/1/fs/upper/", owner: "c1"},
+		{dir: "/var/lib/containerd/s", owner: "c1"},
+		{dir: "/var/log/pods/ns_pod_uid/app", owner: podGroupPrefix + "uid"},
+		{dir: "/var/log", owner: podGroupPrefix + "uid"},
+		{dir: "/var/lib/containerd/s/1/fs2"},
+		{dir: "/data/jobs/42/rw"},
+	}
+	for _, tt := range tests {
+		owner, ok := q.quotaOwner(tt.dir)
+		if owner != tt.owner || ok != (tt.owner != "") {
+			t.Errorf("quotaOwner(%q) = %q, %v, want %q", tt.dir, owner, ok, tt.owner)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// liftRetryInterval 为恢复限额失败或处于备用模式时的重试间隔
+const liftRetryInterval = time.Minute
+
+// liftTimers 按项目 ID 记录等待恢复限额的定时器，共享项目的成员共用一个定时器
+type liftTimers struct {
+	mutex  sync.Mutex
+	timers map[uint32]*time.Timer
+}
+
+func newLiftTimers() *liftTimers {
+	return &liftTimers{timers: make(map[uint32]*time.Timer)}
+}
+
+// schedule 在 at 时刻执行 fn，替换同一项目已有的定时器
+func (l *liftTimers) schedule(projID uint32, at time.Time, fn func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if t, exists := l.timers[projID]; exists {
+		t.Stop()
+	}
+	l.timers[projID] = time.AfterFunc(time.Until(at), fn)
+}
+
+func (l *liftTimers) cancel(projID uint32) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if t, exists := l.timers[projID]; exists {
+		t.Stop()
+		delete(l.timers, projID)
+	}
+}
+
+// LiftLimits 临时解除容器所在项目的限额，到期后自动恢复为状态文件中记录的限额
+func (q *RFSQuota) LiftLimits(containerID string, d time.Duration) (time.Time, error) {
+	if q.standby.Load() {
+		return time.Time{}, api.ErrStandby
+	}
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return time.Time{}, fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+
+	until := time.Now().Add(d)
+	if err := q.sched.Do(q.ctx, containerID, sched.Admin, func(ctx context.Context) error {
+		if err := q.setProjectQuota(ctx, entry.ProjectID, "0", "0", true); err != nil {
+			q.noteFilesystemError(err, entry.Upperdir)
+			return err
+		}
+		return q.markLifted(entry.ProjectID, until)
+	}); err != nil {
+		return time.Time{}, err
+	}
+	q.scheduleRestore(entry.ProjectID, until)
+
+	log.Info("Quota limits lifted",
+		zap.String("container", containerID),
+		zap.Uint32("projectID", entry.ProjectID),
+		zap.Time("until", until))
+	return until, nil
+}
+
+// RestoreLimits 提前结束临时解除，立即恢复限额
+func (q *RFSQuota) RestoreLimits(containerID string) (xfs.Entry, error) {
+	if q.standby.Load() {
+		return xfs.Entry{}, api.ErrStandby
+	}
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists {
+		return xfs.Entry{}, fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+	if entry.LiftedUntil.IsZero() {
+		return entry, nil
+	}
+	q.lifts.cancel(entry.ProjectID)
+	if err := q.sched.Do(q.ctx, containerID, sched.Admin, func(ctx context.Context) error {
+		return q.applyLiftedLimits(ctx, entry)
+	}); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// scheduleRestore 安排在 at 时刻恢复项目的限额
+func (q *RFSQuota) scheduleRestore(projID uint32, at time.Time) {
+	q.lifts.schedule(projID, at, func() { q.restoreLift(projID) })
+}
+
+// restoreLift 在解除到期时恢复项目的限额，失败时稍后重试
+func (q *RFSQuota) restoreLift(projID uint32) {
+	entry, exists := q.liftedEntry(projID)
+	if !exists {
+		q.lifts.cancel(projID)
+		return
+	}
+	if q.standby.Load() || q.degraded.Active() {
+		q.scheduleRestore(projID, time.Now().Add(liftRetryInterval))
+		return
+	}
+	if err := q.sched.Do(q.ctx, entry.ContainerID, sched.Reconcile, func(ctx context.Context) error {
+		return q.applyLiftedLimits(ctx, entry)
+	}); err != nil {
+		log.Error("Failed to restore lifted limits, retrying",
+			zap.Uint32("projectID", projID),
+			zap.Duration("retryIn", liftRetryInterval),
+			zap.Error(err))
+		q.scheduleRestore(projID, time.Now().Add(liftRetryInterval))
+		return
+	}
+	q.lifts.cancel(projID)
+}
+
+// liftedEntry 返回项目中处于解除状态的任一条目，成员被移除后仍可由分组条目恢复
+func (q *RFSQuota) liftedEntry(projID uint32) (xfs.Entry, bool) {
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.ProjectID == projID && !entry.LiftedUntil.IsZero() {
+			return entry, true
+		}
+	}
+	return xfs.Entry{}, false
+}
+
+// applyLiftedLimits 重新写入项目记录的限额并清除解除标记
+func (q *RFSQuota) applyLiftedLimits(ctx context.Context, entry xfs.Entry) error {
+	if err := q.setProjectQuota(ctx, entry.ProjectID, entry.SoftLimit, entry.HardLimit, true); err != nil {
+		q.noteFilesystemError(err, entry.Upperdir)
+		return err
+	}
+	if err := q.markLifted(entry.ProjectID, time.Time{}); err != nil {
+		return err
+	}
+	log.Info("Lifted quota limits restored",
+		zap.String("container", entry.ContainerID),
+		zap.Uint32("projectID", entry.ProjectID),
+		zap.String("soft", entry.SoftLimit),
+		zap.String("hard", entry.HardLimit))
+	return nil
+}
+
+// markLifted 设置共享同一项目的所有条目的解除到期时间，零值表示未解除
+func (q *RFSQuota) markLifted(projID uint32, until time.Time) error {
+	for _, other := range q.stateManager.ListEntries() {
+		if other.ProjectID != projID {
+			continue
+		}
+		if _, err := q.stateManager.UpdateEntry(other.ContainerID, func(e *xfs.Entry) {
+			e.LiftedUntil = until
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resumeLifts 为状态文件中仍处于解除状态的项目重新安排恢复，已到期的立即恢复
+func (q *RFSQuota) resumeLifts() {
+	scheduled := make(map[uint32]bool)
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.LiftedUntil.IsZero() || scheduled[entry.ProjectID] {
+			continue
+		}
+		scheduled[entry.ProjectID] = true
+		q.scheduleRestore(entry.ProjectID, entry.LiftedUntil)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/xfs"
+)
+
+// limitNotifier 为限额变更后通知容器内工作负载的方式
+type limitNotifier struct {
+	file string
+	// signal 为 0 时不发送信号
+	signal syscall.Signal
+	// sel 为 nil 时通知所有容器
+	sel *api.Selector
+}
+
+// limitNotice 为写入容器内通知文件的内容
+type limitNotice struct {
+	ContainerID    string    `json:"container_id"`
+	SoftLimit      string    `json:"soft_limit"`
+	HardLimit      string    `json:"hard_limit"`
+	SoftLimitBytes uint64    `json:"soft_limit_bytes"`
+	HardLimitBytes uint64    `json:"hard_limit_bytes"`
+	PreviousSoft   string    `json:"previous_soft,omitempty"`
+	PreviousHard   string    `json:"previous_hard,omitempty"`
+	Source         string    `json:"source"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// newLimitNotifier 按配置创建通知方式，未配置 file 与 signal 时返回 nil
+func newLimitNotifier(cfg config.LimitNotifyConfig) (*limitNotifier, error) {
+	if cfg.File == "" && cfg.Signal == "" {
+		return nil, nil
+	}
+	ln := &limitNotifier{file: cfg.File}
+	if cfg.Signal != "" {
+		sig, err := notify.ParseSignal(cfg.Signal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit_notify.signal: %v", err)
+		}
+		ln.signal = sig
+	}
+	if cfg.Selector != "" {
+		sel, err := api.ParseSelector(cfg.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit_notify.selector: %v", err)
+		}
+		ln.sel = &sel
+	}
+	return ln, nil
+}
+
+// notifyLimitChanges 在后台通知限额已变更的容器，不阻塞调度器；失败只记录日志
+func (q *RFSQuota) notifyLimitChanges(entries []xfs.Entry, source string) {
+	if q.limitNotify == nil || q.client == nil || len(entries) == 0 {
+		return
+	}
+	go func() {
+		for _, entry := range entries {
+			ctx, cancel := context.WithTimeout(q.namespaceContext(q.entryNamespace(entry)), notifyTimeout)
+			err := q.notifyWorkload(ctx, entry, source)
+			cancel()
+			if err != nil {
+				metrics.LimitNotifications.WithLabelValues("error").Inc()
+				log.Warn("Failed to notify workload of limit change",
+					zap.String("container", entry.ContainerID), zap.Error(err))
+			}
+		}
+	}()
+}
+
+// notifyWorkload 写入通知文件并发送信号，未被选择器选中的容器跳过
+func (q *RFSQuota) notifyWorkload(ctx context.Context, entry xfs.Entry, source string) error {
+	ln := q.limitNotify
+	if strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || isExternalKey(entry.ContainerID) {
+		return nil
+	}
+	if ln.sel != nil {
+		labels := q.containerLabels(entry)
+		if labels == nil || !ln.sel.Matches(labels) {
+			return nil
+		}
+	}
+
+	container, err := q.client.LoadContainer(ctx, entry.ContainerID)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if ln.file != "" {
+		notice := limitNotice{
+			ContainerID: entry.ContainerID,
+			SoftLimit:   entry.SoftLimit,
+			HardLimit:   entry.HardLimit,
+			Source:      source,
+			Timestamp:   time.Now(),
+		}
+		notice.SoftLimitBytes, _ = xfs.ParseSize(entry.SoftLimit)
+		notice.HardLimitBytes, _ = xfs.ParseSize(entry.HardLimit)
+		if n := len(entry.LimitHistory); n > 0 {
+			notice.PreviousSoft = entry.LimitHistory[n-1].OldSoft
+			notice.PreviousHard = entry.LimitHistory[n-1].OldHard
+		}
+		data, err := json.MarshalIndent(notice, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := notify.WriteContainerFile(task.Pid(), ln.file, append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if ln.signal != 0 {
+		if err := task.Kill(ctx, ln.signal); err != nil {
+			return fmt.Errorf("failed to send %v: %v", ln.signal, err)
+		}
+	}
+	metrics.LimitNotifications.WithLabelValues("ok").Inc()
+	log.Ctx(ctx).Debug("Workload notified of limit change",
+		zap.String("container", entry.ContainerID),
+		zap.String("soft", entry.SoftLimit),
+		zap.String("hard", entry.HardLimit))
+	return nil
+}
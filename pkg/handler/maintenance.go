@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/maintenance"
+	"RootfsQuota/pkg/metrics"
+)
+
+// maintenanceCheckInterval 为检查维护窗口开始与结束的间隔
+const maintenanceCheckInterval = 30 * time.Second
+
+// newMaintenanceCalendar 按配置创建维护窗口，未配置窗口时返回 nil
+func newMaintenanceCalendar(cfg config.MaintenanceConfig) (*maintenance.Calendar, error) {
+	if len(cfg.Windows) == 0 {
+		return nil, nil
+	}
+	var location *time.Location
+	if cfg.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	windows := make([]maintenance.Window, 0, len(cfg.Windows))
+	for _, w := range cfg.Windows {
+		schedule, err := maintenance.ParseSchedule(w.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, maintenance.Window{
+			Name:           w.Name,
+			Schedule:       schedule,
+			Duration:       time.Duration(w.DurationMinutes) * time.Minute,
+			Action:         w.Action,
+			RelaxedPercent: w.RelaxedCriticalPercent,
+		})
+	}
+	return maintenance.NewCalendar(windows, location), nil
+}
+
+// enforcementHeld 判断当前的维护窗口是否暂停停止可牺牲容器，freePercent 为当前可用空间百分比；
+// relax 窗口内可用空间低于 relaxed_critical_percent 时照常停止
+func (q *RFSQuota) enforcementHeld(freePercent float64) (string, bool) {
+	w, active := q.maintenance.Active(time.Now())
+	if !active {
+		return "", false
+	}
+	if w.Action == maintenance.ActionRelax && freePercent < w.RelaxedPercent {
+		return "", false
+	}
+	return w.Name, true
+}
+
+// runMaintenanceMonitor 记录维护窗口的开始与结束，并导出当前生效的窗口
+func (q *RFSQuota) runMaintenanceMonitor() {
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	current := ""
+	for {
+		w, active := q.maintenance.Active(time.Now())
+		name := ""
+		if active {
+			name = w.Name
+		}
+		if name != current {
+			if current != "" {
+				metrics.MaintenanceWindowActive.WithLabelValues(current).Set(0)
+				log.Info("Maintenance window ended", zap.String("window", current))
+			}
+			if name != "" {
+				metrics.MaintenanceWindowActive.WithLabelValues(name).Set(1)
+				log.Info("Maintenance window started, destructive actions are held",
+					zap.String("window", name), zap.String("action", w.Action))
+			}
+			current = name
+		}
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
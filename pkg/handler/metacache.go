@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/containers"
+	e "github.com/containerd/containerd/events"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/typeurl/v2"
+
+	"RootfsQuota/pkg/metrics"
+)
+
+// containerRecord 为缓存的 containerd 容器元数据，只保留配额处理用到的字段
+type containerRecord struct {
+	Image       string
+	Runtime     string
+	Snapshotter string
+	SnapshotKey string
+	Labels      map[string]string
+	// Annotations 为 OCI 规格中的注解，规格本身不缓存
+	Annotations map[string]string
+	// Mounts 为 OCI 规格中的 tmpfs 与绑定挂载
+	Mounts []specMount
+}
+
+// specMount 为 OCI 规格中的一个挂载
+type specMount struct {
+	Destination string
+	Type        string
+	Source      string
+	ReadOnly    bool
+}
+
+func newContainerRecord(c containers.Container) containerRecord {
+	r := containerRecord{
+		Image:       c.Image,
+		Runtime:     c.Runtime.Name,
+		Snapshotter: c.Snapshotter,
+		SnapshotKey: c.SnapshotKey,
+		Labels:      c.Labels,
+	}
+	if c.Spec != nil {
+		var spec struct {
+			Annotations map[string]string `json:"annotations"`
+			Mounts      []struct {
+				Destination string   `json:"destination"`
+				Type        string   `json:"type"`
+				Source      string   `json:"source"`
+				Options     []string `json:"options"`
+			} `json:"mounts"`
+		}
+		if err := json.Unmarshal(c.Spec.GetValue(), &spec); err == nil {
+			r.Annotations = spec.Annotations
+			for _, m := range spec.Mounts {
+				bind := m.Type == "bind" || slices.Contains(m.Options, "bind") || slices.Contains(m.Options, "rbind")
+				if m.Type != "tmpfs" && !bind {
+					continue
+				}
+				typ := m.Type
+				if bind {
+					typ = "bind"
+				}
+				r.Mounts = append(r.Mounts, specMount{
+					Destination: m.Destination,
+					Type:        typ,
+					Source:      m.Source,
+					ReadOnly:    slices.Contains(m.Options, "ro"),
+				})
+			}
+		}
+	}
+	return r
+}
+
+// tmpfsPaths 返回容器内挂载为 tmpfs 的目录，/dev 除外
+func (r containerRecord) tmpfsPaths() []string {
+	var paths []string
+	for _, m := range r.Mounts {
+		if m.Type == "tmpfs" && m.Destination != "/dev" {
+			paths = append(paths, m.Destination)
+		}
+	}
+	return paths
+}
+
+// pod 返回标签中的 Pod 信息，非 Kubernetes 容器返回 false
+func (r containerRecord) pod() (podInfo, bool) {
+	if r.Labels[labelPodUID] == "" {
+		return podInfo{}, false
+	}
+	return podInfo{
+		UID:       r.Labels[labelPodUID],
+		Name:      r.Labels[labelPodName],
+		Namespace: r.Labels[labelPodNamespace],
+	}, true
+}
+
+// metaCache 按命名空间与容器 ID 缓存容器元数据，由容器的创建、更新与删除事件失效；
+// 订阅中断期间可能错过事件，重新连接时清空，ttl 为兜底的最长保留时间
+type metaCache struct {
+	mutex sync.Mutex
+	ttl   time.Duration
+	items map[string]cachedRecord
+	// generation 在每次失效时递增，查询期间发生过失效的结果不写入缓存，避免写回旧数据
+	generation uint64
+}
+
+type cachedRecord struct {
+	record containerRecord
+	at     time.Time
+}
+
+func newMetaCache(ttl time.Duration) *metaCache {
+	return &metaCache{ttl: ttl, items: make(map[string]cachedRecord)}
+}
+
+func metaKey(namespace, containerID string) string {
+	return namespace + "/" + containerID
+}
+
+func (c *metaCache) get(namespace, containerID string) (containerRecord, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.items[metaKey(namespace, containerID)]
+	if !ok || time.Since(item.at) > c.ttl {
+		metrics.MetadataCacheLookups.WithLabelValues("miss").Inc()
+		return containerRecord{}, false
+	}
+	metrics.MetadataCacheLookups.WithLabelValues("hit").Inc()
+	return item.record, true
+}
+
+// begin 返回查询 containerd 前的失效计数，写入时交给 put
+func (c *metaCache) begin() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation
+}
+
+func (c *metaCache) put(namespace, containerID string, r containerRecord, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.items[metaKey(namespace, containerID)] = cachedRecord{record: r, at: time.Now()}
+	metrics.MetadataCacheEntries.Set(float64(len(c.items)))
+}
+
+func (c *metaCache) invalidate(namespace, containerID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	delete(c.items, metaKey(namespace, containerID))
+	metrics.MetadataCacheEntries.Set(float64(len(c.items)))
+}
+
+func (c *metaCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.items = make(map[string]cachedRecord)
+	metrics.MetadataCacheEntries.Set(0)
+}
+
+func (c *metaCache) size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.items)
+}
+
+// lookupContainer 返回容器的元数据，命名空间取自 ctx；未命中缓存时向 containerd 查询并写入缓存
+func (q *RFSQuota) lookupContainer(ctx context.Context, containerID string) (containerRecord, error) {
+	if q.client == nil {
+		return containerRecord{}, fmt.Errorf("not connected to containerd")
+	}
+	namespace, _ := namespaces.Namespace(ctx)
+	var generation uint64
+	if q.meta != nil {
+		if r, ok := q.meta.get(namespace, containerID); ok {
+			return r, nil
+		}
+		generation = q.meta.begin()
+	}
+	info, err := q.client.ContainerService().Get(ctx, containerID)
+	if err != nil {
+		return containerRecord{}, err
+	}
+	r := newContainerRecord(info)
+	if q.meta != nil {
+		q.meta.put(namespace, containerID, r, generation)
+	}
+	return r, nil
+}
+
+// listContainers 列出 ctx 所在命名空间的容器 ID，同时以一次查询填充缓存
+func (q *RFSQuota) listContainers(ctx context.Context) ([]string, error) {
+	var generation uint64
+	if q.meta != nil {
+		generation = q.meta.begin()
+	}
+	list, err := q.client.ContainerService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	namespace, _ := namespaces.Namespace(ctx)
+	ids := make([]string, 0, len(list))
+	for _, c := range list {
+		ids = append(ids, c.ID)
+		if q.meta != nil {
+			q.meta.put(namespace, c.ID, newContainerRecord(c), generation)
+		}
+	}
+	return ids, nil
+}
+
+// containerSnapshot 为挂载解析提供容器的快照器与快照键
+func (q *RFSQuota) containerSnapshot(ctx context.Context, containerID string) (string, string, error) {
+	r, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		return "", "", err
+	}
+	return r.Snapshotter, r.SnapshotKey, nil
+}
+
+// observeContainerEvent 在容器元数据变化时使缓存失效；在事件排队前调用，
+// 之后处理的事件总能读到新的元数据
+func (q *RFSQuota) observeContainerEvent(envelope *e.Envelope) {
+	if q.meta == nil || !strings.HasPrefix(envelope.Topic, "/containers/") {
+		return
+	}
+	event, err := typeurl.UnmarshalAny(envelope.Event)
+	if err != nil {
+		return
+	}
+	switch ev := event.(type) {
+	case *events.ContainerCreate:
+		q.meta.invalidate(envelope.Namespace, ev.ID)
+	case *events.ContainerUpdate:
+		q.meta.invalidate(envelope.Namespace, ev.ID)
+	case *events.ContainerDelete:
+		q.meta.invalidate(envelope.Namespace, ev.ID)
+	}
+}
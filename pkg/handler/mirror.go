@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/mirror"
+	"RootfsQuota/pkg/xfs"
+)
+
+// runStateMirror 维护状态的只读镜像：条目变化时或用量到期刷新时重写镜像，其余时候不写文件。
+// Pod 信息按容器缓存，只在容器首次出现时读取标签
+func (q *RFSQuota) runStateMirror() {
+	cfg := q.cfg.Mirror
+	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	usageInterval := time.Duration(cfg.UsageIntervalSeconds) * time.Second
+
+	var (
+		written  bool
+		lastGen  uint64
+		usageAt  time.Time
+		usages   map[uint32]*mirror.Usage
+		pods     = make(map[string]*mirror.Pod)
+		failures int
+	)
+	for {
+		// 备用实例不写镜像，避免与同一路径上的主实例交替覆盖
+		if q.standby.Load() {
+			select {
+			case <-ticker.C:
+				continue
+			case <-q.ctx.Done():
+				return
+			}
+		}
+		gen := q.stateManager.Generation()
+		refresh := time.Since(usageAt) >= usageInterval
+		if refresh {
+			// 用量取自后台轮询的快照，快照尚未更新时不重写
+			if snapshot, err := q.latestUsage(); err == nil && snapshot.At.After(usageAt) {
+				usages = make(map[uint32]*mirror.Usage, len(snapshot.Projects))
+				for id, u := range snapshot.Projects {
+					usages[id] = &mirror.Usage{
+						UsedBytes:      u.UsedBytes,
+						UsedInodes:     u.UsedInodes,
+						SoftLimitBytes: u.SoftLimitBytes,
+						HardLimitBytes: u.HardLimitBytes,
+					}
+				}
+				usageAt = snapshot.At
+			} else {
+				// 用量不可用时沿用上次结果，下个检查间隔再试
+				refresh = false
+			}
+		}
+
+		if !written || gen != lastGen || refresh {
+			doc := q.mirrorDocument(gen, usages, usageAt, pods)
+			if err := mirror.Write(cfg.Path, doc); err != nil {
+				// 连续失败只记录第一次，避免刷屏
+				if failures == 0 {
+					log.Warn("Failed to write state mirror", zap.String("path", cfg.Path), zap.Error(err))
+				}
+				failures++
+			} else {
+				written, lastGen, failures = true, gen, 0
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// mirrorDocument 由当前状态生成镜像内容，并清理已删除容器的 Pod 缓存
+func (q *RFSQuota) mirrorDocument(gen uint64, usages map[uint32]*mirror.Usage, usageAt time.Time, pods map[string]*mirror.Pod) mirror.Document {
+	entries := q.stateManager.ListEntries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ContainerID < entries[j].ContainerID })
+
+	doc := mirror.Document{Generation: gen, GeneratedAt: time.Now(), Entries: make([]mirror.Entry, 0, len(entries))}
+	if usages != nil {
+		doc.UsageAt = usageAt
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		seen[entry.ContainerID] = true
+		pod, cached := pods[entry.ContainerID]
+		if !cached && statsEntry(entry) {
+			pod = q.mirrorPod(entry)
+			// 读取失败（如尚未连接 containerd）时不缓存，下次重试
+			if pod != nil || q.client != nil {
+				pods[entry.ContainerID] = pod
+			}
+		}
+		doc.Entries = append(doc.Entries, mirror.Entry{
+			ContainerID: entry.ContainerID,
+			Namespace:   entry.Namespace,
+			ProjectID:   entry.ProjectID,
+			Upperdir:    entry.Upperdir,
+			SoftLimit:   entry.SoftLimit,
+			HardLimit:   entry.HardLimit,
+			Group:       entry.Group,
+			Paths:       entry.Paths,
+			CreatedAt:   entry.CreatedAt,
+			LiftedUntil: entry.LiftedUntil,
+			Pod:         pod,
+			Usage:       usages[entry.ProjectID],
+		})
+	}
+	for id := range pods {
+		if !seen[id] {
+			delete(pods, id)
+		}
+	}
+	return doc
+}
+
+// mirrorPod 从容器的 CRI 标签读取 Pod 信息，不属于 Pod 时返回 nil
+func (q *RFSQuota) mirrorPod(entry xfs.Entry) *mirror.Pod {
+	labels := q.containerLabels(entry)
+	if labels[labelPodUID] == "" {
+		return nil
+	}
+	return &mirror.Pod{
+		UID:       labels[labelPodUID],
+		Name:      labels[labelPodName],
+		Namespace: labels[labelPodNamespace],
+	}
+}
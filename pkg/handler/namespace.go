@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+)
+
+// nsGroupPrefix 为命名空间共享项目在状态文件中的键前缀
+const nsGroupPrefix = "ns:"
+
+// applyNamespaceQuota 将容器 rootfs 加入所属命名空间的共享项目，命名空间首个容器到达时创建项目，
+// 并纳入配置的额外目录
+func (q *RFSQuota) applyNamespaceQuota(ctx context.Context, namespace string, nsq config.NamespaceQuotaConfig, containerID, upperdir string) (uint32, error) {
+	groupKey := nsGroupPrefix + namespace
+	return q.joinGroup(ctx, namespace, groupSpec{
+		key:    groupKey,
+		kind:   "Namespace",
+		limits: func(context.Context) config.QuotaConfig { return nsq.Quota },
+		paths: func(ctx context.Context, projID uint32) []string {
+			var paths []string
+			for _, path := range nsq.Paths {
+				if err := q.setProjectID(ctx, path, projID); err != nil {
+					log.Ctx(ctx).Warn("Failed to add namespace path to project", zap.String("path", path), zap.Error(err))
+					continue
+				}
+				q.tagOwner(ctx, path, groupKey, "")
+				paths = append(paths, path)
+			}
+			return paths
+		},
+	}, containerID, upperdir)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// runNestedScan 周期检查容器内引擎（Docker-in-Docker、嵌套 containerd/podman）的快照目录，
+// 项目 ID 不一致时重新打标，保证内层镜像层与容器写入都计入容器的配额
+func (q *RFSQuota) runNestedScan() {
+	interval := time.Duration(q.cfg.Nested.IntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			q.scanNested()
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) scanNested() {
+	if q.degraded.Active() || q.standby.Load() {
+		return
+	}
+
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" || isGroupKey(entry.ContainerID) {
+			continue
+		}
+		trees, err := xfs.CheckNested(q.ctx, entry.Upperdir, entry.ProjectID, q.cfg.Nested.MaxEntries)
+		if err != nil {
+			log.Warn("Failed to check nested snapshot directories",
+				zap.String("container", entry.ContainerID), zap.Error(err))
+		}
+		for _, tree := range trees {
+			if tree.Mismatched == 0 {
+				continue
+			}
+			q.retagNested(entry, tree)
+		}
+	}
+}
+
+// retagNested 将内层快照目录整体重新打上容器的项目 ID，与事件处理按容器串行
+func (q *RFSQuota) retagNested(entry xfs.Entry, tree xfs.NestedTree) {
+	log.Warn("Nested snapshot directory has files outside the container's project, re-tagging",
+		zap.String("container", entry.ContainerID),
+		zap.Uint32("projectID", entry.ProjectID),
+		zap.String("root", tree.Root),
+		zap.Int("mismatched", tree.Mismatched),
+		zap.Int("scanned", tree.Scanned),
+		zap.Strings("samples", tree.Samples))
+
+	if err := q.sched.Do(q.ctx, entry.ContainerID, sched.Reconcile, func(ctx context.Context) error {
+		// 等待期间容器可能已被删除，项目 ID 也可能已归还
+		current, exists := q.stateManager.GetEntry(entry.ContainerID)
+		if !exists || current.ProjectID != entry.ProjectID {
+			return nil
+		}
+		if err := q.setProjectID(ctx, tree.Root, entry.ProjectID); err != nil {
+			q.noteFilesystemError(err, tree.Root)
+			return err
+		}
+		metrics.NestedRetagged.Inc()
+		return nil
+	}); err != nil {
+		log.Error("Failed to re-tag nested snapshot directory",
+			zap.String("container", entry.ContainerID),
+			zap.String("root", tree.Root),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/health"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+func nestedRetagged(t *testing.T) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.NestedRetagged.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+// nestedUpperdir creates an upperdir holding an inner Docker snapshot tree.
+func nestedUpperdir(t *testing.T) string {
+	t.Helper()
+	upperdir := t.TempDir()
+	root := filepath.Join(upperdir, "var/lib/docker/overlay2")
+	if err := os.MkdirAll(filepath.Join(root, "l1/diff"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "l1/diff/file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return upperdir
+}
+
+// TestScanNested needs a filesystem with project quota support below the
+// test's temp dir and CAP_SYS_ADMIN; it is skipped elsewhere.
+func TestScanNested(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := &RFSQuota{
+		cfg:          &config.Config{Nested: config.NestedConfig{Enabled: true, MaxEntries: 100}},
+		stateManager: xfs.NewMemoryStateManager(),
+		ctx:          ctx,
+		health:       health.NewStatus(),
+		degraded:     newDegradedState(),
+		sched:        sched.New(1, sched.DefaultOrder),
+	}
+	if err := q.setProjectID(ctx, t.TempDir(), 4300); err != nil {
+		t.Skipf("project ids not supported here: %v", err)
+	}
+
+	upperdir := nestedUpperdir(t)
+	groupUpperdir := nestedUpperdir(t)
+	for _, entry := range []xfs.Entry{
+		{ContainerID: "c1", ProjectID: 4301, Upperdir: upperdir},
+		// shared projects are checked through their members only
+		{ContainerID: nsGroupPrefix + "team", ProjectID: 4302, Upperdir: groupUpperdir},
+		{ContainerID: podGroupPrefix + "uid", ProjectID: 4303},
+	} {
+		if err := q.stateManager.PutEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(upperdir string, projID uint32) int {
+		t.Helper()
+		trees, err := xfs.CheckNested(ctx, upperdir, projID, 0)
+		if err != nil || len(trees) != 1 {
+			t.Fatalf("CheckNested() = %+v, %v", trees, err)
+		}
+		return trees[0].Mismatched
+	}
+
+	q.standby.Store(true)
+	before := nestedRetagged(t)
+	q.scanNested()
+	if got := nestedRetagged(t) - before; got != 0 {
+		t.Errorf("scanNested() in standby re-tagged %v trees, want 0", got)
+	}
+	q.standby.Store(false)
+
+	q.scanNested()
+	if got := nestedRetagged(t) - before; got != 1 {
+		t.Errorf("scanNested() re-tagged %v trees, want 1", got)
+	}
+	if n := check(upperdir, 4301); n != 0 {
+		t.Errorf("container tree has %d mismatched entries after scanNested(), want 0", n)
+	}
+	if n := check(groupUpperdir, 4302); n == 0 {
+		t.Error("group tree was re-tagged, want it left alone")
+	}
+
+	// a consistent tree is not tagged again
+	q.scanNested()
+	if got := nestedRetagged(t) - before; got != 1 {
+		t.Errorf("second scanNested() re-tagged %v trees in total, want 1", got)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/version"
+)
+
+// 节点注解中的执行状态
+const (
+	enforcementOK        = "ok"
+	enforcementStandby   = "standby"
+	enforcementDisabled  = "disabled"
+	enforcementDegraded  = "degraded"
+	enforcementUnhealthy = "unhealthy"
+)
+
+// enforcementTracker 记录本进程内未纳入管理（不支持、不允许或已退出）与配额永久失败的容器，
+// 启动时由同步重新填充
+type enforcementTracker struct {
+	mutex     sync.Mutex
+	unmanaged map[string]bool
+	failed    map[string]bool
+}
+
+func newEnforcementTracker() *enforcementTracker {
+	return &enforcementTracker{unmanaged: make(map[string]bool), failed: make(map[string]bool)}
+}
+
+// observe 记录一次配额设置的结果；可重试的错误不改变记录，永久失败由 fail 记录
+func (t *enforcementTracker) observe(containerID string, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		delete(t.unmanaged, containerID)
+		delete(t.failed, containerID)
+	case skipQuota(err):
+		t.unmanaged[containerID] = true
+		delete(t.failed, containerID)
+	}
+}
+
+func (t *enforcementTracker) fail(containerID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.failed[containerID] = true
+}
+
+func (t *enforcementTracker) forget(containerID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.unmanaged, containerID)
+	delete(t.failed, containerID)
+}
+
+func (t *enforcementTracker) counts() (unmanaged, failed int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.unmanaged), len(t.failed)
+}
+
+// enforcementSummary 返回写入节点注解的摘要，格式为空格分隔的 key=value，
+// 便于 kubectl get nodes -o custom-columns 直接显示
+func (q *RFSQuota) enforcementSummary() string {
+	managed := 0
+	for _, entry := range q.stateManager.ListEntries() {
+		if !isGroupKey(entry.ContainerID) {
+			managed++
+		}
+	}
+	unmanaged, failed := q.enforcement.counts()
+
+	state := enforcementOK
+	switch {
+	case q.standby.Load():
+		state = enforcementStandby
+	case !q.cfg.Features.Enabled(config.FeatureEnforcement):
+		state = enforcementDisabled
+	case q.degraded.Active():
+		state = enforcementDegraded
+	case !q.health.Healthy():
+		state = enforcementUnhealthy
+	}
+	return fmt.Sprintf("state=%s managed=%d unmanaged=%d failures=%d version=%s updated=%s",
+		state, managed, unmanaged, failed, version.Version, time.Now().UTC().Format(time.RFC3339))
+}
+
+// runNodeAnnotation 周期将执行状态摘要写入本节点的注解，失败时只在首次记录警告
+func (q *RFSQuota) runNodeAnnotation() {
+	cfg := q.cfg.NodeAnnotation
+	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		ctx, cancel := context.WithTimeout(q.ctx, notifyTimeout)
+		err := q.annotator.Annotate(ctx, cfg.Key, q.enforcementSummary())
+		cancel()
+		switch {
+		case err != nil && failures == 0:
+			log.Warn("Failed to annotate node with enforcement status", zap.String("node", cfg.NodeName), zap.Error(err))
+			failures++
+		case err != nil:
+			failures++
+		case failures > 0:
+			log.Info("Node annotation recovered", zap.String("node", cfg.NodeName), zap.Int("failures", failures))
+			failures = 0
+		}
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/audit"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+)
+
+// errOptedOut 表示容器通过标签退出了配额管理，且配置允许其退出
+var errOptedOut = errors.New("container opted out of quota enforcement")
+
+// 退出请求的处理结果，用于指标与审计
+const (
+	optOutHonored = "honored"
+	optOutDenied  = "denied"
+)
+
+// checkOptOut 判断容器是否请求并被允许退出配额管理，允许时返回 errOptedOut。
+// 每个容器的请求只在本进程内首次遇到时记录审计日志与指标，重试与重新对账不重复记录
+func (q *RFSQuota) checkOptOut(ctx context.Context, namespace, containerID string) error {
+	cfg := q.cfg.OptOut
+	if len(cfg.Namespaces) == 0 && len(cfg.PodNamespaces) == 0 {
+		return nil
+	}
+	labels := q.containerLabelsIn(namespace, containerID)
+	requested, honored := q.optOutAllowed(namespace, labels)
+	if !requested {
+		return nil
+	}
+	result := optOutDenied
+	if honored {
+		result = optOutHonored
+	}
+	if _, seen := q.optOuts.LoadOrStore(containerID, result); !seen {
+		metrics.QuotaOptOuts.WithLabelValues(result).Inc()
+		q.auditOptOut(ctx, namespace, containerID, labels, result)
+		if honored {
+			log.Ctx(ctx).Warn("Container opted out of quota enforcement", zap.String("label", cfg.Label))
+		} else {
+			log.Ctx(ctx).Warn("Ignoring quota opt-out label outside allowed namespaces", zap.String("label", cfg.Label))
+		}
+	}
+	if honored {
+		return errOptedOut
+	}
+	return nil
+}
+
+// optOutAllowed 判断标签是否请求退出配额管理，以及配置是否允许该命名空间的容器退出
+func (q *RFSQuota) optOutAllowed(namespace string, labels map[string]string) (requested, honored bool) {
+	cfg := q.cfg.OptOut
+	if labels[cfg.Label] != "false" {
+		return false, false
+	}
+	podNamespace := labels[labelPodNamespace]
+	honored = slices.Contains(cfg.Namespaces, namespace) ||
+		(podNamespace != "" && slices.Contains(cfg.PodNamespaces, podNamespace))
+	return true, honored
+}
+
+// containerLabelsIn 读取指定命名空间内容器的 containerd 标签，读取失败时返回 nil
+func (q *RFSQuota) containerLabelsIn(namespace, containerID string) map[string]string {
+	if q.client == nil {
+		return nil
+	}
+	r, err := q.lookupContainer(q.namespaceContext(namespace), containerID)
+	if err != nil {
+		return nil
+	}
+	return r.Labels
+}
+
+func (q *RFSQuota) auditOptOut(ctx context.Context, namespace, containerID string, labels map[string]string, result string) {
+	if q.audit == nil {
+		return
+	}
+	rec := audit.Record{
+		Event:       audit.EventQuotaOptOut,
+		ContainerID: containerID,
+		Namespace:   namespace,
+		Reason:      result,
+	}
+	if ns := labels[labelPodNamespace]; ns != "" {
+		rec.Pod = ns + "/" + labels[labelPodName]
+	}
+	if err := q.audit.Write(rec); err != nil {
+		log.Ctx(ctx).Warn("Failed to write audit record", zap.String("key", containerID), zap.Error(err))
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+const (
+	// podGroupPrefix 为 Pod 共享项目在状态文件中的键前缀
+	podGroupPrefix = "pod:"
+
+	labelPodUID       = "io.kubernetes.pod.uid"
+	labelPodName      = "io.kubernetes.pod.name"
+	labelPodNamespace = "io.kubernetes.pod.namespace"
+)
+
+// podInfo 为从 CRI 容器标签中解析出的 Pod 信息
+type podInfo struct {
+	UID       string
+	Name      string
+	Namespace string
+}
+
+// lookupPod 读取容器标签中的 Pod 信息，非 Kubernetes 容器返回 false
+func (q *RFSQuota) lookupPod(namespace, containerID string) (podInfo, bool) {
+	if q.client == nil {
+		return podInfo{}, false
+	}
+	r, err := q.lookupContainer(q.namespaceContext(namespace), containerID)
+	if err != nil {
+		return podInfo{}, false
+	}
+	return r.pod()
+}
+
+// podEphemeralPaths 返回 Pod 的日志目录与 emptyDir 目录中实际存在的部分
+func (q *RFSQuota) podEphemeralPaths(pod podInfo) []string {
+	var paths []string
+	logDir := filepath.Join(q.cfg.PodEphemeral.PodLogDir, fmt.Sprintf("%s_%s_%s", pod.Namespace, pod.Name, pod.UID))
+	if info, err := os.Stat(logDir); err == nil && info.IsDir() {
+		paths = append(paths, logDir)
+	}
+
+	emptyDirRoot := filepath.Join(q.cfg.PodEphemeral.KubeletRoot, "pods", pod.UID, "volumes", "kubernetes.io~empty-dir")
+	vols, err := os.ReadDir(emptyDirRoot)
+	if err != nil {
+		return paths
+	}
+	for _, vol := range vols {
+		if vol.IsDir() {
+			paths = append(paths, filepath.Join(emptyDirRoot, vol.Name()))
+		}
+	}
+	return paths
+}
+
+// applyPodQuota 将容器 rootfs 加入所属 Pod 的共享项目，Pod 首个容器到达时创建项目，
+// 并纳入 Pod 的日志目录与 emptyDir
+func (q *RFSQuota) applyPodQuota(ctx context.Context, namespace string, pod podInfo, containerID, upperdir string) (uint32, error) {
+	return q.joinGroup(ctx, namespace, groupSpec{
+		key:  podGroupPrefix + pod.UID,
+		kind: "Pod ephemeral",
+		limits: func(ctx context.Context) config.QuotaConfig {
+			return q.podLimits(ctx, pod.UID, q.cfg.PodEphemeral.Quota)
+		},
+		budgetPath: q.cfg.PodEphemeral.PodLogDir,
+		paths: func(ctx context.Context, projID uint32) []string {
+			var paths []string
+			for _, path := range q.podEphemeralPaths(pod) {
+				if err := q.setProjectID(ctx, path, projID); err != nil {
+					// emptyDir 可能位于 tmpfs 或其他文件系统上，跳过即可
+					log.Ctx(ctx).Warn("Failed to add pod path to project", zap.String("path", path), zap.Error(err))
+					continue
+				}
+				paths = append(paths, path)
+			}
+			return paths
+		},
+	}, containerID, upperdir)
+}
+
+// removeGroupMember 移除分组成员，分组内不再有成员时释放共享项目
+func (q *RFSQuota) removeGroupMember(ctx context.Context, entry xfs.Entry) error {
+	q.groupMutex.Lock()
+	defer q.groupMutex.Unlock()
+
+	if err := q.stateManager.RemoveEntry(entry.ContainerID); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return err
+	}
+
+	for _, other := range q.stateManager.ListEntries() {
+		if other.Group == entry.Group {
+			return nil
+		}
+	}
+
+	group, exists := q.stateManager.GetEntry(entry.Group)
+	if !exists {
+		return nil
+	}
+	if err := q.releaseProject(ctx, group.ContainerID, group.ProjectID); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info("Shared quota released", zap.String("group", entry.Group), zap.Uint32("projectID", group.ProjectID))
+	return nil
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/policy"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// DiffPolicy 对比已管理容器的当前限额与策略的期望限额，只返回需要修改的容器
+func (q *RFSQuota) DiffPolicy(p *policy.Policy) ([]policy.Change, error) {
+	changes, _ := q.diffPolicy(p)
+	return changes, nil
+}
+
+// diffPolicy 返回需要修改的容器，以及每条规则命中的容器数（含限额已一致的容器）
+func (q *RFSQuota) diffPolicy(p *policy.Policy) ([]policy.Change, map[string]int) {
+	var changes []policy.Change
+	matched := make(map[string]int)
+	for _, meta := range q.policyTargets() {
+		d := policy.EvaluatePolicy(p, meta)
+		if d.Rule != "" {
+			matched[d.Rule]++
+		}
+		if d.Action == policy.ActionChange {
+			changes = append(changes, d.Change())
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ContainerID < changes[j].ContainerID })
+	return changes, matched
+}
+
+// PolicyTargets 返回所有已管理容器参与策略计算的元数据，可记录下来离线调用 policy.EvaluatePolicy
+func (q *RFSQuota) PolicyTargets() ([]policy.ContainerMeta, error) {
+	targets := q.policyTargets()
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ContainerID < targets[j].ContainerID })
+	return targets, nil
+}
+
+// policyTargets 为每个已管理容器构造策略元数据，共享项目的分组条目不参与计算；
+// 共享项目成员与已固定的容器不读取标签
+func (q *RFSQuota) policyTargets() []policy.ContainerMeta {
+	var targets []policy.ContainerMeta
+	for _, entry := range q.stateManager.ListEntries() {
+		if isGroupKey(entry.ContainerID) {
+			continue
+		}
+		meta := policy.ContainerMeta{
+			ContainerID: entry.ContainerID,
+			Namespace:   q.entryNamespace(entry),
+			SoftLimit:   entry.SoftLimit,
+			HardLimit:   entry.HardLimit,
+			Group:       entry.Group,
+			Pinned:      entry.Pinned,
+		}
+		if entry.Group == "" && !entry.Pinned {
+			meta.Labels = q.containerLabels(entry)
+		}
+		targets = append(targets, meta)
+	}
+	return targets
+}
+
+// isGroupKey 判断状态键是否为共享项目的分组条目
+func isGroupKey(key string) bool {
+	return strings.HasPrefix(key, podGroupPrefix) || strings.HasPrefix(key, sandboxGroupPrefix) || strings.HasPrefix(key, nsGroupPrefix)
+}
+
+// entryNamespace 返回条目所属命名空间，旧状态文件中为空时取默认命名空间
+func (q *RFSQuota) entryNamespace(entry xfs.Entry) string {
+	if entry.Namespace != "" {
+		return entry.Namespace
+	}
+	return q.cfg.Namespace
+}
+
+// containerLabels 读取条目对应容器的 containerd 标签，BuildKit 快照、外部工作负载或读取失败时返回 nil
+func (q *RFSQuota) containerLabels(entry xfs.Entry) map[string]string {
+	if q.client == nil || strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || isExternalKey(entry.ContainerID) {
+		return nil
+	}
+	r, err := q.lookupContainer(q.namespaceContext(q.entryNamespace(entry)), entry.ContainerID)
+	if err != nil {
+		return nil
+	}
+	return r.Labels
+}
+
+// ApplyPolicy 将所有已管理容器收敛到策略，任一修改失败时将已应用的修改恢复为原限额
+func (q *RFSQuota) ApplyPolicy(p *policy.Policy) ([]policy.Change, error) {
+	return q.applyPolicy(p, sched.Admin)
+}
+
+// applyPolicy 按 prio 调度每个修改，管理接口与后台收敛使用不同的优先级
+func (q *RFSQuota) applyPolicy(p *policy.Policy, prio sched.Priority) ([]policy.Change, error) {
+	if q.standby.Load() {
+		return nil, api.ErrStandby
+	}
+	changes, _ := q.diffPolicy(p)
+	return q.applyChanges(changes, prio)
+}
+
+// applyChanges 依次应用策略修改，任一失败时回滚之前已应用的修改
+func (q *RFSQuota) applyChanges(changes []policy.Change, prio sched.Priority) ([]policy.Change, error) {
+	for i := range changes {
+		ch := &changes[i]
+		if err := q.setLimits(q.ctx, ch.ContainerID, ch.NewSoft, ch.NewHard, limitSourcePolicy, prio); err != nil {
+			ch.Error = err.Error()
+			rolledBack := q.rollbackPolicy(changes[:i], prio)
+			return changes, fmt.Errorf("failed to apply policy to %s, rolled back %d of %d changes: %v", ch.ContainerID, rolledBack, i, err)
+		}
+		ch.Applied = true
+		metrics.PolicyRuleChanges.WithLabelValues(ch.Rule).Inc()
+	}
+
+	if len(changes) > 0 {
+		log.Info("Policy applied", zap.Int("changes", len(changes)))
+	}
+	return changes, nil
+}
+
+// rollbackPolicy 逆序恢复已应用的修改，返回成功恢复的数量
+func (q *RFSQuota) rollbackPolicy(applied []policy.Change, prio sched.Priority) int {
+	count := 0
+	for i := len(applied) - 1; i >= 0; i-- {
+		ch := &applied[i]
+		if err := q.setLimits(q.ctx, ch.ContainerID, ch.OldSoft, ch.OldHard, limitSourceRollback, prio); err != nil {
+			log.Error("Failed to roll back policy change", zap.String("container", ch.ContainerID), zap.Error(err))
+			continue
+		}
+		ch.RolledBack = true
+		count++
+	}
+	return count
+}
+
+// runPolicyWatcher 周期性加载策略文件并收敛，文件无效时保留上一次有效的策略
+func (q *RFSQuota) runPolicyWatcher() {
+	ticker := time.NewTicker(time.Duration(q.cfg.Policy.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	var current *policy.Policy
+	var modTime time.Time
+	for {
+		if info, err := os.Stat(q.cfg.Policy.File); err != nil {
+			log.Warn("Failed to stat policy file", zap.String("file", q.cfg.Policy.File), zap.Error(err))
+		} else if !info.ModTime().Equal(modTime) {
+			p, err := policy.Load(q.cfg.Policy.File)
+			if err != nil {
+				log.Error("Invalid policy file, keeping previous policy", zap.String("file", q.cfg.Policy.File), zap.Error(err))
+			} else {
+				log.Info("Loaded policy", zap.String("file", q.cfg.Policy.File), zap.Int("rules", len(p.Rules)))
+				current = p
+			}
+			modTime = info.ModTime()
+		}
+
+		// 每轮都收敛，覆盖策略加载后新建的容器；备用模式下只更新规则命中统计
+		if current != nil {
+			changes, matched := q.diffPolicy(current)
+			recordRuleMatches(current, matched)
+			if !q.degraded.Active() && !q.standby.Load() {
+				if _, err := q.applyChanges(changes, sched.Reconcile); err != nil {
+					log.Error("Policy convergence failed", zap.Error(err))
+				}
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// recordRuleMatches 导出当前策略每条规则命中的容器数，未命中的规则为 0，已删除的规则不再导出
+func recordRuleMatches(p *policy.Policy, matched map[string]int) {
+	metrics.PolicyRuleMatches.Reset()
+	for _, name := range p.RuleNames() {
+		metrics.PolicyRuleMatches.WithLabelValues(name).Set(float64(matched[name]))
+	}
+}
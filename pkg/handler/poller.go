@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/xfs"
+)
+
+// errNoUsageSnapshot 表示还没有可用的用量快照，或最近的快照已过期
+var errNoUsageSnapshot = errors.New("no recent usage snapshot")
+
+// containerMeta 为快照中附加到容器的元数据，容器首次出现时从 containerd 读取一次
+type containerMeta struct {
+	Namespace string
+	Image     string
+	// Labels 只包含 usage_poller.labels 中列出的标签
+	Labels map[string]string
+	// PodNamespace 与 PodName 来自 Kubernetes 的容器标签，供按容器指标使用
+	PodNamespace string
+	PodName      string
+}
+
+// usageSnapshot 为一次轮询得到的所有项目用量及已管理容器的元数据，发布后不再修改
+type usageSnapshot struct {
+	At       time.Time
+	Projects map[uint32]xfs.ProjectUsage
+	// Containers 以条目键索引，不含分组条目与 BuildKit 快照
+	Containers map[string]containerMeta
+}
+
+// usagePoller 保存最近一次用量快照
+type usagePoller struct {
+	mutex    sync.RWMutex
+	snapshot *usageSnapshot
+}
+
+// usagePollerNeeded 判断是否有功能读取用量快照，都未启用或关闭了 collector 时不轮询
+func (q *RFSQuota) usagePollerNeeded() bool {
+	if !q.cfg.Features.Enabled(config.FeatureCollector) {
+		return false
+	}
+	return q.cfg.Alerts.Enabled || len(q.chats) > 0 || q.sink != nil || q.cfg.Mirror.Path != "" ||
+		q.cfg.Aggregator.URL != "" || q.cfg.UsagePoller.ContainerMetrics
+}
+
+// runUsagePoller 周期以一次 report 读取所有项目的用量并发布快照；查询失败时保留上一次快照，
+// 过期后读取方得到 errNoUsageSnapshot
+func (q *RFSQuota) runUsagePoller() {
+	if q.cfg.UsagePoller.ContainerMetrics {
+		if err := metrics.RegisterContainerUsage(q.containerLabelSet(), q.containerUsageMetrics); err != nil {
+			log.Warn("Failed to register per-container usage metrics", zap.Error(err))
+		}
+	}
+	ticker := time.NewTicker(time.Duration(q.cfg.UsagePoller.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	meta := make(map[string]containerMeta)
+	failures := 0
+	for {
+		usages, err := projectUsages(q.ctx)
+		if err != nil {
+			if q.ctx.Err() != nil {
+				return
+			}
+			// 连续失败只记录第一次，避免刷屏
+			if failures == 0 {
+				log.Warn("Failed to poll project usage", zap.Error(err))
+			}
+			failures++
+		} else {
+			failures = 0
+			q.usage.publish(&usageSnapshot{At: time.Now(), Projects: usages, Containers: q.refreshContainerMeta(meta)})
+		}
+
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshContainerMeta 为新出现的容器读取元数据并移除已不存在的条目，返回本次快照使用的副本；
+// 读取失败的容器下次轮询重试
+func (q *RFSQuota) refreshContainerMeta(cache map[string]containerMeta) map[string]containerMeta {
+	live := make(map[string]containerMeta)
+	for _, entry := range q.stateManager.ListEntries() {
+		if !statsEntry(entry) {
+			continue
+		}
+		m, cached := cache[entry.ContainerID]
+		if !cached {
+			var loaded bool
+			if m, loaded = q.loadContainerMeta(entry); loaded {
+				cache[entry.ContainerID] = m
+			}
+		}
+		live[entry.ContainerID] = m
+	}
+	for id := range cache {
+		if _, exists := live[id]; !exists {
+			delete(cache, id)
+		}
+	}
+	return live
+}
+
+// loadContainerMeta 读取容器的镜像与选定标签，读取失败时只有命名空间且 loaded 为 false
+func (q *RFSQuota) loadContainerMeta(entry xfs.Entry) (m containerMeta, loaded bool) {
+	m = containerMeta{Namespace: q.entryNamespace(entry)}
+	if q.client == nil {
+		return m, false
+	}
+	info, err := q.lookupContainer(q.namespaceContext(m.Namespace), entry.ContainerID)
+	if err != nil {
+		log.Debug("Failed to load container metadata for usage snapshot", zap.String("container", entry.ContainerID), zap.Error(err))
+		return m, false
+	}
+	m.Image = info.Image
+	m.PodNamespace, m.PodName = info.Labels[labelPodNamespace], info.Labels[labelPodName]
+	for _, label := range q.cfg.UsagePoller.Labels {
+		if v, ok := info.Labels[label]; ok {
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			m.Labels[label] = v
+		}
+	}
+	return m, true
+}
+
+func (p *usagePoller) publish(s *usageSnapshot) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.snapshot = s
+}
+
+// latestUsage 返回最近一次用量快照；超过三个轮询间隔未更新的快照视为过期
+func (q *RFSQuota) latestUsage() (*usageSnapshot, error) {
+	q.usage.mutex.RLock()
+	s := q.usage.snapshot
+	q.usage.mutex.RUnlock()
+	maxAge := 3 * time.Duration(q.cfg.UsagePoller.IntervalSeconds) * time.Second
+	if s == nil || time.Since(s.At) > maxAge {
+		return nil, errNoUsageSnapshot
+	}
+	return s, nil
+}
+
+// UsageSnapshot 返回所有已管理容器的用量；没有可用快照时实时读取一次，此时不含镜像
+func (q *RFSQuota) UsageSnapshot() (api.UsageSnapshotResponse, error) {
+	s, err := q.latestUsage()
+	source := api.UsageSourcePoller
+	if err != nil {
+		usages, err := projectUsages(q.ctx)
+		if err != nil {
+			return api.UsageSnapshotResponse{}, err
+		}
+		s = &usageSnapshot{At: time.Now(), Projects: usages}
+		source = api.UsageSourceLive
+	}
+
+	resp := api.UsageSnapshotResponse{At: s.At, Source: source, Containers: []api.ContainerUsage{}}
+	for _, entry := range q.stateManager.ListEntries() {
+		if !statsEntry(entry) {
+			continue
+		}
+		usage, ok := s.Projects[entry.ProjectID]
+		if !ok {
+			continue
+		}
+		m, ok := s.Containers[entry.ContainerID]
+		if !ok {
+			m.Namespace = q.entryNamespace(entry)
+		}
+		u := api.ContainerUsage{
+			ContainerID:    entry.ContainerID,
+			Namespace:      m.Namespace,
+			Image:          m.Image,
+			Group:          entry.Group,
+			ProjectID:      entry.ProjectID,
+			UsedBytes:      usage.UsedBytes,
+			UsedInodes:     usage.UsedInodes,
+			SoftLimitBytes: usage.SoftLimitBytes,
+			HardLimitBytes: usage.HardLimitBytes,
+		}
+		if usage.HardLimitBytes > 0 {
+			u.Percent = float64(usage.UsedBytes) * 100 / float64(usage.HardLimitBytes)
+		}
+		resp.Containers = append(resp.Containers, u)
+	}
+	return resp, nil
+}
+
+// containerUsageMetrics 将最近的快照转换为按容器指标的样本，快照过期时不导出
+func (q *RFSQuota) containerUsageMetrics() []metrics.ContainerUsage {
+	s, err := q.latestUsage()
+	if err != nil {
+		return nil
+	}
+	labels := q.containerLabelSet().Labels
+	var list []metrics.ContainerUsage
+	for _, entry := range q.stateManager.ListEntries() {
+		m, ok := s.Containers[entry.ContainerID]
+		if !ok {
+			continue
+		}
+		usage, ok := s.Projects[entry.ProjectID]
+		if !ok {
+			continue
+		}
+		u := metrics.ContainerUsage{
+			ContainerID:  entry.ContainerID,
+			Namespace:    m.Namespace,
+			Image:        m.Image,
+			Group:        entry.Group,
+			PodNamespace: m.PodNamespace,
+			PodName:      m.PodName,
+			UsedBytes:    usage.UsedBytes,
+			HardBytes:    usage.HardLimitBytes,
+			UsedInodes:   usage.UsedInodes,
+		}
+		for _, label := range labels {
+			u.LabelValues = append(u.LabelValues, m.Labels[label])
+		}
+		list = append(list, u)
+	}
+	return list
+}
+
+// containerLabelSet 返回 usage_poller.metric_labels 对应的按容器指标标签集合
+func (q *RFSQuota) containerLabelSet() metrics.ContainerLabelSet {
+	switch q.cfg.UsagePoller.MetricLabels {
+	case config.MetricLabelsContainer:
+		return metrics.ContainerLabelSet{}
+	case config.MetricLabelsPod:
+		return metrics.ContainerLabelSet{Pod: true}
+	}
+	return metrics.ContainerLabelSet{Pod: true, Image: true, Labels: q.cfg.UsagePoller.Labels}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/xfs"
+)
+
+const (
+	// poolMonitorInterval 为项目 ID 池统计的刷新间隔
+	poolMonitorInterval = time.Minute
+	// poolRecommendLogInterval 为记录建议范围大小的间隔
+	poolRecommendLogInterval = time.Hour
+)
+
+// PoolStats 返回项目 ID 池的使用统计
+func (q *RFSQuota) PoolStats() xfs.PoolStats {
+	return q.projectIDPool.Stats()
+}
+
+// runPoolMonitor 周期刷新项目 ID 池指标并定期记录建议的范围大小，峰值使用量进入告警余量时告警
+func (q *RFSQuota) runPoolMonitor() {
+	ticker := time.NewTicker(poolMonitorInterval)
+	defer ticker.Stop()
+
+	warned, alerted := false, false
+	// 启动时峰值尚未观察到，首次记录推迟一个间隔
+	logged := time.Now()
+	for {
+		stats := q.projectIDPool.Stats()
+		metrics.ProjectIDsUsed.Set(float64(stats.Used))
+		metrics.ProjectIDsFree.Set(float64(stats.Free))
+		if stats.Size > 0 {
+			metrics.ProjectIDPoolUtilization.Set(float64(stats.Used) / float64(stats.Size))
+		}
+		metrics.ProjectIDsLargestFreeRun.Set(float64(stats.LargestFreeRun))
+		metrics.ProjectIDAllocationsPerHour.Set(float64(stats.AllocationsPerHour))
+		metrics.ProjectIDRecommendedSize.Set(float64(stats.RecommendedSize))
+
+		warnAt := (1 - q.cfg.Project.WarnMarginPercent/100) * float64(stats.Size)
+		tooSmall := float64(stats.PeakUsed) >= warnAt
+		if tooSmall && !warned {
+			log.Warn("Project ID range is too small for observed container density, consider widening project.id_min/id_max",
+				zap.Int("size", stats.Size),
+				zap.Int("used", stats.Used),
+				zap.Int("peakUsed", stats.PeakUsed),
+				zap.Int("allocationsPerHour", stats.AllocationsPerHour),
+				zap.Int("recommendedSize", stats.RecommendedSize))
+		}
+		if time.Since(logged) >= poolRecommendLogInterval {
+			log.Info("Project ID range recommendation",
+				zap.Int("size", stats.Size),
+				zap.Int("peakUsed", stats.PeakUsed),
+				zap.Int("allocationsPerHour", stats.AllocationsPerHour),
+				zap.Int("recommendedSize", stats.RecommendedSize))
+			logged = time.Now()
+		}
+		warned = tooSmall
+		alerted = q.notifyPoolExhaustion(stats, alerted)
+
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// preflightProjectIDs 将状态文件中已分配的项目 ID 标记为已使用，
+// 并检查配置范围是否与节点上其他工具使用的项目 ID 重叠，重叠的 ID 不再分配
+func (q *RFSQuota) preflightProjectIDs() {
+	owned := make(map[uint32]bool)
+	for _, entry := range q.stateManager.ListEntries() {
+		owned[entry.ProjectID] = true
+		q.projectIDPool.MarkUsed(entry.ProjectID)
+	}
+
+	scanPaths := q.cfg.Project.ReservedScanPaths
+	if scanPaths == nil {
+		scanPaths = xfs.DefaultReservedScanPaths
+	}
+
+	overlaps := 0
+	for _, r := range xfs.DiscoverReservedProjectIDs(scanPaths) {
+		if r.ID < q.cfg.Project.IDMin || r.ID > q.cfg.Project.IDMax || owned[r.ID] {
+			continue
+		}
+		overlaps++
+		q.projectIDPool.MarkUsed(r.ID)
+		log.Warn("Project ID in configured range is used by another tool, excluding it",
+			zap.Uint32("projectID", r.ID),
+			zap.String("source", r.Source))
+	}
+	if overlaps > 0 {
+		log.Warn("Configured project ID range overlaps with IDs in use on this node, consider moving the range",
+			zap.Uint32("idMin", q.cfg.Project.IDMin),
+			zap.Uint32("idMax", q.cfg.Project.IDMax),
+			zap.Int("overlaps", overlaps))
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// loopBeatInterval 为事件循环的心跳间隔
+	loopBeatInterval = 5 * time.Second
+	// loopBeatStale 为心跳超过该时长未更新时认为事件循环卡住
+	loopBeatStale = 30 * time.Second
+	// eventStallFactor 为有事件待处理、但超过 event.timeout_seconds 的该倍数仍没有事件处理结束时认为处理停滞
+	eventStallFactor = 3
+)
+
+// probeHandlers 返回挂在指标服务上的存活与就绪探针
+func (q *RFSQuota) probeHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/healthz": q.health.Handler(q.checkLive),
+		"/readyz":  q.health.Handler(q.checkReady),
+		"/version": q.versionHandler(),
+	}
+}
+
+// eventFinished 记录一个事件处理结束（完成或超时重新入队）
+func (q *RFSQuota) eventFinished() {
+	q.eventsPending.Add(-1)
+	q.eventProgress.Store(time.Now().UnixNano())
+}
+
+// checkReady 在已连接 containerd 且完成状态同步后通过
+func (q *RFSQuota) checkReady() error {
+	cond, exists := q.health.Get(healthContainerd)
+	if !exists || !cond.Healthy {
+		if exists && cond.Message != "" {
+			return fmt.Errorf("not ready: %s", cond.Message)
+		}
+		return fmt.Errorf("not ready")
+	}
+	return nil
+}
+
+// checkLive 检测事件循环是否卡住：监听事件时心跳长时间未更新，或有事件待处理却长时间没有事件处理结束。
+// 等待 containerd 时不算卡住，containerd 不可用不应导致守护进程被重启
+func (q *RFSQuota) checkLive() error {
+	now := time.Now()
+	if beat := q.loopBeat.Load(); beat != 0 {
+		if since := now.Sub(time.Unix(0, beat)); since > loopBeatStale {
+			return fmt.Errorf("event loop has not run for %v", since.Round(time.Second))
+		}
+	}
+	if q.eventsPending.Load() > 0 {
+		stall := eventStallFactor * time.Duration(q.cfg.Event.TimeoutSeconds) * time.Second
+		if since := now.Sub(time.Unix(0, q.eventProgress.Load())); since > stall {
+			return fmt.Errorf("%d events pending and none finished for %v", q.eventsPending.Load(), since.Round(time.Second))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/api/events"
+	e "github.com/containerd/containerd/events"
+	"github.com/containerd/typeurl/v2"
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/chaos"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+)
+
+const (
+	// eventMarkFlushInterval 为事件水位的持久化间隔
+	eventMarkFlushInterval = 10 * time.Second
+	// priorityLookupTimeout 为判断创建事件优先级时查询 containerd 的超时
+	priorityLookupTimeout = 2 * time.Second
+)
+
+// queuedEvent 为待处理的事件及其已尝试次数
+type queuedEvent struct {
+	envelope *e.Envelope
+	attempt  int
+	// received 为首次收到事件的时间，重试时保持不变
+	received time.Time
+}
+
+// dispatchEvent 将事件交给调度器，同一容器的事件按到达顺序串行执行
+func (q *RFSQuota) dispatchEvent(ev queuedEvent) {
+	if ev.received.IsZero() {
+		ev.received = time.Now()
+	}
+	if q.eventsPending.Add(1) == 1 {
+		q.eventProgress.Store(time.Now().UnixNano())
+	}
+	key := eventContainerID(ev.envelope)
+	ctx := q.eventContext(ev.envelope, key)
+	q.sched.Go(ctx, key, q.eventPriority(ev.envelope, key), func(ctx context.Context, release func()) {
+		q.processEvent(ctx, ev, key, release)
+	})
+}
+
+// processEvent 在超时限制内处理事件，超时则按退避重新入队，超过最大重试次数后放弃；
+// release 在处理真正结束后调用，超时的处理结束前同一容器的后续事件不会开始
+func (q *RFSQuota) processEvent(ctx context.Context, ev queuedEvent, key string, release func()) {
+	// 超时后取消上下文，终止仍在执行的 xfs_quota 调用与目录遍历
+	timeout := time.Duration(q.cfg.Event.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer q.eventFinished()
+
+	done := make(chan error, 1)
+	go func() {
+		defer release()
+		chaos.Delay()
+		done <- q.handleEvent(ctx, ev.envelope)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		q.recordEvent(ev.envelope)
+		metrics.EventLatency.WithLabelValues(ev.envelope.Topic).Observe(time.Since(ev.received).Seconds())
+		if err != nil {
+			metrics.EventErrors.Inc()
+			log.Ctx(ctx).Error("Failed to handle event", zap.Error(err))
+			if isCreateEvent(ev.envelope) && key != "" {
+				q.markFailed(ev.envelope.Namespace, key, err)
+			}
+		}
+	case <-timer.C:
+		metrics.EventTimeouts.Inc()
+		log.Ctx(ctx).Warn("Event handling timed out", zap.Int("attempt", ev.attempt))
+		q.requeue(ev, key)
+	case <-q.ctx.Done():
+	}
+}
+
+// eventPriority 返回事件的调度优先级，优先命名空间与运行时的容器创建事件使用 create-high
+func (q *RFSQuota) eventPriority(envelope *e.Envelope, containerID string) sched.Priority {
+	switch envelope.Topic {
+	case "/tasks/create":
+		if q.priorityCreate(envelope.Namespace, containerID) {
+			return sched.CreateHigh
+		}
+		return sched.Create
+	case "/tasks/delete":
+		return sched.Delete
+	}
+	return sched.Reconcile
+}
+
+// priorityCreate 判断容器是否属于配置的优先命名空间或运行时。containerd 命名空间未命中时查询容器的标签与运行时，
+// 查询在事件循环中进行以保持同一容器事件的顺序，因此限制超时
+func (q *RFSQuota) priorityCreate(namespace, containerID string) bool {
+	cfg := q.cfg.Scheduler
+	if len(cfg.PriorityNamespaces) == 0 && len(cfg.PriorityRuntimeClasses) == 0 {
+		return false
+	}
+	if slices.Contains(cfg.PriorityNamespaces, namespace) {
+		return true
+	}
+	if q.client == nil || containerID == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(q.namespaceContext(namespace), priorityLookupTimeout)
+	defer cancel()
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		log.Debug("Failed to look up container for scheduling priority", zap.String("container", containerID), zap.Error(err))
+		return false
+	}
+	if ns := info.Labels[labelPodNamespace]; ns != "" && slices.Contains(cfg.PriorityNamespaces, ns) {
+		return true
+	}
+	for _, name := range cfg.PriorityRuntimeClasses {
+		if name == info.Runtime || name == shortRuntimeName(info.Runtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortRuntimeName 返回运行时的简称，如 io.containerd.kata.v2 返回 kata
+func shortRuntimeName(runtime string) string {
+	name := strings.TrimPrefix(runtime, "io.containerd.")
+	if i := strings.LastIndex(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// eventContext 返回事件所属命名空间的上下文，并附带事件的日志字段
+func (q *RFSQuota) eventContext(envelope *e.Envelope, containerID string) context.Context {
+	fields := []zap.Field{zap.String("topic", envelope.Topic)}
+	if envelope.Namespace != "" {
+		fields = append(fields, zap.String("namespace", envelope.Namespace))
+	}
+	if containerID != "" {
+		fields = append(fields, zap.String("container", containerID))
+	}
+	return log.WithFields(q.namespaceContext(envelope.Namespace), fields...)
+}
+
+// requeue 按指数退避将事件重新放回队列
+func (q *RFSQuota) requeue(ev queuedEvent, key string) {
+	if ev.attempt >= q.cfg.Event.MaxRetries {
+		metrics.EventsDropped.Inc()
+		log.Error("Event dropped after exhausting retries",
+			zap.String("topic", ev.envelope.Topic),
+			zap.String("container", key),
+			zap.Int("attempts", ev.attempt+1))
+		if isCreateEvent(ev.envelope) && key != "" {
+			q.markFailed(ev.envelope.Namespace, key, fmt.Errorf("timed out after %d attempts", ev.attempt+1))
+		}
+		return
+	}
+
+	metrics.EventRequeues.Inc()
+	ev.attempt++
+	delay := time.Duration(1<<ev.attempt) * time.Second
+	time.AfterFunc(delay, func() {
+		select {
+		case q.retryCh <- ev:
+		case <-q.ctx.Done():
+		}
+	})
+}
+
+// eventContainerID 提取事件关联的容器 ID，无法识别时返回空字符串
+func eventContainerID(envelope *e.Envelope) string {
+	event, err := typeurl.UnmarshalAny(envelope.Event)
+	if err != nil {
+		return ""
+	}
+	switch e := event.(type) {
+	case *events.TaskCreate:
+		return e.ContainerID
+	case *events.TaskDelete:
+		return e.ContainerID
+	case *events.ContainerUpdate:
+		return e.ID
+	}
+	return ""
+}
+
+// isCreateEvent 判断是否为任务创建事件
+func isCreateEvent(envelope *e.Envelope) bool {
+	return envelope.Topic == "/tasks/create"
+}
+
+// recordEvent 更新已处理事件的水位与指标
+func (q *RFSQuota) recordEvent(envelope *e.Envelope) {
+	mark := q.stateManager.RecordEvent(envelope.Timestamp, envelope.Topic, envelope.Namespace)
+	metrics.EventsProcessed.Inc()
+	metrics.LastEventSequence.Set(float64(mark.Sequence))
+	if !envelope.Timestamp.IsZero() {
+		metrics.LastEventTimestamp.Set(float64(envelope.Timestamp.Unix()))
+	}
+}
+
+// runEventMarkFlusher 定期持久化事件水位，避免每个事件都写状态文件
+func (q *RFSQuota) runEventMarkFlusher() {
+	ticker := time.NewTicker(eventMarkFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := q.stateManager.Flush(); err != nil {
+				log.Warn("Failed to persist event watermark", zap.Error(err))
+			}
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
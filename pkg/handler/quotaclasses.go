@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+)
+
+// namespaceDefaults 返回命名空间的按容器默认限额，未在 namespace_defaults 中配置时为顶层 quota
+func (q *RFSQuota) namespaceDefaults(namespace string) config.QuotaConfig {
+	if limits, ok := q.cfg.NamespaceDefaults[namespace]; ok {
+		return limits
+	}
+	return q.cfg.Quota
+}
+
+// classLimits 返回容器所选配额类别的限额与类别名；未选择或所选类别未定义时使用 quota_classes.default，
+// 两者都没有时返回命名空间的默认限额与空类别名
+func (q *RFSQuota) classLimits(ctx context.Context, namespace, containerID string) (config.QuotaConfig, string) {
+	cfg := q.cfg.QuotaClasses
+	if len(cfg.Classes) == 0 {
+		return q.namespaceDefaults(namespace), ""
+	}
+	name := ""
+	if q.client != nil && !strings.HasPrefix(containerID, buildkitKeyPrefix) {
+		if info, err := q.lookupContainer(ctx, containerID); err != nil {
+			log.Ctx(ctx).Debug("Failed to load container for quota class", zap.Error(err))
+		} else {
+			name = labelValue(info, cfg.Label)
+		}
+	}
+	if class, ok := cfg.Classes[name]; ok {
+		return class, name
+	}
+	if name != "" {
+		log.Ctx(ctx).Warn("Unknown quota class, using default", zap.String("class", name), zap.String("default", cfg.Default))
+	}
+	if class, ok := cfg.Classes[cfg.Default]; ok {
+		return class, cfg.Default
+	}
+	return q.namespaceDefaults(namespace), ""
+}
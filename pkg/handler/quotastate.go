@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/mounts"
+	"RootfsQuota/pkg/xfs"
+)
+
+// healthQuotaState 为文件系统项目配额开关的健康状况名称
+const healthQuotaState = "quota-state"
+
+// runQuotaStateCheck 周期检查持有已管理容器的文件系统是否仍开启项目配额统计与限制。
+// 管理员执行 xfs_quota -x -c off 或以不带 pquota 的选项重新挂载后，限额写入仍会成功但不再生效
+func (q *RFSQuota) runQuotaStateCheck() {
+	ticker := time.NewTicker(time.Duration(q.cfg.QuotaState.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		q.checkQuotaState()
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) checkQuotaState() {
+	// 按设备号归并已管理的可写层，每个文件系统记录一个示例路径
+	managed := make(map[uint64]string)
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" {
+			continue
+		}
+		var st syscall.Stat_t
+		if err := syscall.Stat(entry.Upperdir, &st); err != nil {
+			continue
+		}
+		if _, seen := managed[uint64(st.Dev)]; !seen {
+			managed[uint64(st.Dev)] = entry.Upperdir
+		}
+	}
+	if len(managed) == 0 {
+		return
+	}
+
+	states, err := xfs.GetQuotaState(q.ctx)
+	if err != nil {
+		log.Warn("Failed to query quota state", zap.Error(err))
+		return
+	}
+	byDev := make(map[uint64]xfs.QuotaState)
+	for _, s := range states {
+		var st syscall.Stat_t
+		if err := syscall.Stat(s.Mountpoint, &st); err == nil {
+			byDev[uint64(st.Dev)] = s
+		}
+	}
+
+	var problems []string
+	for dev, upperdir := range managed {
+		s, ok := byDev[dev]
+		switch {
+		case !ok:
+			where := "the filesystem of " + upperdir
+			if m, err := mounts.ForPath(upperdir); err == nil {
+				where = fmt.Sprintf("%s (%s on %s)", m.Mountpoint, m.FSType, m.Source)
+			}
+			problems = append(problems, fmt.Sprintf("project quota not enabled on %s", where))
+		case !s.Accounting:
+			problems = append(problems, fmt.Sprintf("project quota accounting off on %s", s.Mountpoint))
+		case !s.Enforcement:
+			problems = append(problems, fmt.Sprintf("project quota enforcement off on %s", s.Mountpoint))
+		}
+	}
+	sort.Strings(problems)
+	metrics.QuotaDisabledFilesystems.Set(float64(len(problems)))
+
+	if len(problems) == 0 {
+		q.health.Set(healthQuotaState, true, "")
+		return
+	}
+	message := strings.Join(problems, "; ")
+	if cond, exists := q.health.Get(healthQuotaState); !exists || cond.Healthy || cond.Message != message {
+		log.Error("CRITICAL: project quotas are not enforced, limits have no effect",
+			zap.Strings("problems", problems))
+	}
+	q.health.Set(healthQuotaState, false, message)
+}
@@ -0,0 +1,619 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// rebalancePlanned 为预演时响应中的状态，不写入状态文件
+const rebalancePlanned = "planned"
+
+// rebalanceRetryInterval 为备用或降级期间迁移等待后重新检查的间隔
+const rebalanceRetryInterval = 10 * time.Second
+
+// StartRebalance 为每个 ID 不在新范围内的已管理项目规划迁移，并在后台逐个执行：
+// 目录改标为新 ID、在新 ID 上写入记录的限额、更新状态，最后清除旧 ID 的限额。
+// 新范围内已被其他工具使用（项目文件、扫描目录或 xfs_quota 报告中出现）的 ID 不会分配
+func (q *RFSQuota) StartRebalance(req api.RebalanceRequest) (api.RebalanceResponse, error) {
+	if q.standby.Load() {
+		return api.RebalanceResponse{}, api.ErrStandby
+	}
+	if r, ok := q.stateManager.Rebalance(); ok && r.State != xfs.RebalanceRolledBack {
+		return api.RebalanceResponse{}, fmt.Errorf("%w: a rebalance to %d-%d is %s, finish or roll it back first",
+			api.ErrConflict, r.IDMin, r.IDMax, r.State)
+	}
+
+	entries := q.stateManager.ListEntries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ContainerID < entries[j].ContainerID })
+	owned := make(map[uint32]bool)
+	moves := make(map[uint32]*xfs.RebalanceMove)
+	for _, entry := range entries {
+		owned[entry.ProjectID] = true
+		if entry.ProjectID >= req.IDMin && entry.ProjectID <= req.IDMax {
+			continue
+		}
+		m, ok := moves[entry.ProjectID]
+		if !ok {
+			m = &xfs.RebalanceMove{OldID: entry.ProjectID, Status: xfs.MovePending}
+			moves[entry.ProjectID] = m
+		}
+		m.Containers = append(m.Containers, entry.ContainerID)
+	}
+	plan := xfs.Rebalance{IDMin: req.IDMin, IDMax: req.IDMax, State: xfs.RebalanceRunning, StartedAt: time.Now()}
+	for _, m := range moves {
+		plan.Projects = append(plan.Projects, *m)
+	}
+	sort.Slice(plan.Projects, func(i, j int) bool { return plan.Projects[i].OldID < plan.Projects[j].OldID })
+
+	foreign, err := q.foreignProjectIDs(req.IDMin, req.IDMax, owned)
+	if err != nil {
+		return api.RebalanceResponse{}, err
+	}
+	if free := q.projectIDPool.FreeIn(req.IDMin, req.IDMax) - len(foreign); free < len(plan.Projects) {
+		return api.RebalanceResponse{}, fmt.Errorf("range %d-%d has %d free project IDs, %d projects need to move",
+			req.IDMin, req.IDMax, max(free, 0), len(plan.Projects))
+	}
+	if req.DryRun {
+		plan.State = rebalancePlanned
+		return rebalanceResponse(plan), nil
+	}
+
+	for id, source := range foreign {
+		q.projectIDPool.MarkUsed(id)
+		log.Warn("Project ID in rebalance range is used by another tool, excluding it",
+			zap.Uint32("projectID", id), zap.String("source", source))
+	}
+	if err := q.stateManager.PutRebalance(&plan); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return api.RebalanceResponse{}, err
+	}
+	log.Info("Project ID rebalance started",
+		zap.Uint32("idMin", req.IDMin),
+		zap.Uint32("idMax", req.IDMax),
+		zap.Int("projects", len(plan.Projects)))
+	go q.runRebalance()
+	r, _ := q.stateManager.Rebalance()
+	return rebalanceResponse(r), nil
+}
+
+// foreignProjectIDs 返回范围内不属于本守护进程、但已被其他工具使用的项目 ID 及其来源
+func (q *RFSQuota) foreignProjectIDs(minID, maxID uint32, owned map[uint32]bool) (map[uint32]string, error) {
+	foreign := make(map[uint32]string)
+	scanPaths := q.cfg.Project.ReservedScanPaths
+	if scanPaths == nil {
+		scanPaths = xfs.DefaultReservedScanPaths
+	}
+	for _, r := range xfs.DiscoverReservedProjectIDs(scanPaths) {
+		if r.ID >= minID && r.ID <= maxID && !owned[r.ID] {
+			foreign[r.ID] = r.Source
+		}
+	}
+	usages, err := projectUsages(q.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check range %d-%d for project IDs in use: %v", minID, maxID, err)
+	}
+	for id, u := range usages {
+		if id < minID || id > maxID || owned[id] {
+			continue
+		}
+		if u.UsedBytes > 0 || u.UsedInodes > 0 || u.HardLimitBytes > 0 || u.InodeHardLimit > 0 {
+			if _, seen := foreign[id]; !seen {
+				foreign[id] = "xfs_quota report"
+			}
+		}
+	}
+	return foreign, nil
+}
+
+// RebalanceStatus 返回迁移进度
+func (q *RFSQuota) RebalanceStatus() (api.RebalanceResponse, error) {
+	r, ok := q.stateManager.Rebalance()
+	if !ok {
+		return api.RebalanceResponse{}, api.ErrNoRebalance
+	}
+	return rebalanceResponse(r), nil
+}
+
+// ControlRebalance 暂停、恢复、回滚或结束迁移。恢复时重试失败的项目；
+// 回滚将已迁移的项目改回旧 ID，可在完成后、结束前进行；结束时归还旧 ID 并删除迁移记录
+func (q *RFSQuota) ControlRebalance(action string) (api.RebalanceResponse, error) {
+	if q.standby.Load() {
+		return api.RebalanceResponse{}, api.ErrStandby
+	}
+	r, ok := q.stateManager.Rebalance()
+	if !ok {
+		return api.RebalanceResponse{}, api.ErrNoRebalance
+	}
+	conflict := fmt.Errorf("%w: cannot %s a rebalance that is %s", api.ErrConflict, action, r.State)
+
+	var update func(*xfs.Rebalance)
+	switch action {
+	case api.RebalancePause:
+		if r.State != xfs.RebalanceRunning && r.State != xfs.RebalanceRollingBack {
+			return api.RebalanceResponse{}, conflict
+		}
+		update = func(r *xfs.Rebalance) { r.State = xfs.RebalancePaused }
+	case api.RebalanceResume:
+		if r.State != xfs.RebalancePaused {
+			return api.RebalanceResponse{}, conflict
+		}
+		update = func(r *xfs.Rebalance) {
+			r.State = xfs.RebalanceRunning
+			for i := range r.Projects {
+				if p := &r.Projects[i]; p.Status == xfs.MoveFailed {
+					p.Status, p.Error = xfs.MovePending, ""
+				}
+			}
+		}
+	case api.RebalanceRollback:
+		if r.State == xfs.RebalanceRollingBack || r.State == xfs.RebalanceRolledBack {
+			return api.RebalanceResponse{}, conflict
+		}
+		update = func(r *xfs.Rebalance) { r.State = xfs.RebalanceRollingBack }
+	case api.RebalanceFinish:
+		if r.State != xfs.RebalanceCompleted && r.State != xfs.RebalanceRolledBack {
+			return api.RebalanceResponse{}, conflict
+		}
+		return q.finishRebalance(r)
+	default:
+		return api.RebalanceResponse{}, fmt.Errorf("unknown rebalance action %q", action)
+	}
+
+	if _, err := q.stateManager.UpdateRebalance(update); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return api.RebalanceResponse{}, err
+	}
+	log.Info("Project ID rebalance updated", zap.String("action", action))
+	if action != api.RebalancePause {
+		go q.runRebalance()
+	}
+	r, _ = q.stateManager.Rebalance()
+	return rebalanceResponse(r), nil
+}
+
+// finishRebalance 归还已迁移项目的旧 ID 并删除迁移记录
+func (q *RFSQuota) finishRebalance(r xfs.Rebalance) (api.RebalanceResponse, error) {
+	if err := q.stateManager.PutRebalance(nil); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return api.RebalanceResponse{}, err
+	}
+	for _, m := range r.Projects {
+		if m.Status == xfs.MoveMoved {
+			q.projectIDPool.Release(m.OldID)
+		}
+	}
+	fields := []zap.Field{zap.String("state", r.State), zap.Uint32("idMin", r.IDMin), zap.Uint32("idMax", r.IDMax)}
+	if r.State == xfs.RebalanceCompleted && (q.cfg.Project.IDMin != r.IDMin || q.cfg.Project.IDMax != r.IDMax) {
+		log.Warn("Project ID rebalance finished, set project.id_min/id_max to the new range before the next restart", fields...)
+	} else {
+		log.Info("Project ID rebalance finished", fields...)
+	}
+	return rebalanceResponse(r), nil
+}
+
+// loadRebalance 在启动时保留迁移记录中的新旧 ID；中断的迁移以暂停状态恢复，
+// 已完成但尚未结束的迁移继续在新范围内分配
+func (q *RFSQuota) loadRebalance() {
+	r, ok := q.stateManager.Rebalance()
+	if !ok {
+		return
+	}
+	for _, m := range r.Projects {
+		if m.Status == xfs.MoveMoved || m.Status == xfs.MoveMoving {
+			q.projectIDPool.MarkUsed(m.OldID)
+			q.projectIDPool.MarkUsed(m.NewID)
+		}
+	}
+	switch r.State {
+	case xfs.RebalanceCompleted:
+		q.projectIDPool.SetRange(r.IDMin, r.IDMax)
+		log.Info("Allocating project IDs from the rebalanced range until the rebalance is finished",
+			zap.Uint32("idMin", r.IDMin), zap.Uint32("idMax", r.IDMax))
+	case xfs.RebalanceRunning, xfs.RebalanceRollingBack:
+		if _, err := q.stateManager.UpdateRebalance(func(r *xfs.Rebalance) { r.State = xfs.RebalancePaused }); err != nil {
+			log.Warn("Failed to pause interrupted rebalance", zap.Error(err))
+		}
+		log.Warn("Project ID rebalance was interrupted and is paused, resume or roll it back",
+			zap.String("state", r.State), zap.Uint32("idMin", r.IDMin), zap.Uint32("idMax", r.IDMax))
+	}
+}
+
+// runRebalance 在后台逐个迁移或回滚项目，直到本轮结束、被暂停或进程退出；同一时间只有一个在运行
+func (q *RFSQuota) runRebalance() {
+	for q.rebalancing.CompareAndSwap(false, true) {
+		q.rebalanceLoop()
+		q.rebalancing.Store(false)
+		// 退出前状态可能已被再次恢复，此时由本 goroutine 接着运行
+		if r, ok := q.stateManager.Rebalance(); !ok || (r.State != xfs.RebalanceRunning && r.State != xfs.RebalanceRollingBack) || q.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) rebalanceLoop() {
+	// 本轮已失败的项目，不再重试
+	failed := make(map[uint32]bool)
+	for q.ctx.Err() == nil {
+		r, ok := q.stateManager.Rebalance()
+		if !ok {
+			return
+		}
+		rollback := r.State == xfs.RebalanceRollingBack
+		if r.State != xfs.RebalanceRunning && !rollback {
+			return
+		}
+		if q.standby.Load() || q.degraded.Active() {
+			select {
+			case <-time.After(rebalanceRetryInterval):
+			case <-q.ctx.Done():
+			}
+			continue
+		}
+
+		i := nextRebalanceMove(r, rollback, failed)
+		if i < 0 {
+			q.endRebalancePass(rollback)
+			return
+		}
+		m := r.Projects[i]
+		var err error
+		if rollback {
+			m, err = q.rollbackMove(m)
+		} else {
+			m, err = q.forwardMove(r, m)
+		}
+		if err != nil {
+			failed[m.OldID] = true
+			log.Error("Failed to rebalance project",
+				zap.Bool("rollback", rollback),
+				zap.Uint32("oldID", m.OldID),
+				zap.Uint32("newID", m.NewID),
+				zap.Strings("containers", m.Containers),
+				zap.Error(err))
+		}
+		if _, err := q.stateManager.UpdateRebalance(func(r *xfs.Rebalance) {
+			for j := range r.Projects {
+				if r.Projects[j].OldID == m.OldID {
+					r.Projects[j] = m
+				}
+			}
+		}); err != nil {
+			q.noteFilesystemError(err, q.cfg.StateFilePath)
+			return
+		}
+	}
+}
+
+// nextRebalanceMove 返回下一个要处理的项目：迁移时为待迁移或中断的项目，回滚时为已迁移或中断的项目
+func nextRebalanceMove(r xfs.Rebalance, rollback bool, failed map[uint32]bool) int {
+	for i, m := range r.Projects {
+		if failed[m.OldID] {
+			continue
+		}
+		switch {
+		case m.Status == xfs.MoveMoving:
+			return i
+		case rollback && m.Status == xfs.MoveMoved:
+			return i
+		case !rollback && m.Status == xfs.MovePending:
+			return i
+		}
+	}
+	return -1
+}
+
+// endRebalancePass 结束一轮迁移或回滚：全部成功时标记为完成并切换分配范围，有失败时暂停等待处理
+func (q *RFSQuota) endRebalancePass(rollback bool) {
+	var state string
+	var failures int
+	if _, err := q.stateManager.UpdateRebalance(func(r *xfs.Rebalance) {
+		for _, m := range r.Projects {
+			if m.Status == xfs.MoveFailed || m.Status == xfs.MoveMoving || (rollback && m.Status == xfs.MoveMoved) {
+				failures++
+			}
+		}
+		switch {
+		case failures > 0:
+			r.State = xfs.RebalancePaused
+		case rollback:
+			r.State = xfs.RebalanceRolledBack
+		default:
+			r.State = xfs.RebalanceCompleted
+		}
+		state = r.State
+	}); err != nil {
+		q.noteFilesystemError(err, q.cfg.StateFilePath)
+		return
+	}
+
+	r, _ := q.stateManager.Rebalance()
+	switch state {
+	case xfs.RebalanceCompleted:
+		q.projectIDPool.SetRange(r.IDMin, r.IDMax)
+		log.Info("Project ID rebalance completed, new project IDs are allocated from the new range; finish it to release the old IDs",
+			zap.Uint32("idMin", r.IDMin), zap.Uint32("idMax", r.IDMax))
+	case xfs.RebalanceRolledBack:
+		q.projectIDPool.SetRange(q.cfg.Project.IDMin, q.cfg.Project.IDMax)
+		log.Info("Project ID rebalance rolled back", zap.Uint32("idMin", r.IDMin), zap.Uint32("idMax", r.IDMax))
+	default:
+		log.Warn("Project ID rebalance paused after failures, resume to retry or roll it back",
+			zap.Bool("rollback", rollback), zap.Int("failed", failures))
+	}
+}
+
+// forwardMove 将项目迁移到新范围内的 ID。中断后重做时，条目已使用新 ID 的项目视为已迁移
+func (q *RFSQuota) forwardMove(r xfs.Rebalance, m xfs.RebalanceMove) (xfs.RebalanceMove, error) {
+	if m.Status == xfs.MoveMoving && len(q.entriesOf(m.NewID)) > 0 {
+		if err := q.projectBackend(m.OldID).ClearProject(q.ctx, m.OldID); err != nil {
+			log.Warn("Failed to clear limits of rebalanced project ID", zap.Uint32("projectID", m.OldID), zap.Error(err))
+		}
+		m.Status = xfs.MoveMoved
+		return m, nil
+	}
+	if m.NewID == 0 {
+		id, err := q.projectIDPool.AllocateRange(r.IDMin, r.IDMax)
+		if err != nil {
+			m.Status, m.Error = xfs.MoveFailed, err.Error()
+			return m, err
+		}
+		m.NewID = id
+	}
+	m.Status = xfs.MoveMoving
+	if _, err := q.stateManager.UpdateRebalance(func(r *xfs.Rebalance) {
+		for j := range r.Projects {
+			if r.Projects[j].OldID == m.OldID {
+				r.Projects[j] = m
+			}
+		}
+	}); err != nil {
+		return m, err
+	}
+
+	var moved int
+	err := q.doMembers(m, func(ctx context.Context) error {
+		var err error
+		moved, err = q.moveProjectID(ctx, m.OldID, m.NewID)
+		return err
+	})
+	switch {
+	case err != nil:
+		q.projectIDPool.Release(m.NewID)
+		m.NewID, m.Status, m.Error = 0, xfs.MoveFailed, err.Error()
+		return m, err
+	case moved == 0:
+		// 规划后容器已被删除，旧 ID 已随之归还
+		q.projectIDPool.Release(m.NewID)
+		m.NewID, m.Status, m.Error = 0, xfs.MoveGone, ""
+	default:
+		m.Status, m.Error = xfs.MoveMoved, ""
+		log.Info("Project rebalanced",
+			zap.Uint32("oldID", m.OldID),
+			zap.Uint32("newID", m.NewID),
+			zap.Int("entries", moved))
+	}
+	return m, nil
+}
+
+// doMembers 持有迁移开始时共享项目的每个条目键后执行 fn，与这些条目的事件处理串行；
+// 键按字典序逐个获取，避免与同样按序持有多个键的操作死锁
+func (q *RFSQuota) doMembers(m xfs.RebalanceMove, fn func(ctx context.Context) error) error {
+	keys := append([]string(nil), m.Containers...)
+	sort.Strings(keys)
+	var hold func(ctx context.Context, keys []string) error
+	hold = func(ctx context.Context, keys []string) error {
+		if len(keys) == 0 {
+			return fn(ctx)
+		}
+		return q.sched.Do(ctx, keys[0], sched.Reconcile, func(ctx context.Context) error {
+			return hold(ctx, keys[1:])
+		})
+	}
+	return hold(q.ctx, keys)
+}
+
+// rollbackMove 将已迁移的项目改回旧 ID；中断时条目仍使用旧 ID 的项目只需把已改标的目录改回
+func (q *RFSQuota) rollbackMove(m xfs.RebalanceMove) (xfs.RebalanceMove, error) {
+	from := m.NewID
+	if m.Status == xfs.MoveMoving && len(q.entriesOf(m.NewID)) == 0 {
+		from = m.OldID
+	}
+	moved := -1
+	err := q.doMembers(m, func(ctx context.Context) error {
+		if from == m.OldID {
+			return q.retagProject(ctx, m.OldID, m.NewID)
+		}
+		var err error
+		moved, err = q.moveProjectID(ctx, m.NewID, m.OldID)
+		return err
+	})
+	if err != nil {
+		m.Error = err.Error()
+		return m, err
+	}
+	if moved == 0 {
+		// 迁移后容器已被删除，新 ID 已随之归还，只需归还保留的旧 ID
+		q.projectIDPool.Release(m.OldID)
+		m.NewID, m.Status, m.Error = 0, xfs.MoveGone, ""
+		return m, nil
+	}
+	q.projectIDPool.Release(m.NewID)
+	log.Info("Project rebalance rolled back", zap.Uint32("oldID", m.OldID), zap.Uint32("newID", m.NewID))
+	m.NewID, m.Status, m.Error = 0, xfs.MoveRolledBack, ""
+	return m, nil
+}
+
+// entriesOf 返回使用项目 ID 的所有条目
+func (q *RFSQuota) entriesOf(projID uint32) []xfs.Entry {
+	var list []xfs.Entry
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.ProjectID == projID {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// projectBase 返回记录项目限额的条目：分组条目或独立容器的条目
+func projectBase(entries []xfs.Entry) xfs.Entry {
+	for _, entry := range entries {
+		if entry.Group == "" {
+			return entry
+		}
+	}
+	return entries[0]
+}
+
+// projectDirs 返回条目的可写层与额外目录，去除重复
+func projectDirs(entries []xfs.Entry) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, dir := range append([]string{entry.Upperdir}, entry.Paths...) {
+			if dir != "" && !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// tagDirs 将目录改标为项目 ID，已不存在的目录跳过；失败时已改标的目录改回 undo
+func (q *RFSQuota) tagDirs(ctx context.Context, dirs []string, projID, undo uint32) error {
+	var done []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := q.setProjectID(ctx, dir, projID); err != nil {
+			q.noteFilesystemError(err, dir)
+			// 撤销不受原操作超时的影响
+			undoCtx := context.WithoutCancel(ctx)
+			for _, d := range done {
+				if err := q.setProjectID(undoCtx, d, undo); err != nil {
+					log.Ctx(ctx).Error("Failed to restore project ID after failed rebalance",
+						zap.String("path", d), zap.Uint32("projectID", undo), zap.Error(err))
+				}
+			}
+			return err
+		}
+		done = append(done, dir)
+	}
+	return nil
+}
+
+// moveProjectID 将项目 from 的所有目录改标为 to，在 to 上写入记录的限额并更新条目，最后清除 from 的限额。
+// 改标或写入限额失败时恢复为 from。返回迁移的条目数，项目已没有条目时为 0
+func (q *RFSQuota) moveProjectID(ctx context.Context, from, to uint32) (int, error) {
+	// 分组项目的成员加入与移除需等待迁移完成
+	q.groupMutex.Lock()
+	defer q.groupMutex.Unlock()
+
+	entries := q.entriesOf(from)
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	base := projectBase(entries)
+	oldBackend := q.projectBackend(from)
+
+	dirs := projectDirs(entries)
+	if err := q.tagDirs(ctx, dirs, to, from); err != nil {
+		q.backends.Delete(to)
+		return 0, err
+	}
+	limits := base
+	limits.ProjectID = to
+	err := q.setExtraLimits(ctx, limits)
+	if err == nil && base.LiftedUntil.IsZero() {
+		err = q.setProjectQuota(ctx, to, base.SoftLimit, base.HardLimit, true)
+	}
+	if err != nil {
+		q.tagDirs(context.WithoutCancel(ctx), dirs, from, to)
+		q.projectBackend(to).ClearProject(context.WithoutCancel(ctx), to)
+		q.applied.Forget(to)
+		q.backends.Delete(to)
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if _, err := q.stateManager.UpdateEntry(entry.ContainerID, func(e *xfs.Entry) {
+			e.ProjectID = to
+		}); err != nil {
+			q.noteFilesystemError(err, q.cfg.StateFilePath)
+			return 0, err
+		}
+	}
+	if !base.LiftedUntil.IsZero() {
+		q.lifts.cancel(from)
+		q.scheduleRestore(to, base.LiftedUntil)
+	}
+
+	if err = q.checkProjectFence(from); err == nil {
+		err = oldBackend.ClearProject(ctx, from)
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn("Failed to clear limits of rebalanced project ID", zap.Uint32("projectID", from), zap.Error(err))
+	}
+	q.applied.Forget(from)
+	q.backends.Delete(from)
+	return len(entries), nil
+}
+
+// retagProject 将项目条目的目录重新标记为 projID，用于回滚在改标途中中断的迁移，并清除中断时使用的新 ID stray 的限额
+func (q *RFSQuota) retagProject(ctx context.Context, projID, stray uint32) error {
+	q.groupMutex.Lock()
+	defer q.groupMutex.Unlock()
+
+	if err := q.tagDirs(ctx, projectDirs(q.entriesOf(projID)), projID, stray); err != nil {
+		return err
+	}
+	if err := q.projectBackend(stray).ClearProject(ctx, stray); err != nil {
+		log.Ctx(ctx).Warn("Failed to clear limits of abandoned project ID", zap.Uint32("projectID", stray), zap.Error(err))
+	}
+	q.applied.Forget(stray)
+	q.backends.Delete(stray)
+	return nil
+}
+
+// rebalanceResponse 将迁移记录展开为每个容器一行的进度
+func rebalanceResponse(r xfs.Rebalance) api.RebalanceResponse {
+	resp := api.RebalanceResponse{
+		IDMin:      r.IDMin,
+		IDMax:      r.IDMax,
+		State:      r.State,
+		StartedAt:  r.StartedAt,
+		UpdatedAt:  r.UpdatedAt,
+		Projects:   len(r.Projects),
+		Containers: []api.RebalanceContainer{},
+	}
+	for _, m := range r.Projects {
+		switch m.Status {
+		case xfs.MoveMoved:
+			resp.Moved++
+		case xfs.MoveFailed:
+			resp.Failed++
+		case xfs.MovePending, xfs.MoveMoving:
+			resp.Remaining++
+		}
+		for _, id := range m.Containers {
+			resp.Containers = append(resp.Containers, api.RebalanceContainer{
+				ContainerID: id,
+				OldID:       m.OldID,
+				NewID:       m.NewID,
+				Status:      m.Status,
+				Error:       m.Error,
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/health"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// rebalanceDaemon returns a daemon allocating from 1000-1999, with every
+// project in 1000-1099 and in the new range 2000-2099 on backend. Upperdirs
+// of the entries do not exist, so moves only update limits and the state.
+func rebalanceDaemon(t *testing.T, backend *fakeBackend, entries ...xfs.Entry) *RFSQuota {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	q := &RFSQuota{
+		cfg:           &config.Config{Project: config.ProjectConfig{IDMin: 1000, IDMax: 1999}},
+		stateManager:  xfs.NewMemoryStateManager(),
+		projectIDPool: xfs.NewProjectIDPool(1000, 1999),
+		ctx:           ctx,
+		health:        health.NewStatus(),
+		degraded:      newDegradedState(),
+		sched:         sched.New(1, sched.DefaultOrder),
+		applied:       xfs.NewAppliedLimits(),
+	}
+	for id := uint32(0); id < 100; id++ {
+		q.backends.Store(1000+id, backend)
+		q.backends.Store(2000+id, backend)
+	}
+	gone := filepath.Join(t.TempDir(), "gone")
+	for _, entry := range entries {
+		if entry.Group == "" && !isGroupKey(entry.ContainerID) {
+			entry.Upperdir = filepath.Join(gone, entry.ContainerID)
+		}
+		if err := q.stateManager.PutEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+		q.projectIDPool.MarkUsed(entry.ProjectID)
+	}
+	return q
+}
+
+// startRebalance records a rebalance to 2000-2099 with the given moves.
+func startRebalance(t *testing.T, q *RFSQuota, state string, moves ...xfs.RebalanceMove) xfs.Rebalance {
+	t.Helper()
+	r := xfs.Rebalance{IDMin: 2000, IDMax: 2099, State: state, Projects: moves}
+	if err := q.stateManager.PutRebalance(&r); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		if m.NewID != 0 {
+			q.projectIDPool.MarkUsed(m.NewID)
+		}
+	}
+	r, _ = q.stateManager.Rebalance()
+	return r
+}
+
+func projectIDs(q *RFSQuota) map[string]uint32 {
+	ids := make(map[string]uint32)
+	for _, entry := range q.stateManager.ListEntries() {
+		ids[entry.ContainerID] = entry.ProjectID
+	}
+	return ids
+}
+
+func TestNextRebalanceMove(t *testing.T) {
+	r := xfs.Rebalance{Projects: []xfs.RebalanceMove{
+		{OldID: 1, Status: xfs.MoveMoved},
+		{OldID: 2, Status: xfs.MoveFailed},
+		{OldID: 3, Status: xfs.MovePending},
+		{OldID: 4, Status: xfs.MoveMoving},
+		{OldID: 5, Status: xfs.MoveGone},
+	}}
+	tests := []struct {
+		name     string
+		rollback bool
+		failed   map[uint32]bool
+		want     int
+	}{
+		{name: "first pending in plan order", want: 2},
+		{name: "interrupted move after a failed one", failed: map[uint32]bool{3: true}, want: 3},
+		{name: "nothing left", failed: map[uint32]bool{3: true, 4: true}, want: -1},
+		{name: "rollback takes moved projects", rollback: true, want: 0},
+		{name: "rollback takes interrupted moves", rollback: true, failed: map[uint32]bool{1: true}, want: 3},
+		{name: "rollback skips pending", rollback: true, failed: map[uint32]bool{1: true, 4: true}, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRebalanceMove(r, tt.rollback, tt.failed); got != tt.want {
+				t.Errorf("nextRebalanceMove() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndRebalancePass(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		rollback bool
+		moves    []xfs.RebalanceMove
+		want     string
+		idMin    uint32
+	}{
+		{
+			name:  "all moved",
+			state: xfs.RebalanceRunning,
+			moves: []xfs.RebalanceMove{{OldID: 1001, NewID: 2000, Status: xfs.MoveMoved}, {OldID: 1002, Status: xfs.MoveGone}},
+			want:  xfs.RebalanceCompleted,
+			idMin: 2000,
+		},
+		{
+			name:  "failed move pauses",
+			state: xfs.RebalanceRunning,
+			moves: []xfs.RebalanceMove{{OldID: 1001, NewID: 2000, Status: xfs.MoveMoved}, {OldID: 1002, Status: xfs.MoveFailed}},
+			want:  xfs.RebalancePaused,
+			idMin: 1000,
+		},
+		{
+			name:  "interrupted move pauses",
+			state: xfs.RebalanceRunning,
+			moves: []xfs.RebalanceMove{{OldID: 1001, NewID: 2000, Status: xfs.MoveMoving}},
+			want:  xfs.RebalancePaused,
+			idMin: 1000,
+		},
+		{
+			name:     "all rolled back",
+			state:    xfs.RebalanceRollingBack,
+			rollback: true,
+			moves:    []xfs.RebalanceMove{{OldID: 1001, Status: xfs.MoveRolledBack}, {OldID: 1002, Status: xfs.MovePending}},
+			want:     xfs.RebalanceRolledBack,
+			idMin:    1000,
+		},
+		{
+			name:     "project still moved pauses rollback",
+			state:    xfs.RebalanceRollingBack,
+			rollback: true,
+			moves:    []xfs.RebalanceMove{{OldID: 1001, Status: xfs.MoveRolledBack}, {OldID: 1002, NewID: 2001, Status: xfs.MoveMoved}},
+			want:     xfs.RebalancePaused,
+			idMin:    1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := rebalanceDaemon(t, newFakeBackend())
+			startRebalance(t, q, tt.state, tt.moves...)
+			q.endRebalancePass(tt.rollback)
+			r, _ := q.stateManager.Rebalance()
+			if r.State != tt.want {
+				t.Errorf("state = %s, want %s", r.State, tt.want)
+			}
+			if idMin, _ := q.projectIDPool.Range(); idMin != tt.idMin {
+				t.Errorf("allocation range starts at %d, want %d", idMin, tt.idMin)
+			}
+		})
+	}
+}
+
+func TestForwardMove(t *testing.T) {
+	backend := newFakeBackend()
+	q := rebalanceDaemon(t, backend,
+		xfs.Entry{ContainerID: "c1", ProjectID: 1001, SoftLimit: "1g", HardLimit: "2g"},
+		xfs.Entry{ContainerID: podGroupPrefix + "uid", ProjectID: 1002, SoftLimit: "3g", HardLimit: "4g"},
+		xfs.Entry{ContainerID: "m1", ProjectID: 1002, Group: podGroupPrefix + "uid"},
+		xfs.Entry{ContainerID: "m2", ProjectID: 1002, Group: podGroupPrefix + "uid"},
+	)
+	backend.limits[1001] = [2]string{"1g", "2g"}
+	backend.limits[1002] = [2]string{"3g", "4g"}
+	r := startRebalance(t, q, xfs.RebalanceRunning,
+		xfs.RebalanceMove{OldID: 1001, Containers: []string{"c1"}, Status: xfs.MovePending},
+		xfs.RebalanceMove{OldID: 1002, Containers: []string{"m1", "m2", podGroupPrefix + "uid"}, Status: xfs.MovePending},
+	)
+
+	for i, newID := range []uint32{2000, 2001} {
+		m, err := q.forwardMove(r, r.Projects[i])
+		if err != nil {
+			t.Fatalf("forwardMove(%d): %v", r.Projects[i].OldID, err)
+		}
+		if m.Status != xfs.MoveMoved || m.NewID != newID {
+			t.Errorf("forwardMove(%d) = %+v, want moved to %d", m.OldID, m, newID)
+		}
+	}
+	want := map[string]uint32{"c1": 2000, podGroupPrefix + "uid": 2001, "m1": 2001, "m2": 2001}
+	if got := projectIDs(q); !reflect.DeepEqual(got, want) {
+		t.Errorf("project IDs = %v, want %v", got, want)
+	}
+	wantLimits := map[uint32][2]string{2000: {"1g", "2g"}, 2001: {"3g", "4g"}}
+	if !reflect.DeepEqual(backend.limits, wantLimits) {
+		t.Errorf("limits = %v, want %v, with the old IDs cleared", backend.limits, wantLimits)
+	}
+}
+
+func TestForwardMoveRecovery(t *testing.T) {
+	t.Run("entries already on the new ID", func(t *testing.T) {
+		backend := newFakeBackend()
+		q := rebalanceDaemon(t, backend, xfs.Entry{ContainerID: "c1", ProjectID: 2005, SoftLimit: "1g", HardLimit: "2g"})
+		backend.limits[1001] = [2]string{"1g", "2g"}
+		backend.limits[2005] = [2]string{"1g", "2g"}
+		r := startRebalance(t, q, xfs.RebalanceRunning,
+			xfs.RebalanceMove{OldID: 1001, NewID: 2005, Containers: []string{"c1"}, Status: xfs.MoveMoving})
+
+		m, err := q.forwardMove(r, r.Projects[0])
+		if err != nil || m.Status != xfs.MoveMoved || m.NewID != 2005 {
+			t.Fatalf("forwardMove() = %+v, %v, want moved to 2005", m, err)
+		}
+		if _, ok := backend.limits[1001]; ok {
+			t.Error("limits of the old ID were not cleared")
+		}
+		if got := projectIDs(q)["c1"]; got != 2005 {
+			t.Errorf("c1 has project ID %d, want 2005", got)
+		}
+	})
+
+	t.Run("entries still on the old ID", func(t *testing.T) {
+		backend := newFakeBackend()
+		q := rebalanceDaemon(t, backend, xfs.Entry{ContainerID: "c1", ProjectID: 1001, SoftLimit: "1g", HardLimit: "2g"})
+		r := startRebalance(t, q, xfs.RebalanceRunning,
+			xfs.RebalanceMove{OldID: 1001, NewID: 2005, Containers: []string{"c1"}, Status: xfs.MoveMoving})
+
+		// the interrupted move is redone on the ID it had already picked
+		m, err := q.forwardMove(r, r.Projects[0])
+		if err != nil || m.Status != xfs.MoveMoved || m.NewID != 2005 {
+			t.Fatalf("forwardMove() = %+v, %v, want moved to 2005", m, err)
+		}
+		if got := projectIDs(q)["c1"]; got != 2005 {
+			t.Errorf("c1 has project ID %d, want 2005", got)
+		}
+		if got := backend.limits[2005]; got != [2]string{"1g", "2g"} {
+			t.Errorf("limits of 2005 = %q, want [1g 2g]", got)
+		}
+	})
+
+	t.Run("entries removed since the plan", func(t *testing.T) {
+		q := rebalanceDaemon(t, newFakeBackend())
+		r := startRebalance(t, q, xfs.RebalanceRunning,
+			xfs.RebalanceMove{OldID: 1001, Containers: []string{"c1"}, Status: xfs.MovePending})
+
+		m, err := q.forwardMove(r, r.Projects[0])
+		if err != nil || m.Status != xfs.MoveGone || m.NewID != 0 {
+			t.Fatalf("forwardMove() = %+v, %v, want gone", m, err)
+		}
+		if q.projectIDPool.InUse(2000) {
+			t.Error("picked ID of a gone project was not released")
+		}
+	})
+}
+
+func TestRollbackMove(t *testing.T) {
+	t.Run("moved project", func(t *testing.T) {
+		backend := newFakeBackend()
+		q := rebalanceDaemon(t, backend, xfs.Entry{ContainerID: "c1", ProjectID: 2005, SoftLimit: "1g", HardLimit: "2g"})
+		backend.limits[2005] = [2]string{"1g", "2g"}
+		r := startRebalance(t, q, xfs.RebalanceRollingBack,
+			xfs.RebalanceMove{OldID: 1001, NewID: 2005, Containers: []string{"c1"}, Status: xfs.MoveMoved})
+		q.projectIDPool.MarkUsed(1001)
+
+		m, err := q.rollbackMove(r.Projects[0])
+		if err != nil || m.Status != xfs.MoveRolledBack || m.NewID != 0 {
+			t.Fatalf("rollbackMove() = %+v, %v, want rolled back", m, err)
+		}
+		if got := projectIDs(q)["c1"]; got != 1001 {
+			t.Errorf("c1 has project ID %d, want 1001", got)
+		}
+		if want := map[uint32][2]string{1001: {"1g", "2g"}}; !reflect.DeepEqual(backend.limits, want) {
+			t.Errorf("limits = %v, want %v", backend.limits, want)
+		}
+		if q.projectIDPool.InUse(2005) {
+			t.Error("new ID was not released")
+		}
+	})
+
+	t.Run("interrupted before the entries moved", func(t *testing.T) {
+		backend := newFakeBackend()
+		q := rebalanceDaemon(t, backend, xfs.Entry{ContainerID: "c1", ProjectID: 1001, SoftLimit: "1g", HardLimit: "2g"})
+		backend.limits[1001] = [2]string{"1g", "2g"}
+		backend.limits[2005] = [2]string{"1g", "2g"}
+		r := startRebalance(t, q, xfs.RebalanceRollingBack,
+			xfs.RebalanceMove{OldID: 1001, NewID: 2005, Containers: []string{"c1"}, Status: xfs.MoveMoving})
+
+		m, err := q.rollbackMove(r.Projects[0])
+		if err != nil || m.Status != xfs.MoveRolledBack {
+			t.Fatalf("rollbackMove() = %+v, %v, want rolled back", m, err)
+		}
+		if got := projectIDs(q)["c1"]; got != 1001 {
+			t.Errorf("c1 has project ID %d, want 1001", got)
+		}
+		if want := map[uint32][2]string{1001: {"1g", "2g"}}; !reflect.DeepEqual(backend.limits, want) {
+			t.Errorf("limits = %v, want %v with the abandoned ID cleared", backend.limits, want)
+		}
+	})
+
+	t.Run("interrupted after the entries moved", func(t *testing.T) {
+		backend := newFakeBackend()
+		q := rebalanceDaemon(t, backend, xfs.Entry{ContainerID: "c1", ProjectID: 2005, SoftLimit: "1g", HardLimit: "2g"})
+		r := startRebalance(t, q, xfs.RebalanceRollingBack,
+			xfs.RebalanceMove{OldID: 1001, NewID: 2005, Containers: []string{"c1"}, Status: xfs.MoveMoving})
+		q.projectIDPool.MarkUsed(1001)
+
+		m, err := q.rollbackMove(r.Projects[0])
+		if err != nil || m.Status != xfs.MoveRolledBack {
+			t.Fatalf("rollbackMove() = %+v, %v, want rolled back", m, err)
+		}
+		if got := projectIDs(q)["c1"]; got != 1001 {
+			t.Errorf("c1 has project ID %d, want 1001", got)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"sort"
+	"time"
+
+	"RootfsQuota/pkg/api"
+)
+
+// Report 以一次 report 实时读取所有项目的用量，合并 containerd 中的镜像与命名空间；
+// 轮询快照中已读取的容器元数据直接复用，其余容器逐个向 containerd 查询
+func (q *RFSQuota) Report(namespace string) (api.ReportResponse, error) {
+	usages, err := projectUsages(q.ctx)
+	if err != nil {
+		return api.ReportResponse{}, err
+	}
+	var cached map[string]containerMeta
+	if s, err := q.latestUsage(); err == nil {
+		cached = s.Containers
+	}
+
+	resp := api.ReportResponse{At: time.Now(), Containers: []api.ReportContainer{}}
+	for _, entry := range q.stateManager.ListEntries() {
+		if !statsEntry(entry) {
+			continue
+		}
+		if namespace != "" && q.entryNamespace(entry) != namespace {
+			continue
+		}
+		m, ok := cached[entry.ContainerID]
+		if !ok || m.Image == "" {
+			m, _ = q.loadContainerMeta(entry)
+		}
+		c := api.ReportContainer{
+			ContainerID: entry.ContainerID,
+			Namespace:   m.Namespace,
+			Image:       m.Image,
+			Group:       entry.Group,
+			ProjectID:   entry.ProjectID,
+		}
+		if usage, ok := usages[entry.ProjectID]; ok {
+			c.SoftLimitBytes = usage.SoftLimitBytes
+			c.HardLimitBytes = usage.HardLimitBytes
+			c.UsedBytes = usage.UsedBytes
+			c.InodeSoftLimit = usage.InodeSoftLimit
+			c.InodeHardLimit = usage.InodeHardLimit
+			c.UsedInodes = usage.UsedInodes
+		} else {
+			c.UsageMissing = true
+		}
+		resp.Containers = append(resp.Containers, c)
+	}
+	sort.Slice(resp.Containers, func(i, j int) bool {
+		a, b := resp.Containers[i], resp.Containers[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.ContainerID < b.ContainerID
+	})
+	return resp, nil
+}
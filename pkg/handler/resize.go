@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/kubelet"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// labelContainerName 为 CRI 写入的容器名标签
+const labelContainerName = "io.kubernetes.container.name"
+
+// runResizeWatcher 周期对照 kubelet 中的 ephemeral-storage 限制调整项目限额，覆盖原地扩缩容（in-place resize）
+func (q *RFSQuota) runResizeWatcher() {
+	ticker := time.NewTicker(time.Duration(q.cfg.Kubelet.ResizeIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			q.reconcileEphemeralLimits(q.ctx, "")
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleContainerUpdate 在容器更新（CRI UpdateContainerResources 等）后立即检查其临时存储限制
+func (q *RFSQuota) handleContainerUpdate(ctx context.Context, containerID string) {
+	if q.kubelet == nil {
+		return
+	}
+	if _, exists := q.stateManager.GetEntry(containerID); !exists {
+		return
+	}
+	q.kubelet.Invalidate()
+	q.reconcileEphemeralLimits(ctx, containerID)
+}
+
+// reconcileEphemeralLimits 将 kubelet 规格中的临时存储限制同步到项目硬限制，only 非空时只处理该容器
+func (q *RFSQuota) reconcileEphemeralLimits(ctx context.Context, only string) {
+	if q.kubelet == nil || q.degraded.Active() || q.standby.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	pods, err := q.kubelet.Pods(ctx)
+	if err != nil {
+		log.Warn("Failed to read pod specs from kubelet", zap.Error(err))
+		return
+	}
+
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" || (only != "" && entry.ContainerID != only) {
+			continue
+		}
+		if strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || !podResizable(entry.Group) {
+			continue
+		}
+		labels := q.containerLabels(entry)
+		pod, ok := pods[labels[labelPodUID]]
+		if !ok {
+			continue
+		}
+
+		// Pod 共享项目按 Pod 内各容器限制之和调整
+		key := entry.ContainerID
+		limit, ok := pod.EphemeralLimit(labels[labelContainerName])
+		if entry.Group != "" {
+			key = entry.Group
+			limit, ok = pod.PodEphemeralLimit()
+		}
+		if !ok {
+			continue
+		}
+		if limit, ok = q.ephemeralQuota(limit); ok {
+			q.resizeProject(ctx, key, limit)
+		}
+	}
+}
+
+// podResizable 判断分组是否跟随 Pod 规格调整：独立容器与 Pod 共享项目跟随，命名空间等其他分组的预算与单个 Pod 无关
+func podResizable(group string) bool {
+	return group == "" || strings.HasPrefix(group, podGroupPrefix) || strings.HasPrefix(group, sandboxGroupPrefix)
+}
+
+// resizeProject 在硬限制与期望值不同时在线调整，软限制按原比例缩放
+func (q *RFSQuota) resizeProject(ctx context.Context, key string, limit uint64) {
+	entry, exists := q.stateManager.GetEntry(key)
+	if !exists || entry.Pinned {
+		return
+	}
+	hard, err := xfs.ParseSize(entry.HardLimit)
+	if err != nil || xfs.FormatSize(hard) == xfs.FormatSize(limit) {
+		return
+	}
+
+	newSoft := xfs.FormatSize(limit)
+	if soft, err := xfs.ParseSize(entry.SoftLimit); err == nil && hard > 0 && soft < hard {
+		newSoft = xfs.FormatSize(uint64(float64(limit) * float64(soft) / float64(hard)))
+	}
+	newHard := xfs.FormatSize(limit)
+	if err := q.setLimits(ctx, key, newSoft, newHard, limitSourceResize, sched.Reconcile); err != nil {
+		log.Error("Failed to apply resized ephemeral-storage limit", zap.String("key", key), zap.Error(err))
+		return
+	}
+	log.Info("Applied resized ephemeral-storage limit",
+		zap.String("key", key),
+		zap.Uint32("projectID", entry.ProjectID),
+		zap.String("oldHard", entry.HardLimit),
+		zap.String("newHard", newHard))
+}
+
+// ephemeralLimits 以 Pod 规格中容器的 ephemeral-storage 限制换算块限额，取代 limits 中的默认值；
+// 未启用、不是 Pod 容器、读取失败或容器未设置限制时返回 limits
+func (q *RFSQuota) ephemeralLimits(ctx context.Context, containerID string, limits config.QuotaConfig) config.QuotaConfig {
+	if !q.cfg.EphemeralStorage.Enabled || q.kubelet == nil || q.client == nil || strings.HasPrefix(containerID, buildkitKeyPrefix) {
+		return limits
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		return limits
+	}
+	pod, ok := q.kubeletPod(ctx, info.Labels[labelPodUID])
+	if !ok {
+		return limits
+	}
+	limit, ok := pod.EphemeralLimit(info.Labels[labelContainerName])
+	if !ok {
+		return limits
+	}
+	return q.ephemeralScaled(ctx, limit, limits)
+}
+
+// ephemeralScaled 将换算后的限制设为硬限制，软限制保持 limits 中软、硬限制的比例；换算结果无效时返回 limits
+func (q *RFSQuota) ephemeralScaled(ctx context.Context, limit uint64, limits config.QuotaConfig) config.QuotaConfig {
+	hard, ok := q.ephemeralQuota(limit)
+	if !ok {
+		log.Ctx(ctx).Warn("Ephemeral-storage limit maps to no space, using defaults", zap.Uint64("limit", limit))
+		return limits
+	}
+	soft := hard
+	defSoft, errSoft := xfs.ParseSize(limits.DefaultSoft)
+	defHard, errHard := xfs.ParseSize(limits.DefaultHard)
+	if errSoft == nil && errHard == nil && defHard > 0 && defSoft < defHard {
+		soft = uint64(float64(hard) * float64(defSoft) / float64(defHard))
+	}
+	limits.DefaultSoft, limits.DefaultHard = xfs.FormatSize(soft), xfs.FormatSize(hard)
+	log.Ctx(ctx).Info("Using ephemeral-storage limit", zap.Uint64("limit", limit),
+		zap.String("soft", limits.DefaultSoft), zap.String("hard", limits.DefaultHard))
+	return limits
+}
+
+// ephemeralQuota 按 ephemeral_storage 的 ratio 与 offset 将限制换算为硬限制，结果不为正时返回 false
+func (q *RFSQuota) ephemeralQuota(limit uint64) (uint64, bool) {
+	cfg := q.cfg.EphemeralStorage
+	hard := float64(limit)*cfg.Ratio + float64(cfg.OffsetBytes())
+	if hard < 1 || hard >= math.MaxUint64 {
+		return 0, false
+	}
+	return uint64(hard), true
+}
+
+// kubeletPod 返回 kubelet 中的 Pod 规格；缓存中没有时（Pod 刚创建）刷新一次
+func (q *RFSQuota) kubeletPod(ctx context.Context, uid string) (kubelet.Pod, bool) {
+	if uid == "" {
+		return kubelet.Pod{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for attempt := 0; attempt < 2; attempt++ {
+		pods, err := q.kubelet.Pods(ctx)
+		if err != nil {
+			log.Ctx(ctx).Warn("Failed to read pod specs from kubelet", zap.Error(err))
+			return kubelet.Pod{}, false
+		}
+		if pod, ok := pods[uid]; ok {
+			return pod, true
+		}
+		q.kubelet.Invalidate()
+	}
+	return kubelet.Pod{}, false
+}
+
+// newKubeletClient 按配置创建 kubelet 客户端，未配置时返回 nil
+func newKubeletClient(url, tokenFile string, insecure bool) (*kubelet.Client, error) {
+	if url == "" {
+		return nil, nil
+	}
+	return kubelet.NewClient(url, tokenFile, insecure)
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/quota"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// runningContainer 为 containerd 中仍存在且可写层目录存在的容器
+type runningContainer struct {
+	namespace string
+	upperdir  string
+}
+
+// Resync 对比 containerd 中的容器、目录上的项目 ID 与状态文件，生成并执行完整的对账计划；
+// dryRun 时只返回计划
+func (q *RFSQuota) Resync(dryRun bool) ([]api.ResyncAction, error) {
+	if q.client == nil {
+		return nil, fmt.Errorf("not connected to containerd")
+	}
+	if !dryRun {
+		if q.standby.Load() {
+			return nil, api.ErrStandby
+		}
+		if q.degraded.Active() {
+			return nil, fmt.Errorf("filesystem is degraded, refusing to resync")
+		}
+	}
+
+	actions, err := q.planResync()
+	if err != nil || dryRun {
+		return actions, err
+	}
+
+	failed := 0
+	for i := range actions {
+		a := &actions[i]
+		ctx := log.WithFields(q.namespaceContext(a.Namespace),
+			zap.String("container", a.ContainerID), zap.String("action", a.Action))
+		if err := q.sched.Do(ctx, a.ContainerID, sched.Admin, func(ctx context.Context) error {
+			return q.executeResync(ctx, *a)
+		}); err != nil {
+			a.Error = err.Error()
+			failed++
+			log.Ctx(ctx).Error("Resync action failed", zap.Error(err))
+			continue
+		}
+		a.Done = true
+	}
+	log.Info("Resync completed", zap.Int("actions", len(actions)), zap.Int("failed", failed))
+	return actions, nil
+}
+
+// runReconcileSignal 在收到 SIGUSR2 时执行与 POST /v1/reconcile 相同的完整对账，
+// 重新发现容器并恢复缺失的配额，无需重启进程
+func (q *RFSQuota) runReconcileSignal() {
+	for {
+		select {
+		case <-q.reconcileCh:
+		case <-q.ctx.Done():
+			return
+		}
+		log.Info("Received SIGUSR2, reconciling")
+		actions, err := q.Resync(false)
+		if err != nil {
+			log.Warn("Signal-triggered reconcile failed", zap.Error(err))
+			continue
+		}
+		failed := 0
+		for _, a := range actions {
+			if a.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.Warn("Signal-triggered reconcile left failed actions", zap.Int("actions", len(actions)), zap.Int("failed", failed))
+		}
+	}
+}
+
+// planResync 生成对账计划，不做任何修改
+func (q *RFSQuota) planResync() ([]api.ResyncAction, error) {
+	namespaces := map[string]bool{q.cfg.Namespace: true}
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir != "" && !strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) && !isExternalKey(entry.ContainerID) {
+			namespaces[q.entryNamespace(entry)] = true
+		}
+	}
+
+	running := make(map[string]runningContainer)
+	for ns := range namespaces {
+		ctx := q.namespaceContext(ns)
+		ids, err := q.listContainers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers in namespace %s: %v", ns, err)
+		}
+		for _, id := range ids {
+			upperdir, err := q.resolver.Upperdir(ctx, id)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(upperdir); err != nil {
+				continue
+			}
+			// 不支持项目配额的文件系统上或不在允许列表内的容器不纳入对账
+			if _, err := quota.Detect(upperdir); err != nil || !q.upperdirAllowed(upperdir) {
+				continue
+			}
+			// 已获准退出配额管理或命中排除规则且尚无配额的容器同样不纳入
+			if _, exists := q.stateManager.GetEntry(id); !exists {
+				if q.excludedBy(ctx, ns, id) != "" {
+					continue
+				}
+				if r, err := q.lookupContainer(ctx, id); err == nil {
+					if _, honored := q.optOutAllowed(ns, r.Labels); honored {
+						continue
+					}
+				}
+			}
+			running[id] = runningContainer{namespace: ns, upperdir: upperdir}
+		}
+	}
+
+	var actions []api.ResyncAction
+	for id, rc := range running {
+		ctx := q.namespaceContext(rc.namespace)
+		a := api.ResyncAction{ContainerID: id, Namespace: rc.namespace, Upperdir: rc.upperdir}
+
+		entry, exists := q.stateManager.GetEntry(id)
+		if !exists {
+			if projID, _, ok := q.adoptable(ctx, id, rc.upperdir); ok {
+				a.Action, a.ProjectID, a.Reason = api.ResyncAdopt, projID, "upperdir is tagged with this container and an in-range project ID"
+			} else {
+				a.Action, a.Reason = api.ResyncCreate, "running container has no quota"
+			}
+			actions = append(actions, a)
+			continue
+		}
+
+		a.ProjectID = entry.ProjectID
+		if entry.Upperdir != rc.upperdir {
+			a.Action, a.Reason = api.ResyncRepair, fmt.Sprintf("recorded upperdir %s differs from the snapshot's", entry.Upperdir)
+			actions = append(actions, a)
+			continue
+		}
+		projID, err := xfs.GetProjectIDFromXFS(ctx, rc.upperdir)
+		if err != nil {
+			a.Action, a.Reason = api.ResyncRepair, fmt.Sprintf("cannot read project ID of upperdir: %v", err)
+			actions = append(actions, a)
+		} else if projID != entry.ProjectID {
+			a.Action, a.Reason = api.ResyncRepair, fmt.Sprintf("upperdir has project ID %d instead of %d", projID, entry.ProjectID)
+			actions = append(actions, a)
+		}
+	}
+
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir == "" || isGroupKey(entry.ContainerID) || strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) || isExternalKey(entry.ContainerID) {
+			continue
+		}
+		if _, exists := running[entry.ContainerID]; exists {
+			continue
+		}
+		actions = append(actions, api.ResyncAction{
+			Action:      api.ResyncRemove,
+			ContainerID: entry.ContainerID,
+			Namespace:   q.entryNamespace(entry),
+			ProjectID:   entry.ProjectID,
+			Upperdir:    entry.Upperdir,
+			Reason:      "container or its snapshot no longer exists",
+		})
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Action != actions[j].Action {
+			return actions[i].Action < actions[j].Action
+		}
+		return actions[i].ContainerID < actions[j].ContainerID
+	})
+	return actions, nil
+}
+
+// executeResync 执行单个对账动作
+func (q *RFSQuota) executeResync(ctx context.Context, a api.ResyncAction) error {
+	switch a.Action {
+	case api.ResyncAdopt:
+		if !q.adoptExisting(ctx, a.Namespace, a.ContainerID, a.Upperdir) {
+			return fmt.Errorf("upperdir tags changed since planning")
+		}
+		return nil
+	case api.ResyncCreate:
+		_, err := q.ensureQuota(ctx, a.Namespace, a.ContainerID, a.Upperdir)
+		return err
+	case api.ResyncRepair:
+		entry, exists := q.stateManager.GetEntry(a.ContainerID)
+		if !exists {
+			return fmt.Errorf("entry disappeared since planning")
+		}
+		if err := q.setProjectID(ctx, a.Upperdir, entry.ProjectID); err != nil {
+			q.noteFilesystemError(err, a.Upperdir)
+			return err
+		}
+		q.tagOwner(ctx, a.Upperdir, entry.ContainerID, entry.Group)
+		if entry.LiftedUntil.IsZero() {
+			if err := q.setProjectQuota(ctx, entry.ProjectID, entry.SoftLimit, entry.HardLimit, true); err != nil {
+				return err
+			}
+		}
+		if err := q.setExtraLimits(ctx, entry); err != nil {
+			return err
+		}
+		if _, err := q.stateManager.UpdateEntry(a.ContainerID, func(e *xfs.Entry) {
+			e.Upperdir = a.Upperdir
+		}); err != nil {
+			q.noteFilesystemError(err, q.cfg.StateFilePath)
+			return err
+		}
+		return nil
+	case api.ResyncRemove:
+		entry, exists := q.stateManager.GetEntry(a.ContainerID)
+		if !exists {
+			return nil
+		}
+		return q.removeQuota(ctx, a.ContainerID, entry.ProjectID)
+	}
+	return fmt.Errorf("unknown resync action %q", a.Action)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+)
+
+// sandboxGroupPrefix 为 Pod 沙箱共享项目在状态文件中的键前缀
+const sandboxGroupPrefix = "sandbox:"
+
+// lookupSandbox 返回容器所属 Pod 沙箱的 ID 与 Pod 信息，沙箱容器自身返回自己的 ID；
+// 没有沙箱 ID 的容器返回 false
+func (q *RFSQuota) lookupSandbox(ctx context.Context, containerID string) (string, podInfo, bool) {
+	if q.client == nil {
+		return "", podInfo{}, false
+	}
+	info, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		log.Ctx(ctx).Debug("Failed to load container for pod sandbox", zap.Error(err))
+		return "", podInfo{}, false
+	}
+	pod, _ := info.pod()
+	if id := labelValue(info, q.cfg.PodAggregate.SandboxKey); id != "" {
+		return id, pod, true
+	}
+	if info.Labels[labelCRIKind] == criKindSandbox {
+		return containerID, pod, true
+	}
+	return "", podInfo{}, false
+}
+
+// applySandboxQuota 将容器 rootfs 加入所属 Pod 沙箱的共享项目，沙箱首个容器到达时创建项目
+func (q *RFSQuota) applySandboxQuota(ctx context.Context, namespace, sandboxID string, pod podInfo, containerID, upperdir string) (uint32, error) {
+	return q.joinGroup(ctx, namespace, groupSpec{
+		key:  sandboxGroupPrefix + sandboxID,
+		kind: "Pod aggregate",
+		limits: func(ctx context.Context) config.QuotaConfig {
+			return q.podLimits(ctx, pod.UID, q.cfg.PodAggregate.Quota)
+		},
+	}, containerID, upperdir)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+// ScaleLimits 批量调整所有项目的限额，用于 xfs_growfs 扩容后按比例放大或重置为新的默认值。
+// 共享项目只调整一次（以分组条目为准），重置为默认值时跳过运维固定的限额，DryRun 时不做任何修改。
+func (q *RFSQuota) ScaleLimits(req api.ScaleRequest) ([]api.LimitChange, error) {
+	var changes []api.LimitChange
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Group != "" {
+			continue
+		}
+
+		change := api.LimitChange{
+			ContainerID: entry.ContainerID,
+			ProjectID:   entry.ProjectID,
+			OldSoft:     entry.SoftLimit,
+			OldHard:     entry.HardLimit,
+		}
+		if req.ToDefaults && entry.Pinned && !req.IncludePinned {
+			change.Error = "limits are pinned, not reset to defaults without include_pinned"
+			changes = append(changes, change)
+			continue
+		}
+		var err error
+		if req.ToDefaults {
+			limits := q.defaultLimitsFor(entry)
+			change.NewSoft, change.NewHard = limits.DefaultSoft, limits.DefaultHard
+		} else {
+			change.NewSoft, change.NewHard, err = scaleLimits(entry, req.Factor)
+		}
+		if err != nil {
+			change.Error = err.Error()
+			changes = append(changes, change)
+			continue
+		}
+
+		if !req.DryRun {
+			if err := q.setLimits(q.ctx, entry.ContainerID, change.NewSoft, change.NewHard, limitSourceScale, sched.Admin); err != nil {
+				change.Error = err.Error()
+			} else {
+				change.Applied = true
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ContainerID < changes[j].ContainerID })
+	if !req.DryRun {
+		log.Info("Bulk limit adjustment applied", zap.Int("projects", len(changes)))
+	}
+	return changes, nil
+}
+
+// defaultLimitsFor 返回条目类型对应的默认限额
+func (q *RFSQuota) defaultLimitsFor(entry xfs.Entry) config.QuotaConfig {
+	limits := q.namespaceDefaults(q.entryNamespace(entry))
+	if class, ok := q.cfg.QuotaClasses.Classes[entry.Class]; ok {
+		limits = class
+	}
+	switch {
+	case strings.HasPrefix(entry.ContainerID, podGroupPrefix):
+		limits = q.cfg.PodEphemeral.Quota
+	case strings.HasPrefix(entry.ContainerID, sandboxGroupPrefix):
+		limits = q.cfg.PodAggregate.Quota
+	case strings.HasPrefix(entry.ContainerID, nsGroupPrefix):
+		limits = q.cfg.NamespaceQuotas[strings.TrimPrefix(entry.ContainerID, nsGroupPrefix)].Quota
+	case strings.HasPrefix(entry.ContainerID, externalKeyPrefix):
+		limits = q.cfg.LifecycleWebhook.Quota
+	case q.isKataSandbox(entry):
+		limits = q.cfg.Kata.Quota
+	case strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix), q.isBuildkitNamespace(entry.Namespace):
+		limits = q.cfg.Buildkit.Quota
+	}
+	// 对应功能已在配置中关闭时回退到全局默认值
+	if limits.DefaultHard == "" {
+		return q.namespaceDefaults(q.entryNamespace(entry))
+	}
+	return limits
+}
+
+func scaleLimits(entry xfs.Entry, factor float64) (string, string, error) {
+	soft, err := xfs.ParseSize(entry.SoftLimit)
+	if err != nil {
+		return "", "", err
+	}
+	hard, err := xfs.ParseSize(entry.HardLimit)
+	if err != nil {
+		return "", "", err
+	}
+	return xfs.FormatSize(uint64(math.Ceil(float64(soft) * factor))),
+		xfs.FormatSize(uint64(math.Ceil(float64(hard) * factor))), nil
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"testing"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/xfs"
+)
+
+func TestDefaultLimitsFor(t *testing.T) {
+	quota := func(hard string) config.QuotaConfig { return config.QuotaConfig{DefaultSoft: "1g", DefaultHard: hard} }
+	q := &RFSQuota{cfg: &config.Config{
+		Quota:            quota("10g"),
+		PodEphemeral:     config.PodEphemeralConfig{Quota: quota("20g")},
+		NamespaceQuotas:  map[string]config.NamespaceQuotaConfig{"team": {Quota: quota("30g")}},
+		LifecycleWebhook: config.LifecycleWebhookConfig{Quota: quota("40g")},
+		Kata:             config.KataConfig{Enabled: true, SharedDir: "/run/kata", Quota: quota("50g")},
+	}}
+
+	tests := []struct {
+		name  string
+		entry xfs.Entry
+		hard  string
+	}{
+		{name: "container", entry: xfs.Entry{ContainerID: "c1"}, hard: "10g"},
+		{name: "pod group", entry: xfs.Entry{ContainerID: podGroupPrefix + "uid"}, hard: "20g"},
+		{name: "namespace group", entry: xfs.Entry{ContainerID: nsGroupPrefix + "team"}, hard: "30g"},
+		{name: "namespace without quota", entry: xfs.Entry{ContainerID: nsGroupPrefix + "other"}, hard: "10g"},
+		{name: "external workload", entry: xfs.Entry{ContainerID: externalKeyPrefix + "job"}, hard: "40g"},
+		{name: "kata sandbox", entry: xfs.Entry{ContainerID: "s1", Paths: []string{"/run/kata/s1"}}, hard: "50g"},
+		{name: "other container's shared dir", entry: xfs.Entry{ContainerID: "c2", Paths: []string{"/run/kata/s1"}}, hard: "10g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := q.defaultLimitsFor(tt.entry); got.DefaultHard != tt.hard {
+				t.Errorf("defaultLimitsFor() hard = %q, want %q", got.DefaultHard, tt.hard)
+			}
+		})
+	}
+}
+
+func TestScaleLimitsSkipsPinned(t *testing.T) {
+	q := &RFSQuota{
+		cfg:          &config.Config{Quota: config.QuotaConfig{DefaultSoft: "1g", DefaultHard: "10g"}},
+		stateManager: xfs.NewMemoryStateManager(),
+	}
+	for _, entry := range []xfs.Entry{
+		{ContainerID: "c1", ProjectID: 1001, SoftLimit: "2g", HardLimit: "20g"},
+		{ContainerID: "c2", ProjectID: 1002, SoftLimit: "3g", HardLimit: "30g", Pinned: true},
+	} {
+		if err := q.stateManager.PutEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes, err := q.ScaleLimits(api.ScaleRequest{ToDefaults: true, DryRun: true})
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("ScaleLimits() = %+v, %v", changes, err)
+	}
+	if c := changes[0]; c.NewHard != "10g" || c.Error != "" {
+		t.Errorf("unpinned change = %+v, want a reset to 10g", c)
+	}
+	if c := changes[1]; c.NewHard != "" || c.Error == "" {
+		t.Errorf("pinned change = %+v, want it skipped with an error", c)
+	}
+
+	changes, err = q.ScaleLimits(api.ScaleRequest{ToDefaults: true, IncludePinned: true, DryRun: true})
+	if err != nil || len(changes) != 2 || changes[1].NewHard != "10g" || changes[1].Error != "" {
+		t.Errorf("ScaleLimits() with include_pinned = %+v, %v", changes, err)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// ErrConfigChanged 表示远程配置源上的配置已变化，调用方应重新加载配置后再次启动
+var ErrConfigChanged = errors.New("configuration changed at source")
+
+// runConfigSourceWatcher 周期轮询远程配置源，配置变化时停止服务以便以新配置重启；
+// 配置源不可达或返回无效配置时继续使用当前配置
+func (q *RFSQuota) runConfigSourceWatcher() {
+	interval := time.Duration(q.cfg.ConfigSource.IntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.ctx.Done():
+			return
+		}
+		changed, err := q.source.Poll(q.ctx)
+		if err != nil {
+			log.Warn("Failed to poll config source, keeping current config",
+				zap.String("url", q.source.URL()), zap.Error(err))
+			continue
+		}
+		if changed {
+			log.Info("Configuration changed at source, restarting", zap.String("url", q.source.URL()))
+			q.configChanged.Store(true)
+			q.cancel()
+			return
+		}
+	}
+}
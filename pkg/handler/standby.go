@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+// SetStandby 设置备用模式：照常处理事件与计算配额，但不修改文件系统、状态文件与容器标签，需在 Run 之前调用
+func (q *RFSQuota) SetStandby(standby bool) {
+	q.standby.Store(standby)
+	q.stateManager.SetReadOnly(standby)
+}
+
+// Standby 判断是否处于备用模式
+func (q *RFSQuota) Standby() bool {
+	return q.standby.Load()
+}
+
+// Promote 将备用实例提升为主实例：重新加载状态文件、保留其中的项目 ID，并补齐备用期间创建的容器配额
+func (q *RFSQuota) Promote() error {
+	if !q.standby.Load() {
+		return nil
+	}
+	if err := q.stateManager.Reload(); err != nil {
+		return err
+	}
+	q.stateManager.SetReadOnly(false)
+	if err := q.beginEpoch(); err != nil {
+		q.stateManager.SetReadOnly(true)
+		return err
+	}
+	q.preflightProjectIDs()
+	q.standby.Store(false)
+	q.resumeLifts()
+	log.Info("Promoted from standby to active")
+
+	if q.client != nil {
+		go func() {
+			if err := q.syncState(); err != nil {
+				log.Error("State sync after promotion failed", zap.Error(err))
+			}
+		}()
+	}
+	return nil
+}
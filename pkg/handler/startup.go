@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+)
+
+const (
+	healthContainerd = "containerd"
+
+	// socketRecheckInterval 为等待 socket 时的兜底检查间隔，防止 inotify 丢失事件
+	socketRecheckInterval = 30 * time.Second
+	// connectBackoffMax 为连接 containerd 失败后的最大重试间隔
+	connectBackoffMax = 30 * time.Second
+)
+
+// readyOnce 保证就绪通知只发送一次
+var readyOnce sync.Once
+
+// socketPath 返回配置的 containerd socket 的文件路径
+func (q *RFSQuota) socketPath() string {
+	return strings.TrimPrefix(q.cfg.ContainerdSock, "unix://")
+}
+
+// waitForContainerdSocket 阻塞直到 containerd socket 出现，ctx 结束时返回 false
+func (q *RFSQuota) waitForContainerdSocket() bool {
+	path := q.socketPath()
+	if isSocket(path) {
+		return true
+	}
+
+	q.health.Set(healthContainerd, false, "waiting for containerd socket "+path)
+	log.Info("Waiting for containerd socket", zap.String("path", path))
+	start := time.Now()
+	for {
+		changed := watchDir(q.ctx, filepath.Dir(path), socketRecheckInterval)
+		if q.ctx.Err() != nil {
+			return false
+		}
+		if isSocket(path) {
+			log.Info("Containerd socket appeared", zap.String("path", path), zap.Duration("waited", time.Since(start)))
+			return true
+		}
+		if !changed {
+			log.Debug("Still waiting for containerd socket", zap.String("path", path))
+		}
+	}
+}
+
+// isSocket 判断 path 是否为 unix socket
+func isSocket(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// watchDir 用 inotify 等待 dir 中有文件创建或移入，超时或 ctx 结束时返回；
+// 目录不存在或 inotify 不可用时退化为按超时轮询。返回是否观察到变化
+func watchDir(ctx context.Context, dir string, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		sleepCtx(ctx, timer)
+		return false
+	}
+	// 非阻塞 fd 交由运行时轮询，Close 可唤醒阻塞中的 Read
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE|syscall.IN_MOVED_TO); err != nil {
+		// 父目录尚未创建（如 /run/containerd），按超时轮询
+		sleepCtx(ctx, timer)
+		return false
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		if _, err := file.Read(buf); err == nil {
+			events <- struct{}{}
+		}
+	}()
+
+	select {
+	case <-events:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
+
+func sleepCtx(ctx context.Context, timer *time.Timer) {
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// connectBackoff 返回第 attempt 次连接失败后的等待时间，从 1s 翻倍至 connectBackoffMax
+func connectBackoff(attempt int) time.Duration {
+	if attempt > 5 {
+		return connectBackoffMax
+	}
+	if d := time.Second << attempt; d < connectBackoffMax {
+		return d
+	}
+	return connectBackoffMax
+}
+
+// markReady 在连接 containerd 并完成状态同步后标记就绪，首次就绪时通知 systemd
+func (q *RFSQuota) markReady() {
+	q.health.Set(healthContainerd, true, "")
+	metrics.Ready.Set(1)
+	readyOnce.Do(func() {
+		log.Info("Ready: connected to containerd and state synced")
+		notifySystemd("READY=1")
+	})
+}
+
+// markNotReady 在与 containerd 的连接中断时清除就绪状态
+func (q *RFSQuota) markNotReady(reason string) {
+	q.health.Set(healthContainerd, false, reason)
+	metrics.Ready.Set(0)
+}
+
+// notifySystemd 向 NOTIFY_SOCKET 发送 sd_notify 消息，未由 systemd 以 Type=notify 启动时忽略
+func notifySystemd(state string) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return
+	}
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		log.Warn("Failed to notify systemd", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		log.Warn("Failed to notify systemd", zap.Error(err))
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/stats"
+	"RootfsQuota/pkg/xfs"
+)
+
+// statsEntry 判断条目是否为统计服务返回的容器：分组条目、BuildKit 快照与外部工作负载不是容器
+func statsEntry(entry xfs.Entry) bool {
+	return entry.Upperdir != "" && !isGroupKey(entry.ContainerID) && !strings.HasPrefix(entry.ContainerID, buildkitKeyPrefix) && !isExternalKey(entry.ContainerID)
+}
+
+// ContainerStats 返回容器可写层的实时用量，共享项目的容器返回整个组的用量
+func (q *RFSQuota) ContainerStats(ctx context.Context, containerID string) (stats.Container, error) {
+	entry, exists := q.stateManager.GetEntry(containerID)
+	if !exists || !statsEntry(entry) {
+		return stats.Container{}, fmt.Errorf("%w: %s", api.ErrNotFound, containerID)
+	}
+	usage, err := q.projectBackend(entry.ProjectID).GetUsage(ctx, entry.ProjectID)
+	if err != nil {
+		return stats.Container{}, err
+	}
+	return q.statsContainer(entry, usage), nil
+}
+
+// ListContainerStats 返回已管理容器可写层的实时用量，所有项目由一次批量查询读取；
+// 批量结果中没有的项目（非 XFS 后端）单独查询，查询失败的容器跳过
+func (q *RFSQuota) ListContainerStats(ctx context.Context, namespace string) ([]stats.Container, error) {
+	batch, err := projectUsages(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Debug("Failed to list project usage for stats, querying per project", zap.Error(err))
+	}
+	usages := make(map[uint32]xfs.ProjectUsage)
+	var list []stats.Container
+	for _, entry := range q.stateManager.ListEntries() {
+		if !statsEntry(entry) || (namespace != "" && q.entryNamespace(entry) != namespace) {
+			continue
+		}
+		usage, cached := batch[entry.ProjectID]
+		if !cached {
+			usage, cached = usages[entry.ProjectID]
+		}
+		if !cached {
+			var err error
+			if usage, err = q.projectBackend(entry.ProjectID).GetUsage(ctx, entry.ProjectID); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Debug("Failed to query usage for stats",
+					zap.String("container", entry.ContainerID), zap.Uint32("projectID", entry.ProjectID), zap.Error(err))
+				continue
+			}
+			usages[entry.ProjectID] = usage
+		}
+		list = append(list, q.statsContainer(entry, usage))
+	}
+	return list, nil
+}
+
+// ContainerFilesystems 返回已管理容器可写层所在的目录，同一目录只返回一次
+func (q *RFSQuota) ContainerFilesystems() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, entry := range q.stateManager.ListEntries() {
+		if !statsEntry(entry) {
+			continue
+		}
+		if !seen[entry.Upperdir] {
+			seen[entry.Upperdir] = true
+			paths = append(paths, entry.Upperdir)
+		}
+	}
+	return paths
+}
+
+// usageCall 为一次进行中的批量用量查询
+type usageCall struct {
+	done   chan struct{}
+	usages map[uint32]xfs.ProjectUsage
+	err    error
+}
+
+// usageFlight 合并并发的批量用量查询：查询进行中时，后来的调用方等待并共享其结果，
+// 轮询、垃圾回收、偏差检查与管理接口同时查询时每个文件系统仍只遍历一次
+var usageFlight struct {
+	mutex sync.Mutex
+	call  *usageCall
+}
+
+// projectUsages 以一次批量查询读取所有 XFS 项目的用量，同一项目出现在多个文件系统时取第一个；
+// 返回的映射由并发的调用方共享，不得修改
+func projectUsages(ctx context.Context) (map[uint32]xfs.ProjectUsage, error) {
+	usageFlight.mutex.Lock()
+	call := usageFlight.call
+	if call == nil {
+		call = &usageCall{done: make(chan struct{})}
+		usageFlight.call = call
+		usageFlight.mutex.Unlock()
+
+		call.usages, call.err = listProjectUsages(ctx)
+		usageFlight.mutex.Lock()
+		usageFlight.call = nil
+		usageFlight.mutex.Unlock()
+		close(call.done)
+		return call.usages, call.err
+	}
+	usageFlight.mutex.Unlock()
+	metrics.UsageQueriesShared.Inc()
+
+	select {
+	case <-call.done:
+		return call.usages, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func listProjectUsages(ctx context.Context) (map[uint32]xfs.ProjectUsage, error) {
+	start := time.Now()
+	list, err := xfs.ListProjectUsage(ctx)
+	metrics.UsageListDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	usages := make(map[uint32]xfs.ProjectUsage, len(list))
+	for _, u := range list {
+		if _, seen := usages[u.ProjectID]; !seen {
+			usages[u.ProjectID] = xfs.ProjectUsage{
+				ProjectID:      u.ProjectID,
+				UsedBytes:      u.UsedBytes,
+				SoftLimitBytes: u.SoftLimitBytes,
+				HardLimitBytes: u.HardLimitBytes,
+				UsedInodes:     u.UsedInodes,
+				InodeSoftLimit: u.InodeSoftLimit,
+				InodeHardLimit: u.InodeHardLimit,
+			}
+		}
+	}
+	return usages, nil
+}
+
+func (q *RFSQuota) statsContainer(entry xfs.Entry, usage xfs.ProjectUsage) stats.Container {
+	return stats.Container{
+		ID:        entry.ContainerID,
+		Namespace: q.entryNamespace(entry),
+		Labels:    q.containerLabels(entry),
+		Group:     entry.Group,
+		Upperdir:  entry.Upperdir,
+		Usage:     usage,
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/containerd/containerd/namespaces"
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+)
+
+const (
+	// statusLabel 记录容器的配额状态，便于无节点权限的用户排查
+	statusLabel = "conquotas.io/status"
+	// maxStatusReason 限制写入标签的失败原因长度
+	maxStatusReason = 256
+)
+
+// markFailed 在容器配额永久失败时写入 conquotas.io/status=failed: <reason> 标签
+func (q *RFSQuota) markFailed(namespace, containerID string, reason error) {
+	q.enforcement.fail(containerID)
+	if q.standby.Load() {
+		return
+	}
+	msg := truncateReason(reason.Error())
+	if err := q.setContainerLabel(namespace, containerID, statusLabel, "failed: "+msg); err != nil {
+		log.Warn("Failed to label container with quota status",
+			zap.String("container", containerID), zap.Error(err))
+	}
+}
+
+// truncateReason 将失败原因截断到 maxStatusReason 字节以内，不截断多字节字符
+func truncateReason(msg string) string {
+	if len(msg) <= maxStatusReason {
+		return msg
+	}
+	n := maxStatusReason
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n]
+}
+
+// clearFailed 在配额成功设置后移除此前写入的失败标签
+func (q *RFSQuota) clearFailed(namespace, containerID string) {
+	if q.standby.Load() {
+		return
+	}
+	ctx := q.namespaceContext(namespace)
+	r, err := q.lookupContainer(ctx, containerID)
+	if err != nil {
+		return
+	}
+	if _, exists := r.Labels[statusLabel]; !exists {
+		return
+	}
+	// containerd 会删除值为空的标签
+	if err := q.setContainerLabel(namespace, containerID, statusLabel, ""); err != nil {
+		log.Warn("Failed to clear container quota status label",
+			zap.String("container", containerID), zap.Error(err))
+	}
+}
+
+func (q *RFSQuota) setContainerLabel(namespace, containerID, key, value string) error {
+	if q.client == nil {
+		return nil
+	}
+	ctx := q.namespaceContext(namespace)
+	container, err := q.client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	_, err = container.SetLabels(ctx, map[string]string{key: value})
+	if q.meta != nil {
+		ns, _ := namespaces.Namespace(ctx)
+		q.meta.invalidate(ns, containerID)
+	}
+	return err
+}
+
+// namespaceContext 返回指定命名空间的上下文，为空时使用默认命名空间
+func (q *RFSQuota) namespaceContext(namespace string) context.Context {
+	if namespace == "" {
+		return q.ctx
+	}
+	return namespaces.WithNamespace(q.ctx, namespace)
+}
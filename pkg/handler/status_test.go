@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateReason(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want int
+	}{
+		{name: "short", msg: "failed to set quota", want: 19},
+		{name: "ascii", msg: strings.Repeat("a", 300), want: maxStatusReason},
+		// 3-byte runes: 255 bytes hold 85 of them, the 86th would be split
+		{name: "multi-byte rune at the limit", msg: strings.Repeat("配", 100), want: 255},
+		{name: "rune ending at the limit", msg: "a" + strings.Repeat("额", 85) + "xyz", want: maxStatusReason},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateReason(tt.msg)
+			if len(got) != tt.want || !utf8.ValidString(got) || !strings.HasPrefix(tt.msg, got) {
+				t.Errorf("truncateReason() = %d bytes, valid %v, want a %d-byte prefix", len(got), utf8.ValidString(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/config"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/notify"
+	"RootfsQuota/pkg/xfs"
+)
+
+// 通知模板的类型，即 notify.TemplateData.Event
+const (
+	templateUsageAlert = "usage_alert"
+	templateHardLimit  = "hard_limit"
+)
+
+// notifyTemplate 为一种通知已解析的模板，未配置的模板为 nil
+type notifyTemplate struct {
+	message     *notify.Template
+	payload     *notify.Template
+	contentType string
+}
+
+// notifyTemplates 为所有通知已解析的模板
+type notifyTemplates struct {
+	usageAlert notifyTemplate
+	hardLimit  notifyTemplate
+}
+
+func newNotifyTemplates(cfg config.TemplatesConfig) (notifyTemplates, error) {
+	var ts notifyTemplates
+	var err error
+	if ts.usageAlert, err = newNotifyTemplate(templateUsageAlert, cfg.UsageAlert); err != nil {
+		return ts, err
+	}
+	if ts.hardLimit, err = newNotifyTemplate(templateHardLimit, cfg.HardLimit); err != nil {
+		return ts, err
+	}
+	return ts, nil
+}
+
+// newNotifyTemplate 解析模板并以空数据试渲染一次，使引用不存在字段的模板在启动时报错
+func newNotifyTemplate(name string, cfg config.NotificationTemplate) (notifyTemplate, error) {
+	nt := notifyTemplate{contentType: cfg.ContentType}
+	for field, text := range map[string]string{"message": cfg.Message, "payload": cfg.Payload} {
+		if text == "" {
+			continue
+		}
+		t, err := notify.ParseTemplate(name+"."+field, text)
+		if err == nil {
+			_, err = t.Render(notify.TemplateData{})
+		}
+		if err != nil {
+			return nt, fmt.Errorf("invalid templates.%s.%s: %v", name, field, err)
+		}
+		if field == "message" {
+			nt.message = t
+		} else {
+			nt.payload = t
+		}
+	}
+	return nt, nil
+}
+
+// render 渲染模板，未配置模板或渲染失败时返回 fallback
+func render(t *notify.Template, data notify.TemplateData, fallback string) string {
+	if t == nil {
+		return fallback
+	}
+	text, err := t.Render(data)
+	if err != nil {
+		log.Warn("Failed to render notification template, using built-in format", zap.Error(err))
+		return fallback
+	}
+	return text
+}
+
+// templateData 生成条目的模板数据；容器元数据取自最近的用量快照，快照中没有时从 containerd 读取标签
+func (q *RFSQuota) templateData(event, reason string, entry xfs.Entry, usage xfs.ProjectUsage) notify.TemplateData {
+	data := notify.TemplateData{
+		Event:     event,
+		Node:      q.nodeName(),
+		Timestamp: time.Now(),
+		Reason:    reason,
+		Container: notify.TemplateContainer{
+			ID:        entry.ContainerID,
+			Namespace: q.entryNamespace(entry),
+			ProjectID: entry.ProjectID,
+		},
+		Limits: notify.TemplateLimits{
+			Soft:      entry.SoftLimit,
+			Hard:      entry.HardLimit,
+			SoftBytes: usage.SoftLimitBytes,
+			HardBytes: usage.HardLimitBytes,
+		},
+		Usage: notify.TemplateUsage{UsedBytes: usage.UsedBytes, UsedInodes: usage.UsedInodes},
+	}
+	if usage.HardLimitBytes > 0 {
+		data.Usage.Percent = float64(usage.UsedBytes) / float64(usage.HardLimitBytes) * 100
+	}
+	if isGroupKey(entry.ContainerID) {
+		return data
+	}
+	if snapshot, err := q.latestUsage(); err == nil {
+		data.Container.Image = snapshot.Containers[entry.ContainerID].Image
+	}
+	labels := q.containerLabels(entry)
+	data.Container.Labels = labels
+	data.Pod = notify.TemplatePod{
+		Name:      labels[labelPodName],
+		Namespace: labels[labelPodNamespace],
+		UID:       labels[labelPodUID],
+	}
+	return data
+}
+
+// nodeName 返回通知中的节点名，取自告警或聊天通知的配置
+func (q *RFSQuota) nodeName() string {
+	if q.cfg.Alerts.NodeName != "" {
+		return q.cfg.Alerts.NodeName
+	}
+	return q.cfg.Chat.NodeName
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 
 	"go.uber.org/zap"
@@ -9,9 +10,9 @@ import (
 	"RootfsQuota/pkg/xfs"
 )
 
-func GetProjectID(containerId, path string, stateManager *xfs.StateManager) (uint32, error) {
+func GetProjectID(ctx context.Context, containerId, path string, stateManager *xfs.StateManager) (uint32, error) {
 
-	id, err := xfs.GetProjectIDFromXFS(path)
+	id, err := xfs.GetProjectIDFromXFS(ctx, path)
 
 	log.Info("File exists", zap.Uint32("projectID", id))
 
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+	"RootfsQuota/pkg/sched"
+	"RootfsQuota/pkg/xfs"
+)
+
+const (
+	// healthEnforcement 为配额实际生效情况的健康状况名称
+	healthEnforcement = "enforcement"
+	// probeOverrun 为探测时超出剩余额度多写的字节数
+	probeOverrun = 1 << 20
+)
+
+// runVerifySweep 周期抽样已管理容器，写入超过剩余额度的临时文件以证明配额确实生效
+func (q *RFSQuota) runVerifySweep() {
+	interval := time.Duration(q.cfg.Verify.IntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			q.verifySample()
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *RFSQuota) verifySample() {
+	if q.degraded.Active() || q.standby.Load() {
+		return
+	}
+
+	var candidates []xfs.Entry
+	for _, entry := range q.stateManager.ListEntries() {
+		if entry.Upperdir != "" && entry.LiftedUntil.IsZero() {
+			candidates = append(candidates, entry)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	if len(candidates) > q.cfg.Verify.SampleSize {
+		candidates = candidates[:q.cfg.Verify.SampleSize]
+	}
+
+	failures := 0
+	for _, entry := range candidates {
+		if err := q.verifyEntry(entry); err != nil {
+			failures++
+			metrics.VerifyFailures.Inc()
+			log.Error("CRITICAL: quota enforcement verification failed",
+				zap.String("container", entry.ContainerID),
+				zap.Uint32("projectID", entry.ProjectID),
+				zap.Error(err))
+			// 内核状态可能已偏离缓存的限额，强制重新写入
+			if err := q.sched.Do(q.ctx, entry.ContainerID, sched.Reconcile, func(ctx context.Context) error {
+				return q.setProjectQuota(ctx, entry.ProjectID, entry.SoftLimit, entry.HardLimit, true)
+			}); err != nil {
+				log.Warn("Failed to re-apply limits after verification failure", zap.String("container", entry.ContainerID), zap.Error(err))
+			}
+		}
+	}
+	if failures > 0 {
+		q.health.Set(healthEnforcement, false, fmt.Sprintf("%d of %d sampled containers not enforced", failures, len(candidates)))
+	} else if len(candidates) > 0 {
+		q.health.Set(healthEnforcement, true, "")
+	}
+}
+
+// verifyEntry 在剩余额度不超过 max_write_mb 时执行写入探测，额度过大的容器跳过
+func (q *RFSQuota) verifyEntry(entry xfs.Entry) error {
+	usage, err := q.projectBackend(entry.ProjectID).GetUsage(q.ctx, entry.ProjectID)
+	if err != nil {
+		return err
+	}
+	if usage.HardLimitBytes == 0 {
+		return fmt.Errorf("no hard limit set on project %d", entry.ProjectID)
+	}
+
+	var remaining uint64
+	if usage.HardLimitBytes > usage.UsedBytes {
+		remaining = usage.HardLimitBytes - usage.UsedBytes
+	}
+	maxWrite := uint64(q.cfg.Verify.MaxWriteMB) << 20
+	limit := remaining + probeOverrun
+	if limit > maxWrite {
+		return nil
+	}
+
+	enforced, written, err := xfs.ProbeEnforcement(q.ctx, entry.Upperdir, limit)
+	if err != nil {
+		return err
+	}
+	if !enforced {
+		return fmt.Errorf("wrote %d bytes past the hard limit without EDQUOT", written)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Condition 表示某个子系统的健康状况
+type Condition struct {
+	Name    string    `json:"name"`
+	Healthy bool      `json:"healthy"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// Status 汇总各子系统的健康状况，并发安全
+type Status struct {
+	conditions map[string]Condition
+	mutex      sync.RWMutex
+}
+
+// NewStatus 创建健康状态
+func NewStatus() *Status {
+	return &Status{conditions: make(map[string]Condition)}
+}
+
+// Set 更新指定子系统的状况，状态未变化时保留原始时间
+func (s *Status) Set(name string, healthy bool, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cond, exists := s.conditions[name]
+	if !exists || cond.Healthy != healthy {
+		cond.Since = time.Now()
+	}
+	cond.Name = name
+	cond.Healthy = healthy
+	cond.Message = message
+	s.conditions[name] = cond
+}
+
+// Get 返回指定子系统的状况
+func (s *Status) Get(name string) (Condition, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	cond, exists := s.conditions[name]
+	return cond, exists
+}
+
+// Healthy 判断所有子系统是否健康
+func (s *Status) Healthy() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, cond := range s.conditions {
+		if !cond.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Conditions 返回按名称排序的状况列表
+func (s *Status) Conditions() []Condition {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	conds := make([]Condition, 0, len(s.conditions))
+	for _, cond := range s.conditions {
+		conds = append(conds, cond)
+	}
+	sort.Slice(conds, func(i, j int) bool { return conds[i].Name < conds[j].Name })
+	return conds
+}
+
+// probeResponse 为探针接口的响应
+type probeResponse struct {
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// Handler 返回探针接口：check 返回 nil 时响应 200，否则响应 503，响应体附带所有子系统的状况
+func (s *Status) Handler(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := probeResponse{Status: "ok", Conditions: s.Conditions()}
+		status := http.StatusOK
+		if err := check(); err != nil {
+			resp.Status = "fail"
+			resp.Error = err.Error()
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
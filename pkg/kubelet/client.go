@@ -0,0 +1,114 @@
+package kubelet
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheTTL 为 Pod 列表的缓存时间，避免事件风暴时频繁请求 kubelet
+const cacheTTL = 5 * time.Second
+
+// Pod 为 kubelet /pods 接口返回的 Pod 中本服务关心的字段
+type Pod struct {
+	Metadata struct {
+		UID       string `json:"uid"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Containers []Container `json:"containers"`
+	} `json:"spec"`
+}
+
+// Container 为 Pod 中的容器
+type Container struct {
+	Name      string `json:"name"`
+	Resources struct {
+		Limits map[string]string `json:"limits"`
+	} `json:"resources"`
+}
+
+type podList struct {
+	Items []Pod `json:"items"`
+}
+
+// Client 读取本节点 kubelet 上的 Pod 规格
+type Client struct {
+	url    string
+	token  string
+	http   *http.Client
+	mutex  sync.Mutex
+	cached map[string]Pod
+	at     time.Time
+}
+
+// NewClient 创建 kubelet 客户端，url 如 https://127.0.0.1:10250/pods，tokenFile 为空时不做认证
+func NewClient(url, tokenFile string, insecureSkipVerify bool) (*Client, error) {
+	c := &Client{
+		url: url,
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify},
+			},
+		},
+	}
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubelet token: %v", err)
+		}
+		c.token = strings.TrimSpace(string(data))
+	}
+	return c, nil
+}
+
+// Pods 返回以 UID 为键的 Pod 规格，cacheTTL 内复用上次结果
+func (c *Client) Pods(ctx context.Context) (map[string]Pod, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.cached != nil && time.Since(c.at) < cacheTTL {
+		return c.cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kubelet pods: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list kubelet pods: %s", resp.Status)
+	}
+
+	var list podList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode kubelet pods: %v", err)
+	}
+	pods := make(map[string]Pod, len(list.Items))
+	for _, pod := range list.Items {
+		pods[pod.Metadata.UID] = pod
+	}
+	c.cached, c.at = pods, time.Now()
+	return pods, nil
+}
+
+// Invalidate 丢弃缓存，下次调用 Pods 时重新请求
+func (c *Client) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cached = nil
+}
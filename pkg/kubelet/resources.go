@@ -0,0 +1,67 @@
+package kubelet
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ResourceEphemeralStorage 为临时存储资源名
+const ResourceEphemeralStorage = "ephemeral-storage"
+
+// quantitySuffixes 为 Kubernetes 资源数量的单位，二进制单位须先于十进制单位匹配
+var quantitySuffixes = []struct {
+	suffix string
+	mult   float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+	{"m", 1e-3},
+}
+
+// ParseQuantity 将 Kubernetes 资源数量（如 "2Gi"、"500M"、"1e9"）转换为字节数，向上取整
+func ParseQuantity(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	mult := 1.0
+	for _, u := range quantitySuffixes {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSuffix(s, u.suffix), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return uint64(math.Ceil(v * mult)), nil
+}
+
+// EphemeralLimit 返回容器的临时存储限制，未设置时返回 false
+func (p Pod) EphemeralLimit(container string) (uint64, bool) {
+	for _, c := range p.Spec.Containers {
+		if c.Name != container {
+			continue
+		}
+		q, ok := c.Resources.Limits[ResourceEphemeralStorage]
+		if !ok {
+			return 0, false
+		}
+		v, err := ParseQuantity(q)
+		return v, err == nil
+	}
+	return 0, false
+}
+
+// PodEphemeralLimit 返回 Pod 内所有容器临时存储限制之和，任一容器未设置时返回 false（此时 Pod 级无上限）
+func (p Pod) PodEphemeralLimit() (uint64, bool) {
+	var total uint64
+	for _, c := range p.Spec.Containers {
+		v, ok := p.EphemeralLimit(c.Name)
+		if !ok {
+			return 0, false
+		}
+		total += v
+	}
+	return total, len(p.Spec.Containers) > 0
+}
@@ -0,0 +1,211 @@
+// Package lifecycle 为外部系统（自研调度器、构建系统等）推送工作负载生命周期通知的 HTTP 接收端：
+// 工作负载启动前通知其可写目录以预先设置配额，结束后通知以提前释放，用于不由 containerd 管理的同节点工作负载
+package lifecycle
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/listener"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/xfs"
+)
+
+// ErrUnsupported 表示目录无法设置项目配额（文件系统不支持或不在允许列表内）
+var ErrUnsupported = errors.New("directory cannot get a project quota")
+
+// StartRequest 为工作负载即将启动的通知，未给出的限额使用 lifecycle_webhook.quota
+type StartRequest struct {
+	// ID 为工作负载在外部系统中的唯一标识
+	ID string `json:"id"`
+	// Dir 为工作负载的可写目录，必须已存在
+	Dir       string `json:"dir"`
+	SoftLimit string `json:"soft_limit,omitempty"`
+	HardLimit string `json:"hard_limit,omitempty"`
+}
+
+// FinishRequest 为工作负载已结束的通知
+type FinishRequest struct {
+	ID string `json:"id"`
+}
+
+// Workload 为已设置配额的外部工作负载
+type Workload struct {
+	ID        string `json:"id"`
+	Dir       string `json:"dir"`
+	ProjectID uint32 `json:"project_id"`
+	SoftLimit string `json:"soft_limit,omitempty"`
+	HardLimit string `json:"hard_limit,omitempty"`
+	// Existing 表示启动通知重复，配额此前已设置
+	Existing bool `json:"existing,omitempty"`
+}
+
+// Handler 处理生命周期通知，由 handler 实现
+type Handler interface {
+	// StartWorkload 为工作负载的目录设置配额，同一 ID 已按同一目录设置时返回已有配额；
+	// ID 已记录了其他目录时返回 api.ErrConflict
+	StartWorkload(ctx context.Context, req StartRequest) (Workload, error)
+	// FinishWorkload 移除工作负载的配额并归还项目 ID，未记录时返回 api.ErrNotFound
+	FinishWorkload(ctx context.Context, id string) (Workload, error)
+	// ListWorkloads 返回已设置配额的外部工作负载
+	ListWorkloads() []Workload
+}
+
+// Server 为生命周期通知接收端
+type Server struct {
+	addr    string
+	token   string
+	handler Handler
+	mux     *http.ServeMux
+}
+
+// NewServer 创建接收端，addr 为 listener.Listen 的地址格式，token 为空时不认证
+func NewServer(addr, token string, handler Handler) *Server {
+	s := &Server{addr: addr, token: token, handler: handler, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /v1/lifecycle/start", s.handleStart)
+	s.mux.HandleFunc("POST /v1/lifecycle/finish", s.handleFinish)
+	s.mux.HandleFunc("GET /v1/lifecycle/workloads", s.handleList)
+	return s
+}
+
+// Serve 监听并处理通知，直到 ctx 结束
+func (s *Server) Serve(ctx context.Context) error {
+	l, err := listener.Listen(s.addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Info("Serving lifecycle webhook", zap.String("addr", s.addr), zap.Bool("auth", s.token != ""))
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP 实现 http.Handler，设置了 token 时要求 Bearer 认证
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, api.ErrorResponse{Error: "missing or invalid bearer token"})
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if err := validateStart(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+	workload, err := s.handler.StartWorkload(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusCreated
+	if workload.Existing {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, workload)
+}
+
+func (s *Server) handleFinish(w http.ResponseWriter, r *http.Request) {
+	var req FinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.ID == "" {
+		writeJSON(w, http.StatusBadRequest, api.ErrorResponse{Error: "id is required"})
+		return
+	}
+	workload, err := s.handler.FinishWorkload(r.Context(), req.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, workload)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.handler.ListWorkloads())
+}
+
+// validateStart 校验启动通知并规范化目录；只给出硬限制时软限制与之相同，限额不能为 0
+func validateStart(req *StartRequest) error {
+	if req.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if !filepath.IsAbs(req.Dir) || filepath.Clean(req.Dir) == "/" {
+		return fmt.Errorf("dir %q must be an absolute path other than /", req.Dir)
+	}
+	req.Dir = filepath.Clean(req.Dir)
+	if req.SoftLimit == "" && req.HardLimit == "" {
+		return nil
+	}
+	if req.HardLimit == "" {
+		return fmt.Errorf("hard_limit is required when soft_limit is set")
+	}
+	if req.SoftLimit == "" {
+		req.SoftLimit = req.HardLimit
+	}
+	soft, err := xfs.ParseSize(req.SoftLimit)
+	if err != nil {
+		return fmt.Errorf("invalid soft_limit: %v", err)
+	}
+	hard, err := xfs.ParseSize(req.HardLimit)
+	if err != nil {
+		return fmt.Errorf("invalid hard_limit: %v", err)
+	}
+	if soft == 0 || hard == 0 {
+		// 0 在 XFS 中表示不限制
+		return fmt.Errorf("soft_limit and hard_limit must be above 0")
+	}
+	if soft > hard {
+		return fmt.Errorf("soft_limit %s exceeds hard_limit %s", req.SoftLimit, req.HardLimit)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error("Failed to write response", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, api.ErrStandby):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, api.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, ErrUnsupported):
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, api.ErrorResponse{Error: err.Error()})
+}
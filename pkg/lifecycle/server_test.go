@@ -0,0 +1,43 @@
+package lifecycle
+
+import "testing"
+
+func TestValidateStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      StartRequest
+		wantDir  string
+		wantSoft string
+		wantErr  bool
+	}{
+		{name: "defaults", req: StartRequest{ID: "w1", Dir: "/data/w1/"}, wantDir: "/data/w1"},
+		{name: "limits", req: StartRequest{ID: "w1", Dir: "/data/w1", SoftLimit: "8g", HardLimit: "10g"}, wantDir: "/data/w1", wantSoft: "8g"},
+		{name: "hard only", req: StartRequest{ID: "w1", Dir: "/data/w1", HardLimit: "10g"}, wantDir: "/data/w1", wantSoft: "10g"},
+		{name: "missing id", req: StartRequest{Dir: "/data/w1"}, wantErr: true},
+		{name: "relative dir", req: StartRequest{ID: "w1", Dir: "data/w1"}, wantErr: true},
+		{name: "root dir", req: StartRequest{ID: "w1", Dir: "/./"}, wantErr: true},
+		{name: "soft only", req: StartRequest{ID: "w1", Dir: "/data/w1", SoftLimit: "8g"}, wantErr: true},
+		{name: "soft above hard", req: StartRequest{ID: "w1", Dir: "/data/w1", SoftLimit: "12g", HardLimit: "10g"}, wantErr: true},
+		{name: "zero hard", req: StartRequest{ID: "w1", Dir: "/data/w1", HardLimit: "0"}, wantErr: true},
+		{name: "zero soft", req: StartRequest{ID: "w1", Dir: "/data/w1", SoftLimit: "0k", HardLimit: "10g"}, wantErr: true},
+		{name: "invalid hard", req: StartRequest{ID: "w1", Dir: "/data/w1", HardLimit: "big"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := validateStart(&req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("validateStart(%+v) succeeded, want an error", tt.req)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateStart(%+v): %v", tt.req, err)
+			}
+			if req.Dir != tt.wantDir || req.SoftLimit != tt.wantSoft {
+				t.Errorf("validateStart(%+v) gave dir %q, soft %q, want %q, %q", tt.req, req.Dir, req.SoftLimit, tt.wantDir, tt.wantSoft)
+			}
+		})
+	}
+}
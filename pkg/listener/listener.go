@@ -0,0 +1,132 @@
+// Package listener 按地址创建管理接口、指标与统计服务的监听器，支持 TCP（IPv4、IPv6）、
+// Unix 套接字、Linux 抽象套接字与 systemd 套接字激活，并提供对应的客户端拨号
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// systemd 套接字激活传入的第一个文件描述符
+const listenFdsStart = 3
+
+// Listen 按地址监听：
+//
+//	host:port、[::1]:port、:port  TCP，主机为空时同时监听 IPv4 与 IPv6
+//	tcp4://host:port、tcp6://[addr]:port  只监听 IPv4 或 IPv6
+//	unix:///path  Unix 套接字，监听前移除残留的套接字文件
+//	unix://@name  Linux 抽象套接字，不在文件系统中创建文件
+//	systemd://name  systemd 套接字激活中 FileDescriptorName 为 name 的套接字，systemd:// 表示第一个
+func Listen(addr string) (net.Listener, error) {
+	scheme, rest, ok := strings.Cut(addr, "://")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	switch scheme {
+	case "tcp", "tcp4", "tcp6":
+		return net.Listen(scheme, rest)
+	case "unix":
+		if strings.HasPrefix(rest, "@") {
+			return net.Listen("unix", rest)
+		}
+		if err := os.MkdirAll(filepath.Dir(rest), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create socket directory: %v", err)
+		}
+		if err := os.Remove(rest); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+		return net.Listen("unix", rest)
+	case "systemd":
+		return activated(rest)
+	}
+	return nil, fmt.Errorf("unsupported listen address scheme %q", scheme)
+}
+
+// Dial 按 Listen 的地址格式连接，systemd:// 地址无法从客户端推断
+func Dial(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	scheme, rest, ok := strings.Cut(addr, "://")
+	if !ok {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	switch scheme {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return d.DialContext(ctx, scheme, rest)
+	}
+	return nil, fmt.Errorf("cannot dial address scheme %q", scheme)
+}
+
+var (
+	activationOnce  sync.Once
+	activationMutex sync.Mutex
+	activationErr   error
+	// activationFds 为尚未使用的激活套接字，按传入顺序排列；未命名的套接字名称为 systemd 的默认值 unknown
+	activationFds []activationFd
+)
+
+type activationFd struct {
+	name string
+	file *os.File
+}
+
+// activated 取出 systemd 传入的套接字，每个套接字只能使用一次
+func activated(name string) (net.Listener, error) {
+	activationOnce.Do(loadActivation)
+	if activationErr != nil {
+		return nil, activationErr
+	}
+	activationMutex.Lock()
+	defer activationMutex.Unlock()
+	for i, fd := range activationFds {
+		if name != "" && fd.name != name {
+			continue
+		}
+		activationFds = append(activationFds[:i], activationFds[i+1:]...)
+		l, err := net.FileListener(fd.file)
+		fd.file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("systemd socket %q is not a listening socket: %v", fd.name, err)
+		}
+		return l, nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no systemd socket left")
+	}
+	return nil, fmt.Errorf("no systemd socket named %q", name)
+}
+
+// loadActivation 读取 LISTEN_PID、LISTEN_FDS 与 LISTEN_FDNAMES，并清除这些环境变量，避免子进程误用
+func loadActivation() {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		activationErr = fmt.Errorf("not started by systemd socket activation (LISTEN_PID not set to this process)")
+		return
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		activationErr = fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+		return
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < n; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		activationFds = append(activationFds, activationFd{name: name, file: os.NewFile(uintptr(fd), name)})
+	}
+}
@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type fieldsKey struct{}
+
+// WithFields 返回附带日志字段的上下文，上下文中已有的字段会保留
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Ctx 返回自动附带上下文字段（容器 ID、Pod、命名空间、项目 ID 等）的 Logger
+func Ctx(ctx context.Context) *zap.Logger {
+	if ctx == nil {
+		return logger
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
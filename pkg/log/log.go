@@ -32,6 +32,11 @@ func Warn(msg string, fields ...zap.Field) {
 	logger.Warn(msg, fields...)
 }
 
+// Debug 记录调试日志，默认级别下不输出
+func Debug(msg string, fields ...zap.Field) {
+	logger.Debug(msg, fields...)
+}
+
 // Sync 同步日志
 func Sync() {
 	logger.Sync()
@@ -0,0 +1,186 @@
+// Package maintenance 解析维护窗口：以 cron 表达式给出开始时间、持续一段时间的时段，
+// 窗口内暂停或放宽破坏性的处理动作（如告急时停止容器），观测与上报照常进行
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 窗口内的处理方式
+const (
+	// ActionSuppress 表示窗口内完全不执行破坏性动作
+	ActionSuppress = "suppress"
+	// ActionRelax 表示窗口内只在情况比放宽后的阈值更严重时执行
+	ActionRelax = "relax"
+)
+
+// MaxDuration 为单个窗口的最长持续时间
+const MaxDuration = 7 * 24 * time.Hour
+
+// Schedule 为五段式 cron 表达式（分 时 日 月 周），支持 *、数字、a-b 范围、/n 步长与逗号列表；
+// 日与周同时限定时任一满足即匹配，周日为 0 或 7
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// field 为 cron 一段的取值范围
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseSchedule 解析 cron 表达式
+func ParseSchedule(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %v", expr, err)
+		}
+		bits[i] = b
+	}
+	// 周日 7 与 0 等价
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepStr, f.name)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", f.name, item)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", f.name, item)
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", f.name, item, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches 判断 t 所在的分钟是否为触发时间
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Window 为一个维护窗口：每次 Schedule 触发后持续 Duration
+type Window struct {
+	Name     string
+	Schedule *Schedule
+	Duration time.Duration
+	Action   string
+	// RelaxedPercent 为放宽时使用的阈值，含义由使用方决定（如告急的可用空间百分比）
+	RelaxedPercent float64
+}
+
+// Active 判断 t 是否处于窗口内，即 (t-Duration, t] 内存在触发时间
+func (w *Window) Active(t time.Time) bool {
+	start := t.Truncate(time.Minute)
+	for at := start; t.Sub(at) < w.Duration; at = at.Add(-time.Minute) {
+		if w.Schedule.Matches(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// Calendar 为所有维护窗口，按配置的时区判断
+type Calendar struct {
+	windows  []Window
+	location *time.Location
+}
+
+// NewCalendar 创建窗口集合，location 为 nil 时使用本地时区
+func NewCalendar(windows []Window, location *time.Location) *Calendar {
+	if location == nil {
+		location = time.Local
+	}
+	return &Calendar{windows: windows, location: location}
+}
+
+// Active 返回 t 时处于的窗口，有多个时 suppress 优先，其次为放宽阈值最低的 relax 窗口
+func (c *Calendar) Active(t time.Time) (Window, bool) {
+	if c == nil {
+		return Window{}, false
+	}
+	t = t.In(c.location)
+	var best Window
+	found := false
+	for _, w := range c.windows {
+		if !w.Active(t) {
+			continue
+		}
+		switch {
+		case !found:
+			best, found = w, true
+		case w.Action == ActionSuppress && best.Action != ActionSuppress:
+			best = w
+		case w.Action == ActionRelax && best.Action == ActionRelax && w.RelaxedPercent < best.RelaxedPercent:
+			best = w
+		}
+	}
+	return best, found
+}
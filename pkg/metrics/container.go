@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ContainerUsage 为用量快照中一个容器的样本，LabelValues 与注册时的标签名一一对应
+type ContainerUsage struct {
+	ContainerID string
+	Namespace   string
+	Image       string
+	// Group 为共享项目的 Pod 或命名空间组，此时用量与限额为整个组的
+	Group string
+	// PodNamespace 与 PodName 来自 Kubernetes 的容器标签，非 Pod 容器为空
+	PodNamespace string
+	PodName      string
+	LabelValues  []string
+	UsedBytes    uint64
+	HardBytes    uint64
+	UsedInodes   uint64
+}
+
+// ContainerLabelSet 选择按容器指标的标签。container_id 总是导出，且不会与按 Kubernetes 服务发现
+// 重写的目标标签（container、pod、namespace）冲突，适合作为记录规则与关联查询的稳定标识
+type ContainerLabelSet struct {
+	// Pod 为真时导出 container、namespace、group、pod_namespace 与 pod_name
+	Pod bool
+	// Image 为真时导出 image
+	Image bool
+	// Labels 为附加的容器标签名，导出为 label_<名称>（非法字符替换为 _）
+	Labels []string
+}
+
+// names 返回标签集合对应的标签名，顺序与 values 一致
+func (s ContainerLabelSet) names() []string {
+	names := []string{"container_id"}
+	if s.Pod {
+		names = append(names, "container", "namespace", "group", "pod_namespace", "pod_name")
+	}
+	if s.Image {
+		names = append(names, "image")
+	}
+	for _, label := range s.Labels {
+		names = append(names, "label_"+sanitizeLabel(label))
+	}
+	return names
+}
+
+func (s ContainerLabelSet) values(u ContainerUsage) []string {
+	values := []string{u.ContainerID}
+	if s.Pod {
+		values = append(values, u.ContainerID, u.Namespace, u.Group, u.PodNamespace, u.PodName)
+	}
+	if s.Image {
+		values = append(values, u.Image)
+	}
+	return append(values, u.LabelValues...)
+}
+
+// containerUsageCollector 在抓取时从最近一次用量快照生成按容器的指标，不自行查询用量
+type containerUsageCollector struct {
+	source     func() []ContainerUsage
+	labels     ContainerLabelSet
+	usedBytes  *prometheus.Desc
+	hardBytes  *prometheus.Desc
+	usedInodes *prometheus.Desc
+}
+
+// containerUsage 为当前注册的采集器，配置重新加载后重新注册时替换
+var containerUsage *containerUsageCollector
+
+// RegisterContainerUsage 注册按容器的用量指标，labels 选择导出的标签；已注册的采集器被替换
+func RegisterContainerUsage(labels ContainerLabelSet, source func() []ContainerUsage) error {
+	names := labels.names()
+	c := &containerUsageCollector{
+		source: source,
+		labels: labels,
+		usedBytes: prometheus.NewDesc(namespace+"_container_used_bytes",
+			"Bytes used by the writable layer of a managed container, from the latest usage poll.", names, nil),
+		hardBytes: prometheus.NewDesc(namespace+"_container_hard_limit_bytes",
+			"Hard limit of a managed container's project, from the latest usage poll.", names, nil),
+		usedInodes: prometheus.NewDesc(namespace+"_container_used_inodes",
+			"Inodes used by the writable layer of a managed container, from the latest usage poll.", names, nil),
+	}
+	if containerUsage != nil {
+		prometheus.Unregister(containerUsage)
+	}
+	if err := prometheus.Register(c); err != nil {
+		return err
+	}
+	containerUsage = c
+	return nil
+}
+
+func (c *containerUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.usedBytes
+	ch <- c.hardBytes
+	ch <- c.usedInodes
+}
+
+func (c *containerUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, u := range c.source() {
+		values := c.labels.values(u)
+		ch <- prometheus.MustNewConstMetric(c.usedBytes, prometheus.GaugeValue, float64(u.UsedBytes), values...)
+		ch <- prometheus.MustNewConstMetric(c.hardBytes, prometheus.GaugeValue, float64(u.HardBytes), values...)
+		ch <- prometheus.MustNewConstMetric(c.usedInodes, prometheus.GaugeValue, float64(u.UsedInodes), values...)
+	}
+}
+
+// sanitizeLabel 将容器标签名转换为合法的 Prometheus 标签名
+func sanitizeLabel(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,435 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/listener"
+	"RootfsQuota/pkg/log"
+)
+
+const namespace = "conquotas"
+
+var (
+	// EventTimeouts 统计处理超时的事件数
+	EventTimeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_timeouts_total",
+		Help:      "Number of containerd events whose handling exceeded the per-event timeout.",
+	})
+
+	// EventRequeues 统计超时后重新入队的事件数
+	EventRequeues = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_requeues_total",
+		Help:      "Number of containerd events requeued after a timeout.",
+	})
+
+	// EventsDropped 统计超过最大重试次数后放弃的事件数
+	EventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_dropped_total",
+		Help:      "Number of containerd events dropped after exhausting retries.",
+	})
+
+	// EventsProcessed 统计已处理的事件数
+	EventsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_processed_total",
+		Help:      "Number of containerd events processed.",
+	})
+
+	// EventErrors 统计处理失败的事件数
+	EventErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_errors_total",
+		Help:      "Number of containerd events whose handling returned an error.",
+	})
+
+	// EventLatency 为事件从收到到处理完成的耗时，包含调度排队时间，按事件类型区分
+	EventLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_processing_seconds",
+		Help:      "Time from receiving a containerd event to finishing its handling, including scheduler wait, by topic.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"topic"})
+
+	// ContainerdReconnects 统计首次连接之后重新连接 containerd 的次数
+	ContainerdReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "containerd_reconnects_total",
+		Help:      "Number of times the daemon reconnected to containerd after the first connection.",
+	})
+
+	// LastEventTimestamp 为最近处理事件的发生时间
+	LastEventTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_event_timestamp_seconds",
+		Help:      "Unix timestamp of the last processed containerd event.",
+	})
+
+	// LastEventSequence 为最近处理事件的本地序号
+	LastEventSequence = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_event_sequence",
+		Help:      "Local sequence number of the last processed containerd event.",
+	})
+)
+
+// 配额设置与移除
+var (
+	// QuotasSet 统计成功设置的配额数
+	QuotasSet = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quotas_set_total",
+		Help:      "Number of quotas set on container rootfs and BuildKit snapshots.",
+	})
+
+	// QuotasRemoved 统计成功移除的配额数
+	QuotasRemoved = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quotas_removed_total",
+		Help:      "Number of quotas removed.",
+	})
+
+	// QuotaErrors 统计设置或移除配额失败的次数，按操作（set、remove）区分
+	QuotaErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_errors_total",
+		Help:      "Number of failed quota operations, by operation.",
+	}, []string{"operation"})
+
+	// BackendErrors 统计失败的 xfs_quota、xfs_io 调用与项目 ID ioctl，按工具与错误类别区分
+	BackendErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_errors_total",
+		Help:      "Number of failed quota tool invocations and syscalls, by tool and error class.",
+	}, []string{"tool", "class"})
+
+	// BackendWarnings 统计退出码为 0 但 stderr 中有可识别错误的调用，如多个文件系统中某一个失败
+	BackendWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_warnings_total",
+		Help:      "Number of quota tool invocations that succeeded but reported a classified error on stderr, by tool and error class.",
+	}, []string{"tool", "class"})
+)
+
+// 节点配额预算
+var (
+	// NodeBudgetBytes 为扣除预留空间并按超分比例放大后的节点配额预算
+	NodeBudgetBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_budget_bytes",
+		Help:      "Bytes available to container quotas after the reserve and overcommit ratio.",
+	})
+
+	// NodeCommittedBytes 为已分配的硬限制总和
+	NodeCommittedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_committed_bytes",
+		Help:      "Sum of hard limits handed out to container quotas.",
+	})
+)
+
+// VerifyFailures 统计深度校验发现配额未生效的次数
+var VerifyFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "verify_failures_total",
+	Help:      "Number of sampled containers where a write past the hard limit was not refused.",
+})
+
+// QuotaDisabledFilesystems 为持有已管理容器、但项目配额统计或限制未开启的文件系统数量
+var QuotaDisabledFilesystems = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "quota_disabled_filesystems",
+	Help:      "Filesystems holding managed upperdirs whose project quota accounting or enforcement is off.",
+})
+
+// NestedRetagged 统计嵌套快照目录项目 ID 不一致而重新打标的次数
+var NestedRetagged = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "nested_retagged_total",
+	Help:      "Number of nested (Docker-in-Docker) snapshot trees re-tagged because files did not carry the container's project ID.",
+})
+
+// PolicyRuleMatches 为策略文件中每条规则当前命中的已管理容器数，用于发现未生效或过宽的规则
+var PolicyRuleMatches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "policy_rule_matched_containers",
+	Help:      "Managed containers currently matched by each rule of the policy file.",
+}, []string{"rule"})
+
+// PolicyRuleChanges 统计按规则应用的限额修改次数
+var PolicyRuleChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "policy_rule_changes_total",
+	Help:      "Limit changes applied by each policy rule.",
+}, []string{"rule"})
+
+var (
+	// FilesystemFreeBytes 为告急检查所监视文件系统的可用字节数
+	FilesystemFreeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "filesystem_free_bytes",
+		Help:      "Free bytes on the filesystem watched for low-disk emergencies.",
+	})
+	// EmergencyActive 为 1 表示可用空间低于告急阈值
+	EmergencyActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "emergency_active",
+		Help:      "1 while free space is below the emergency threshold.",
+	})
+	// EmergencyStops 统计告急期间停止的可牺牲容器数
+	EmergencyStops = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emergency_stopped_containers_total",
+		Help:      "Sacrificial containers stopped during low-disk emergencies.",
+	})
+)
+
+// LimitNotifications 统计限额变更后对容器内工作负载的通知次数，按结果（ok、error）区分
+var LimitNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "limit_notifications_total",
+	Help:      "Notifications of limit changes sent to workloads, by result.",
+}, []string{"result"})
+
+// UsageListDuration 为读取所有项目用量的批量查询耗时
+var UsageListDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "usage_list_duration_seconds",
+	Help:      "Duration of listing the usage of all projects in one batch.",
+	Buckets:   prometheus.DefBuckets,
+})
+
+// UsageQueriesShared 统计等待进行中的批量用量查询、共享其结果的调用次数
+var UsageQueriesShared = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "usage_queries_shared_total",
+	Help:      "Number of usage listings served by joining a batch query already in flight.",
+})
+
+// PipelineBatchSize 为实验性异步后端每批合并写入的限额请求数
+var PipelineBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "backend_pipeline_batch_size",
+	Help:      "Number of limit requests applied together by the async backend pipeline.",
+	Buckets:   []float64{1, 2, 4, 8, 16, 32, 64, 128},
+})
+
+// PipelineFallbacks 统计异步后端无法使用 quotactl、改用 xfs_quota 的请求数
+var PipelineFallbacks = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "backend_pipeline_fallbacks_total",
+	Help:      "Number of async backend limit requests that fell back to xfs_quota.",
+})
+
+// LimitWritesSkipped 统计因限额未变化而跳过的 xfs_quota 调用次数
+var LimitWritesSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "limit_writes_skipped_total",
+	Help:      "Number of limit writes skipped because the project already had the same limits applied.",
+})
+
+// MetadataCacheLookups 统计容器元数据缓存的查询次数，result 为 hit 或 miss
+var MetadataCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "metadata_cache_lookups_total",
+	Help:      "Number of container metadata lookups served from the cache (hit) or from containerd (miss).",
+}, []string{"result"})
+
+// MetadataCacheEntries 为容器元数据缓存中的容器数
+var MetadataCacheEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "metadata_cache_entries",
+	Help:      "Number of containers in the container metadata cache.",
+})
+
+// CRIGateResults 统计 CRI 门控的结果：applied、skipped（无需配额）、rejected（失败并拒绝）或 allowed（失败但放行）
+var CRIGateResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cri_gate_results_total",
+	Help:      "Number of CRI CreateContainer calls held by the quota gate, by result.",
+}, []string{"result"})
+
+// CRIGateLatency 为 CRI 门控等待配额设置的耗时
+var CRIGateLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "cri_gate_seconds",
+	Help:      "Time a CRI CreateContainer response was held until the container's quota was applied.",
+	Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+})
+
+// LifecycleNotifications 统计生命周期通知的处理结果，event 为 start 或 finish，result 为 applied、existing（重复的启动通知）、
+// removed、rejected（冲突、未记录或目录不支持）或 failed
+var LifecycleNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "lifecycle_notifications_total",
+	Help:      "Number of workload lifecycle notifications received by the webhook, by event and result.",
+}, []string{"event", "result"})
+
+// 项目 ID 池统计
+var (
+	// ProjectIDsUsed 为配置范围内已使用的项目 ID 数
+	ProjectIDsUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_ids_used",
+		Help:      "Project IDs in use within the configured range.",
+	})
+
+	// ProjectIDsFree 为配置范围内空闲的项目 ID 数
+	ProjectIDsFree = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_ids_free",
+		Help:      "Free project IDs within the configured range.",
+	})
+
+	// ProjectIDPoolUtilization 为配置范围内已使用的项目 ID 比例
+	ProjectIDPoolUtilization = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_id_pool_utilization",
+		Help:      "Fraction of the configured project ID range in use.",
+	})
+
+	// ProjectIDsLargestFreeRun 为最长连续空闲 ID 段的长度
+	ProjectIDsLargestFreeRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_ids_largest_free_run",
+		Help:      "Length of the largest contiguous run of free project IDs.",
+	})
+
+	// ProjectIDRecommendedSize 为按峰值使用量与分配频率估算的建议范围大小
+	ProjectIDRecommendedSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_id_recommended_range_size",
+		Help:      "Recommended size of the project ID range, from peak usage and allocation churn.",
+	})
+
+	// ProjectIDAllocationsPerHour 为最近一小时的项目 ID 分配次数
+	ProjectIDAllocationsPerHour = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "project_id_allocations_per_hour",
+		Help:      "Project IDs allocated during the last hour.",
+	})
+
+	// QuotaOptOuts 统计容器通过标签请求退出配额管理的次数，按结果（honored、denied）区分
+	QuotaOptOuts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_opt_outs_total",
+		Help:      "Containers that asked to opt out of quota enforcement by label, by result.",
+	}, []string{"result"})
+
+	// QuotaExclusions 统计命中排除规则而未设置配额的次数，按规则名区分
+	QuotaExclusions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_exclusions_total",
+		Help:      "Containers that matched an exclude rule and got no quota, by rule.",
+	}, []string{"rule"})
+
+	// UsageAlerts 统计用量超过硬限制百分比阈值的告警次数，按配额类别与阈值区分
+	UsageAlerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_alerts_total",
+		Help:      "Usage alerts fired when a project passed a percentage of its hard limit, by quota class and threshold.",
+	}, []string{"class", "threshold"})
+
+	// UsageOverThreshold 为当前超过各阈值（取超过的最高阈值）的项目数
+	UsageOverThreshold = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "usage_over_threshold_projects",
+		Help:      "Projects whose usage is currently above an alert threshold, by the highest threshold passed.",
+	}, []string{"threshold"})
+
+	// MaintenanceWindowActive 在维护窗口生效期间为 1，按窗口名称区分
+	MaintenanceWindowActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "maintenance_window_active",
+		Help:      "1 while a maintenance window holds destructive actions, by window name.",
+	}, []string{"window"})
+
+	// EventSinkPublished 统计发布到事件接收端（Kafka、NATS）的配额事件数，按事件类型区分
+	EventSinkPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_sink_published_total",
+		Help:      "Quota lifecycle events published to the event sink, by type.",
+	}, []string{"type"})
+
+	// EventSinkErrors 统计发布失败的配额事件数
+	EventSinkErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_sink_errors_total",
+		Help:      "Quota lifecycle events that failed to publish.",
+	})
+
+	// EventSinkDropped 统计因发布队列已满而丢弃的配额事件数
+	EventSinkDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_sink_dropped_total",
+		Help:      "Quota lifecycle events dropped because the publish queue was full.",
+	})
+)
+
+// Ready 在连接 containerd 并完成状态同步后为 1
+var Ready = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "ready",
+	Help:      "1 once the daemon is connected to containerd and has synced state, 0 otherwise.",
+})
+
+// SchedulerWaiting 为按优先级统计的排队中操作数
+var SchedulerWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "scheduler_waiting",
+	Help:      "Quota operations waiting for the scheduler, by priority class.",
+}, []string{"priority"})
+
+func init() {
+	prometheus.MustRegister(Ready, SchedulerWaiting, EventTimeouts, EventRequeues, EventsDropped,
+		EventsProcessed, EventErrors, EventLatency, ContainerdReconnects, QuotasSet, QuotasRemoved, QuotaErrors,
+		BackendErrors, BackendWarnings,
+		LastEventTimestamp, LastEventSequence, VerifyFailures, QuotaDisabledFilesystems, NestedRetagged,
+		PolicyRuleMatches, PolicyRuleChanges, FilesystemFreeBytes, EmergencyActive, EmergencyStops,
+		NodeBudgetBytes, NodeCommittedBytes, LimitWritesSkipped, MetadataCacheLookups, MetadataCacheEntries, CRIGateResults, CRIGateLatency, LimitNotifications,
+		ProjectIDsUsed, ProjectIDsFree, ProjectIDPoolUtilization, ProjectIDsLargestFreeRun, ProjectIDAllocationsPerHour, ProjectIDRecommendedSize,
+		EventSinkPublished, EventSinkErrors, EventSinkDropped, QuotaOptOuts, QuotaExclusions,
+		UsageAlerts, UsageOverThreshold, MaintenanceWindowActive, UsageListDuration, UsageQueriesShared,
+		PipelineBatchSize, PipelineFallbacks, LifecycleNotifications)
+}
+
+// PprofHandlers 返回 net/http/pprof 的处理函数，挂在 /debug/pprof/ 下
+func PprofHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/debug/pprof/":        http.HandlerFunc(pprof.Index),
+		"/debug/pprof/cmdline": http.HandlerFunc(pprof.Cmdline),
+		"/debug/pprof/profile": http.HandlerFunc(pprof.Profile),
+		"/debug/pprof/symbol":  http.HandlerFunc(pprof.Symbol),
+		"/debug/pprof/trace":   http.HandlerFunc(pprof.Trace),
+	}
+}
+
+// Serve 在指定端口或 listener.Listen 格式的地址上暴露 /metrics 与 handlers 中的其他路径（如健康探针），
+// 阻塞直到监听失败
+func Serve(port string, handlers map[string]http.Handler) error {
+	addr := port
+	if !strings.Contains(addr, ":") {
+		addr = ":" + addr
+	}
+	l, err := listener.Listen(addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	for path, h := range handlers {
+		mux.Handle(path, h)
+	}
+
+	log.Info("Serving metrics", zap.String("addr", addr))
+	return http.Serve(l, mux)
+}
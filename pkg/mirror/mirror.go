@@ -0,0 +1,94 @@
+// Package mirror 维护状态的只读镜像文件，供外部代理读取而不与守护进程写状态文件产生竞争。
+// 镜像路径是指向完整文件的符号链接，新版本写完后替换链接，读者打开路径时总能得到完整的一份
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Document 为镜像文件的内容
+type Document struct {
+	// Generation 为状态条目的变更计数，只增不减（守护进程重启后从 0 重新计数）
+	Generation  uint64    `json:"generation"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// UsageAt 为用量的采集时间，尚未采集时为零值
+	UsageAt time.Time `json:"usage_at,omitempty"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Entry 为镜像中的单个条目，在状态条目基础上去掉限额变更记录，补充 Pod 信息与最近一次用量
+type Entry struct {
+	ContainerID string    `json:"container_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	ProjectID   uint32    `json:"project_id"`
+	Upperdir    string    `json:"upperdir,omitempty"`
+	SoftLimit   string    `json:"soft_limit,omitempty"`
+	HardLimit   string    `json:"hard_limit,omitempty"`
+	Group       string    `json:"group,omitempty"`
+	Paths       []string  `json:"paths,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	LiftedUntil time.Time `json:"lifted_until,omitempty"`
+	Pod         *Pod      `json:"pod,omitempty"`
+	// Usage 为最近一次采集的项目用量，分组成员为整个分组的用量
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Pod 为容器所属 Pod 的信息，取自 CRI 标签
+type Pod struct {
+	UID       string `json:"uid"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Usage 为项目的用量
+type Usage struct {
+	UsedBytes      uint64 `json:"used_bytes"`
+	UsedInodes     uint64 `json:"used_inodes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes,omitempty"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes,omitempty"`
+}
+
+// Write 将镜像写入 path 所在目录中的新版本文件（只读权限），再以 rename 原子替换 path 处的符号链接，
+// 最后删除旧版本文件；已经打开旧版本的读者不受影响。同一路径只能由一个进程写入
+func Write(path string, doc Document) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %v", err)
+	}
+
+	name := "." + base + "." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	target := filepath.Join(dir, name)
+	if err := os.WriteFile(target, append(data, '\n'), 0444); err != nil {
+		return fmt.Errorf("failed to write mirror: %v", err)
+	}
+
+	link := filepath.Join(dir, "."+base+".link")
+	os.Remove(link)
+	if err := os.Symlink(name, link); err != nil {
+		os.Remove(target)
+		return fmt.Errorf("failed to create mirror link: %v", err)
+	}
+	if err := os.Rename(link, path); err != nil {
+		os.Remove(link)
+		os.Remove(target)
+		return fmt.Errorf("failed to swap mirror link: %v", err)
+	}
+
+	// 删除旧版本，包括此前中断的写入遗留的文件
+	old, _ := filepath.Glob(filepath.Join(dir, "."+base+".[0-9]*"))
+	for _, file := range old {
+		if filepath.Base(file) != name {
+			os.Remove(file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Package mounts parses the mount table in /proc/self/mountinfo to find the
+// mount that holds a path, its filesystem type and its options, so callers do
+// not have to assume that everything lives on one filesystem.
+package mounts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MountInfoPath is the mount table of the calling process.
+const MountInfoPath = "/proc/self/mountinfo"
+
+// Mount is one line of mountinfo, see proc(5).
+type Mount struct {
+	ID       int `json:"id"`
+	ParentID int `json:"parent_id"`
+	Major    int `json:"major"`
+	Minor    int `json:"minor"`
+	// Root is the directory of the filesystem mounted at Mountpoint, "/"
+	// unless this is a bind mount of a subdirectory.
+	Root       string   `json:"root"`
+	Mountpoint string   `json:"mountpoint"`
+	Options    []string `json:"options"`
+	FSType     string   `json:"fs_type"`
+	Source     string   `json:"source"`
+	// SuperOptions are the per-superblock options, where quota options
+	// such as prjquota show up.
+	SuperOptions []string `json:"super_options"`
+}
+
+// HasOption reports whether opt is among the mount or superblock options.
+func (m Mount) HasOption(opt string) bool {
+	for _, o := range m.Options {
+		if o == opt {
+			return true
+		}
+	}
+	for _, o := range m.SuperOptions {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectQuota reports whether the mount has project quota accounting
+// enabled. XFS shows prjquota when project quotas are enforced and
+// pqnoenforce when they are only accounted, whichever alias was used to mount.
+func (m Mount) ProjectQuota() bool {
+	return m.HasOption("prjquota") || m.HasOption("pquota") || m.HasOption("pqnoenforce")
+}
+
+// Parse reads mountinfo formatted lines.
+func Parse(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, scanner.Err()
+}
+
+// parseLine parses
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// where the optional fields before "-" may be absent or repeated.
+func parseLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 || len(fields) < sep+3 {
+		return Mount{}, fmt.Errorf("malformed mountinfo line: %q", line)
+	}
+
+	var m Mount
+	var err error
+	if m.ID, err = strconv.Atoi(fields[0]); err != nil {
+		return Mount{}, fmt.Errorf("malformed mount id in %q", line)
+	}
+	if m.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+		return Mount{}, fmt.Errorf("malformed parent id in %q", line)
+	}
+	major, minor, ok := strings.Cut(fields[2], ":")
+	if !ok {
+		return Mount{}, fmt.Errorf("malformed device in %q", line)
+	}
+	if m.Major, err = strconv.Atoi(major); err != nil {
+		return Mount{}, fmt.Errorf("malformed device in %q", line)
+	}
+	if m.Minor, err = strconv.Atoi(minor); err != nil {
+		return Mount{}, fmt.Errorf("malformed device in %q", line)
+	}
+	m.Root = unescape(fields[3])
+	m.Mountpoint = unescape(fields[4])
+	m.Options = strings.Split(fields[5], ",")
+	m.FSType = fields[sep+1]
+	m.Source = unescape(fields[sep+2])
+	if len(fields) > sep+3 {
+		m.SuperOptions = strings.Split(fields[sep+3], ",")
+	}
+	return m, nil
+}
+
+// unescape decodes the octal escapes (\040 for space, \011, \012, \134) the
+// kernel uses for whitespace and backslashes in paths.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Load reads the mount table of the calling process.
+func Load() ([]Mount, error) {
+	f, err := os.Open(MountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Find returns the mount holding path: the one with the longest mountpoint
+// that is a prefix of the cleaned path. When several mounts share that
+// mountpoint the last one wins, as it hides the others. Symlinks are not
+// resolved; callers pass paths they got from the kernel or resolve them first.
+func Find(mounts []Mount, path string) (Mount, bool) {
+	path = filepath.Clean(path)
+	best := -1
+	for i, m := range mounts {
+		if !within(path, m.Mountpoint) {
+			continue
+		}
+		if best < 0 || len(m.Mountpoint) >= len(mounts[best].Mountpoint) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Mount{}, false
+	}
+	return mounts[best], true
+}
+
+func within(path, mountpoint string) bool {
+	if mountpoint == "/" {
+		return true
+	}
+	return path == mountpoint || strings.HasPrefix(path, mountpoint+"/")
+}
+
+// ForPath loads the mount table and finds the mount holding path, resolving
+// symlinks in path when it exists.
+func ForPath(path string) (Mount, error) {
+	mounts, err := Load()
+	if err != nil {
+		return Mount{}, fmt.Errorf("failed to read mount table: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	m, ok := Find(mounts, path)
+	if !ok {
+		return Mount{}, fmt.Errorf("no mount found for %s", path)
+	}
+	return m, nil
+}
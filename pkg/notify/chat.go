@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Chat 将一条文本消息发送到聊天工具
+type Chat interface {
+	Send(ctx context.Context, text string) error
+}
+
+// ChatFactory 由机器人 webhook 地址与签名密钥创建 Chat，secret 为空表示不签名
+type ChatFactory func(webhookURL, secret string) Chat
+
+var (
+	chatMutex     sync.RWMutex
+	chatProviders = map[string]ChatFactory{
+		"slack":    newSlack,
+		"feishu":   newFeishu,
+		"dingtalk": newDingTalk,
+	}
+)
+
+// RegisterChat 注册聊天工具，同名时覆盖
+func RegisterChat(name string, factory ChatFactory) {
+	chatMutex.Lock()
+	defer chatMutex.Unlock()
+	chatProviders[name] = factory
+}
+
+// ChatProviders 返回已注册的聊天工具名称，按字母排序
+func ChatProviders() []string {
+	chatMutex.RLock()
+	defer chatMutex.RUnlock()
+	names := make([]string, 0, len(chatProviders))
+	for name := range chatProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewChat 按名称创建聊天工具
+func NewChat(provider, webhookURL, secret string) (Chat, error) {
+	chatMutex.RLock()
+	factory, ok := chatProviders[provider]
+	chatMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown chat provider %q", provider)
+	}
+	return factory(webhookURL, secret), nil
+}
+
+// chatClient 为各聊天工具共用的 HTTP 客户端
+var chatClient = &http.Client{Timeout: 10 * time.Second}
+
+// postChat 以 JSON POST 发送消息，非 2xx 响应视为失败；check 非 nil 时校验响应体中的业务错误码
+func postChat(ctx context.Context, target string, v interface{}, check func(body []byte) error) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := chatClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook returned %s", resp.Status)
+	}
+	if check == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	return check(buf.Bytes())
+}
+
+// sign 按飞书与钉钉的算法计算签名：以 "timestamp\nsecret" 为密钥或消息的 HMAC-SHA256，再 base64 编码
+func sign(key, message []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// slack 为 Slack incoming webhook
+type slack struct {
+	url string
+}
+
+func newSlack(webhookURL, _ string) Chat {
+	return &slack{url: webhookURL}
+}
+
+func (s *slack) Send(ctx context.Context, text string) error {
+	return postChat(ctx, s.url, map[string]string{"text": text}, nil)
+}
+
+// feishu 为飞书（Lark）自定义机器人，设置了签名校验时需要 secret
+type feishu struct {
+	url    string
+	secret string
+}
+
+func newFeishu(webhookURL, secret string) Chat {
+	return &feishu{url: webhookURL, secret: secret}
+}
+
+func (f *feishu) Send(ctx context.Context, text string) error {
+	msg := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": text},
+	}
+	if f.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		msg["timestamp"] = timestamp
+		msg["sign"] = sign([]byte(timestamp+"\n"+f.secret), nil)
+	}
+	return postChat(ctx, f.url, msg, func(body []byte) error {
+		var resp struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(body, &resp) == nil && resp.Code != 0 {
+			return fmt.Errorf("feishu rejected message: %d %s", resp.Code, resp.Msg)
+		}
+		return nil
+	})
+}
+
+// dingTalk 为钉钉自定义机器人，设置了加签时需要 secret
+type dingTalk struct {
+	url    string
+	secret string
+}
+
+func newDingTalk(webhookURL, secret string) Chat {
+	return &dingTalk{url: webhookURL, secret: secret}
+}
+
+func (d *dingTalk) Send(ctx context.Context, text string) error {
+	target := d.url
+	if d.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		signature := sign([]byte(d.secret), []byte(timestamp+"\n"+d.secret))
+		u, err := url.Parse(d.url)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		q.Set("timestamp", timestamp)
+		q.Set("sign", signature)
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+	msg := map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": text},
+	}
+	return postChat(ctx, target, msg, func(body []byte) error {
+		var resp struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if json.Unmarshal(body, &resp) == nil && resp.ErrCode != 0 {
+			return fmt.Errorf("dingtalk rejected message: %d %s", resp.ErrCode, resp.ErrMsg)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// WriteContainerFile 通过 /proc/<pid>/root 将 data 原子写入进程所在挂载命名空间中的 path。
+// 路径以 RESOLVE_IN_ROOT 解析，容器内的符号链接无法指向宿主机上的文件
+func WriteContainerFile(pid uint32, path string, data []byte) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("container path %q is not absolute", path)
+	}
+	root, err := unix.Open(fmt.Sprintf("/proc/%d/root", pid), unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open root of pid %d: %v", pid, err)
+	}
+	defer unix.Close(root)
+
+	dir, name := filepath.Split(path)
+	dirfd, err := unix.Openat2(root, dir, &unix.OpenHow{
+		Flags:   unix.O_PATH | unix.O_DIRECTORY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_IN_ROOT | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open %s in container: %v", dir, err)
+	}
+	defer unix.Close(dirfd)
+
+	// 临时文件与目标位于同一目录，rename 保证读者不会看到写了一半的内容
+	tmp := "." + name + ".tmp"
+	fd, err := unix.Openat(dirfd, tmp, unix.O_WRONLY|unix.O_CREAT|unix.O_TRUNC|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s in container: %v", path, err)
+	}
+	f := os.NewFile(uintptr(fd), tmp)
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = unix.Renameat(dirfd, tmp, dirfd, name)
+	}
+	if err != nil {
+		unix.Unlinkat(dirfd, tmp, 0)
+		return fmt.Errorf("failed to write %s in container: %v", path, err)
+	}
+	return nil
+}
+
+// ParseSignal 解析信号名（"SIGUSR1" 或 "USR1"）或信号编号
+func ParseSignal(name string) (syscall.Signal, error) {
+	if num, err := strconv.Atoi(name); err == nil {
+		if num <= 0 || num > 64 {
+			return 0, fmt.Errorf("invalid signal number %d", num)
+		}
+		return syscall.Signal(num), nil
+	}
+	upper := strings.ToUpper(name)
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	if sig := unix.SignalNum(upper); sig != 0 {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
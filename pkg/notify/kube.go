@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir 为 Pod 内挂载的 ServiceAccount 凭据目录
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// 事件类型
+const (
+	EventNormal  = "Normal"
+	EventWarning = "Warning"
+)
+
+// NodeEvents 以集群内 ServiceAccount 身份为本节点的 Node 对象创建 Kubernetes 事件，
+// 需要在 default 命名空间创建 events 的权限
+type NodeEvents struct {
+	node     string
+	endpoint string
+	api      inCluster
+}
+
+// NewNodeEvents 从集群内环境（KUBERNETES_SERVICE_HOST 与 ServiceAccount 凭据）创建事件发送器
+func NewNodeEvents(node string) (*NodeEvents, error) {
+	api, err := newInCluster()
+	if err != nil {
+		return nil, err
+	}
+	return &NodeEvents{
+		node:     node,
+		endpoint: api.base + "/api/v1/namespaces/default/events",
+		api:      api,
+	}, nil
+}
+
+// inCluster 为集群内访问 Kubernetes API 所需的地址、凭据与客户端
+type inCluster struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+func newInCluster() (inCluster, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return inCluster{}, fmt.Errorf("KUBERNETES_SERVICE_HOST/PORT not set, not running in a cluster")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return inCluster{}, fmt.Errorf("failed to read service account token: %v", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return inCluster{}, fmt.Errorf("failed to read service account CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return inCluster{}, fmt.Errorf("no certificates found in service account CA")
+	}
+	return inCluster{
+		base:  "https://" + net.JoinHostPort(host, port),
+		token: strings.TrimSpace(string(token)),
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// do 发送请求并检查响应状态
+func (c inCluster) do(ctx context.Context, method, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kubernetes API returned %s", resp.Status)
+	}
+	return nil
+}
+
+type objectReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	UID  string `json:"uid,omitempty"`
+}
+
+type event struct {
+	APIVersion     string            `json:"apiVersion"`
+	Kind           string            `json:"kind"`
+	Metadata       map[string]string `json:"metadata"`
+	InvolvedObject objectReference   `json:"involvedObject"`
+	Reason         string            `json:"reason"`
+	Message        string            `json:"message"`
+	Type           string            `json:"type"`
+	FirstTimestamp time.Time         `json:"firstTimestamp"`
+	LastTimestamp  time.Time         `json:"lastTimestamp"`
+	Count          int               `json:"count"`
+	Source         map[string]string `json:"source"`
+}
+
+// Emit 创建一条关于本节点的事件，eventType 为 EventNormal 或 EventWarning
+func (n *NodeEvents) Emit(ctx context.Context, eventType, reason, message string) error {
+	now := time.Now().UTC()
+	ev := event{
+		APIVersion: "v1",
+		Kind:       "Event",
+		Metadata: map[string]string{
+			"generateName": n.node + ".",
+			"namespace":    "default",
+		},
+		// kubectl describe node 按名称与 UID 关联事件，节点的 UID 即其名称
+		InvolvedObject: objectReference{Kind: "Node", Name: n.node, UID: n.node},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source:         map[string]string{"component": "conquotas", "host": n.node},
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return n.api.do(ctx, http.MethodPost, n.endpoint, "application/json", body)
+}
+
+// NodeAnnotator 以集群内 ServiceAccount 身份设置本节点 Node 对象的注解，需要 patch nodes 的权限
+type NodeAnnotator struct {
+	endpoint string
+	api      inCluster
+}
+
+// NewNodeAnnotator 从集群内环境创建节点注解写入器
+func NewNodeAnnotator(node string) (*NodeAnnotator, error) {
+	api, err := newInCluster()
+	if err != nil {
+		return nil, err
+	}
+	return &NodeAnnotator{endpoint: api.base + "/api/v1/nodes/" + url.PathEscape(node), api: api}, nil
+}
+
+// Annotate 以 merge patch 设置一个注解，不影响其他注解
+func (n *NodeAnnotator) Annotate(ctx context.Context, key, value string) error {
+	patch := map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{key: value},
+		},
+	}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	return n.api.do(ctx, http.MethodPatch, n.endpoint, "application/merge-patch+json", body)
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData 为通知模板可引用的字段
+type TemplateData struct {
+	// Event 为通知类型：usage_alert 或 hard_limit
+	Event     string
+	Node      string
+	Timestamp time.Time
+	// Reason 为触发通知的原因，如 "usage passed 95% of the hard limit"
+	Reason    string
+	Container TemplateContainer
+	// Pod 为 CRI 容器所属的 Pod，非 Kubernetes 容器的字段为空
+	Pod    TemplatePod
+	Limits TemplateLimits
+	Usage  TemplateUsage
+}
+
+// TemplateContainer 为通知涉及的容器，共享项目时 ID 为分组键
+type TemplateContainer struct {
+	ID        string
+	Namespace string
+	Image     string
+	ProjectID uint32
+	Labels    map[string]string
+}
+
+// TemplatePod 为容器所属的 Pod
+type TemplatePod struct {
+	Name      string
+	Namespace string
+	UID       string
+}
+
+// TemplateLimits 为项目的限额，Soft、Hard 为配置中的写法
+type TemplateLimits struct {
+	Soft      string
+	Hard      string
+	SoftBytes uint64
+	HardBytes uint64
+}
+
+// TemplateUsage 为项目的用量，Percent 为占硬限制的百分比，未设置硬限制时为 0
+type TemplateUsage struct {
+	UsedBytes  uint64
+	UsedInodes uint64
+	Percent    float64
+	// Threshold 为越过的告警阈值，仅用量告警设置
+	Threshold float64
+}
+
+// templateFuncs 为模板可用的函数：json 输出值的 JSON 编码（用于在 JSON 请求体中嵌入字符串），
+// mib 将字节数换算为 MiB，upper、lower 转换大小写
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"mib":   func(bytes uint64) uint64 { return bytes >> 20 },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Template 为一条通知的 Go 模板
+type Template struct {
+	t *template.Template
+}
+
+// ParseTemplate 解析通知模板
+func ParseTemplate(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{t: t}, nil
+}
+
+// Render 以 data 渲染模板
+func (t *Template) Render(data TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %v", t.t.Name(), err)
+	}
+	return buf.String(), nil
+}
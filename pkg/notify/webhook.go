@@ -0,0 +1,51 @@
+// Package notify 将节点级告警发送到外部系统（通用 webhook、Kubernetes 事件与聊天工具机器人），
+// 并将限额变更通知到容器内的工作负载
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook 以 JSON POST 发送告警
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook 创建 webhook 发送器
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Post 发送 v 的 JSON 编码，非 2xx 响应视为失败
+func (w *Webhook) Post(ctx context.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.PostBody(ctx, "application/json", body)
+}
+
+// PostBody 发送已编码的请求体，用于模板渲染的自定义格式
+func (w *Webhook) PostBody(ctx context.Context, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
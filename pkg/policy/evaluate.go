@@ -0,0 +1,86 @@
+package policy
+
+// ContainerMeta 为策略计算所需的容器元数据，可由 GET /v1/policy/targets 从节点记录，
+// 供准入 Webhook 与 CI 在不连接守护进程的情况下验证策略修改
+type ContainerMeta struct {
+	ContainerID string            `json:"container_id"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// SoftLimit 与 HardLimit 为当前限额，为空表示尚未设置
+	SoftLimit string `json:"soft_limit,omitempty"`
+	HardLimit string `json:"hard_limit,omitempty"`
+	// Group 为所属的共享项目（Pod、命名空间分组），共享项目不受按容器的策略约束
+	Group string `json:"group,omitempty"`
+	// Pinned 表示限额由管理员手动设置，策略不覆盖
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// 策略对单个容器的决定
+const (
+	// ActionChange 表示限额将被修改为期望值
+	ActionChange = "change"
+	// ActionKeep 表示命中规则且当前限额已与期望值一致
+	ActionKeep = "keep"
+	// ActionUnmatched 表示未命中任何规则且策略未设置默认值
+	ActionUnmatched = "unmatched"
+	// ActionSkip 表示容器不受策略约束（共享项目或已固定的限额）
+	ActionSkip = "skip"
+)
+
+// Decision 为策略对单个容器的决定，与守护进程 diff/apply 使用相同的计算
+type Decision struct {
+	ContainerID string `json:"container_id"`
+	Namespace   string `json:"namespace,omitempty"`
+	Action      string `json:"action"`
+	// Rule 为命中的规则名，未命中或跳过时为空
+	Rule    string `json:"rule,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	OldSoft string `json:"old_soft,omitempty"`
+	OldHard string `json:"old_hard,omitempty"`
+	// NewSoft 与 NewHard 为期望限额，未命中或跳过时为空
+	NewSoft string `json:"new_soft,omitempty"`
+	NewHard string `json:"new_hard,omitempty"`
+}
+
+// EvaluatePolicy 计算策略对容器的决定而不做任何修改
+func EvaluatePolicy(p *Policy, meta ContainerMeta) Decision {
+	d := Decision{
+		ContainerID: meta.ContainerID,
+		Namespace:   meta.Namespace,
+		OldSoft:     meta.SoftLimit,
+		OldHard:     meta.HardLimit,
+	}
+	switch {
+	case meta.Group != "":
+		d.Action, d.Reason = ActionSkip, "member of shared project "+meta.Group
+		return d
+	case meta.Pinned:
+		d.Action, d.Reason = ActionSkip, "limits pinned by an administrator"
+		return d
+	}
+
+	want, rule, ok := p.Evaluate(Target{ContainerID: meta.ContainerID, Namespace: meta.Namespace, Labels: meta.Labels})
+	if !ok {
+		d.Action, d.Reason = ActionUnmatched, "no rule matched and the policy has no defaults"
+		return d
+	}
+	d.Rule, d.NewSoft, d.NewHard = rule, want.Soft, want.Hard
+	d.Action = ActionKeep
+	if Differs(meta.SoftLimit, meta.HardLimit, want) {
+		d.Action = ActionChange
+	}
+	return d
+}
+
+// Change 返回决定对应的修改，仅在 Action 为 ActionChange 时有意义
+func (d Decision) Change() Change {
+	return Change{
+		ContainerID: d.ContainerID,
+		Namespace:   d.Namespace,
+		Rule:        d.Rule,
+		OldSoft:     d.OldSoft,
+		OldHard:     d.OldHard,
+		NewSoft:     d.NewSoft,
+		NewHard:     d.NewHard,
+	}
+}
@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"RootfsQuota/pkg/xfs"
+)
+
+// Policy 为声明式的期望配额策略：按顺序匹配规则，首个命中的规则生效，均未命中时使用默认值
+type Policy struct {
+	Defaults Limits `json:"defaults" yaml:"defaults"`
+	Rules    []Rule `json:"rules" yaml:"rules"`
+}
+
+// Limits 为一组软/硬限制，Hard 为空表示不做修改
+type Limits struct {
+	Soft string `json:"soft" yaml:"soft"`
+	Hard string `json:"hard" yaml:"hard"`
+}
+
+// Rule 为单条匹配规则，所有条件同时满足才命中，条件为空表示不限制
+type Rule struct {
+	Name        string            `json:"name" yaml:"name"`
+	Namespace   string            `json:"namespace,omitempty" yaml:"namespace"`
+	MatchLabels map[string]string `json:"match_labels,omitempty" yaml:"match_labels"`
+	Limits      `yaml:",inline"`
+}
+
+// Target 为参与策略计算的容器
+type Target struct {
+	ContainerID string
+	Namespace   string
+	Labels      map[string]string
+}
+
+// Change 为单个容器当前限额与期望限额的差异
+type Change struct {
+	ContainerID string `json:"container_id"`
+	Namespace   string `json:"namespace,omitempty"`
+	// Rule 为命中的规则名，使用默认值时为 "defaults"
+	Rule    string `json:"rule"`
+	OldSoft string `json:"old_soft"`
+	OldHard string `json:"old_hard"`
+	NewSoft string `json:"new_soft"`
+	NewHard string `json:"new_hard"`
+	Applied bool   `json:"applied,omitempty"`
+	// RolledBack 表示该修改在后续失败后已恢复为原限额
+	RolledBack bool   `json:"rolled_back,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DefaultsRule 为未命中任何规则时 Change.Rule 的取值
+const DefaultsRule = "defaults"
+
+// Load 从文件加载策略，支持 YAML 与 JSON
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse 解析并校验策略文档
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate 校验所有限额均可解析且软限制不超过硬限制
+func (p *Policy) Validate() error {
+	if err := p.Defaults.validate(); err != nil {
+		return fmt.Errorf("invalid defaults: %v", err)
+	}
+	for i, rule := range p.Rules {
+		if rule.Hard == "" {
+			return fmt.Errorf("rule %d (%s): hard is required", i, rule.Name)
+		}
+		if err := rule.Limits.validate(); err != nil {
+			return fmt.Errorf("rule %d (%s): %v", i, rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (l Limits) validate() error {
+	if l.Hard == "" {
+		if l.Soft != "" {
+			return fmt.Errorf("soft given without hard")
+		}
+		return nil
+	}
+	hard, err := xfs.ParseSize(l.Hard)
+	if err != nil {
+		return err
+	}
+	if l.Soft == "" {
+		return nil
+	}
+	soft, err := xfs.ParseSize(l.Soft)
+	if err != nil {
+		return err
+	}
+	if soft > hard {
+		return fmt.Errorf("soft %s exceeds hard %s", l.Soft, l.Hard)
+	}
+	return nil
+}
+
+// Evaluate 返回容器的期望限额及命中的规则名，ok 为 false 表示策略不约束该容器
+func (p *Policy) Evaluate(t Target) (limits Limits, rule string, ok bool) {
+	for i, r := range p.Rules {
+		if r.matches(t) {
+			return r.Limits.withSoft(), r.name(i), true
+		}
+	}
+	if p.Defaults.Hard == "" {
+		return Limits{}, "", false
+	}
+	return p.Defaults.withSoft(), DefaultsRule, true
+}
+
+// RuleNames 按顺序返回所有规则的名称，设置了默认值时最后为 DefaultsRule
+func (p *Policy) RuleNames() []string {
+	names := make([]string, 0, len(p.Rules)+1)
+	for i, r := range p.Rules {
+		names = append(names, r.name(i))
+	}
+	if p.Defaults.Hard != "" {
+		names = append(names, DefaultsRule)
+	}
+	return names
+}
+
+// name 返回规则名，未命名时按位置生成
+func (r Rule) name(i int) string {
+	if r.Name == "" {
+		return fmt.Sprintf("rule-%d", i)
+	}
+	return r.Name
+}
+
+func (r Rule) matches(t Target) bool {
+	if r.Namespace != "" && r.Namespace != t.Namespace {
+		return false
+	}
+	for k, v := range r.MatchLabels {
+		if t.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// withSoft 在未指定软限制时令其等于硬限制
+func (l Limits) withSoft() Limits {
+	if l.Soft == "" {
+		l.Soft = l.Hard
+	}
+	return l
+}
+
+// Differs 判断当前限额与期望限额是否不同，按字节比较以忽略单位写法差异
+func Differs(soft, hard string, want Limits) bool {
+	return !sameSize(soft, want.Soft) || !sameSize(hard, want.Hard)
+}
+
+func sameSize(a, b string) bool {
+	x, errA := xfs.ParseSize(a)
+	y, errB := xfs.ParseSize(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return x == y
+}
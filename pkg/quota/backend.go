@@ -0,0 +1,129 @@
+// Package quota dispatches project quota operations to a backend chosen by
+// the filesystem type of the directory being limited. Project IDs come from a
+// single pool shared by all filesystems, so once a directory is tagged the
+// remaining operations address the project by ID only.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+
+	"RootfsQuota/pkg/mounts"
+	"RootfsQuota/pkg/xfs"
+)
+
+// QuotaBackend applies project quotas on one filesystem type.
+type QuotaBackend interface {
+	// Name identifies the backend in logs, e.g. "xfs".
+	Name() string
+	// SetProjectID tags path and everything below it with projid so that
+	// new files inherit it.
+	SetProjectID(ctx context.Context, path string, projid uint32) error
+	// SetLimits sets the block soft and hard limits of a project.
+	SetLimits(ctx context.Context, projid uint32, soft, hard string) error
+	// SetInodeLimits sets the inode soft and hard limits of a project,
+	// leaving its block limits unchanged. Zero removes a limit.
+	SetInodeLimits(ctx context.Context, projid uint32, soft, hard uint64) error
+	// SetRealtimeLimits sets the block limits of a project on the realtime
+	// subvolume, for filesystems that have one. "0" removes a limit.
+	SetRealtimeLimits(ctx context.Context, projid uint32, soft, hard string) error
+	// GetUsage returns the live usage and limits of a project.
+	GetUsage(ctx context.Context, projid uint32) (xfs.ProjectUsage, error)
+	// ClearProject removes the limits of a project before its ID is reused.
+	ClearProject(ctx context.Context, projid uint32) error
+}
+
+// ErrUnsupported is returned by Detect for filesystems without a backend.
+var ErrUnsupported = errors.New("filesystem does not support project quotas")
+
+// Filesystem magic numbers from statfs(2).
+const (
+	MagicXFS     int64 = 0x58465342
+	MagicExt4    int64 = 0xef53
+	MagicBtrfs   int64 = 0x9123683e
+	MagicOverlay int64 = 0x794c7630
+	MagicTmpfs   int64 = 0x01021994
+	MagicZFS     int64 = 0x2fc12fc1
+)
+
+var fsNames = map[int64]string{
+	MagicXFS:     "xfs",
+	MagicExt4:    "ext4",
+	MagicBtrfs:   "btrfs",
+	MagicOverlay: "overlay",
+	MagicTmpfs:   "tmpfs",
+	MagicZFS:     "zfs",
+}
+
+var (
+	mutex    sync.RWMutex
+	byMagic  = make(map[int64]QuotaBackend)
+	byName   = make(map[string]QuotaBackend)
+	fallback QuotaBackend
+)
+
+// Register makes b the backend for filesystems with the given statfs magic
+// number. The first registered backend is also the Default.
+func Register(magic int64, b QuotaBackend) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	byMagic[magic] = b
+	byName[b.Name()] = b
+	if fallback == nil {
+		fallback = b
+	}
+}
+
+// Lookup returns the backend registered under name.
+func Lookup(name string) (QuotaBackend, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	b, ok := byName[name]
+	return b, ok
+}
+
+// Default returns the backend used for projects whose filesystem is not
+// known, e.g. because their directory no longer exists.
+func Default() QuotaBackend {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return fallback
+}
+
+// Detect statfs's path and returns the backend for its filesystem type. The
+// error wraps ErrUnsupported when no backend handles that type, or when the
+// mount table shows an XFS mount without project quotas. If the mount table
+// cannot be read, the statfs result alone decides.
+func Detect(path string) (QuotaBackend, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, fmt.Errorf("failed to statfs %s: %v", path, err)
+	}
+	magic := int64(st.Type)
+	m, mountErr := mounts.ForPath(path)
+
+	mutex.RLock()
+	b, ok := byMagic[magic]
+	mutex.RUnlock()
+	if !ok {
+		if mountErr == nil {
+			return nil, fmt.Errorf("%w: %s is on %s mounted at %s", ErrUnsupported, path, FilesystemName(magic), m.Mountpoint)
+		}
+		return nil, fmt.Errorf("%w: %s is on %s", ErrUnsupported, path, FilesystemName(magic))
+	}
+	if magic == MagicXFS && mountErr == nil && m.FSType == "xfs" && !m.ProjectQuota() {
+		return nil, fmt.Errorf("%w: xfs at %s is mounted without prjquota", ErrUnsupported, m.Mountpoint)
+	}
+	return b, nil
+}
+
+// FilesystemName returns a readable name for a statfs magic number.
+func FilesystemName(magic int64) string {
+	if name, ok := fsNames[magic]; ok {
+		return name
+	}
+	return fmt.Sprintf("filesystem type 0x%x", magic)
+}
@@ -0,0 +1,76 @@
+package quota
+
+import (
+	"context"
+
+	"RootfsQuota/pkg/xfs"
+)
+
+func init() {
+	Register(MagicXFS, xfsBackend{})
+}
+
+// xfsBackend implements project quotas with FS_IOC_FSSETXATTR and xfs_quota.
+type xfsBackend struct{}
+
+func (xfsBackend) Name() string { return "xfs" }
+
+func (xfsBackend) SetProjectID(ctx context.Context, path string, projid uint32) error {
+	return xfs.SetProjectIDWithXFSQuota(ctx, path, projid)
+}
+
+func (xfsBackend) SetLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	return xfs.SetProjectQuotaWithXFSQuota(ctx, projid, soft, hard, nil)
+}
+
+func (xfsBackend) SetInodeLimits(ctx context.Context, projid uint32, soft, hard uint64) error {
+	return xfs.SetProjectQuotaWithXFSQuota(ctx, projid, "", "", &xfs.InodeLimits{Soft: soft, Hard: hard})
+}
+
+func (xfsBackend) SetRealtimeLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	return xfs.SetProjectRealtimeQuotaWithXFSQuota(ctx, projid, soft, hard)
+}
+
+func (xfsBackend) GetUsage(ctx context.Context, projid uint32) (xfs.ProjectUsage, error) {
+	return xfs.GetProjectUsage(ctx, projid)
+}
+
+func (xfsBackend) ClearProject(ctx context.Context, projid uint32) error {
+	return xfs.ClearProjectQuotaWithXFSQuota(ctx, projid)
+}
+
+// xfsPipelineBackend is xfsBackend with limits applied through an
+// xfs.Pipeline instead of one xfs_quota process per call.
+type xfsPipelineBackend struct {
+	xfsBackend
+	p *xfs.Pipeline
+}
+
+// UseXFSPipeline makes projects on XFS apply their limits through p. It is
+// meant to be called once at startup, before any backend is detected.
+func UseXFSPipeline(p *xfs.Pipeline) {
+	b := xfsPipelineBackend{p: p}
+	mutex.Lock()
+	defer mutex.Unlock()
+	byMagic[MagicXFS] = b
+	byName[b.Name()] = b
+	if fallback != nil && fallback.Name() == b.Name() {
+		fallback = b
+	}
+}
+
+func (b xfsPipelineBackend) SetLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	return b.p.SetLimits(ctx, projid, soft, hard, nil)
+}
+
+func (b xfsPipelineBackend) SetInodeLimits(ctx context.Context, projid uint32, soft, hard uint64) error {
+	return b.p.SetLimits(ctx, projid, "", "", &xfs.InodeLimits{Soft: soft, Hard: hard})
+}
+
+func (b xfsPipelineBackend) SetRealtimeLimits(ctx context.Context, projid uint32, soft, hard string) error {
+	return b.p.SetRealtimeLimits(ctx, projid, soft, hard)
+}
+
+func (b xfsPipelineBackend) ClearProject(ctx context.Context, projid uint32) error {
+	return b.p.Clear(ctx, projid)
+}
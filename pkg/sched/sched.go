@@ -0,0 +1,274 @@
+// Package sched 定义配额操作的执行模型：
+//
+//   - 同一容器（键）的操作严格按提交顺序串行执行，优先级不会让后提交的操作越过先提交的；
+//   - 全局同时执行的操作数不超过并发上限；
+//   - 不同容器之间按优先级调度，默认顺序为管理接口、优先创建、创建、删除、对账，同一优先级内先到先得。
+//
+// 在已持有键的上下文中再次提交（如事件处理中调整 Pod 分组限额）不会再占用并发槽位，
+// 提交相同的键时直接执行，避免自身死锁。
+package sched
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"RootfsQuota/pkg/metrics"
+)
+
+// Priority 为操作的优先级类别
+type Priority int
+
+const (
+	// Admin 为管理接口发起的操作
+	Admin Priority = iota
+	// CreateHigh 为配置的优先命名空间与运行时的容器创建事件
+	CreateHigh
+	// Create 为容器创建事件
+	Create
+	// Delete 为容器删除事件
+	Delete
+	// Reconcile 为后台对账、收敛与巡检
+	Reconcile
+
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"admin", "create-high", "create", "delete", "reconcile"}
+
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority 解析优先级名称
+func ParsePriority(name string) (Priority, error) {
+	for i, n := range priorityNames {
+		if n == name {
+			return Priority(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", name)
+}
+
+// DefaultOrder 为默认的优先级顺序
+var DefaultOrder = []Priority{Admin, CreateHigh, Create, Delete, Reconcile}
+
+// Scheduler 按键串行、按优先级调度并限制全局并发
+type Scheduler struct {
+	mutex   sync.Mutex
+	slots   int
+	running int
+	// rank 为各优先级的调度次序，越小越先
+	rank    [numPriorities]int
+	waiting []*waiter
+	held    map[string]bool
+}
+
+type waiter struct {
+	key     string
+	prio    Priority
+	slot    bool
+	granted bool
+	ready   chan struct{}
+}
+
+// New 创建调度器，concurrency 为全局并发上限，order 为从高到低的优先级顺序，未列出的优先级排在最后；
+// order 列出了 create 而未列出 create-high 时，create-high 紧排在 create 之前
+func New(concurrency int, order []Priority) *Scheduler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	order = withCreateHigh(order)
+	s := &Scheduler{slots: concurrency, held: make(map[string]bool)}
+	for p := range s.rank {
+		s.rank[p] = len(order) + p
+	}
+	for i, p := range order {
+		s.rank[p] = i
+	}
+	return s
+}
+
+func withCreateHigh(order []Priority) []Priority {
+	at := -1
+	for i, p := range order {
+		if p == CreateHigh {
+			return order
+		}
+		if p == Create {
+			at = i
+		}
+	}
+	if at < 0 {
+		return order
+	}
+	out := append([]Priority{}, order[:at]...)
+	out = append(out, CreateHigh)
+	return append(out, order[at:]...)
+}
+
+// holdKey 为上下文中记录已持有键与槽位的链表值
+type holdKey struct{}
+
+type hold struct {
+	parent *hold
+	key    string
+}
+
+func (h *hold) has(key string) bool {
+	for ; h != nil; h = h.parent {
+		if h.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Do 在调度允许时执行 fn，key 为空表示不需要串行化；fn 收到的上下文记录了已持有的键
+func (s *Scheduler) Do(ctx context.Context, key string, prio Priority, fn func(ctx context.Context) error) error {
+	h, _ := ctx.Value(holdKey{}).(*hold)
+	if h != nil && (key == "" || h.has(key)) {
+		return fn(ctx)
+	}
+	w := s.submit(key, prio, h == nil)
+	if err := s.wait(ctx, w); err != nil {
+		return err
+	}
+	defer s.release(w)
+	return fn(context.WithValue(ctx, holdKey{}, &hold{parent: h, key: key}))
+}
+
+// Go 立即登记操作以固定其在同一键上的顺序，并在获准后于新的 goroutine 中调用 fn；
+// fn 必须在操作结束时调用 release，可在返回之后调用（如超时后仍在执行的处理）。
+// ctx 在获准前结束时 fn 不会被调用
+func (s *Scheduler) Go(ctx context.Context, key string, prio Priority, fn func(ctx context.Context, release func())) {
+	w := s.submit(key, prio, true)
+	go func() {
+		if err := s.wait(ctx, w); err != nil {
+			return
+		}
+		var once sync.Once
+		release := func() { once.Do(func() { s.release(w) }) }
+		fn(context.WithValue(ctx, holdKey{}, &hold{key: key}), release)
+	}()
+}
+
+// Stats 为调度器的当前状态，用于排查卡住的操作
+type Stats struct {
+	Slots   int `json:"slots"`
+	Running int `json:"running"`
+	// Waiting 为各优先级等待中的操作数
+	Waiting map[string]int `json:"waiting"`
+	// Held 为正在执行操作的键，按字典序排列
+	Held []string `json:"held"`
+}
+
+// Stats 返回调度器的当前状态
+func (s *Scheduler) Stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st := Stats{Slots: s.slots, Running: s.running, Waiting: make(map[string]int), Held: make([]string, 0, len(s.held))}
+	for _, w := range s.waiting {
+		st.Waiting[w.prio.String()]++
+	}
+	for key := range s.held {
+		st.Held = append(st.Held, key)
+	}
+	sort.Strings(st.Held)
+	return st
+}
+
+func (s *Scheduler) submit(key string, prio Priority, slot bool) *waiter {
+	w := &waiter{key: key, prio: prio, slot: slot, ready: make(chan struct{})}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.waiting = append(s.waiting, w)
+	metrics.SchedulerWaiting.WithLabelValues(prio.String()).Inc()
+	s.dispatch()
+	return w
+}
+
+func (s *Scheduler) wait(ctx context.Context, w *waiter) error {
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if w.granted {
+		// 已获准但调用方放弃，归还资源
+		s.releaseLocked(w)
+		return ctx.Err()
+	}
+	for i, other := range s.waiting {
+		if other == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			metrics.SchedulerWaiting.WithLabelValues(w.prio.String()).Dec()
+			break
+		}
+	}
+	s.dispatch()
+	return ctx.Err()
+}
+
+func (s *Scheduler) release(w *waiter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.releaseLocked(w)
+}
+
+func (s *Scheduler) releaseLocked(w *waiter) {
+	if w.slot {
+		s.running--
+	}
+	if w.key != "" {
+		delete(s.held, w.key)
+	}
+	s.dispatch()
+}
+
+// dispatch 反复挑选可执行的等待者中优先级最高、到达最早的一个，调用方需持有锁
+func (s *Scheduler) dispatch() {
+	for {
+		best := -1
+		// blocked 为已有更早等待者或正在执行的键，后到的同键操作必须继续等待
+		blocked := make(map[string]bool)
+		for i, w := range s.waiting {
+			if w.key != "" {
+				if s.held[w.key] || blocked[w.key] {
+					blocked[w.key] = true
+					continue
+				}
+				blocked[w.key] = true
+			}
+			if w.slot && s.running >= s.slots {
+				continue
+			}
+			if best < 0 || s.rank[w.prio] < s.rank[s.waiting[best].prio] {
+				best = i
+			}
+		}
+		if best < 0 {
+			return
+		}
+
+		w := s.waiting[best]
+		s.waiting = append(s.waiting[:best], s.waiting[best+1:]...)
+		metrics.SchedulerWaiting.WithLabelValues(w.prio.String()).Dec()
+		if w.slot {
+			s.running++
+		}
+		if w.key != "" {
+			s.held[w.key] = true
+		}
+		w.granted = true
+		close(w.ready)
+	}
+}
@@ -0,0 +1,187 @@
+package sched
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// granted reports which of ws have been let through, in order.
+func granted(s *Scheduler, ws ...*waiter) []bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	out := make([]bool, len(ws))
+	for i, w := range ws {
+		out[i] = w.granted
+	}
+	return out
+}
+
+func TestSameKeyFIFO(t *testing.T) {
+	s := New(4, DefaultOrder)
+	first := s.submit("c1", Reconcile, true)
+	// a higher priority never overtakes an earlier operation on the same key
+	second := s.submit("c1", Reconcile, true)
+	third := s.submit("c1", Admin, true)
+	other := s.submit("c2", Reconcile, true)
+
+	if got, want := granted(s, first, second, third, other), []bool{true, false, false, true}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("granted = %v, want %v", got, want)
+	}
+	s.release(first)
+	if got, want := granted(s, second, third), []bool{true, false}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after the first release granted = %v, want %v", got, want)
+	}
+	s.release(second)
+	if got := granted(s, third); !got[0] {
+		t.Fatal("third operation not granted after the second finished")
+	}
+	s.release(third)
+	s.release(other)
+	if st := s.Stats(); st.Running != 0 || len(st.Held) != 0 {
+		t.Errorf("Stats() = %+v after all releases", st)
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []Priority
+		want  []string
+	}{
+		{name: "default", order: DefaultOrder, want: []string{"admin", "admin-2", "create-high", "create", "delete", "reconcile"}},
+		{name: "reconcile first", order: []Priority{Reconcile, Admin}, want: []string{"reconcile", "admin", "admin-2", "create-high", "create", "delete"}},
+		{name: "create-high before create", order: []Priority{Delete, Create}, want: []string{"delete", "create-high", "create", "admin", "admin-2", "reconcile"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(1, tt.order)
+			busy := s.submit("busy", Admin, true)
+			waiters := map[string]*waiter{
+				"reconcile":   s.submit("r", Reconcile, true),
+				"delete":      s.submit("d", Delete, true),
+				"create":      s.submit("c", Create, true),
+				"create-high": s.submit("h", CreateHigh, true),
+				"admin":       s.submit("a", Admin, true),
+				"admin-2":     s.submit("a2", Admin, true),
+			}
+
+			var got []string
+			running := busy
+			for range waiters {
+				s.release(running)
+				running = nil
+				for name, w := range waiters {
+					if granted(s, w)[0] && !contains(got, name) {
+						if running != nil {
+							t.Fatalf("%s granted while another operation holds the only slot", name)
+						}
+						got = append(got, name)
+						running = w
+					}
+				}
+				if running == nil {
+					t.Fatalf("no operation granted after %v", got)
+				}
+			}
+			s.release(running)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNestedDoTakesNoSlot(t *testing.T) {
+	s := New(1, DefaultOrder)
+	ctx := context.Background()
+	var inner Stats
+	err := s.Do(ctx, "pod:uid", Create, func(ctx context.Context) error {
+		// another key nested in the only slot would deadlock if it waited for a slot
+		return s.Do(ctx, "c1", Admin, func(ctx context.Context) error {
+			// the same key again runs directly
+			return s.Do(ctx, "pod:uid", Admin, func(ctx context.Context) error {
+				inner = s.Stats()
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		t.Fatalf("Do() = %v", err)
+	}
+	if inner.Running != 1 || !reflect.DeepEqual(inner.Held, []string{"c1", "pod:uid"}) {
+		t.Errorf("nested Stats() = %+v, want 1 running and both keys held", inner)
+	}
+	if st := s.Stats(); st.Running != 0 || len(st.Held) != 0 {
+		t.Errorf("Stats() = %+v after Do returned", st)
+	}
+}
+
+func TestCancelWhileWaiting(t *testing.T) {
+	s := New(1, DefaultOrder)
+	busy := s.submit("c1", Admin, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Do(ctx, "c1", Admin, func(context.Context) error {
+			return errors.New("canceled operation ran")
+		})
+	}()
+	next := s.submit("c1", Reconcile, true)
+	for s.Stats().Waiting["admin"] == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() = %v, want %v", err, context.Canceled)
+	}
+	// the canceled waiter no longer blocks the key
+	s.release(busy)
+	if !granted(s, next)[0] {
+		t.Fatal("operation behind the canceled one was not granted")
+	}
+	s.release(next)
+}
+
+func TestCancelAfterGrant(t *testing.T) {
+	s := New(1, DefaultOrder)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// with both the grant and the cancellation ready, wait may take either; when
+	// it gives up a granted operation, the slot and the key must be returned
+	var gaveUp int
+	for i := 0; i < 200; i++ {
+		w := s.submit("c1", Create, true)
+		if !granted(s, w)[0] {
+			t.Fatal("operation on an idle scheduler not granted")
+		}
+		if err := s.wait(ctx, w); err != nil {
+			gaveUp++
+		} else {
+			s.release(w)
+		}
+		if st := s.Stats(); st.Running != 0 || len(st.Held) != 0 {
+			t.Fatalf("Stats() = %+v after a canceled grant", st)
+		}
+	}
+	if gaveUp == 0 {
+		t.Skip("wait never chose the cancellation")
+	}
+	w := s.submit("c1", Create, true)
+	if !granted(s, w)[0] {
+		t.Error("key still held after canceled grants")
+	}
+	s.release(w)
+}
@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd/mount"
+)
+
+// Layout is the writable layout a plugin extracts from snapshot mounts.
+type Layout struct {
+	Type      string
+	Upperdir  string
+	Workdir   string
+	Lowerdirs []string
+}
+
+// Plugin turns the mounts of one snapshotter into a Layout. It reports false
+// when the mounts do not look like ones its snapshotter produces.
+type Plugin interface {
+	Layout(mounts []mount.Mount) (Layout, bool)
+}
+
+// PluginFunc adapts a function to the Plugin interface.
+type PluginFunc func(mounts []mount.Mount) (Layout, bool)
+
+// Layout calls f.
+func (f PluginFunc) Layout(mounts []mount.Mount) (Layout, bool) {
+	return f(mounts)
+}
+
+type registry struct {
+	mutex   sync.RWMutex
+	plugins map[string]Plugin
+	// order is the registration order, used to probe mounts whose snapshotter
+	// is not known
+	order []string
+}
+
+var plugins = &registry{plugins: make(map[string]Plugin)}
+
+// Register makes a plugin available for the named snapshotter. Registering a
+// name twice replaces the earlier plugin.
+func Register(snapshotter string, p Plugin) {
+	plugins.mutex.Lock()
+	defer plugins.mutex.Unlock()
+	if _, exists := plugins.plugins[snapshotter]; !exists {
+		plugins.order = append(plugins.order, snapshotter)
+	}
+	plugins.plugins[snapshotter] = p
+}
+
+// Alias makes snapshotter use the plugin registered for target, for in-house
+// snapshotters that lay out mounts like a known one.
+func Alias(snapshotter, target string) error {
+	plugins.mutex.Lock()
+	defer plugins.mutex.Unlock()
+	p, ok := plugins.plugins[target]
+	if !ok {
+		return fmt.Errorf("no snapshot plugin registered for %q", target)
+	}
+	if _, exists := plugins.plugins[snapshotter]; !exists {
+		plugins.order = append(plugins.order, snapshotter)
+	}
+	plugins.plugins[snapshotter] = p
+	return nil
+}
+
+// Registered returns the sorted names of snapshotters with a plugin.
+func Registered() []string {
+	plugins.mutex.RLock()
+	defer plugins.mutex.RUnlock()
+	names := make([]string, 0, len(plugins.plugins))
+	for name := range plugins.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupLayout asks the plugin of snapshotter for the layout of mounts. With
+// an empty or unregistered snapshotter every plugin is tried in registration
+// order and the first match wins.
+func lookupLayout(snapshotter string, mounts []mount.Mount) (Layout, bool) {
+	plugins.mutex.RLock()
+	defer plugins.mutex.RUnlock()
+	if p, ok := plugins.plugins[snapshotter]; ok {
+		return p.Layout(mounts)
+	}
+	for _, name := range plugins.order {
+		if layout, ok := plugins.plugins[name].Layout(mounts); ok {
+			return layout, true
+		}
+	}
+	return Layout{}, false
+}
+
+func init() {
+	overlay := PluginFunc(overlayLayout)
+	Register("overlayfs", overlay)
+	Register("fuse-overlayfs", overlay)
+	// nydus mounts the rootfs as overlay (or fuse.nydus-overlayfs) with a
+	// regular upperdir on the host
+	Register("nydus", overlay)
+	Register("native", PluginFunc(bindLayout))
+	Register("erofs", PluginFunc(erofsLayout))
+}
+
+// overlayLayout handles kernel overlay mounts and FUSE overlay variants such
+// as "fuse3.fuse-overlayfs" and "fuse.nydus-overlayfs", which carry the same
+// upperdir/workdir/lowerdir options.
+func overlayLayout(mounts []mount.Mount) (Layout, bool) {
+	var layout Layout
+	for _, m := range mounts {
+		typ := mountType(m.Type)
+		if typ != "overlay" && !strings.HasSuffix(typ, "overlayfs") {
+			continue
+		}
+		layout.Type = typ
+		upper, work, lower := ParseOverlayOptions(m.Options)
+		if upper != "" {
+			layout.Upperdir = upper
+		}
+		if work != "" {
+			layout.Workdir = work
+		}
+		if len(lower) > 0 {
+			layout.Lowerdirs = lower
+		}
+	}
+	return layout, layout.Upperdir != ""
+}
+
+// erofsLayout handles the erofs snapshotter. Layers are erofs images that are
+// mounted read-only (type "erofs", source the layer blob), and the container's
+// writable layer is a plain directory used as overlay upperdir. The overlay
+// options list lowerdir first and may use one lowerdir+= per layer. Where the
+// mount manager is in use a lowerdir can be a "{{ mount N }}" template
+// referring to an earlier mount in the list, and is reported as that mount's
+// source (the layer image). A container without parent layers gets a single
+// bind mount of its upperdir.
+func erofsLayout(mounts []mount.Mount) (Layout, bool) {
+	if layout, ok := bindLayout(mounts); ok {
+		return layout, true
+	}
+	layout, ok := overlayLayout(mounts)
+	if !ok {
+		return Layout{}, false
+	}
+	for i, dir := range layout.Lowerdirs {
+		var n int
+		if _, err := fmt.Sscanf(dir, "{{ mount %d }}", &n); err == nil && n >= 0 && n < len(mounts) {
+			layout.Lowerdirs[i] = mounts[n].Source
+		}
+	}
+	return layout, true
+}
+
+// bindLayout handles snapshotters (native, btrfs-like) whose active snapshot
+// is a single bind mount of a writable directory.
+func bindLayout(mounts []mount.Mount) (Layout, bool) {
+	if len(mounts) != 1 || mounts[0].Type != "bind" {
+		return Layout{}, false
+	}
+	return Layout{Type: "bind", Upperdir: mounts[0].Source}, true
+}
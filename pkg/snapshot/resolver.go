@@ -0,0 +1,232 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/mount"
+	"go.uber.org/zap"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/mounts"
+)
+
+// MountInfo describes the rootfs mounts of a container's snapshot.
+type MountInfo struct {
+	ContainerID string   `json:"container_id"`
+	Snapshotter string   `json:"snapshotter"`
+	SnapshotKey string   `json:"snapshot_key"`
+	Type        string   `json:"type"`
+	Upperdir    string   `json:"upperdir,omitempty"`
+	Workdir     string   `json:"workdir,omitempty"`
+	Lowerdirs   []string `json:"lowerdirs,omitempty"`
+	BackingFS   string   `json:"backing_fs,omitempty"`
+	// BackingMount is the host mount holding the upperdir.
+	BackingMount *mounts.Mount `json:"backing_mount,omitempty"`
+	Mounts       []mount.Mount `json:"mounts"`
+}
+
+// SnapshotFunc returns the snapshotter and snapshot key of a container; ctx
+// carries its namespace.
+type SnapshotFunc func(ctx context.Context, containerID string) (snapshotter, key string, err error)
+
+// MountResolver inspects container snapshot mounts through containerd.
+type MountResolver struct {
+	client   *containerd.Client
+	snapshot SnapshotFunc
+}
+
+// NewMountResolver creates a resolver backed by a containerd client.
+func NewMountResolver(client *containerd.Client) *MountResolver {
+	return NewCachedMountResolver(client, func(ctx context.Context, containerID string) (string, string, error) {
+		info, err := client.ContainerService().Get(ctx, containerID)
+		if err != nil {
+			return "", "", err
+		}
+		return info.Snapshotter, info.SnapshotKey, nil
+	})
+}
+
+// NewCachedMountResolver creates a resolver that looks up container records
+// with snapshot, e.g. from a metadata cache, and asks the snapshotter for the
+// mounts on every call.
+func NewCachedMountResolver(client *containerd.Client, snapshot SnapshotFunc) *MountResolver {
+	return &MountResolver{client: client, snapshot: snapshot}
+}
+
+// Resolve looks up the container, asks its snapshotter for the rootfs mounts
+// and parses the overlay layout and backing filesystem out of them.
+func (r *MountResolver) Resolve(ctx context.Context, containerID string) (*MountInfo, error) {
+	snapshotterName, key, err := r.snapshot(ctx, containerID)
+	if err != nil {
+		log.Error("Failed to load container", zap.String("containerID", containerID), zap.Error(err))
+		return nil, err
+	}
+
+	snapshotter := r.client.SnapshotService(snapshotterName)
+	mounts, err := snapshotter.Mounts(ctx, key)
+	if err != nil {
+		log.Error("Failed to get snapshot mounts", zap.String("containerID", containerID), zap.Error(err))
+		return nil, err
+	}
+
+	mi := ParseSnapshotMounts(snapshotterName, mounts)
+	mi.ContainerID = containerID
+	mi.Snapshotter = snapshotterName
+	mi.SnapshotKey = key
+	return mi, nil
+}
+
+// Upperdir returns the writable layer directory of the container.
+func (r *MountResolver) Upperdir(ctx context.Context, containerID string) (string, error) {
+	mi, err := r.Resolve(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if mi.Upperdir == "" {
+		return "", fmt.Errorf("upperdir not found for container %s", containerID)
+	}
+	return mi.Upperdir, nil
+}
+
+// Workdir returns the overlay workdir of the container.
+func (r *MountResolver) Workdir(ctx context.Context, containerID string) (string, error) {
+	mi, err := r.Resolve(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if mi.Workdir == "" {
+		return "", fmt.Errorf("workdir not found for container %s", containerID)
+	}
+	return mi.Workdir, nil
+}
+
+// Lowerdirs returns the read-only layers of the container, top-most first.
+func (r *MountResolver) Lowerdirs(ctx context.Context, containerID string) ([]string, error) {
+	mi, err := r.Resolve(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return mi.Lowerdirs, nil
+}
+
+// Snapshotter returns the snapshotter name the container uses.
+func (r *MountResolver) Snapshotter(ctx context.Context, containerID string) (string, error) {
+	snapshotter, _, err := r.snapshot(ctx, containerID)
+	return snapshotter, err
+}
+
+// BackingFilesystem returns the filesystem type holding the writable layer.
+func (r *MountResolver) BackingFilesystem(ctx context.Context, containerID string) (string, error) {
+	mi, err := r.Resolve(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if mi.BackingFS == "" {
+		return "", fmt.Errorf("backing filesystem unknown for container %s", containerID)
+	}
+	return mi.BackingFS, nil
+}
+
+// ParseMounts extracts the writable layout from a list of mounts of an unknown
+// snapshotter by probing the registered plugins. The backing filesystem is
+// detected via statfs.
+func ParseMounts(mounts []mount.Mount) *MountInfo {
+	return ParseSnapshotMounts("", mounts)
+}
+
+// ParseSnapshotMounts extracts the writable layout of mounts produced by the
+// named snapshotter using its registered plugin.
+func ParseSnapshotMounts(snapshotter string, mounts []mount.Mount) *MountInfo {
+	mi := &MountInfo{Mounts: mounts}
+	if len(mounts) > 0 {
+		mi.Type = mounts[0].Type
+	}
+	if layout, ok := lookupLayout(snapshotter, mounts); ok {
+		mi.Type = layout.Type
+		mi.Upperdir = layout.Upperdir
+		mi.Workdir = layout.Workdir
+		mi.Lowerdirs = layout.Lowerdirs
+	}
+	if mi.Upperdir != "" {
+		mi.BackingFS = FilesystemType(mi.Upperdir)
+		mi.BackingMount = backingMount(mi.Upperdir)
+	}
+	return mi
+}
+
+// backingMount returns the host mount holding dir, nil when the mount table
+// cannot be read.
+func backingMount(dir string) *mounts.Mount {
+	m, err := mounts.ForPath(dir)
+	if err != nil {
+		return nil
+	}
+	return &m
+}
+
+// ParseOverlayOptions returns upperdir, workdir and lowerdirs from overlay
+// mount options in any order. Lower layers may be given as one colon
+// separated lowerdir= option or, as the erofs snapshotter does on kernels
+// with the new mount API, as one lowerdir+= option per layer, top-most first.
+// Data-only layers (datadir+=) are not part of the stack and are skipped.
+func ParseOverlayOptions(options []string) (string, string, []string) {
+	var upper, work string
+	var lower []string
+	for _, opt := range options {
+		switch {
+		case strings.HasPrefix(opt, "upperdir="):
+			upper = strings.TrimPrefix(opt, "upperdir=")
+		case strings.HasPrefix(opt, "workdir="):
+			work = strings.TrimPrefix(opt, "workdir=")
+		case strings.HasPrefix(opt, "lowerdir+="):
+			lower = append(lower, strings.TrimPrefix(opt, "lowerdir+="))
+		case strings.HasPrefix(opt, "lowerdir="):
+			lower = strings.Split(strings.TrimPrefix(opt, "lowerdir="), ":")
+		}
+	}
+	return upper, work, lower
+}
+
+// mountType strips the "format/" and "mkdir/" transformer prefixes that
+// containerd's mount manager puts in front of mount types, e.g.
+// "format/mkdir/overlay".
+func mountType(t string) string {
+	for {
+		switch {
+		case strings.HasPrefix(t, "format/"):
+			t = strings.TrimPrefix(t, "format/")
+		case strings.HasPrefix(t, "mkdir/"):
+			t = strings.TrimPrefix(t, "mkdir/")
+		default:
+			return t
+		}
+	}
+}
+
+// filesystem magic numbers from statfs(2)
+var fsMagic = map[int64]string{
+	0x58465342: "xfs",
+	0xef53:     "ext4",
+	0x9123683e: "btrfs",
+	0x01021994: "tmpfs",
+	0x794c7630: "overlay",
+	0x2fc12fc1: "zfs",
+	0x65735546: "fuse",
+	0xe0f5e1e2: "erofs",
+}
+
+// FilesystemType returns the filesystem type name of path, or an empty string.
+func FilesystemType(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	if name, ok := fsMagic[int64(st.Type)]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", st.Type)
+}
@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/containerd/containerd/mount"
+)
+
+func TestParseOverlayOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		upper   string
+		work    string
+		lower   []string
+	}{
+		{
+			name:    "colon separated lowerdir",
+			options: []string{"index=off", "workdir=/s/2/work", "upperdir=/s/2/fs", "lowerdir=/s/1/fs:/s/0/fs"},
+			upper:   "/s/2/fs",
+			work:    "/s/2/work",
+			lower:   []string{"/s/1/fs", "/s/0/fs"},
+		},
+		{
+			name:    "one lowerdir+= per layer",
+			options: []string{"lowerdir+=/s/1/fs", "lowerdir+=/s/0/fs", "datadir+=/s/data", "upperdir=/s/2/fs", "workdir=/s/2/work"},
+			upper:   "/s/2/fs",
+			work:    "/s/2/work",
+			lower:   []string{"/s/1/fs", "/s/0/fs"},
+		},
+		{
+			name:    "read-only view",
+			options: []string{"ro", "lowerdir=/s/0/fs"},
+			lower:   []string{"/s/0/fs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upper, work, lower := ParseOverlayOptions(tt.options)
+			if upper != tt.upper || work != tt.work || !reflect.DeepEqual(lower, tt.lower) {
+				t.Errorf("ParseOverlayOptions() = %q, %q, %q, want %q, %q, %q", upper, work, lower, tt.upper, tt.work, tt.lower)
+			}
+		})
+	}
+}
+
+func TestMountType(t *testing.T) {
+	tests := map[string]string{
+		"overlay":                     "overlay",
+		"format/overlay":              "overlay",
+		"mkdir/overlay":               "overlay",
+		"format/mkdir/overlay":        "overlay",
+		"mkdir/format/erofs":          "erofs",
+		"fuse3.fuse-overlayfs":        "fuse3.fuse-overlayfs",
+		"format/fuse.nydus-overlayfs": "fuse.nydus-overlayfs",
+	}
+	for in, want := range tests {
+		if got := mountType(in); got != want {
+			t.Errorf("mountType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSnapshotLayouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		snapshotter string
+		mounts      []mount.Mount
+		want        Layout
+		ok          bool
+	}{
+		{
+			name:        "overlayfs",
+			snapshotter: "overlayfs",
+			mounts: []mount.Mount{{
+				Type:    "overlay",
+				Source:  "overlay",
+				Options: []string{"workdir=/s/2/work", "upperdir=/s/2/fs", "lowerdir=/s/1/fs:/s/0/fs"},
+			}},
+			want: Layout{Type: "overlay", Upperdir: "/s/2/fs", Workdir: "/s/2/work", Lowerdirs: []string{"/s/1/fs", "/s/0/fs"}},
+			ok:   true,
+		},
+		{
+			name:        "overlayfs behind mount manager prefixes",
+			snapshotter: "overlayfs",
+			mounts: []mount.Mount{{
+				Type:    "format/mkdir/overlay",
+				Source:  "overlay",
+				Options: []string{"upperdir=/s/2/fs", "workdir=/s/2/work", "lowerdir+=/s/1/fs"},
+			}},
+			want: Layout{Type: "overlay", Upperdir: "/s/2/fs", Workdir: "/s/2/work", Lowerdirs: []string{"/s/1/fs"}},
+			ok:   true,
+		},
+		{
+			name:        "overlayfs read-only view",
+			snapshotter: "overlayfs",
+			mounts: []mount.Mount{{
+				Type:    "overlay",
+				Source:  "overlay",
+				Options: []string{"ro", "lowerdir=/s/1/fs:/s/0/fs"},
+			}},
+		},
+		{
+			name:        "erofs with templated lowerdirs",
+			snapshotter: "erofs",
+			mounts: []mount.Mount{
+				{Type: "erofs", Source: "/s/1/layer.erofs", Options: []string{"ro"}},
+				{Type: "erofs", Source: "/s/0/layer.erofs", Options: []string{"ro"}},
+				{
+					Type:    "format/mkdir/overlay",
+					Source:  "overlay",
+					Options: []string{"lowerdir+={{ mount 0 }}", "lowerdir+={{ mount 1 }}", "upperdir=/s/2/fs", "workdir=/s/2/work"},
+				},
+			},
+			want: Layout{Type: "overlay", Upperdir: "/s/2/fs", Workdir: "/s/2/work", Lowerdirs: []string{"/s/1/layer.erofs", "/s/0/layer.erofs"}},
+			ok:   true,
+		},
+		{
+			name:        "erofs template out of range is kept",
+			snapshotter: "erofs",
+			mounts: []mount.Mount{{
+				Type:    "overlay",
+				Source:  "overlay",
+				Options: []string{"lowerdir={{ mount 5 }}", "upperdir=/s/2/fs", "workdir=/s/2/work"},
+			}},
+			want: Layout{Type: "overlay", Upperdir: "/s/2/fs", Workdir: "/s/2/work", Lowerdirs: []string{"{{ mount 5 }}"}},
+			ok:   true,
+		},
+		{
+			name:        "erofs single layer bind",
+			snapshotter: "erofs",
+			mounts:      []mount.Mount{{Type: "bind", Source: "/s/1/fs", Options: []string{"rbind", "rw"}}},
+			want:        Layout{Type: "bind", Upperdir: "/s/1/fs"},
+			ok:          true,
+		},
+		{
+			name:        "native bind",
+			snapshotter: "native",
+			mounts:      []mount.Mount{{Type: "bind", Source: "/s/3", Options: []string{"rbind", "rw"}}},
+			want:        Layout{Type: "bind", Upperdir: "/s/3"},
+			ok:          true,
+		},
+		{
+			name:        "native with more than one mount",
+			snapshotter: "native",
+			mounts: []mount.Mount{
+				{Type: "bind", Source: "/s/3"},
+				{Type: "bind", Source: "/s/4"},
+			},
+		},
+		{
+			name:   "unknown snapshotter probes plugins",
+			mounts: []mount.Mount{{Type: "fuse3.fuse-overlayfs", Options: []string{"upperdir=/s/2/fs", "workdir=/s/2/work", "lowerdir=/s/1/fs"}}},
+			want:   Layout{Type: "fuse3.fuse-overlayfs", Upperdir: "/s/2/fs", Workdir: "/s/2/work", Lowerdirs: []string{"/s/1/fs"}},
+			ok:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupLayout(tt.snapshotter, tt.mounts)
+			if ok != tt.ok {
+				t.Fatalf("lookupLayout() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupLayout() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
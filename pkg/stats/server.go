@@ -0,0 +1,216 @@
+// Package stats 以 gRPC 提供与 CRI 文件系统统计结构一致的容器可写层用量，数据直接取自项目配额计数器，
+// 监控代理无需像 cadvisor 那样遍历目录
+package stats
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"RootfsQuota/pkg/api"
+	"RootfsQuota/pkg/listener"
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/mounts"
+	statsv1 "RootfsQuota/pkg/stats/v1"
+	"RootfsQuota/pkg/xfs"
+)
+
+// Container 为一个已管理容器可写层的实时用量
+type Container struct {
+	ID        string
+	Namespace string
+	Labels    map[string]string
+	// Group 为共享项目的 Pod 或命名空间组，此时用量与限额为整个组的
+	Group    string
+	Upperdir string
+	Usage    xfs.ProjectUsage
+}
+
+// Source 为统计数据来源，由 handler 实现
+type Source interface {
+	// ContainerStats 返回容器的实时用量，未被管理时返回 api.ErrNotFound
+	ContainerStats(ctx context.Context, containerID string) (Container, error)
+	// ListContainerStats 返回命名空间内已管理容器的实时用量，namespace 为空时返回所有命名空间
+	ListContainerStats(ctx context.Context, namespace string) ([]Container, error)
+	// ContainerFilesystems 返回已管理容器可写层所在的目录
+	ContainerFilesystems() []string
+}
+
+// Server 为文件系统统计 gRPC 服务
+type Server struct {
+	statsv1.UnimplementedStatsServiceServer
+
+	addr        string
+	imageFsPath string
+	source      Source
+}
+
+// NewServer 创建统计服务，imageFsPath 为镜像存储所在的目录
+func NewServer(addr, imageFsPath string, source Source) *Server {
+	return &Server{addr: addr, imageFsPath: imageFsPath, source: source}
+}
+
+// Serve 监听并处理请求，直到 ctx 结束
+func (s *Server) Serve(ctx context.Context) error {
+	lis, err := listener.Listen(s.addr)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	statsv1.RegisterStatsServiceServer(gs, s)
+	// 注册反射服务，grpcurl 等工具无需 .proto 文件即可调用
+	reflection.Register(gs)
+	go func() {
+		<-ctx.Done()
+		gs.Stop()
+	}()
+	log.Info("Serving stats API", zap.String("addr", s.addr))
+	return gs.Serve(lis)
+}
+
+// ImageFsInfo 返回镜像存储与容器可写层所在文件系统的用量
+func (s *Server) ImageFsInfo(ctx context.Context, req *statsv1.ImageFsInfoRequest) (*statsv1.ImageFsInfoResponse, error) {
+	table, err := mounts.Load()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read mount table: %v", err)
+	}
+	now := time.Now().UnixNano()
+	resp := &statsv1.ImageFsInfoResponse{}
+	if fs, err := filesystemUsage(now, table, s.imageFsPath); err == nil {
+		resp.ImageFilesystems = append(resp.ImageFilesystems, fs)
+	} else {
+		log.Debug("Failed to stat image filesystem", zap.String("path", s.imageFsPath), zap.Error(err))
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range s.source.ContainerFilesystems() {
+		fs, err := filesystemUsage(now, table, path)
+		if err != nil {
+			log.Debug("Failed to stat container filesystem", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		if seen[fs.FsId.Mountpoint] {
+			continue
+		}
+		seen[fs.FsId.Mountpoint] = true
+		resp.ContainerFilesystems = append(resp.ContainerFilesystems, fs)
+	}
+	return resp, nil
+}
+
+// filesystemUsage 返回 path 所在文件系统整体的已用空间与 inode
+func filesystemUsage(now int64, table []mounts.Mount, path string) (*statsv1.FilesystemUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, err
+	}
+	mountpoint := path
+	if m, ok := mounts.Find(table, path); ok {
+		mountpoint = m.Mountpoint
+	}
+	return &statsv1.FilesystemUsage{
+		Timestamp:  now,
+		FsId:       &statsv1.FilesystemIdentifier{Mountpoint: mountpoint},
+		UsedBytes:  &statsv1.UInt64Value{Value: (st.Blocks - st.Bfree) * uint64(st.Bsize)},
+		InodesUsed: &statsv1.UInt64Value{Value: st.Files - st.Ffree},
+	}, nil
+}
+
+// ContainerStats 返回单个容器可写层的用量
+func (s *Server) ContainerStats(ctx context.Context, req *statsv1.ContainerStatsRequest) (*statsv1.ContainerStatsResponse, error) {
+	if req.ContainerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "container_id is required")
+	}
+	c, err := s.source.ContainerStats(ctx, req.ContainerId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	table, _ := mounts.Load()
+	return &statsv1.ContainerStatsResponse{Stats: containerStats(time.Now().UnixNano(), table, c)}, nil
+}
+
+// ListContainerStats 返回满足过滤条件的已管理容器可写层的用量
+func (s *Server) ListContainerStats(ctx context.Context, req *statsv1.ListContainerStatsRequest) (*statsv1.ListContainerStatsResponse, error) {
+	filter := req.Filter
+	if filter == nil {
+		filter = &statsv1.ContainerStatsFilter{}
+	}
+	var containers []Container
+	if filter.Id != "" {
+		c, err := s.source.ContainerStats(ctx, filter.Id)
+		if errors.Is(err, api.ErrNotFound) {
+			return &statsv1.ListContainerStatsResponse{}, nil
+		}
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		containers = append(containers, c)
+	} else {
+		var err error
+		if containers, err = s.source.ListContainerStats(ctx, filter.Namespace); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+
+	table, _ := mounts.Load()
+	now := time.Now().UnixNano()
+	resp := &statsv1.ListContainerStatsResponse{}
+	for _, c := range containers {
+		if filter.Namespace != "" && c.Namespace != filter.Namespace {
+			continue
+		}
+		if !matchLabels(c.Labels, filter.LabelSelector) {
+			continue
+		}
+		resp.Stats = append(resp.Stats, containerStats(now, table, c))
+	}
+	return resp, nil
+}
+
+// matchLabels 判断 labels 是否包含 selector 中的所有键值，与 CRI 的 label_selector 语义一致
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func containerStats(now int64, table []mounts.Mount, c Container) *statsv1.ContainerStats {
+	mountpoint := c.Upperdir
+	if m, ok := mounts.Find(table, c.Upperdir); ok {
+		mountpoint = m.Mountpoint
+	}
+	return &statsv1.ContainerStats{
+		Attributes: &statsv1.ContainerAttributes{
+			Id:        c.ID,
+			Labels:    c.Labels,
+			Namespace: c.Namespace,
+		},
+		WritableLayer: &statsv1.FilesystemUsage{
+			Timestamp:  now,
+			FsId:       &statsv1.FilesystemIdentifier{Mountpoint: mountpoint},
+			UsedBytes:  &statsv1.UInt64Value{Value: c.Usage.UsedBytes},
+			InodesUsed: &statsv1.UInt64Value{Value: c.Usage.UsedInodes},
+		},
+		SoftLimitBytes: c.Usage.SoftLimitBytes,
+		HardLimitBytes: c.Usage.HardLimitBytes,
+		ProjectId:      c.Usage.ProjectID,
+		Group:          c.Group,
+	}
+}
+
+func grpcError(err error) error {
+	if errors.Is(err, api.ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -132,3 +132,36 @@ func setProjectID(targetPath string, projectID uint32) error {
 
 	return nil
 }
+
+// setProjectIDFile - set the project id of an opened directory or regular
+// file, adding PROJINHERIT on directories; path is only used in errors
+func setProjectIDFile(f *os.File, path string, projectID uint32, isDir bool) error {
+	var fsx fsxattr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(fsGetXattr),
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return fmt.Errorf("failed to get projid for %q: %w", path, errno)
+	}
+	fsx.projectid = projectID
+	if isDir {
+		fsx.flags |= flagProjInhert
+	}
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(fsSetXattr),
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return fmt.Errorf("failed to set projid for %q: %w", path, errno)
+	}
+	return nil
+}
+
+// getProjectIDFile - get the project id of an opened directory or regular
+// file and whether PROJINHERIT is set on it; path is only used in errors
+func getProjectIDFile(f *os.File, path string) (uint32, bool, error) {
+	var fsx fsxattr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(fsGetXattr),
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return 0, false, fmt.Errorf("failed to get projid for %q: %w", path, errno)
+	}
+	return fsx.projectid, fsx.flags&flagProjInhert != 0, nil
+}
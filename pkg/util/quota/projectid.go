@@ -0,0 +1,194 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// GetProjectID - get the project id of path on xfs via FS_IOC_FSGETXATTR
+func GetProjectID(targetPath string) (uint32, error) {
+	return getProjectID(targetPath)
+}
+
+// SetProjectIDRecursive - set the project id of root and of every directory
+// and regular file below it via FS_IOC_FSSETXATTR, like `xfs_quota -x -c
+// 'project -s'` but without building a command string from the path.
+// Symlinks, devices, fifos and sockets are skipped. Entries are opened
+// relative to their parent directory, so trees nested deeper than PATH_MAX
+// are tagged in full. The walk stops early when ctx is done, leaving the tree
+// partially tagged.
+func SetProjectIDRecursive(ctx context.Context, root string, projectID uint32) error {
+	return walkTree(ctx, root, func(path string, f *os.File, isDir bool) error {
+		return setProjectIDFile(f, path, projectID, isDir)
+	})
+}
+
+// CheckProjectIDRecursive - walk root like SetProjectIDRecursive and count the
+// directories and regular files whose project id differs from projectID, or
+// directories missing PROJINHERIT (new files below them would not inherit the
+// id). At most maxEntries entries are examined when maxEntries > 0. It returns
+// the number examined, the number mismatched and up to sampleSize of the
+// mismatched paths.
+func CheckProjectIDRecursive(ctx context.Context, root string, projectID uint32, maxEntries, sampleSize int) (int, int, []string, error) {
+	var scanned, mismatched int
+	var samples []string
+	errLimit := errors.New("entry limit reached")
+	err := walkTree(ctx, root, func(path string, f *os.File, isDir bool) error {
+		if maxEntries > 0 && scanned >= maxEntries {
+			return errLimit
+		}
+		scanned++
+		id, inherit, err := getProjectIDFile(f, path)
+		if err != nil {
+			return err
+		}
+		if id != projectID || (isDir && !inherit) {
+			mismatched++
+			if len(samples) < sampleSize {
+				samples = append(samples, path)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errLimit) {
+		err = nil
+	}
+	return scanned, mismatched, samples, err
+}
+
+// walkFunc is called by walkTree with each entry opened read-only without
+// following symlinks; path is the entry's full path, which may be longer
+// than PATH_MAX and must not be passed to the kernel.
+type walkFunc func(path string, f *os.File, isDir bool) error
+
+// openFlags opens an entry for FS_IOC_FSGETXATTR without following symlinks
+// or blocking on fifos swapped in while walking.
+const openFlags = syscall.O_RDONLY | syscall.O_NOFOLLOW | syscall.O_NONBLOCK | syscall.O_CLOEXEC
+
+// walkTree - call fn for root and for every directory and regular file below
+// it, parents before children and siblings in lexical order. Each entry is
+// opened with openat relative to its parent directory, so no path handed to
+// the kernel is longer than one name. Like nftw(FTW_PHYS|FTW_MOUNT) used by
+// `xfs_quota project -s`, the walk stays on root's mount: mount points below
+// it (nested rootfs mounts, and bind mounts even of the same filesystem, such
+// as other containers' upperdirs in a Kata shared dir) are skipped along with
+// everything below them. Entries removed while walking are skipped, a root
+// that is neither a directory nor a regular file is ignored.
+func walkTree(ctx context.Context, root string, fn walkFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	st, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() && !st.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.OpenFile(root, openFlags, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	mnt, err := fileMount(f)
+	if err != nil {
+		return &fs.PathError{Op: "statx", Path: root, Err: err}
+	}
+	if err := fn(root, f, st.IsDir()); err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		return nil
+	}
+	return walkDir(ctx, f, root, mnt, fn)
+}
+
+// mountID identifies the mount a file lives on. mnt is the kernel's mount ID
+// (Linux 5.8+), which tells bind mounts of one filesystem apart; it is 0 on
+// older kernels, where only the device is compared.
+type mountID struct {
+	dev uint64
+	mnt uint64
+}
+
+// fileMount returns the mount an opened file lives on.
+func fileMount(f *os.File) (mountID, error) {
+	var stx unix.Statx_t
+	if err := unix.Statx(int(f.Fd()), "", unix.AT_EMPTY_PATH, unix.STATX_MNT_ID, &stx); err != nil {
+		return mountID{}, err
+	}
+	id := mountID{dev: unix.Mkdev(stx.Dev_major, stx.Dev_minor)}
+	if stx.Mask&unix.STATX_MNT_ID != 0 {
+		id.mnt = stx.Mnt_id
+	}
+	return id, nil
+}
+
+func walkDir(ctx context.Context, dir *os.File, path string, mnt mountID, fn walkFunc) error {
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %q: %w", path, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, d := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			continue
+		}
+		child := filepath.Join(path, d.Name())
+		fd, err := syscall.Openat(int(dir.Fd()), d.Name(), openFlags, 0)
+		if err != nil {
+			// files removed while walking are not an error
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return &fs.PathError{Op: "openat", Path: child, Err: err}
+		}
+		f := os.NewFile(uintptr(fd), child)
+		childMnt, err := fileMount(f)
+		if err != nil {
+			f.Close()
+			return &fs.PathError{Op: "statx", Path: child, Err: err}
+		}
+		if childMnt != mnt {
+			// a mount point, whose files belong to another container or filesystem
+			f.Close()
+			continue
+		}
+		err = fn(child, f, d.IsDir())
+		if err == nil && d.IsDir() {
+			err = walkDir(ctx, f, child, mnt, fn)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
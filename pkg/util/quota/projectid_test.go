@@ -0,0 +1,295 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// mkdirDeep creates a chain of directories named name below root with
+// mkdirat/openat, so the chain may be longer than PATH_MAX, and returns the
+// full path of the deepest one.
+func mkdirDeep(t *testing.T, root, name string, depth int) string {
+	t.Helper()
+	fd, err := syscall.Open(root, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := root
+	for i := 0; i < depth; i++ {
+		if err := syscall.Mkdirat(fd, name, 0o755); err != nil {
+			t.Fatalf("mkdirat at depth %d: %v", i, err)
+		}
+		next, err := syscall.Openat(fd, name, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+		syscall.Close(fd)
+		if err != nil {
+			t.Fatalf("openat at depth %d: %v", i, err)
+		}
+		fd = next
+		path = filepath.Join(path, name)
+	}
+	syscall.Close(fd)
+	return path
+}
+
+func walkPaths(t *testing.T, root string) []string {
+	t.Helper()
+	var paths []string
+	err := walkTree(context.Background(), root, func(path string, f *os.File, isDir bool) error {
+		paths = append(paths, strings.TrimPrefix(path, root))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkTree(): %v", err)
+	}
+	return paths
+}
+
+func TestWalkTreeNames(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"with space", "with space/$(touch x)", "semi;colon", "quote'\"`"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"with space/a b", "semi;colon/*", "quote'\"`/|&>"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// symlinks and fifos are skipped, the symlink target is not followed
+	if err := os.Symlink("/", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(root, "fifo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"",
+		"/quote'\"`",
+		"/quote'\"`/|&>",
+		"/semi;colon",
+		"/semi;colon/*",
+		"/with space",
+		"/with space/$(touch x)",
+		"/with space/a b",
+	}
+	if got := walkPaths(t, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("walkTree() visited %q, want %q", got, want)
+	}
+}
+
+func TestWalkTreeBeyondPathMax(t *testing.T) {
+	root := t.TempDir()
+	name := strings.Repeat("d", 200)
+	deepest := mkdirDeep(t, root, name, 30)
+	if len(deepest) < 4096 {
+		t.Fatalf("test tree is only %d bytes deep", len(deepest))
+	}
+
+	paths := walkPaths(t, root)
+	if len(paths) != 31 {
+		t.Fatalf("walkTree() visited %d entries, want 31", len(paths))
+	}
+	if got := root + paths[len(paths)-1]; got != deepest {
+		t.Errorf("walkTree() deepest entry %.64s..., want %.64s...", got, deepest)
+	}
+}
+
+func TestWalkTreeStopsOnError(t *testing.T) {
+	root := t.TempDir()
+	mkdirDeep(t, root, "d", 5)
+	errStop := errors.New("stop")
+	var visited int
+	err := walkTree(context.Background(), root, func(path string, f *os.File, isDir bool) error {
+		if visited++; visited == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || visited != 3 {
+		t.Errorf("walkTree() = %v after %d entries, want %v after 3", err, visited, errStop)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := walkTree(ctx, root, func(string, *os.File, bool) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("walkTree() with canceled ctx = %v, want %v", err, context.Canceled)
+	}
+}
+
+// TestWalkTreeStaysOnFilesystem mounts a tmpfs below the root and needs
+// CAP_SYS_ADMIN; it is skipped elsewhere.
+func TestWalkTreeStaysOnFilesystem(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "mnt", "z"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mnt := filepath.Join(root, "mnt")
+	if err := syscall.Mount("tmpfs", mnt, "tmpfs", 0, "size=1m"); err != nil {
+		t.Skipf("cannot mount tmpfs here: %v", err)
+	}
+	t.Cleanup(func() { syscall.Unmount(mnt, syscall.MNT_DETACH) })
+	if err := os.MkdirAll(filepath.Join(mnt, "other/dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mnt, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"", "/a", "/z"}
+	if got := walkPaths(t, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("walkTree() visited %q, want %q", got, want)
+	}
+
+	// a bind mount of a directory on the root's own filesystem, as in a Kata
+	// shared dir, is skipped as well
+	bind := filepath.Join(root, "z")
+	if err := syscall.Mount(filepath.Join(root, "a"), bind, "", syscall.MS_BIND, ""); err != nil {
+		t.Fatalf("bind mount: %v", err)
+	}
+	t.Cleanup(func() { syscall.Unmount(bind, syscall.MNT_DETACH) })
+	f, err := os.Open(bind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := fileMount(f)
+	f.Close()
+	if err != nil || id.mnt == 0 {
+		t.Skipf("no mount IDs from statx here: %v", err)
+	}
+	want = []string{"", "/a"}
+	if got := walkPaths(t, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("walkTree() with a bind mount visited %q, want %q", got, want)
+	}
+	// walking the mount itself covers its own filesystem
+	if got := len(walkPaths(t, mnt)); got != 4 {
+		t.Errorf("walkTree() of the mount visited %d entries, want 4", got)
+	}
+}
+
+// TestSetProjectIDRecursive needs a filesystem with project quota support
+// (xfs, or ext4 with the project feature) and CAP_SYS_ADMIN; it is skipped
+// elsewhere.
+func TestSetProjectIDRecursive(t *testing.T) {
+	root := t.TempDir()
+	if deepest := mkdirDeep(t, root, strings.Repeat("d", 200), 25); len(deepest) < 4096 {
+		t.Fatalf("test tree is only %d bytes deep", len(deepest))
+	}
+	if err := os.Mkdir(filepath.Join(root, "a $(b)"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	const projectID = 4242
+	if err := SetProjectIDRecursive(context.Background(), root, projectID); err != nil {
+		if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOTTY) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) {
+			t.Skipf("project ids not supported here: %v", err)
+		}
+		t.Fatalf("SetProjectIDRecursive(): %v", err)
+	}
+
+	scanned, mismatched, samples, err := CheckProjectIDRecursive(context.Background(), root, projectID, 0, 5)
+	if err != nil {
+		t.Fatalf("CheckProjectIDRecursive(): %v", err)
+	}
+	if scanned != 27 || mismatched != 0 {
+		t.Errorf("CheckProjectIDRecursive() = %d scanned, %d mismatched %q, want 27, 0", scanned, mismatched, samples)
+	}
+}
+
+// fsxattrTree creates a tree of 4 directories (root included) and 3 regular
+// files and returns the project id it carries. New entries copy the parent's
+// project id, so the tree is uniform; none has PROJINHERIT. It skips the test
+// when the filesystem has no FS_IOC_FSGETXATTR or already sets PROJINHERIT.
+func fsxattrTree(t *testing.T) (string, uint32) {
+	t.Helper()
+	root := t.TempDir()
+	f, err := os.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, inherit, err := getProjectIDFile(f, root)
+	f.Close()
+	if err != nil {
+		t.Skipf("FS_IOC_FSGETXATTR not supported here: %v", err)
+	}
+	if inherit {
+		t.Skip("temp dir already has PROJINHERIT")
+	}
+	for _, dir := range []string{"a", "a/b", "c d"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"f", "a/f", "a/b/f"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	return root, id
+}
+
+func TestCheckProjectIDRecursive(t *testing.T) {
+	root, id := fsxattrTree(t)
+	tests := []struct {
+		name       string
+		projectID  uint32
+		maxEntries int
+		sampleSize int
+		scanned    int
+		mismatched int
+		samples    []string
+	}{
+		{
+			name:       "directories missing PROJINHERIT",
+			projectID:  id,
+			sampleSize: 10,
+			scanned:    7,
+			mismatched: 4,
+			samples:    []string{"", "/a", "/a/b", "/c d"},
+		},
+		{
+			name:       "other project id",
+			projectID:  id + 1,
+			sampleSize: 2,
+			scanned:    7,
+			mismatched: 7,
+			samples:    []string{"", "/a"},
+		},
+		{
+			name:       "entry cap",
+			projectID:  id + 1,
+			maxEntries: 3,
+			sampleSize: 10,
+			scanned:    3,
+			mismatched: 3,
+			samples:    []string{"", "/a", "/a/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanned, mismatched, samples, err := CheckProjectIDRecursive(context.Background(), root, tt.projectID, tt.maxEntries, tt.sampleSize)
+			if err != nil {
+				t.Fatalf("CheckProjectIDRecursive(): %v", err)
+			}
+			for i := range samples {
+				samples[i] = strings.TrimPrefix(samples[i], root)
+			}
+			if scanned != tt.scanned || mismatched != tt.mismatched || !reflect.DeepEqual(samples, tt.samples) {
+				t.Errorf("CheckProjectIDRecursive() = %d, %d, %q, want %d, %d, %q",
+					scanned, mismatched, samples, tt.scanned, tt.mismatched, tt.samples)
+			}
+		})
+	}
+}
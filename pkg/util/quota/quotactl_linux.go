@@ -18,6 +18,7 @@ package quota
 
 import (
 	"fmt"
+	"math"
 	"syscall"
 	"unsafe"
 )
@@ -25,8 +26,12 @@ import (
 const (
 	DQUOT_VERSION = 0x1 //nolint
 
-	FS_DQ_BHARD = 0x8 //nolint
-	FS_DQ_BSOFT = 0x4 //nolint
+	FS_DQ_ISOFT   = 0x1  //nolint
+	FS_DQ_IHARD   = 0x2  //nolint
+	FS_DQ_BHARD   = 0x8  //nolint
+	FS_DQ_BSOFT   = 0x4  //nolint
+	FS_DQ_RTBSOFT = 0x10 //nolint
+	FS_DQ_RTBHARD = 0x20 //nolint
 )
 
 // type
@@ -48,6 +53,8 @@ const (
 	Q_XSETQLIM                      /* set disk limits */                //nolint
 	Q_XGETQSTAT                     /* get quota subsystem status */     //nolint
 	Q_XQUOTARM                      /* free disk space used by dquots */ //nolint
+
+	Q_XGETNEXTQUOTA quotaCmd = 9 /* get the next dquot at or after an ID */ //nolint
 )
 
 // reference: https://man7.org/linux/man-pages/man2/quotactl.2.html
@@ -117,3 +124,114 @@ func GetProjectQuota(backingFsBlockDev string, projectID uint32) (Size, error) {
 	}
 	return Size(fd.blkHardLimit) * 512, nil
 }
+
+// Limits selects the limits SetProjectLimits changes. Only the pairs whose
+// flag is set are written; byte values are rounded down to 512-byte basic
+// blocks and zero removes a limit.
+type Limits struct {
+	Blocks       bool
+	BlockSoft    uint64
+	BlockHard    uint64
+	Inodes       bool
+	InodeSoft    uint64
+	InodeHard    uint64
+	Realtime     bool
+	RealtimeSoft uint64
+	RealtimeHard uint64
+}
+
+// SetProjectLimits writes the selected limits of projectID on
+// backingFsBlockDev with a single Q_XSETQLIM, creating the dquot if needed.
+// The error wraps the errno.
+func SetProjectLimits(backingFsBlockDev string, projectID uint32, l Limits) error {
+	var fd = fsDiskQuota{}
+	fd.version = DQUOT_VERSION
+	fd.id = projectID
+	fd.flags = int8(XFS_PROJ_QUOTA)
+	if l.Blocks {
+		fd.fieldmask |= FS_DQ_BSOFT | FS_DQ_BHARD
+		fd.blkSoftLimit, fd.blkHardLimit = l.BlockSoft/512, l.BlockHard/512
+	}
+	if l.Inodes {
+		fd.fieldmask |= FS_DQ_ISOFT | FS_DQ_IHARD
+		fd.inoSoftLimit, fd.inoHardLimit = l.InodeSoft, l.InodeHard
+	}
+	if l.Realtime {
+		fd.fieldmask |= FS_DQ_RTBSOFT | FS_DQ_RTBHARD
+		fd.rtbSoftLimit, fd.rtbHardLimit = l.RealtimeSoft/512, l.RealtimeHard/512
+	}
+	devbyte := append([]byte(backingFsBlockDev), 0)
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(qcmd(Q_XSETQLIM, XFS_PROJ_QUOTA)),
+		uintptr(unsafe.Pointer(&devbyte[0])), uintptr(fd.id),
+		uintptr(unsafe.Pointer(&fd)), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to set quota limits for projid %d on %s: %w",
+			projectID, backingFsBlockDev, errno)
+	}
+	return nil
+}
+
+// ProjectUsage is the usage and limits of a project on one filesystem as
+// returned by Q_XGETQUOTA, converted from 512-byte basic blocks to bytes.
+type ProjectUsage struct {
+	UsedBytes      uint64
+	SoftLimitBytes uint64
+	HardLimitBytes uint64
+	UsedInodes     uint64
+	InodeSoftLimit uint64
+	InodeHardLimit uint64
+}
+
+// GetProjectUsage reads the dquot of projectID on backingFsBlockDev. The
+// error wraps syscall.ENOENT when the filesystem has no dquot for the
+// project.
+func GetProjectUsage(backingFsBlockDev string, projectID uint32) (ProjectUsage, error) {
+	var fd = fsDiskQuota{}
+	devbyte := append([]byte(backingFsBlockDev), 0)
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(qcmd(Q_XGETQUOTA, XFS_PROJ_QUOTA)),
+		uintptr(unsafe.Pointer(&devbyte[0])), uintptr(projectID),
+		uintptr(unsafe.Pointer(&fd)), 0, 0)
+	if errno != 0 {
+		return ProjectUsage{}, fmt.Errorf("failed to get quota for projid %d on %s: %w",
+			projectID, backingFsBlockDev, errno)
+	}
+	return fd.usage(), nil
+}
+
+// ListProjectUsage walks every project dquot on backingFsBlockDev with
+// Q_XGETNEXTQUOTA, one syscall per project and no xfs_quota process. The
+// error wraps the errno, EINVAL on kernels before 4.6 that lack the command.
+func ListProjectUsage(backingFsBlockDev string, fn func(projectID uint32, usage ProjectUsage)) error {
+	devbyte := append([]byte(backingFsBlockDev), 0)
+	for id := uint32(0); ; {
+		var fd = fsDiskQuota{}
+		_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(qcmd(Q_XGETNEXTQUOTA, XFS_PROJ_QUOTA)),
+			uintptr(unsafe.Pointer(&devbyte[0])), uintptr(id),
+			uintptr(unsafe.Pointer(&fd)), 0, 0)
+		if errno == syscall.ENOENT {
+			return nil
+		}
+		if errno != 0 {
+			return fmt.Errorf("failed to list quotas on %s: %w", backingFsBlockDev, errno)
+		}
+		fn(fd.id, fd.usage())
+		if fd.id == math.MaxUint32 {
+			return nil
+		}
+		id = fd.id + 1
+	}
+}
+
+// usage converts the dquot from 512-byte basic blocks to bytes.
+func (fd *fsDiskQuota) usage() ProjectUsage {
+	return ProjectUsage{
+		UsedBytes:      fd.bcount * 512,
+		SoftLimitBytes: fd.blkSoftLimit * 512,
+		HardLimitBytes: fd.blkHardLimit * 512,
+		UsedInodes:     fd.icount,
+		InodeSoftLimit: fd.inoSoftLimit,
+		InodeHardLimit: fd.inoHardLimit,
+	}
+}
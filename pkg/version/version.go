@@ -0,0 +1,5 @@
+// Package version 记录构建版本，发布构建时以 -ldflags "-X RootfsQuota/pkg/version.Version=v1.2.3" 设置
+package version
+
+// Version 为构建版本，未设置时为 dev
+var Version = "dev"
@@ -0,0 +1,64 @@
+package xfs
+
+import (
+	"context"
+	"sync"
+)
+
+type appliedLimit struct {
+	soft, hard uint64
+}
+
+// AppliedLimits remembers the limits last written for each project so that
+// reconciliation passes can skip xfs_quota calls that would not change
+// anything. The cache lives in memory only; after a restart the first write
+// of every project goes to the kernel again.
+type AppliedLimits struct {
+	mutex  sync.Mutex
+	limits map[uint32]appliedLimit
+}
+
+// NewAppliedLimits returns an empty cache.
+func NewAppliedLimits() *AppliedLimits {
+	return &AppliedLimits{limits: make(map[uint32]appliedLimit)}
+}
+
+// Apply sets the project limits through set unless the same limits were
+// already applied. force always writes them, e.g. when verification found the
+// kernel state to have drifted. It reports whether set was called.
+func (a *AppliedLimits) Apply(ctx context.Context, projid uint32, bsoft, bhard string, force bool,
+	set func(ctx context.Context, projid uint32, bsoft, bhard string) error) (bool, error) {
+	soft, err := ParseSize(bsoft)
+	if err != nil {
+		return false, err
+	}
+	hard, err := ParseSize(bhard)
+	if err != nil {
+		return false, err
+	}
+	want := appliedLimit{soft: soft, hard: hard}
+
+	a.mutex.Lock()
+	last, ok := a.limits[projid]
+	a.mutex.Unlock()
+	if ok && last == want && !force {
+		return false, nil
+	}
+
+	if err := set(ctx, projid, bsoft, bhard); err != nil {
+		a.Forget(projid)
+		return true, err
+	}
+
+	a.mutex.Lock()
+	a.limits[projid] = want
+	a.mutex.Unlock()
+	return true, nil
+}
+
+// Forget drops the cached limits of a project, e.g. after it was released.
+func (a *AppliedLimits) Forget(projid uint32) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.limits, projid)
+}
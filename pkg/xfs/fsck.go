@@ -0,0 +1,228 @@
+package xfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// 状态检查发现的问题类别
+const (
+	// FsckKeyMismatch 为条目键与 container_id 不一致
+	FsckKeyMismatch = "key_mismatch"
+	// FsckMissingProjectID 为条目没有项目 ID
+	FsckMissingProjectID = "missing_project_id"
+	// FsckEmptyEntry 为既没有 upperdir、额外目录，也没有分组成员的条目
+	FsckEmptyEntry = "empty_entry"
+	// FsckDuplicateUpperdir 为多个条目记录了同一个 upperdir
+	FsckDuplicateUpperdir = "duplicate_upperdir"
+	// FsckDuplicateProject 为多个独立条目使用了同一个项目 ID
+	FsckDuplicateProject = "duplicate_project_id"
+	// FsckIDOutOfRange 为项目 ID 不在配置的范围内
+	FsckIDOutOfRange = "id_out_of_range"
+	// FsckMissingGroup 为分组成员所属的分组条目不存在
+	FsckMissingGroup = "missing_group"
+	// FsckGroupMismatch 为分组成员的项目 ID 与分组条目不同
+	FsckGroupMismatch = "group_project_mismatch"
+	// FsckInvalidLimits 为限额无法解析
+	FsckInvalidLimits = "invalid_limits"
+)
+
+// 可自动执行的修复
+const (
+	FixRemoveEntry    = "remove_entry"
+	FixSetContainerID = "set_container_id"
+)
+
+// FsckFinding 为状态检查发现的一个问题
+type FsckFinding struct {
+	Key     string `json:"key"`
+	Problem string `json:"problem"`
+	Detail  string `json:"detail"`
+	// Fix 为可自动执行的修复，为空表示需要人工处理
+	Fix      string `json:"fix,omitempty"`
+	Repaired bool   `json:"repaired,omitempty"`
+}
+
+// FsckReport 为状态检查结果
+type FsckReport struct {
+	Entries  int           `json:"entries"`
+	Findings []FsckFinding `json:"findings"`
+	Repair   bool          `json:"repair"`
+	// Compacted 为截断了超长限额历史的条目数
+	Compacted int `json:"compacted"`
+	// BytesBefore、BytesAfter 为状态文件压缩前后的大小，内存状态为 0
+	BytesBefore int `json:"bytes_before,omitempty"`
+	BytesAfter  int `json:"bytes_after,omitempty"`
+}
+
+// Fsck 检查内存中的状态；repair 时执行可自动修复的问题并截断超长的限额历史后保存。
+// minID、maxID 为 0 或存在项目 ID 迁移时不检查 ID 范围
+func (m *StateManager) Fsck(minID, maxID uint32, repair bool) (FsckReport, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	report := FsckReport{Entries: len(m.state.Entries), Repair: repair}
+	report.Findings = checkState(&m.state, minID, maxID)
+	if !repair {
+		return report, nil
+	}
+	repairState(&m.state, report.Findings)
+	report.Compacted = compactState(&m.state)
+	m.generation++
+	return report, m.save()
+}
+
+// FsckFile 检查守护进程未运行时的状态文件，文件无法解析时返回错误；
+// repair 时修复、压缩后以原子替换的方式写回
+func FsckFile(path string, minID, maxID uint32, repair bool) (FsckReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FsckReport{}, fmt.Errorf("failed to read state file: %v", err)
+	}
+	state := State{Entries: make(map[string]Entry)}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return FsckReport{}, fmt.Errorf("failed to parse state file: %v", err)
+		}
+	}
+
+	report := FsckReport{Entries: len(state.Entries), Repair: repair, BytesBefore: len(data)}
+	report.Findings = checkState(&state, minID, maxID)
+	if !repair {
+		return report, nil
+	}
+	repairState(&state, report.Findings)
+	report.Compacted = compactState(&state)
+
+	out, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	tmp := path + ".fsck"
+	if err := os.WriteFile(tmp, out, 0644); err != nil {
+		return report, fmt.Errorf("failed to write state file: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return report, fmt.Errorf("failed to replace state file: %v", err)
+	}
+	report.BytesAfter = len(out)
+	return report, nil
+}
+
+// checkState 返回状态中的问题，按条目键排序
+func checkState(s *State, minID, maxID uint32) []FsckFinding {
+	keys := make([]string, 0, len(s.Entries))
+	members := make(map[string]int)
+	for key, entry := range s.Entries {
+		keys = append(keys, key)
+		if entry.Group != "" {
+			members[entry.Group]++
+		}
+	}
+	sort.Strings(keys)
+	checkRange := minID != 0 && maxID != 0 && s.Rebalance == nil
+
+	var findings []FsckFinding
+	add := func(key, problem, fix, format string, args ...any) {
+		findings = append(findings, FsckFinding{Key: key, Problem: problem, Fix: fix, Detail: fmt.Sprintf(format, args...)})
+	}
+	upperdirs := make(map[string]string)
+	projects := make(map[uint32]string)
+	for _, key := range keys {
+		entry := s.Entries[key]
+		if entry.ContainerID != key {
+			add(key, FsckKeyMismatch, FixSetContainerID, "container_id is %q", entry.ContainerID)
+		}
+		if entry.ProjectID == 0 {
+			add(key, FsckMissingProjectID, FixRemoveEntry, "entry has no project ID")
+			continue
+		}
+		if entry.Upperdir == "" && len(entry.Paths) == 0 && members[key] == 0 {
+			add(key, FsckEmptyEntry, FixRemoveEntry, "no upperdir, paths or group members")
+			continue
+		}
+		if checkRange && (entry.ProjectID < minID || entry.ProjectID > maxID) {
+			add(key, FsckIDOutOfRange, "", "project ID %d is outside %d-%d", entry.ProjectID, minID, maxID)
+		}
+		if entry.Upperdir != "" {
+			if other, ok := upperdirs[entry.Upperdir]; ok {
+				// 保留较新的条目，较旧的条目通常是错过删除事件的残留
+				older := key
+				if s.Entries[other].CreatedAt.Before(entry.CreatedAt) {
+					older, upperdirs[entry.Upperdir] = other, key
+				}
+				add(older, FsckDuplicateUpperdir, FixRemoveEntry, "upperdir %s is also recorded for %s", entry.Upperdir, upperdirs[entry.Upperdir])
+			} else {
+				upperdirs[entry.Upperdir] = key
+			}
+		}
+		if entry.Group != "" {
+			group, ok := s.Entries[entry.Group]
+			switch {
+			case !ok:
+				add(key, FsckMissingGroup, "", "group %s has no entry", entry.Group)
+			case group.ProjectID != entry.ProjectID:
+				// 只改记录的 ID 不会重新标记目录，须由守护进程在分组锁内移除并重新加入
+				add(key, FsckGroupMismatch, "", "project ID %d, group %s has %d", entry.ProjectID, entry.Group, group.ProjectID)
+			}
+		} else if other, ok := projects[entry.ProjectID]; ok {
+			add(key, FsckDuplicateProject, "", "project ID %d is also used by %s", entry.ProjectID, other)
+		} else {
+			projects[entry.ProjectID] = key
+		}
+		if !validLimits(entry.SoftLimit, entry.HardLimit) {
+			add(key, FsckInvalidLimits, "", "soft %q, hard %q", entry.SoftLimit, entry.HardLimit)
+		}
+	}
+	return findings
+}
+
+// validLimits 判断记录的限额可解析，未设置的限额视为有效
+func validLimits(soft, hard string) bool {
+	for _, limit := range []string{soft, hard} {
+		if limit == "" {
+			continue
+		}
+		if _, err := ParseSize(limit); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// repairState 执行 findings 中可自动修复的问题并标记 Repaired
+func repairState(s *State, findings []FsckFinding) {
+	for i := range findings {
+		f := &findings[i]
+		entry, ok := s.Entries[f.Key]
+		if !ok {
+			continue
+		}
+		switch f.Fix {
+		case FixRemoveEntry:
+			delete(s.Entries, f.Key)
+		case FixSetContainerID:
+			entry.ContainerID = f.Key
+			s.Entries[f.Key] = entry
+		default:
+			continue
+		}
+		f.Repaired = true
+	}
+}
+
+// compactState 截断超过 maxLimitHistory 的限额历史，返回截断的条目数
+func compactState(s *State) int {
+	compacted := 0
+	for key, entry := range s.Entries {
+		if n := len(entry.LimitHistory); n > maxLimitHistory {
+			entry.LimitHistory = append([]LimitRecord(nil), entry.LimitHistory[n-maxLimitHistory:]...)
+			s.Entries[key] = entry
+			compacted++
+		}
+	}
+	return compacted
+}
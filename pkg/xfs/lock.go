@@ -0,0 +1,47 @@
+package xfs
+
+import (
+	"sync"
+	"syscall"
+)
+
+// xfs_quota invocations are serialized so concurrent workers cannot
+// interleave project setup on the same filesystem. Commands without a path
+// (limit, report) act on every mounted XFS filesystem and take the global
+// lock exclusively; path based commands share it and lock their device.
+var (
+	globalQuotaLock sync.RWMutex
+
+	fsLocksMutex sync.Mutex
+	fsLocks      = make(map[uint64]*sync.Mutex)
+)
+
+// lockFilesystem locks the filesystem that holds path and returns the unlock
+// function. Paths that cannot be stat'ed fall back to the global lock.
+func lockFilesystem(path string) func() {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return lockAllFilesystems()
+	}
+
+	fsLocksMutex.Lock()
+	l, ok := fsLocks[uint64(st.Dev)]
+	if !ok {
+		l = &sync.Mutex{}
+		fsLocks[uint64(st.Dev)] = l
+	}
+	fsLocksMutex.Unlock()
+
+	globalQuotaLock.RLock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		globalQuotaLock.RUnlock()
+	}
+}
+
+// lockAllFilesystems takes the global lock exclusively.
+func lockAllFilesystems() func() {
+	globalQuotaLock.Lock()
+	return globalQuotaLock.Unlock
+}
@@ -0,0 +1,70 @@
+package xfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"RootfsQuota/pkg/util/quota"
+)
+
+// nestedSamples is how many mismatched paths a NestedTree keeps for logging.
+const nestedSamples = 5
+
+// nestedSnapshotDirs are where container engines running inside a container
+// keep their overlay layers, relative to the outer upperdir. Each child of
+// these directories is an inner snapshot whose diff/fs directory becomes the
+// upperdir of an inner overlay mount.
+var nestedSnapshotDirs = []string{
+	"var/lib/docker/overlay2",
+	"var/lib/docker/fuse-overlayfs",
+	"var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots",
+	"var/lib/containerd/io.containerd.snapshotter.v1.fuse-overlayfs/snapshots",
+	"var/lib/containers/storage/overlay",
+	"var/lib/rancher/k3s/agent/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots",
+}
+
+// NestedTree describes an inner engine's snapshot directory found under an
+// outer upperdir and how much of it carries the expected project ID.
+type NestedTree struct {
+	Root       string
+	Scanned    int
+	Mismatched int
+	// Samples holds a few of the mismatched paths.
+	Samples []string
+}
+
+// FindNestedSnapshotDirs returns the inner engines' snapshot directories that
+// exist under upperdir. The inner overlay mounts themselves only exist in the
+// container's mount namespace; from the host these are plain directories on
+// the outer upperdir and are accounted to the outer project like any file.
+func FindNestedSnapshotDirs(upperdir string) []string {
+	var dirs []string
+	for _, rel := range nestedSnapshotDirs {
+		dir := filepath.Join(upperdir, rel)
+		if fi, err := os.Lstat(dir); err == nil && fi.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// CheckNested walks every inner snapshot directory under upperdir and counts
+// the directories and regular files that do not carry projid, or directories
+// without PROJINHERIT. Files created by the inner engine normally inherit the
+// project ID; they end up mismatched when they were extracted before the outer
+// upperdir was tagged, or moved in from another project by a copy fallback
+// after rename returned EXDEV. At most maxEntries entries are examined per
+// tree when maxEntries > 0.
+func CheckNested(ctx context.Context, upperdir string, projid uint32, maxEntries int) ([]NestedTree, error) {
+	var trees []NestedTree
+	for _, root := range FindNestedSnapshotDirs(upperdir) {
+		scanned, mismatched, samples, err := quota.CheckProjectIDRecursive(ctx, root, projid, maxEntries, nestedSamples)
+		if err != nil {
+			return trees, fmt.Errorf("failed to check project id under %q: %w", root, err)
+		}
+		trees = append(trees, NestedTree{Root: root, Scanned: scanned, Mismatched: mismatched, Samples: samples})
+	}
+	return trees, nil
+}
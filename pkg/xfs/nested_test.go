@@ -0,0 +1,87 @@
+package xfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"RootfsQuota/pkg/util/quota"
+)
+
+func TestFindNestedSnapshotDirs(t *testing.T) {
+	upperdir := t.TempDir()
+	for _, dir := range []string{
+		"var/lib/docker/overlay2/l1/diff",
+		"var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots/1/fs",
+		"var/lib/containers/storage",
+		"elsewhere",
+	} {
+		if err := os.MkdirAll(filepath.Join(upperdir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a regular file or a symlink at a snapshot path is not an inner engine
+	if err := os.WriteFile(filepath.Join(upperdir, "var/lib/containers/storage/overlay"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(upperdir, "elsewhere"), filepath.Join(upperdir, "var/lib/docker/fuse-overlayfs")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(upperdir, "var/lib/docker/overlay2"),
+		filepath.Join(upperdir, "var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots"),
+	}
+	if got := FindNestedSnapshotDirs(upperdir); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNestedSnapshotDirs() = %q, want %q", got, want)
+	}
+	if got := FindNestedSnapshotDirs(t.TempDir()); got != nil {
+		t.Errorf("FindNestedSnapshotDirs() on an empty upperdir = %q, want none", got)
+	}
+}
+
+func TestCheckNested(t *testing.T) {
+	upperdir := t.TempDir()
+	id, err := quota.GetProjectID(upperdir)
+	if err != nil {
+		t.Skipf("FS_IOC_FSGETXATTR not supported here: %v", err)
+	}
+	// the outer upperdir itself is not part of any nested tree
+	if err := os.WriteFile(filepath.Join(upperdir, "outer"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docker := filepath.Join(upperdir, "var/lib/docker/overlay2")
+	podman := filepath.Join(upperdir, "var/lib/containers/storage/overlay")
+	for _, dir := range []string{docker + "/l1/diff", docker + "/l2/diff", podman} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{docker + "/l1/diff/a", docker + "/l1/diff/b"} {
+		if err := os.WriteFile(file, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trees, err := CheckNested(context.Background(), upperdir, id+1, 0)
+	if err != nil {
+		t.Fatalf("CheckNested(): %v", err)
+	}
+	want := []NestedTree{
+		{Root: docker, Scanned: 7, Mismatched: 7, Samples: []string{docker, docker + "/l1", docker + "/l1/diff", docker + "/l1/diff/a", docker + "/l1/diff/b"}},
+		{Root: podman, Scanned: 1, Mismatched: 1, Samples: []string{podman}},
+	}
+	if !reflect.DeepEqual(trees, want) {
+		t.Errorf("CheckNested() = %+v, want %+v", trees, want)
+	}
+
+	trees, err = CheckNested(context.Background(), upperdir, id+1, 2)
+	if err != nil {
+		t.Fatalf("CheckNested() with an entry cap: %v", err)
+	}
+	if len(trees) != 2 || trees[0].Scanned != 2 || trees[1].Scanned != 1 {
+		t.Errorf("CheckNested() with an entry cap of 2 = %+v", trees)
+	}
+}
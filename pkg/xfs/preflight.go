@@ -0,0 +1,84 @@
+package xfs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"RootfsQuota/pkg/util/quota"
+)
+
+// ReservedProjectID is a project ID already claimed by something other than
+// this daemon, together with where it was found.
+type ReservedProjectID struct {
+	ID     uint32
+	Source string
+}
+
+// DefaultReservedScanPaths lists directories of other tools known to hand out
+// XFS project IDs on their own (Docker overlay2 quotas, LXD storage pools).
+var DefaultReservedScanPaths = []string{
+	"/var/lib/docker/overlay2",
+	"/var/lib/lxd/storage-pools",
+	"/var/snap/lxd/common/lxd/storage-pools",
+}
+
+// DiscoverReservedProjectIDs collects project IDs configured in /etc/projects
+// and /etc/projid, plus the IDs set on the given directories and their direct
+// children. Missing files and directories are skipped silently.
+func DiscoverReservedProjectIDs(scanPaths []string) []ReservedProjectID {
+	var reserved []ReservedProjectID
+	reserved = append(reserved, parseProjectFile("/etc/projects", 0)...)
+	reserved = append(reserved, parseProjectFile("/etc/projid", 1)...)
+
+	for _, dir := range scanPaths {
+		if id, err := quota.GetProjectID(dir); err == nil && id != 0 {
+			reserved = append(reserved, ReservedProjectID{ID: id, Source: dir})
+		}
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			if !child.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, child.Name())
+			if id, err := quota.GetProjectID(path); err == nil && id != 0 {
+				reserved = append(reserved, ReservedProjectID{ID: id, Source: path})
+			}
+		}
+	}
+	return reserved
+}
+
+// parseProjectFile reads colon separated project files; field is the index of
+// the numeric ID (0 for /etc/projects "id:path", 1 for /etc/projid "name:id").
+func parseProjectFile(path string, field int) []ReservedProjectID {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var reserved []ReservedProjectID
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(parts[field]), 10, 32)
+		if err != nil || id == 0 {
+			continue
+		}
+		reserved = append(reserved, ReservedProjectID{ID: uint32(id), Source: path})
+	}
+	return reserved
+}
@@ -0,0 +1,46 @@
+package xfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+)
+
+const probeChunk = 1 << 20
+
+// ProbeEnforcement writes up to limit bytes into a throwaway file under dir
+// and reports whether the kernel refused the write with EDQUOT (or ENOSPC,
+// which XFS returns for project quotas). The file is always removed, and
+// writing stops when ctx is done.
+func ProbeEnforcement(ctx context.Context, dir string, limit uint64) (bool, uint64, error) {
+	file, err := os.CreateTemp(dir, ".conquotas-verify-")
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	buf := make([]byte, probeChunk)
+	var written uint64
+	for written < limit {
+		if err := ctx.Err(); err != nil {
+			return false, written, err
+		}
+		n, err := file.Write(buf)
+		written += uint64(n)
+		if errors.Is(err, syscall.EDQUOT) || errors.Is(err, syscall.ENOSPC) {
+			return true, written, nil
+		}
+		if err != nil {
+			return false, written, err
+		}
+	}
+	// 延迟分配可能在 fsync 时才报告超限
+	if err := file.Sync(); errors.Is(err, syscall.EDQUOT) || errors.Is(err, syscall.ENOSPC) {
+		return true, written, nil
+	}
+	return false, written, nil
+}
@@ -2,15 +2,55 @@ package xfs
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
+// churnWindow 为统计分配频率的时间窗口
+const churnWindow = time.Hour
+
 // ProjectIDPool 管理项目 ID 的分配
 type ProjectIDPool struct {
 	used  map[uint32]bool
 	mutex sync.Mutex
 	minID uint32
 	maxID uint32
+
+	allocations uint64
+	releases    uint64
+	peakUsed    int
+	// recent 为窗口内的分配时间，用于计算分配频率
+	recent []time.Time
+}
+
+// PoolStats 为项目 ID 池的使用统计
+type PoolStats struct {
+	Min  uint32 `json:"min"`
+	Max  uint32 `json:"max"`
+	Size int    `json:"size"`
+	Used int    `json:"used"`
+	Free int    `json:"free"`
+	// PeakUsed 为进程启动以来的最高使用量
+	PeakUsed int `json:"peak_used"`
+	// LargestFreeRun 为最长的连续空闲 ID 段长度
+	LargestFreeRun int    `json:"largest_free_run"`
+	Allocations    uint64 `json:"allocations"`
+	Releases       uint64 `json:"releases"`
+	// AllocationsPerHour 为最近一小时的分配次数
+	AllocationsPerHour int `json:"allocations_per_hour"`
+	// RecommendedSize 为按峰值使用量与分配频率估算的建议范围大小
+	RecommendedSize int `json:"recommended_size"`
+}
+
+// recommendedPoolSize 估算所需的范围大小：一小时内的新分配可能在旧 ID 释放前到达，
+// 峰值与其之和再留一倍余量，按 1000 向上取整
+func recommendedPoolSize(peakUsed, allocationsPerHour int) int {
+	need := 2 * (peakUsed + allocationsPerHour)
+	if need < 1000 {
+		return 1000
+	}
+	return (need + 999) / 1000 * 1000
 }
 
 // NewProjectIDPool 创建项目 ID 池
@@ -26,20 +66,63 @@ func NewProjectIDPool(minID, maxID uint32) *ProjectIDPool {
 func (p *ProjectIDPool) Allocate() (uint32, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
+	return p.allocate(p.minID, p.maxID)
+}
 
-	for id := p.minID; id <= p.maxID; id++ {
-		if !p.used[id] {
-			p.used[id] = true
-			return id, nil
+// AllocateRange 在 [minID, maxID] 内分配一个未使用的项目 ID，用于迁移到池范围以外的新范围
+func (p *ProjectIDPool) AllocateRange(minID, maxID uint32) (uint32, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.allocate(minID, maxID)
+}
+
+// allocate 分配范围内最小的未使用 ID，调用方需持有锁
+func (p *ProjectIDPool) allocate(minID, maxID uint32) (uint32, error) {
+	for id := uint64(minID); id <= uint64(maxID); id++ {
+		if !p.used[uint32(id)] {
+			p.used[uint32(id)] = true
+			p.noteAllocation()
+			return uint32(id), nil
 		}
 	}
 	return 0, fmt.Errorf("no available project ID")
 }
 
+// FreeIn 返回 [minID, maxID] 内未使用的 ID 数量
+func (p *ProjectIDPool) FreeIn(minID, maxID uint32) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	free := int(maxID-minID) + 1
+	for id := range p.used {
+		if id >= minID && id <= maxID {
+			free--
+		}
+	}
+	return free
+}
+
+// SetRange 修改分配范围，已分配的 ID 不受影响
+func (p *ProjectIDPool) SetRange(minID, maxID uint32) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.minID, p.maxID = minID, maxID
+}
+
+// Range 返回当前的分配范围
+func (p *ProjectIDPool) Range() (minID, maxID uint32) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.minID, p.maxID
+}
+
 // Release 释放项目 ID
 func (p *ProjectIDPool) Release(id uint32) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
+	if p.used[id] {
+		p.releases++
+	}
 	delete(p.used, id)
 }
 
@@ -48,4 +131,106 @@ func (p *ProjectIDPool) MarkUsed(id uint32) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 	p.used[id] = true
+	if len(p.used) > p.peakUsed {
+		p.peakUsed = len(p.used)
+	}
+}
+
+// InUse 返回项目 ID 是否已分配或保留
+func (p *ProjectIDPool) InUse(id uint32) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.used[id]
+}
+
+// Claim 在 ID 未使用时将其标记为已使用并返回 true，用完后由调用方 Release；
+// 用于在清理遗留项目期间阻止该 ID 被分配
+func (p *ProjectIDPool) Claim(id uint32) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.used[id] {
+		return false
+	}
+	p.used[id] = true
+	return true
+}
+
+// Used 返回已使用的项目 ID 数量
+func (p *ProjectIDPool) Used() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.used)
+}
+
+// Size 返回项目 ID 范围的大小
+func (p *ProjectIDPool) Size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return int(p.maxID-p.minID) + 1
+}
+
+// noteAllocation 更新分配计数，调用方需持有锁
+func (p *ProjectIDPool) noteAllocation() {
+	now := time.Now()
+	p.allocations++
+	if len(p.used) > p.peakUsed {
+		p.peakUsed = len(p.used)
+	}
+
+	cutoff := now.Add(-churnWindow)
+	i := 0
+	for i < len(p.recent) && p.recent[i].Before(cutoff) {
+		i++
+	}
+	p.recent = append(p.recent[i:], now)
+}
+
+// Stats 返回池的使用统计，仅统计配置范围内的 ID
+func (p *ProjectIDPool) Stats() PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	ids := make([]uint32, 0, len(p.used))
+	for id := range p.used {
+		if id >= p.minID && id <= p.maxID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// 相邻已用 ID 之间（含范围两端）的间隔即为空闲段
+	largest := 0
+	next := uint64(p.minID)
+	for _, id := range ids {
+		if gap := int(uint64(id) - next); gap > largest {
+			largest = gap
+		}
+		next = uint64(id) + 1
+	}
+	if gap := int(uint64(p.maxID) + 1 - next); gap > largest {
+		largest = gap
+	}
+
+	cutoff := time.Now().Add(-churnWindow)
+	recent := 0
+	for _, t := range p.recent {
+		if !t.Before(cutoff) {
+			recent++
+		}
+	}
+
+	size := int(p.maxID-p.minID) + 1
+	return PoolStats{
+		Min:                p.minID,
+		Max:                p.maxID,
+		Size:               size,
+		Used:               len(ids),
+		Free:               size - len(ids),
+		PeakUsed:           p.peakUsed,
+		LargestFreeRun:     largest,
+		Allocations:        p.allocations,
+		Releases:           p.releases,
+		AllocationsPerHour: recent,
+		RecommendedSize:    recommendedPoolSize(p.peakUsed, recent),
+	}
 }
@@ -0,0 +1,87 @@
+package xfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"RootfsQuota/pkg/chaos"
+)
+
+// QuotaState is the project quota state of one mounted XFS filesystem.
+type QuotaState struct {
+	Mountpoint  string `json:"mountpoint"`
+	Device      string `json:"device"`
+	Accounting  bool   `json:"accounting"`
+	Enforcement bool   `json:"enforcement"`
+}
+
+// GetQuotaState reports the project quota state of every mounted XFS
+// filesystem using `xfs_quota -x -c 'state -p'`. Filesystems mounted without
+// pquota may be missing from the result altogether; `xfs_quota -x -c off`
+// shows up as enforcement (and possibly accounting) turned off.
+func GetQuotaState(ctx context.Context) ([]QuotaState, error) {
+	if err := chaos.Fail("state"); err != nil {
+		return nil, err
+	}
+	defer lockAllFilesystems()()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	output, err := runTool(ctx, "xfs_quota", "state -p", "-x", "-c", "state -p")
+	if err != nil {
+		return nil, err
+	}
+	return parseQuotaState(bytes.NewReader(output))
+}
+
+// parseQuotaState parses sections such as
+//
+//	Project quota state on /var/lib/containerd (/dev/sdb1)
+//	  Accounting: ON
+//	  Enforcement: ON
+//
+// Grace time lines and sections for other quota types are ignored.
+func parseQuotaState(r io.Reader) ([]QuotaState, error) {
+	const header = "Project quota state on "
+	var states []QuotaState
+	var current *QuotaState
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") {
+			current = nil
+			if !strings.HasPrefix(line, header) {
+				continue
+			}
+			rest := strings.TrimPrefix(line, header)
+			st := QuotaState{Mountpoint: rest}
+			// the mountpoint may contain spaces, the device is the last "(...)"
+			if i := strings.LastIndex(rest, " ("); i >= 0 && strings.HasSuffix(rest, ")") {
+				st.Mountpoint = rest[:i]
+				st.Device = rest[i+2 : len(rest)-1]
+			}
+			states = append(states, st)
+			current = &states[len(states)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		on := strings.TrimSpace(value) == "ON"
+		switch key {
+		case "Accounting":
+			current.Accounting = on
+		case "Enforcement":
+			current.Enforcement = on
+		}
+	}
+	return states, scanner.Err()
+}
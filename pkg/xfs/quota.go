@@ -3,23 +3,24 @@ package xfs
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 
-	"RootfsQuota/pkg/log"
-
-	"github.com/containerd/containerd"
-	"go.uber.org/zap"
+	"RootfsQuota/pkg/chaos"
+	"RootfsQuota/pkg/util/quota"
 )
 
 // GetProjectIDFromXFS retrieves the XFS project ID for a given file path.
-func GetProjectIDFromXFS(path string) (uint32, error) {
-	cmd := exec.Command("xfs_io", "-r", "-c", "stat", path)
-	output, err := cmd.CombinedOutput()
+// xfs_io is killed when ctx is done.
+func GetProjectIDFromXFS(ctx context.Context, path string) (uint32, error) {
+	if err := ValidatePath(path); err != nil {
+		return 0, err
+	}
+	output, err := runTool(ctx, "xfs_io", "stat", "-r", "-c", "stat", path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute xfs_io: %v, output: %s", err, string(output))
+		return 0, err
 	}
 
 	re := regexp.MustCompile(`projid\s*=\s*(\d+)`)
@@ -36,64 +37,125 @@ func GetProjectIDFromXFS(path string) (uint32, error) {
 	return uint32(projid), nil
 }
 
-// SetProjectIDWithXFSQuota sets an XFS project ID for a given path using xfs_quota.
-func SetProjectIDWithXFSQuota(path string, projid uint32) error {
-	cmdStr := fmt.Sprintf("project -s -p %s %d", path, projid)
-	cmd := exec.Command("xfs_quota", "-x", "-c", cmdStr)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to execute xfs_quota: %v, output: %s", err, string(output))
+// maxPathLen is PATH_MAX; longer paths cannot be passed to the kernel.
+const maxPathLen = 4096
+
+// ValidatePath rejects paths that cannot be handed to the kernel safely:
+// relative paths, embedded NUL bytes and paths of PATH_MAX or longer.
+// Spaces, quotes and shell metacharacters are fine since no command
+// string is built from the path.
+func ValidatePath(path string) error {
+	switch {
+	case path == "":
+		return fmt.Errorf("empty path")
+	case !filepath.IsAbs(path):
+		return fmt.Errorf("path %q is not absolute", path)
+	case strings.ContainsRune(path, 0):
+		return fmt.Errorf("path %q contains a NUL byte", path)
+	case len(path) >= maxPathLen:
+		return fmt.Errorf("path of %d bytes exceeds PATH_MAX (%d): %.64s...", len(path), maxPathLen, path)
 	}
 	return nil
 }
 
-// SetProjectQuotaWithXFSQuota sets XFS project quota limits for a given project ID.
-func SetProjectQuotaWithXFSQuota(projid uint32, bsoft, bhard string) error {
-	cmdStr := fmt.Sprintf("limit -p bsoft=%s bhard=%s %d", bsoft, bhard, projid)
-	cmd := exec.Command("xfs_quota", "-x", "-c", cmdStr)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to execute xfs_quota: %v, output: %s", err, string(output))
+// SetProjectIDWithXFSQuota sets an XFS project ID on path and everything below
+// it, equivalent to `xfs_quota -x -c 'project -s -p <path> <id>'`. It uses
+// FS_IOC_FSSETXATTR directly so that paths with spaces or shell
+// metacharacters never end up in an xfs_quota command string.
+func SetProjectIDWithXFSQuota(ctx context.Context, path string, projid uint32) error {
+	if err := chaos.Fail("set-project-id"); err != nil {
+		return err
+	}
+	if err := ValidatePath(path); err != nil {
+		return err
+	}
+	defer lockFilesystem(path)()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := quota.SetProjectIDRecursive(ctx, path, projid); err != nil {
+		countSyscallError(ctx, "fssetxattr", err)
+		return fmt.Errorf("failed to set project id %d on %q: %w", projid, path, err)
 	}
 	return nil
 }
 
-// GetSnapshotUpperdir retrieves the upperdir path for a container's snapshot.
-func GetSnapshotUpperdir(ctx context.Context, client *containerd.Client, containerID string) (string, error) {
-	container, err := client.LoadContainer(ctx, containerID)
-	if err != nil {
-		log.Error("Failed to load container", zap.String("containerID", containerID), zap.Error(err))
-		return "", err
+// InodeLimits are the soft and hard limits on the number of inodes a project
+// may use. Zero means no limit.
+type InodeLimits struct {
+	Soft uint64
+	Hard uint64
+}
+
+// SetProjectQuotaWithXFSQuota sets XFS project quota limits for a given project ID.
+// Block limits are left unchanged when bsoft and bhard are both empty, inode
+// limits when inodes is nil.
+// Serialization locks cannot be interrupted; ctx is checked once the lock is
+// held and kills xfs_quota when done.
+func SetProjectQuotaWithXFSQuota(ctx context.Context, projid uint32, bsoft, bhard string, inodes *InodeLimits) error {
+	if err := chaos.Fail("set-project-quota"); err != nil {
+		return err
 	}
+	var args []string
+	if bsoft != "" || bhard != "" {
+		sizes, err := sizeArgs("b", bsoft, bhard)
+		if err != nil {
+			return err
+		}
+		args = append(args, sizes...)
+	}
+	if inodes != nil {
+		args = append(args, fmt.Sprintf("isoft=%d", inodes.Soft), fmt.Sprintf("ihard=%d", inodes.Hard))
+	}
+	return runLimit(ctx, projid, args)
+}
 
-	info, err := container.Info(ctx)
+// SetProjectRealtimeQuotaWithXFSQuota sets the block limits a project may use
+// on the realtime subvolume, leaving its data and inode limits unchanged.
+// Filesystems without a realtime section accept and ignore them.
+func SetProjectRealtimeQuotaWithXFSQuota(ctx context.Context, projid uint32, rtbsoft, rtbhard string) error {
+	if err := chaos.Fail("set-project-quota"); err != nil {
+		return err
+	}
+	args, err := sizeArgs("rtb", rtbsoft, rtbhard)
 	if err != nil {
-		log.Error("Failed to get container info", zap.String("containerID", containerID), zap.Error(err))
-		return "", err
+		return err
 	}
+	return runLimit(ctx, projid, args)
+}
 
-	snapshotterName := info.Snapshotter
-	snapshotKey := info.SnapshotKey
-	snapshotter := client.SnapshotService(snapshotterName)
+// ClearProjectQuotaWithXFSQuota removes every block, inode and realtime
+// limit of a project in a single xfs_quota call.
+func ClearProjectQuotaWithXFSQuota(ctx context.Context, projid uint32) error {
+	if err := chaos.Fail("set-project-quota"); err != nil {
+		return err
+	}
+	return runLimit(ctx, projid, []string{"bsoft=0", "bhard=0", "isoft=0", "ihard=0", "rtbsoft=0", "rtbhard=0"})
+}
 
-	mounts, err := snapshotter.Mounts(ctx, snapshotKey)
-	if err != nil {
-		log.Error("Failed to get snapshot mounts", zap.String("containerID", containerID), zap.Error(err))
-		return "", err
-	}
-
-	var upperDir string
-	for _, mount := range mounts {
-		if mount.Type == "overlay" {
-			for _, opt := range mount.Options {
-				if strings.HasPrefix(opt, "upperdir=") {
-					upperDir = strings.TrimPrefix(opt, "upperdir=")
-				}
-			}
+// sizeArgs renders <prefix>soft= and <prefix>hard= arguments. Limits end up
+// in the -c command string, so only plain sizes are accepted.
+func sizeArgs(prefix, soft, hard string) ([]string, error) {
+	for _, limit := range []string{soft, hard} {
+		if _, err := ParseSize(limit); err != nil {
+			return nil, fmt.Errorf("invalid limit %q: %v", limit, err)
 		}
 	}
-	if upperDir == "" {
-		return "", fmt.Errorf("upperdir not found for container %s", containerID)
+	return []string{prefix + "soft=" + soft, prefix + "hard=" + hard}, nil
+}
+
+// runLimit runs `limit -p <args> <projid>` with all filesystems locked.
+func runLimit(ctx context.Context, projid uint32, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	defer lockAllFilesystems()()
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	return upperDir, nil
+
+	cmdStr := fmt.Sprintf("limit -p %s %d", strings.Join(args, " "), projid)
+	_, err := runTool(ctx, "xfs_quota", cmdStr, "-x", "-c", cmdStr)
+	return err
 }
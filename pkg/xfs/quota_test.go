@@ -0,0 +1,34 @@
+package xfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+	}{
+		{"plain", "/var/lib/containerd/snapshots/1/fs", true},
+		{"spaces", "/data/my volume/fs", true},
+		{"shell metacharacters", "/data/$(rm -rf)/`x`;|&>*?'\"", true},
+		{"newline", "/data/a\nb", true},
+		{"just below PATH_MAX", "/" + strings.Repeat("a", maxPathLen-2), true},
+		{"empty", "", false},
+		{"relative", "data/fs", false},
+		{"dot relative", "./fs", false},
+		{"NUL byte", "/data/a\x00b", false},
+		{"PATH_MAX", "/" + strings.Repeat("a", maxPathLen-1), false},
+		{"beyond PATH_MAX", "/" + strings.Repeat("a/", maxPathLen), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePath(tt.path)
+			if (err == nil) != tt.ok {
+				t.Errorf("ValidatePath(%.32q) = %v, want ok %v", tt.path, err, tt.ok)
+			}
+		})
+	}
+}
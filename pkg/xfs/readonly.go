@@ -0,0 +1,42 @@
+package xfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// stRdonly is the ST_RDONLY mount flag reported by statfs.
+const stRdonly = 0x1
+
+// IsReadOnlyError reports whether err indicates that the backing filesystem
+// went read-only or hit an I/O error (e.g. after an errors=remount-ro event).
+func IsReadOnlyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.EROFS) || errors.Is(err, syscall.EIO) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Read-only file system") || strings.Contains(msg, "Input/output error")
+}
+
+// IsWritable reports whether the filesystem containing path (or its closest
+// existing ancestor) is mounted read-write.
+func IsWritable(path string) (bool, error) {
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false, err
+	}
+	return st.Flags&stRdonly == 0, nil
+}
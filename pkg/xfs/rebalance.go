@@ -0,0 +1,81 @@
+package xfs
+
+import "time"
+
+// 迁移的状态
+const (
+	RebalanceRunning     = "running"
+	RebalancePaused      = "paused"
+	RebalanceCompleted   = "completed"
+	RebalanceRollingBack = "rolling_back"
+	RebalanceRolledBack  = "rolled_back"
+)
+
+// 迁移中单个项目的状态
+const (
+	MovePending = "pending"
+	// MoveMoving 表示正在改标目录，进程在此时退出的项目恢复后从头重做
+	MoveMoving     = "moving"
+	MoveMoved      = "moved"
+	MoveFailed     = "failed"
+	MoveGone       = "gone"
+	MoveRolledBack = "rolled_back"
+)
+
+// Rebalance 为将已管理项目迁移到新 ID 范围的进度，保存在状态文件中。
+// 完成迁移前旧 ID 一直保留，结束（finish）时才归还，因此完成后仍可回滚
+type Rebalance struct {
+	IDMin     uint32          `json:"id_min"`
+	IDMax     uint32          `json:"id_max"`
+	State     string          `json:"state"`
+	StartedAt time.Time       `json:"started_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Projects  []RebalanceMove `json:"projects"`
+}
+
+// RebalanceMove 为一个项目的迁移，Containers 为开始时共享该项目的条目键
+type RebalanceMove struct {
+	OldID      uint32   `json:"old_id"`
+	NewID      uint32   `json:"new_id,omitempty"`
+	Containers []string `json:"containers"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Rebalance 返回进行中或最近一次迁移的副本
+func (m *StateManager) Rebalance() (Rebalance, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.state.Rebalance == nil {
+		return Rebalance{}, false
+	}
+	r := *m.state.Rebalance
+	r.Projects = append([]RebalanceMove(nil), r.Projects...)
+	return r, true
+}
+
+// PutRebalance 写入迁移记录并持久化，r 为 nil 时删除记录
+func (m *StateManager) PutRebalance(r *Rebalance) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if r != nil {
+		r.UpdatedAt = time.Now()
+	}
+	m.state.Rebalance = r
+	return m.save()
+}
+
+// UpdateRebalance 在锁内修改迁移记录并持久化，没有记录时返回 false
+func (m *StateManager) UpdateRebalance(update func(*Rebalance)) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.state.Rebalance == nil {
+		return false, nil
+	}
+	update(m.state.Rebalance)
+	m.state.Rebalance.UpdatedAt = time.Now()
+	return true, m.save()
+}
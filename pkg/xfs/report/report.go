@@ -0,0 +1,206 @@
+// Package report parses project quota reports into typed per-project usage.
+//
+// The text parser understands the output of
+//
+//	xfs_quota -x -c 'report -p -N -b -i'
+//
+// with or without -n (numeric IDs) and -N (no header). FromDqblk converts the
+// result of a quotactl(Q_GETQUOTA) call into the same type, so a collector
+// does not care which source it reads from.
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Usage is the consumption and limits of one project on one filesystem.
+// Byte values are converted from the 1KiB blocks xfs_quota prints.
+type Usage struct {
+	// ProjectID is zero when the report shows a project name instead of
+	// "#<id>" (report without -n) and the name is not numeric.
+	ProjectID uint32 `json:"project_id"`
+	// Name is the project name from /etc/projid, empty for numeric reports.
+	Name string `json:"name,omitempty"`
+	// Filesystem is the mount point from the "Project quota on" header, empty
+	// when the report was produced with -N.
+	Filesystem string `json:"filesystem,omitempty"`
+
+	UsedBytes      uint64 `json:"used_bytes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	// BlockWarnings is the warning count xfs_quota tracks for blocks.
+	BlockWarnings uint64 `json:"block_warnings"`
+	// BlockGrace is the remaining grace period as printed ("7 days"), empty
+	// when no grace period is running.
+	BlockGrace string `json:"block_grace,omitempty"`
+
+	UsedInodes     uint64 `json:"used_inodes"`
+	InodeSoftLimit uint64 `json:"inode_soft_limit"`
+	InodeHardLimit uint64 `json:"inode_hard_limit"`
+	InodeWarnings  uint64 `json:"inode_warnings"`
+	InodeGrace     string `json:"inode_grace,omitempty"`
+}
+
+// headerPrefix starts the per-filesystem header xfs_quota prints without -N,
+// e.g. "Project quota on /var/lib/containerd (/dev/sdb1)".
+const headerPrefix = "Project quota on "
+
+// Parse reads a `report -p -b -i` listing and returns one Usage per project
+// line, in report order. Header, ruler and blank lines are skipped; a line
+// that looks like a project row but does not parse is an error rather than
+// being silently dropped.
+func Parse(r io.Reader) ([]Usage, error) {
+	var (
+		usages     []Usage
+		filesystem string
+	)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, headerPrefix):
+			filesystem = parseHeader(line)
+			continue
+		case strings.HasPrefix(line, "Project ID"), strings.HasPrefix(line, "Blocks"),
+			strings.HasPrefix(line, "Inodes"), strings.HasPrefix(line, "---"):
+			continue
+		}
+
+		u, err := ParseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse xfs_quota report line %d: %v", lineNo, err)
+		}
+		u.Filesystem = filesystem
+		usages = append(usages, u)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read xfs_quota report: %v", err)
+	}
+	return usages, nil
+}
+
+// ParseLine parses a single project row such as
+//
+//	#1000   1024   0   2048   00 [--------]   5   0   0   00 [--------]
+//
+// Columns past the inode group (e.g. realtime blocks with -r) are ignored.
+func ParseLine(line string) (Usage, error) {
+	fields, graces, err := splitFields(line)
+	if err != nil {
+		return Usage{}, err
+	}
+	if len(fields) < 9 {
+		return Usage{}, fmt.Errorf("expected project, block and inode columns, got %q", line)
+	}
+
+	u := Usage{}
+	if id, ok := strings.CutPrefix(fields[0], "#"); ok {
+		n, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return Usage{}, fmt.Errorf("invalid project ID %q", fields[0])
+		}
+		u.ProjectID = uint32(n)
+	} else {
+		u.Name = fields[0]
+		if n, err := strconv.ParseUint(fields[0], 10, 32); err == nil {
+			u.ProjectID = uint32(n)
+		}
+	}
+
+	var values [8]uint64
+	for i := range values {
+		v, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			return Usage{}, fmt.Errorf("invalid value %q in %q", fields[i+1], line)
+		}
+		values[i] = v
+	}
+	u.UsedBytes = values[0] * 1024
+	u.SoftLimitBytes = values[1] * 1024
+	u.HardLimitBytes = values[2] * 1024
+	u.BlockWarnings = values[3]
+	u.UsedInodes = values[4]
+	u.InodeSoftLimit = values[5]
+	u.InodeHardLimit = values[6]
+	u.InodeWarnings = values[7]
+	if len(graces) > 0 {
+		u.BlockGrace = graces[0]
+	}
+	if len(graces) > 1 {
+		u.InodeGrace = graces[1]
+	}
+	return u, nil
+}
+
+// splitFields splits a row on whitespace, pulling out bracketed grace columns
+// ("[7 days]", "[--------]") which may themselves contain spaces. Graces of
+// only dashes or "-none-" mean no grace period and are returned empty.
+func splitFields(line string) (fields, graces []string, err error) {
+	for {
+		open := strings.IndexByte(line, '[')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(line[open:], ']')
+		if end < 0 {
+			return nil, nil, fmt.Errorf("unterminated grace column in %q", line)
+		}
+		fields = append(fields, strings.Fields(line[:open])...)
+		graces = append(graces, normalizeGrace(line[open+1:open+end]))
+		line = line[open+end+1:]
+	}
+	return append(fields, strings.Fields(line)...), graces, nil
+}
+
+func normalizeGrace(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "-none-" || strings.Trim(s, "-") == "" {
+		return ""
+	}
+	return s
+}
+
+// parseHeader returns the mount point of a "Project quota on <mnt> (<dev>)"
+// header line.
+func parseHeader(line string) string {
+	rest := strings.TrimPrefix(line, headerPrefix)
+	if i := strings.LastIndex(rest, " ("); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// Dqblk mirrors the kernel's struct if_dqblk as filled by
+// quotactl(Q_GETQUOTA, PRJQUOTA). Block limits are in 1KiB units and
+// CurSpace is in bytes.
+type Dqblk struct {
+	BHardLimit uint64
+	BSoftLimit uint64
+	CurSpace   uint64
+	IHardLimit uint64
+	ISoftLimit uint64
+	CurInodes  uint64
+	BTime      uint64
+	ITime      uint64
+	Valid      uint32
+}
+
+// FromDqblk converts a quotactl result for project id into a Usage.
+// Warning counts and grace periods are not part of if_dqblk and stay empty.
+func FromDqblk(id uint32, d Dqblk) Usage {
+	return Usage{
+		ProjectID:      id,
+		UsedBytes:      d.CurSpace,
+		SoftLimitBytes: d.BSoftLimit * 1024,
+		HardLimitBytes: d.BHardLimit * 1024,
+		UsedInodes:     d.CurInodes,
+		InodeSoftLimit: d.ISoftLimit,
+		InodeHardLimit: d.IHardLimit,
+	}
+}
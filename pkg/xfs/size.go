@@ -0,0 +1,57 @@
+package xfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = map[byte]uint64{
+	'b': 1,
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+	'p': 1 << 50,
+}
+
+// ParseSize converts an xfs_quota style size ("512k", "10g", "0") into bytes.
+// Units are binary and case-insensitive; a bare number is taken as bytes.
+func ParseSize(s string) (uint64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	mult := uint64(1)
+	if unit, ok := sizeUnits[s[len(s)-1]]; ok {
+		mult = unit
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", s, err)
+	}
+	if n != 0 && n > ^uint64(0)/mult {
+		return 0, fmt.Errorf("size %q overflows", s)
+	}
+	return n * mult, nil
+}
+
+// FormatSize renders bytes in the largest unit that represents it exactly,
+// rounding up to whole KiB as xfs_quota works in 1KiB blocks.
+func FormatSize(bytes uint64) string {
+	if bytes == 0 {
+		return "0"
+	}
+	kib := (bytes + 1023) / 1024
+	for _, u := range []struct {
+		suffix string
+		k      uint64
+	}{{"t", 1 << 30}, {"g", 1 << 20}, {"m", 1 << 10}} {
+		if kib%u.k == 0 {
+			return fmt.Sprintf("%d%s", kib/u.k, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dk", kib)
+}
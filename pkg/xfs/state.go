@@ -2,21 +2,120 @@ package xfs
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
+
+	"RootfsQuota/pkg/chaos"
 )
 
 // State 存储容器 ID 与项目 ID 和 upperdir 的映射
 type State struct {
+	// Epoch 为最近启动的守护进程实例的隔离纪元，每次成为主实例时递增；
+	// 必须为第一个字段，save 只解码文件开头即可读出
+	Epoch   uint64           `json:"epoch,omitempty"`
 	Entries map[string]Entry `json:"entries"`
+	// LastEvent 为最近处理的 containerd 事件水位，用于崩溃后判断事件缺口
+	LastEvent *EventMark `json:"last_event,omitempty"`
+	// ImageOverrides 为按镜像摘要调整的默认限额，键为镜像摘要
+	ImageOverrides map[string]ImageOverride `json:"image_overrides,omitempty"`
+	// Rebalance 为进行中或尚未结束的项目 ID 迁移
+	Rebalance *Rebalance `json:"rebalance,omitempty"`
+}
+
+// 镜像限额覆盖的来源
+const (
+	OverrideManual  = "manual"
+	OverrideLearned = "learned"
+)
+
+// ImageOverride 为某一镜像的容器创建时使用的限额，取代配置的默认限额
+type ImageOverride struct {
+	Digest string `json:"digest"`
+	// Image 为最近一次见到的镜像名，仅供展示
+	Image     string `json:"image,omitempty"`
+	SoftLimit string `json:"soft_limit"`
+	HardLimit string `json:"hard_limit"`
+	// Source 为 manual（通过管理接口设置）或 learned（由达到硬限制的容器推算）
+	Source string `json:"source"`
+	// Breaches 为该镜像的容器结束时达到硬限制的次数
+	Breaches  int       `json:"breaches,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EventMark 记录处理过的事件位置
+type EventMark struct {
+	// Sequence 为本地单调递增的事件序号，跨重启延续
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	Topic     string    `json:"topic"`
+	Namespace string    `json:"namespace"`
 }
 
 // Entry 表示单条映射
 type Entry struct {
 	ContainerID string `json:"container_id"`
+	Namespace   string `json:"namespace,omitempty"`
 	ProjectID   uint32 `json:"project_id"`
 	Upperdir    string `json:"upperdir"`
+	SoftLimit   string `json:"soft_limit,omitempty"`
+	HardLimit   string `json:"hard_limit,omitempty"`
+	// InodeSoft、InodeHard 为设置的 inode 数限额，0 表示未限制
+	InodeSoft uint64 `json:"inode_soft,omitempty"`
+	InodeHard uint64 `json:"inode_hard,omitempty"`
+	// ImageDigest 为容器创建时的镜像摘要，用于按镜像学习限额覆盖
+	ImageDigest string `json:"image_digest,omitempty"`
+	// Class 为容器创建时选择的配额类别，为空表示使用顶层 quota
+	Class string `json:"class,omitempty"`
+	// RealtimeSoft、RealtimeHard 为设置的实时子卷块限额，为空表示未设置
+	RealtimeSoft string `json:"rt_soft_limit,omitempty"`
+	RealtimeHard string `json:"rt_hard_limit,omitempty"`
+	// Group 为共享项目 ID 的分组键（如 pod:<uid>），为空表示独立项目
+	Group string `json:"group,omitempty"`
+	// Paths 为额外纳入项目的目录：分组条目的日志目录、emptyDir 等，或容器通过注解声明的可写目录
+	Paths []string `json:"paths,omitempty"`
+	// CreatedAt 为配额设置时间，用于统计生命周期
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// LiftedUntil 为临时解除限额的到期时间，零值表示限额正常生效
+	LiftedUntil time.Time `json:"lifted_until,omitempty"`
+	// Pinned 为真表示限额由运维手动设置，策略收敛与 kubelet 扩缩容不再修改
+	Pinned bool `json:"pinned,omitempty"`
+	// LimitHistory 为最近的限额变更记录，按时间先后排列
+	LimitHistory []LimitRecord `json:"limit_history,omitempty"`
+}
+
+// maxLimitHistory 为每个条目保留的限额变更记录数
+const maxLimitHistory = 20
+
+// LimitRecord 为一次限额变更
+type LimitRecord struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	OldSoft string    `json:"old_soft,omitempty"`
+	OldHard string    `json:"old_hard,omitempty"`
+	NewSoft string    `json:"new_soft,omitempty"`
+	NewHard string    `json:"new_hard,omitempty"`
+}
+
+// SetLimits 修改条目的限额并追加变更记录，只保留最近的 maxLimitHistory 条
+func (e *Entry) SetLimits(soft, hard, source string) {
+	e.LimitHistory = append(e.LimitHistory, LimitRecord{
+		Time:    time.Now(),
+		Source:  source,
+		OldSoft: e.SoftLimit,
+		OldHard: e.HardLimit,
+		NewSoft: soft,
+		NewHard: hard,
+	})
+	if n := len(e.LimitHistory); n > maxLimitHistory {
+		e.LimitHistory = append([]LimitRecord(nil), e.LimitHistory[n-maxLimitHistory:]...)
+	}
+	e.SoftLimit = soft
+	e.HardLimit = hard
 }
 
 // StateManager 管理状态的并发安全结构
@@ -24,6 +123,22 @@ type StateManager struct {
 	filePath string
 	state    State
 	mutex    sync.RWMutex
+	// dirty 表示存在尚未持久化的事件水位
+	dirty bool
+	// readOnly 为真时只在内存中修改，不写文件（备用实例）
+	readOnly bool
+	// epoch 为本实例持有的纪元，0 表示未参与隔离
+	epoch uint64
+	// generation 为条目的变更计数，每次修改条目递增，供镜像等消费方判断是否需要刷新
+	generation uint64
+}
+
+// ErrFenced 表示更新的守护进程实例已接管，本实例的修改被拒绝
+var ErrFenced = errors.New("fenced by a newer daemon instance")
+
+// NewMemoryStateManager 创建不持久化的状态管理器，用于无盘节点，状态在启动时从磁盘标记重建
+func NewMemoryStateManager() *StateManager {
+	return &StateManager{state: State{Entries: make(map[string]Entry)}}
 }
 
 // NewStateManager 创建状态管理器
@@ -67,38 +182,214 @@ func (m *StateManager) load() error {
 	return json.Unmarshal(data, &m.state)
 }
 
-// save 保存状态到文件
+// SetReadOnly 设置是否禁止写入状态文件
+func (m *StateManager) SetReadOnly(readOnly bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.readOnly = readOnly
+}
+
+// Reload 丢弃内存中的状态并重新从文件加载，用于备用实例提升为主实例
+func (m *StateManager) Reload() error {
+	if m.filePath == "" {
+		return nil
+	}
+	m.mutex.Lock()
+	m.state = State{Entries: make(map[string]Entry)}
+	m.dirty = false
+	m.generation++
+	m.mutex.Unlock()
+
+	if err := m.load(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// AdvanceEpoch 为本实例取得新的纪元并写入状态文件，此后文件中出现更大的纪元时拒绝保存。
+// 内存模式下没有可递增的记录，以当前 Unix 时间作为纪元
+func (m *StateManager) AdvanceEpoch() (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	next := m.state.Epoch + 1
+	if m.filePath == "" {
+		next = uint64(time.Now().Unix())
+	} else if onDisk, err := readEpoch(m.filePath); err == nil && onDisk >= next {
+		next = onDisk + 1
+	}
+	prev := m.state.Epoch
+	m.state.Epoch, m.epoch = next, next
+	if err := m.save(); err != nil {
+		m.state.Epoch, m.epoch = prev, 0
+		return 0, err
+	}
+	return next, nil
+}
+
+// Epoch 返回本实例持有的纪元
+func (m *StateManager) Epoch() uint64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.epoch
+}
+
+// CheckEpoch 检查状态文件中的纪元，更新的实例已写入更大的纪元时返回 ErrFenced；
+// 未参与隔离或内存模式下总是返回 nil
+func (m *StateManager) CheckEpoch() error {
+	m.mutex.RLock()
+	epoch, filePath := m.epoch, m.filePath
+	m.mutex.RUnlock()
+	if epoch == 0 || filePath == "" {
+		return nil
+	}
+	if onDisk, err := readEpoch(filePath); err == nil && onDisk > epoch {
+		return fmt.Errorf("%w: state file has epoch %d, ours is %d", ErrFenced, onDisk, epoch)
+	}
+	return nil
+}
+
+// readEpoch 只解码状态文件开头的 epoch 字段，文件为空或没有该字段时返回 0
+func readEpoch(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return 0, nil
+	}
+	if tok, err := dec.Token(); err != nil || tok != "epoch" {
+		return 0, nil
+	}
+	var epoch uint64
+	if err := dec.Decode(&epoch); err != nil {
+		return 0, err
+	}
+	return epoch, nil
+}
+
+// save 保存状态到文件，内存模式下不做任何事；文件已被更新的实例写入更大的纪元时返回 ErrFenced
 func (m *StateManager) save() error {
+	if m.filePath == "" || m.readOnly {
+		return nil
+	}
+	if m.epoch != 0 {
+		if onDisk, err := readEpoch(m.filePath); err == nil && onDisk > m.epoch {
+			return fmt.Errorf("%w: state file has epoch %d, ours is %d", ErrFenced, onDisk, m.epoch)
+		}
+	}
 
 	data, err := json.MarshalIndent(m.state, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(m.filePath, data, 0644)
+	if err := os.WriteFile(m.filePath, chaos.Corrupt(data), 0644); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
 }
 
 // AddEntry 添加或更新映射
 func (m *StateManager) AddEntry(containerID string, projectID uint32, upperdir string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	m.state.Entries[containerID] = Entry{
+	return m.PutEntry(Entry{
 		ContainerID: containerID,
 		ProjectID:   projectID,
 		Upperdir:    upperdir,
-	}
+	})
+}
+
+// PutEntry 写入完整的映射
+func (m *StateManager) PutEntry(entry Entry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.state.Entries[entry.ContainerID] = entry
+	m.generation++
 	return m.save()
 }
 
+// UpdateEntry 在锁内修改已有映射并持久化，映射不存在时返回 false
+func (m *StateManager) UpdateEntry(containerID string, update func(*Entry)) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, exists := m.state.Entries[containerID]
+	if !exists {
+		return false, nil
+	}
+	update(&entry)
+	m.state.Entries[containerID] = entry
+	m.generation++
+	return true, m.save()
+}
+
 // RemoveEntry 删除映射
 func (m *StateManager) RemoveEntry(containerID string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	delete(m.state.Entries, containerID)
+	m.generation++
+	return m.save()
+}
+
+// PutImageOverride 写入镜像的限额覆盖并持久化
+func (m *StateManager) PutImageOverride(o ImageOverride) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.state.ImageOverrides == nil {
+		m.state.ImageOverrides = make(map[string]ImageOverride)
+	}
+	m.state.ImageOverrides[o.Digest] = o
 	return m.save()
 }
 
+// RemoveImageOverride 删除镜像的限额覆盖，不存在时返回 false
+func (m *StateManager) RemoveImageOverride(digest string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.state.ImageOverrides[digest]; !exists {
+		return false, nil
+	}
+	delete(m.state.ImageOverrides, digest)
+	return true, m.save()
+}
+
+// GetImageOverride 返回镜像的限额覆盖
+func (m *StateManager) GetImageOverride(digest string) (ImageOverride, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	o, exists := m.state.ImageOverrides[digest]
+	return o, exists
+}
+
+// ListImageOverrides 返回所有镜像限额覆盖，按摘要排序
+func (m *StateManager) ListImageOverrides() []ImageOverride {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	list := make([]ImageOverride, 0, len(m.state.ImageOverrides))
+	for _, o := range m.state.ImageOverrides {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Digest < list[j].Digest })
+	return list
+}
+
+// Generation 返回条目的变更计数
+func (m *StateManager) Generation() uint64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.generation
+}
+
 // GetEntry 获取映射
 func (m *StateManager) GetEntry(containerID string) (Entry, bool) {
 	m.mutex.RLock()
@@ -107,3 +398,51 @@ func (m *StateManager) GetEntry(containerID string) (Entry, bool) {
 	entry, exists := m.state.Entries[containerID]
 	return entry, exists
 }
+
+// ListEntries 返回所有映射的副本
+func (m *StateManager) ListEntries() []Entry {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(m.state.Entries))
+	for _, entry := range m.state.Entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// RecordEvent 更新事件水位并返回分配的序号，水位由 Flush 或下一次保存时持久化
+func (m *StateManager) RecordEvent(timestamp time.Time, topic, namespace string) EventMark {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mark := EventMark{Timestamp: timestamp, Topic: topic, Namespace: namespace, Sequence: 1}
+	if m.state.LastEvent != nil {
+		mark.Sequence = m.state.LastEvent.Sequence + 1
+	}
+	m.state.LastEvent = &mark
+	m.dirty = true
+	return mark
+}
+
+// LastEvent 返回最近处理的事件水位
+func (m *StateManager) LastEvent() (EventMark, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.state.LastEvent == nil {
+		return EventMark{}, false
+	}
+	return *m.state.LastEvent, true
+}
+
+// Flush 持久化尚未保存的事件水位
+func (m *StateManager) Flush() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.dirty {
+		return nil
+	}
+	return m.save()
+}
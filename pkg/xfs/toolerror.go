@@ -0,0 +1,149 @@
+package xfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"RootfsQuota/pkg/log"
+	"RootfsQuota/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Classes of backend tool failures. They label
+// conquotas_backend_errors_total and appear in the error message, so the
+// dominant failure mode is visible without reading raw tool output.
+const (
+	// ClassNoSuchProject: the project ID is not known to the filesystem.
+	ClassNoSuchProject = "no_such_project"
+	// ClassNoSuchPath: the path vanished, typically a snapshot removed
+	// while its event was being handled.
+	ClassNoSuchPath = "no_such_path"
+	// ClassPermissionDenied: missing CAP_SYS_ADMIN or a read-only mount.
+	ClassPermissionDenied = "permission_denied"
+	// ClassNoProjectQuota: the filesystem is not mounted with pquota, or
+	// project quota accounting has been turned off.
+	ClassNoProjectQuota = "pquota_missing"
+	// ClassTimer: setting or reading grace timers failed.
+	ClassTimer = "timer"
+	// ClassTimeout: the tool was killed because its context was done.
+	ClassTimeout = "timeout"
+	// ClassToolMissing: the tool is not installed or not in PATH.
+	ClassToolMissing = "tool_missing"
+	// ClassUnknown: none of the above.
+	ClassUnknown = "unknown"
+)
+
+// outputClasses maps tool output to a class. Earlier patterns win, so the
+// specific messages come before the generic errno strings.
+var outputClasses = []struct {
+	class   string
+	pattern *regexp.Regexp
+}{
+	{ClassNoSuchProject, regexp.MustCompile(`(?i)no such project|project .*not found|invalid project|cannot find project`)},
+	{ClassTimer, regexp.MustCompile(`(?i)timer|grace`)},
+	{ClassNoProjectQuota, regexp.MustCompile(`(?i)function not implemented|no such process|not supported|quota.* not (enabled|on|active)|quota.* off|not mounted with|no quota|cannot find mount point|not a mount point`)},
+	{ClassPermissionDenied, regexp.MustCompile(`(?i)permission denied|operation not permitted|read-only file system|must be root`)},
+	{ClassNoSuchPath, regexp.MustCompile(`(?i)no such file or directory`)},
+}
+
+// ToolError is a failed xfs_quota or xfs_io invocation with its captured
+// output and failure class.
+type ToolError struct {
+	Tool string
+	// Command is the -c argument the tool was run with.
+	Command string
+	Class   string
+	// Output is the tool's combined stdout and stderr, trimmed.
+	Output string
+	Err    error
+}
+
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("failed to execute %s (%s): %v, output: %s", e.Tool, e.Class, e.Err, e.Output)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// runTool runs tool with args and returns its stdout. A failure is
+// classified and counted per tool and class. xfs_quota often exits 0 after
+// printing an error for one of the filesystems it visited, so stderr that
+// matches a known class on success is counted as a warning and logged.
+func runTool(ctx context.Context, tool, command string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+		te := &ToolError{Tool: tool, Command: command, Output: output, Err: err}
+		// Classify from stderr when there is any: report headers on stdout
+		// mention grace times and would be taken for timer errors.
+		diagnostic := stderr.String()
+		if strings.TrimSpace(diagnostic) == "" {
+			diagnostic = output
+		}
+		te.Class = classify(ctx, err, diagnostic)
+		metrics.BackendErrors.WithLabelValues(tool, te.Class).Inc()
+		return nil, te
+	}
+	if warning := strings.TrimSpace(stderr.String()); warning != "" {
+		if class := classifyOutput(warning); class != ClassUnknown {
+			metrics.BackendWarnings.WithLabelValues(tool, class).Inc()
+			log.Ctx(ctx).Warn("Backend tool reported an error but exited successfully",
+				zap.String("tool", tool), zap.String("command", command),
+				zap.String("class", class), zap.String("stderr", warning))
+		}
+	}
+	return stdout.Bytes(), nil
+}
+
+// countSyscallError counts a failed call that does not go through a tool,
+// such as the project ID ioctl, under the given tool label.
+func countSyscallError(ctx context.Context, tool string, err error) {
+	metrics.BackendErrors.WithLabelValues(tool, classify(ctx, err, "")).Inc()
+}
+
+// classify derives the class from the context, the error and then the
+// output.
+func classify(ctx context.Context, err error, output string) string {
+	if ctx.Err() != nil {
+		return ClassTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ClassTimeout
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return ClassToolMissing
+	}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.EPERM, syscall.EACCES, syscall.EROFS:
+			return ClassPermissionDenied
+		case syscall.ENOSYS, syscall.ESRCH, syscall.ENOTSUP, syscall.ENOTTY:
+			return ClassNoProjectQuota
+		case syscall.ENOENT:
+			return ClassNoSuchPath
+		}
+	}
+	return classifyOutput(output)
+}
+
+func classifyOutput(output string) string {
+	for _, c := range outputClasses {
+		if c.pattern.MatchString(output) {
+			return c.class
+		}
+	}
+	return ClassUnknown
+}
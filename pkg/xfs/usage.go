@@ -0,0 +1,175 @@
+package xfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"RootfsQuota/pkg/chaos"
+	"RootfsQuota/pkg/mounts"
+	"RootfsQuota/pkg/util/quota"
+	"RootfsQuota/pkg/xfs/report"
+)
+
+// ProjectUsage holds the current consumption and limits of a project as
+// reported by the kernel. Byte values are converted from 1KiB blocks.
+type ProjectUsage struct {
+	ProjectID      uint32 `json:"project_id"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"`
+	HardLimitBytes uint64 `json:"hard_limit_bytes"`
+	UsedInodes     uint64 `json:"used_inodes"`
+	InodeSoftLimit uint64 `json:"inode_soft_limit"`
+	InodeHardLimit uint64 `json:"inode_hard_limit"`
+}
+
+// GetProjectUsage queries the live block and inode usage of a project ID. It
+// reads the dquot with quotactl(Q_XGETQUOTA) from the XFS mounts with project
+// quotas and falls back to xfs_quota's report command, which is killed when
+// ctx is done, when quotactl cannot be used or finds no dquot.
+func GetProjectUsage(ctx context.Context, projid uint32) (ProjectUsage, error) {
+	if err := chaos.Fail("report"); err != nil {
+		return ProjectUsage{}, err
+	}
+	if usage, ok := quotactlUsage(projid); ok {
+		return usage, nil
+	}
+	usages, err := execReport(ctx, fmt.Sprintf("report -p -n -N -b -i -L %d -U %d", projid, projid))
+	if err != nil {
+		return ProjectUsage{}, err
+	}
+	for _, u := range usages {
+		if u.ProjectID == projid {
+			return fromReport(u), nil
+		}
+	}
+	return ProjectUsage{}, fmt.Errorf("project %d not found in xfs_quota report", projid)
+}
+
+// ListProjectUsage reports every project on every mounted XFS filesystem,
+// for collectors that would otherwise query one project at a time. It walks
+// the dquots of each filesystem with quotactl and falls back to a single
+// xfs_quota report when quotactl cannot be used.
+func ListProjectUsage(ctx context.Context) ([]report.Usage, error) {
+	if err := chaos.Fail("report"); err != nil {
+		return nil, err
+	}
+	if usages, ok := quotactlListUsage(); ok {
+		return usages, nil
+	}
+	return execReport(ctx, "report -p -n -b -i")
+}
+
+// quotactlListUsage lists the dquots of each XFS filesystem mounted with
+// project quotas, one quotactl loop per filesystem. Like quotactlUsage it
+// reports false when the mount table or a device node is unavailable, or
+// quotactl fails, e.g. on kernels without Q_XGETNEXTQUOTA.
+func quotactlListUsage() ([]report.Usage, bool) {
+	fsList, ok := quotactlFilesystems()
+	if !ok {
+		return nil, false
+	}
+	var usages []report.Usage
+	for _, m := range fsList {
+		err := quota.ListProjectUsage(m.Source, func(id uint32, u quota.ProjectUsage) {
+			usages = append(usages, report.Usage{
+				ProjectID:      id,
+				Filesystem:     m.Mountpoint,
+				UsedBytes:      u.UsedBytes,
+				SoftLimitBytes: u.SoftLimitBytes,
+				HardLimitBytes: u.HardLimitBytes,
+				UsedInodes:     u.UsedInodes,
+				InodeSoftLimit: u.InodeSoftLimit,
+				InodeHardLimit: u.InodeHardLimit,
+			})
+		})
+		if err != nil {
+			return nil, false
+		}
+	}
+	return usages, true
+}
+
+// quotactlFilesystems returns one mount per XFS filesystem mounted with
+// project quotas, the set xfs_quota acts on without a path. It reports false
+// when the mount table cannot be read or a device node is missing (e.g. in a
+// container without /dev), so that callers fall back to xfs_quota.
+func quotactlFilesystems() ([]mounts.Mount, bool) {
+	all, err := mounts.Load()
+	if err != nil {
+		return nil, false
+	}
+	var fsList []mounts.Mount
+	seen := make(map[[2]int]bool)
+	for _, m := range all {
+		dev := [2]int{m.Major, m.Minor}
+		if m.FSType != "xfs" || !m.ProjectQuota() || seen[dev] {
+			continue
+		}
+		seen[dev] = true
+		if fi, err := os.Stat(m.Source); err != nil || fi.Mode()&os.ModeDevice == 0 {
+			return nil, false
+		}
+		fsList = append(fsList, m)
+	}
+	return fsList, true
+}
+
+// quotactlUsage reads the dquot of projid from each XFS filesystem mounted
+// with project quotas, without starting an xfs_quota process. It reports
+// false when the mount table cannot be read, a device node is missing (e.g.
+// in a container without /dev), quotactl fails for another reason than a
+// missing dquot, or no filesystem has a dquot for the project.
+func quotactlUsage(projid uint32) (ProjectUsage, bool) {
+	fsList, ok := quotactlFilesystems()
+	if !ok {
+		return ProjectUsage{}, false
+	}
+	for _, m := range fsList {
+		u, err := quota.GetProjectUsage(m.Source, projid)
+		if errors.Is(err, syscall.ENOENT) {
+			continue
+		}
+		if err != nil {
+			return ProjectUsage{}, false
+		}
+		return ProjectUsage{
+			ProjectID:      projid,
+			UsedBytes:      u.UsedBytes,
+			SoftLimitBytes: u.SoftLimitBytes,
+			HardLimitBytes: u.HardLimitBytes,
+			UsedInodes:     u.UsedInodes,
+			InodeSoftLimit: u.InodeSoftLimit,
+			InodeHardLimit: u.InodeHardLimit,
+		}, true
+	}
+	return ProjectUsage{}, false
+}
+
+func execReport(ctx context.Context, cmdStr string) ([]report.Usage, error) {
+	defer lockAllFilesystems()()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	output, err := runTool(ctx, "xfs_quota", cmdStr, "-x", "-c", cmdStr)
+	if err != nil {
+		return nil, err
+	}
+	return report.Parse(bytes.NewReader(output))
+}
+
+func fromReport(u report.Usage) ProjectUsage {
+	return ProjectUsage{
+		ProjectID:      u.ProjectID,
+		UsedBytes:      u.UsedBytes,
+		SoftLimitBytes: u.SoftLimitBytes,
+		HardLimitBytes: u.HardLimitBytes,
+		UsedInodes:     u.UsedInodes,
+		InodeSoftLimit: u.InodeSoftLimit,
+		InodeHardLimit: u.InodeHardLimit,
+	}
+}
@@ -0,0 +1,78 @@
+package xfs
+
+import (
+	"errors"
+	"strconv"
+	"syscall"
+)
+
+const (
+	// ownerXattr tags a managed directory with the state key that owns it.
+	ownerXattr = "trusted.conquotas.owner"
+	// groupXattr tags a managed directory with its shared project group, if any.
+	groupXattr = "trusted.conquotas.group"
+	epochXattr = "trusted.conquotas.epoch"
+)
+
+// SetOwnerTag records which state key (and optional group) owns path, so the
+// mapping can be rebuilt from disk without a state file.
+func SetOwnerTag(path, owner, group string) error {
+	if err := syscall.Setxattr(path, ownerXattr, []byte(owner), 0); err != nil {
+		return err
+	}
+	if group == "" {
+		if err := syscall.Removexattr(path, groupXattr); err != nil && !errors.Is(err, syscall.ENODATA) {
+			return err
+		}
+		return nil
+	}
+	return syscall.Setxattr(path, groupXattr, []byte(group), 0)
+}
+
+// GetOwnerTag returns the owner and group recorded by SetOwnerTag. An
+// untagged path returns empty strings and no error.
+func GetOwnerTag(path string) (string, string, error) {
+	owner, err := getXattr(path, ownerXattr)
+	if err != nil {
+		return "", "", err
+	}
+	group, err := getXattr(path, groupXattr)
+	if err != nil {
+		return "", "", err
+	}
+	return owner, group, nil
+}
+
+// SetEpochTag records the fencing epoch of the daemon instance that last
+// tagged path.
+func SetEpochTag(path string, epoch uint64) error {
+	return syscall.Setxattr(path, epochXattr, []byte(strconv.FormatUint(epoch, 10)), 0)
+}
+
+// GetEpochTag returns the epoch recorded by SetEpochTag, or 0 for an
+// untagged path.
+func GetEpochTag(path string) (uint64, error) {
+	value, err := getXattr(path, epochXattr)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func getXattr(path, name string) (string, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, name, buf)
+		if errors.Is(err, syscall.ENODATA) {
+			return "", nil
+		}
+		if errors.Is(err, syscall.ERANGE) {
+			buf = make([]byte, len(buf)*2)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+}